@@ -0,0 +1,2 @@
+DELETE FROM config WHERE key = 'base_currency';
+DROP TABLE IF EXISTS exchange_rates;
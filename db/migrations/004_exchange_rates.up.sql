@@ -0,0 +1,9 @@
+-- Exchange rates are stored as units of currency per one unit of the base currency
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency TEXT PRIMARY KEY,
+    rate REAL NOT NULL CHECK (rate > 0),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- Totals are converted to the base currency
+INSERT OR IGNORE INTO config (key, value) VALUES ('base_currency', 'USD');
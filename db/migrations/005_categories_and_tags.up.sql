@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+INSERT OR IGNORE INTO categories (name) VALUES
+    ('streaming'),
+    ('software'),
+    ('utilities'),
+    ('news'),
+    ('gaming'),
+    ('cloud');
+
+-- Tags are free-form, stored lower-case, sorted and comma separated
+ALTER TABLE subscriptions ADD COLUMN category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL;
+ALTER TABLE subscriptions ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_category ON subscriptions(category_id);
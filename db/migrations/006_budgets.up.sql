@@ -0,0 +1,13 @@
+-- Budgets cap spending per billing period, in the base currency.
+-- A NULL category_id is the overall subscription budget.
+CREATE TABLE IF NOT EXISTS budgets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+    amount REAL NOT NULL CHECK (amount > 0),
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- One budget per category, and a single overall budget
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_category ON budgets(category_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_overall ON budgets((category_id IS NULL)) WHERE category_id IS NULL;
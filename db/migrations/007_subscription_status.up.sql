@@ -0,0 +1,10 @@
+-- Subscriptions are active, on a free trial until trial_end_date, paused
+-- from pause_date, or cancelled from cancel_date (all YYYY-MM-DD)
+ALTER TABLE subscriptions ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (
+    status IN ('active', 'trial', 'paused', 'cancelled')
+);
+ALTER TABLE subscriptions ADD COLUMN trial_end_date TEXT;
+ALTER TABLE subscriptions ADD COLUMN pause_date TEXT;
+ALTER TABLE subscriptions ADD COLUMN cancel_date TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
@@ -0,0 +1,32 @@
+CREATE TABLE subscriptions_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    amount REAL NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'USD',
+    billing_cycle TEXT NOT NULL CHECK (
+        billing_cycle IN ('weekly', 'biweekly', 'monthly', 'quarterly', 'semiannual', 'yearly')
+        OR billing_cycle GLOB 'every [1-9]* *'
+    ),
+    next_renewal_date TEXT,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    tags TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'active' CHECK (
+        status IN ('active', 'trial', 'paused', 'cancelled')
+    ),
+    trial_end_date TEXT,
+    pause_date TEXT,
+    cancel_date TEXT
+);
+
+INSERT INTO subscriptions_old (id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date)
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date FROM subscriptions;
+
+DROP TABLE subscriptions;
+ALTER TABLE subscriptions_old RENAME TO subscriptions;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_billing_cycle ON subscriptions(billing_cycle);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_next_renewal ON subscriptions(next_renewal_date);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_category ON subscriptions(category_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
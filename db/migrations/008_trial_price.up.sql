@@ -0,0 +1,2 @@
+-- Price charged once a free trial ends; NULL keeps the current amount
+ALTER TABLE subscriptions ADD COLUMN trial_price REAL CHECK (trial_price IS NULL OR trial_price >= 0);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_payments_renewal;
+DROP INDEX IF EXISTS idx_payments_subscription;
+DROP INDEX IF EXISTS idx_payments_paid_on;
+DROP TABLE IF EXISTS payments;
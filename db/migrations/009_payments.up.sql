@@ -0,0 +1,20 @@
+-- Ledger of charges that actually happened. Renewals are recorded as their
+-- dates pass; manual entries cover one-off or adjusted charges. The name and
+-- currency are kept so a payment outlives its subscription.
+CREATE TABLE IF NOT EXISTS payments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER REFERENCES subscriptions(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    amount REAL NOT NULL,
+    currency TEXT NOT NULL,
+    paid_on TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('renewal', 'manual')),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_paid_on ON payments(paid_on);
+CREATE INDEX IF NOT EXISTS idx_payments_subscription ON payments(subscription_id);
+
+-- A renewal is recorded once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_renewal ON payments(subscription_id, paid_on) WHERE source = 'renewal';
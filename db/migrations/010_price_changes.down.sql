@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_price_changes_effective;
+DROP INDEX IF EXISTS idx_price_changes_subscription;
+DROP TABLE IF EXISTS price_changes;
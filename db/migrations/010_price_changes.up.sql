@@ -0,0 +1,16 @@
+-- Amount and currency changes of subscriptions. Each row keeps the price
+-- before and after the change, so the price in effect on any date is the old
+-- price of the first change after it, or the current price if there is none.
+CREATE TABLE IF NOT EXISTS price_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    old_amount REAL NOT NULL,
+    old_currency TEXT NOT NULL,
+    amount REAL NOT NULL,
+    currency TEXT NOT NULL,
+    effective_date TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_changes_subscription ON price_changes(subscription_id, effective_date);
+CREATE INDEX IF NOT EXISTS idx_price_changes_effective ON price_changes(effective_date);
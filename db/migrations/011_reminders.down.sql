@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_sent_reminders_date;
+DROP INDEX IF EXISTS idx_sent_reminders_renewal;
+DROP TABLE IF EXISTS sent_reminders;
+DROP TABLE IF EXISTS reminder_settings;
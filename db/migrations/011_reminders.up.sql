@@ -0,0 +1,19 @@
+-- Days before each renewal to send a reminder, per subscription, as a comma
+-- separated list such as "7,1"; an empty list turns reminders off. Subscriptions
+-- without a row use the default lead times from the config.
+CREATE TABLE IF NOT EXISTS reminder_settings (
+    subscription_id INTEGER PRIMARY KEY REFERENCES subscriptions(id) ON DELETE CASCADE,
+    lead_days TEXT NOT NULL DEFAULT ''
+);
+
+-- Reminders already delivered, so a restarted watcher doesn't send them twice
+CREATE TABLE IF NOT EXISTS sent_reminders (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    renewal_date TEXT NOT NULL,
+    days INTEGER NOT NULL,
+    sent_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sent_reminders_renewal ON sent_reminders(subscription_id, renewal_date, days);
+CREATE INDEX IF NOT EXISTS idx_sent_reminders_date ON sent_reminders(renewal_date);
@@ -0,0 +1,32 @@
+-- Webhook endpoints that are posted subscription events. events is a comma
+-- separated list of event types, empty for all of them; days is how long
+-- before a renewal its renewal.upcoming event is sent.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL DEFAULT '',
+    days INTEGER NOT NULL DEFAULT 3,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- Every event posted to a webhook, with the outcome of its latest attempt.
+-- Pending deliveries are retried from next_attempt_at. event_key identifies
+-- events that are only sent once, such as the reminder of a renewal.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    event_key TEXT,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    delivered_at TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_key ON webhook_deliveries(webhook_id, event_key);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(status, next_attempt_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscriptions_uuid;
+ALTER TABLE subscriptions DROP COLUMN uuid;
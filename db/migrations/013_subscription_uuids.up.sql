@@ -0,0 +1,11 @@
+-- Stable identity of a subscription across devices, so that sync can tell
+-- an edit from a delete and an add. Existing subscriptions get a random
+-- version 4 UUID.
+ALTER TABLE subscriptions ADD COLUMN uuid TEXT NOT NULL DEFAULT '';
+
+UPDATE subscriptions SET uuid = lower(
+    hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+    substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_uuid ON subscriptions(uuid);
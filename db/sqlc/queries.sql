@@ -1,6 +1,6 @@
 -- name: CreateSubscription :one
-INSERT INTO subscriptions (name, amount, currency, billing_cycle, next_renewal_date)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO subscriptions (name, amount, currency, billing_cycle, next_renewal_date, category_id, tags, status, trial_end_date, trial_price, uuid)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING *;
 
 -- name: GetSubscription :one
@@ -12,15 +12,21 @@ SELECT * FROM subscriptions ORDER BY name ASC;
 -- name: ListSubscriptionsByBillingCycle :many
 SELECT * FROM subscriptions WHERE billing_cycle = ? ORDER BY name ASC;
 
+-- name: ListSubscriptionsByCategory :many
+SELECT * FROM subscriptions WHERE category_id = ? ORDER BY name ASC;
+
 -- name: ListMonthlySubscriptions :many
 SELECT * FROM subscriptions WHERE billing_cycle = 'monthly' ORDER BY name ASC;
 
 -- name: ListYearlySubscriptions :many
 SELECT * FROM subscriptions WHERE billing_cycle = 'yearly' ORDER BY next_renewal_date ASC;
 
+-- name: ListOtherCycleSubscriptions :many
+SELECT * FROM subscriptions WHERE billing_cycle NOT IN ('monthly', 'yearly') ORDER BY name ASC;
+
 -- name: UpdateSubscription :one
 UPDATE subscriptions
-SET name = ?, amount = ?, currency = ?, billing_cycle = ?, next_renewal_date = ?, updated_at = datetime('now')
+SET name = ?, amount = ?, currency = ?, billing_cycle = ?, next_renewal_date = ?, category_id = ?, tags = ?, updated_at = datetime('now')
 WHERE id = ?
 RETURNING *;
 
@@ -30,6 +36,27 @@ SET next_renewal_date = ?, updated_at = datetime('now')
 WHERE id = ?
 RETURNING *;
 
+-- name: UpdateSubscriptionStatus :one
+UPDATE subscriptions
+SET status = ?, trial_end_date = ?, pause_date = ?, cancel_date = ?, updated_at = datetime('now')
+WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSubscriptionTrial :one
+UPDATE subscriptions
+SET status = ?, trial_end_date = ?, trial_price = ?, updated_at = datetime('now')
+WHERE id = ?
+RETURNING *;
+
+-- name: EndTrial :one
+UPDATE subscriptions
+SET status = 'active', amount = COALESCE(trial_price, amount), updated_at = datetime('now')
+WHERE id = ?
+RETURNING *;
+
+-- name: SetSubscriptionUUID :exec
+UPDATE subscriptions SET uuid = ? WHERE id = ?;
+
 -- name: DeleteSubscription :exec
 DELETE FROM subscriptions WHERE id = ?;
 
@@ -39,10 +66,34 @@ WHERE billing_cycle = 'yearly' AND strftime('%Y-%m', next_renewal_date) = ?
 ORDER BY next_renewal_date ASC;
 
 -- name: GetAllSubscriptionsForExport :many
-SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid
 FROM subscriptions
 ORDER BY name ASC;
 
+-- Category queries
+-- name: ListCategories :many
+SELECT * FROM categories ORDER BY name ASC;
+
+-- name: GetCategory :one
+SELECT * FROM categories WHERE id = ?;
+
+-- name: GetCategoryByName :one
+SELECT * FROM categories WHERE name = ?;
+
+-- name: CreateCategory :one
+INSERT INTO categories (name) VALUES (?)
+RETURNING *;
+
+-- name: RenameCategory :one
+UPDATE categories SET name = ? WHERE id = ?
+RETURNING *;
+
+-- name: DeleteCategory :exec
+DELETE FROM categories WHERE id = ?;
+
+-- name: ClearSubscriptionCategory :exec
+UPDATE subscriptions SET category_id = NULL, updated_at = datetime('now') WHERE category_id = ?;
+
 -- Config queries
 -- name: GetConfig :one
 SELECT value FROM config WHERE key = ?;
@@ -53,3 +104,153 @@ ON CONFLICT(key) DO UPDATE SET value = excluded.value;
 
 -- name: GetAllConfig :many
 SELECT key, value FROM config ORDER BY key;
+
+-- Exchange rate queries
+-- name: ListExchangeRates :many
+SELECT * FROM exchange_rates ORDER BY currency;
+
+-- name: GetExchangeRate :one
+SELECT * FROM exchange_rates WHERE currency = ?;
+
+-- name: SetExchangeRate :exec
+INSERT INTO exchange_rates (currency, rate) VALUES (?, ?)
+ON CONFLICT(currency) DO UPDATE SET rate = excluded.rate, updated_at = datetime('now');
+
+-- name: DeleteExchangeRate :exec
+DELETE FROM exchange_rates WHERE currency = ?;
+
+-- name: DeleteAllExchangeRates :exec
+DELETE FROM exchange_rates;
+
+-- Budget queries
+-- name: ListBudgets :many
+SELECT * FROM budgets ORDER BY category_id IS NOT NULL, category_id;
+
+-- name: GetOverallBudget :one
+SELECT * FROM budgets WHERE category_id IS NULL;
+
+-- name: GetCategoryBudget :one
+SELECT * FROM budgets WHERE category_id = ?;
+
+-- name: CreateBudget :one
+INSERT INTO budgets (category_id, amount) VALUES (?, ?)
+RETURNING *;
+
+-- name: UpdateBudgetAmount :one
+UPDATE budgets SET amount = ?, updated_at = datetime('now') WHERE id = ?
+RETURNING *;
+
+-- name: DeleteBudget :exec
+DELETE FROM budgets WHERE id = ?;
+
+-- name: DeleteCategoryBudget :exec
+DELETE FROM budgets WHERE category_id = ?;
+
+-- Payment queries
+-- name: ListPaymentsInPeriod :many
+SELECT * FROM payments
+WHERE paid_on >= sqlc.arg(start_date) AND paid_on <= sqlc.arg(end_date)
+ORDER BY paid_on ASC, id ASC;
+
+-- name: ListSubscriptionPayments :many
+SELECT * FROM payments WHERE subscription_id = ? ORDER BY paid_on DESC, id DESC;
+
+-- name: GetPayment :one
+SELECT * FROM payments WHERE id = ?;
+
+-- name: CreatePayment :one
+INSERT INTO payments (subscription_id, name, amount, currency, paid_on, source, note)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: RecordRenewalPayment :exec
+INSERT INTO payments (subscription_id, name, amount, currency, paid_on, source)
+VALUES (?, ?, ?, ?, ?, 'renewal')
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePayment :exec
+DELETE FROM payments WHERE id = ?;
+
+-- name: DeleteAllPayments :exec
+DELETE FROM payments;
+
+-- name: ClearPaymentSubscription :exec
+UPDATE payments SET subscription_id = NULL WHERE subscription_id = ?;
+
+-- Price change queries
+-- name: ListPriceChanges :many
+SELECT * FROM price_changes ORDER BY subscription_id, effective_date, id;
+
+-- name: ListSubscriptionPriceChanges :many
+SELECT * FROM price_changes WHERE subscription_id = ? ORDER BY effective_date, id;
+
+-- name: ListPriceChangesInPeriod :many
+SELECT price_changes.*, subscriptions.name FROM price_changes
+JOIN subscriptions ON subscriptions.id = price_changes.subscription_id
+WHERE price_changes.effective_date >= sqlc.arg(start_date) AND price_changes.effective_date <= sqlc.arg(end_date)
+ORDER BY price_changes.effective_date, price_changes.id;
+
+-- name: CreatePriceChange :one
+INSERT INTO price_changes (subscription_id, old_amount, old_currency, amount, currency, effective_date)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING *;
+
+-- name: DeleteSubscriptionPriceChanges :exec
+DELETE FROM price_changes WHERE subscription_id = ?;
+
+-- Reminder queries
+-- name: ListReminderSettings :many
+SELECT * FROM reminder_settings ORDER BY subscription_id;
+
+-- name: SetReminderSetting :exec
+INSERT INTO reminder_settings (subscription_id, lead_days) VALUES (?, ?)
+ON CONFLICT(subscription_id) DO UPDATE SET lead_days = excluded.lead_days;
+
+-- name: DeleteReminderSetting :exec
+DELETE FROM reminder_settings WHERE subscription_id = ?;
+
+-- name: ListSentReminders :many
+SELECT * FROM sent_reminders WHERE renewal_date >= ? ORDER BY subscription_id, renewal_date, days;
+
+-- name: RecordSentReminder :exec
+INSERT INTO sent_reminders (subscription_id, renewal_date, days) VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteSubscriptionSentReminders :exec
+DELETE FROM sent_reminders WHERE subscription_id = ?;
+
+-- Webhook queries
+-- name: CreateWebhook :one
+INSERT INTO webhooks (url, secret, events, days) VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: GetWebhook :one
+SELECT * FROM webhooks WHERE id = ?;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks ORDER BY id;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = ?;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event, event_key, payload, next_attempt_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(webhook_id, event_key) DO NOTHING
+RETURNING *;
+
+-- name: ListDueWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE status = 'pending' AND next_attempt_at <= ?
+ORDER BY next_attempt_at, id;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries ORDER BY id DESC LIMIT ?;
+
+-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = ?, response_code = ?, error = ?, next_attempt_at = ?, delivered_at = ?
+WHERE id = ?;
+
+-- name: DeleteWebhookDeliveries :exec
+DELETE FROM webhook_deliveries WHERE webhook_id = ?;
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -22,8 +23,17 @@ type App struct {
 	SubscriptionService *service.SubscriptionService
 	SpendingService     *service.SpendingService
 	ExportService       *service.ExportService
+	ReportService       *service.ReportService
+	ReminderService     *service.ReminderService
+	ImportService       *service.ImportService
+	StatementService    *service.StatementService
 	ConfigService       *service.ConfigService
 	SyncService         *service.SyncService
+	CurrencyService     *service.CurrencyService
+	CategoryService     *service.CategoryService
+	BudgetService       *service.BudgetService
+	PaymentService      *service.PaymentService
+	WebhookService      *service.WebhookService
 }
 
 func New() (*App, error) {
@@ -31,7 +41,12 @@ func New() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
+	return Open(dbPath)
+}
 
+// Open opens the database at dbPath, or any other go-sqlite3 data source such
+// as an in-memory database, and migrates it to the latest schema
+func Open(dbPath string) (*App, error) {
 	database, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -44,15 +59,34 @@ func New() (*App, error) {
 
 	queries := db.New(database)
 	configService := service.NewConfigService(queries)
+	subscriptionService := service.NewSubscriptionService(queries)
+	spendingService := service.NewSpendingService(queries, configService)
+	webhookService := service.NewWebhookService(queries)
+	subscriptionService.AddEventHandler(webhookService)
+
+	// Roll past renewal dates forward, recording the renewals in the payment ledger
+	if err := subscriptionService.AdvanceRenewalDates(context.Background()); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("failed to advance renewal dates: %w", err)
+	}
 
 	return &App{
 		DB:                  database,
 		Queries:             queries,
-		SubscriptionService: service.NewSubscriptionService(queries),
-		SpendingService:     service.NewSpendingService(queries, configService),
-		ExportService:       service.NewExportService(queries),
+		SubscriptionService: subscriptionService,
+		SpendingService:     spendingService,
+		ExportService:       service.NewExportService(queries, spendingService),
+		ReportService:       service.NewReportService(queries, spendingService),
+		ReminderService:     service.NewReminderService(queries, subscriptionService, configService),
+		ImportService:       service.NewImportService(queries, subscriptionService),
+		StatementService:    service.NewStatementService(queries, subscriptionService, configService),
 		ConfigService:       configService,
 		SyncService:         service.NewSyncService(queries, configService),
+		CurrencyService:     service.NewCurrencyService(queries, configService),
+		CategoryService:     service.NewCategoryService(queries),
+		BudgetService:       service.NewBudgetService(queries),
+		PaymentService:      service.NewPaymentService(queries),
+		WebhookService:      webhookService,
 	}, nil
 }
 
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"subscription-tracker/internal/service"
+)
+
+// runBudgets lists and edits the overall and per-category spending limits
+func runBudgets(ctx context.Context, c *CLI, args []string) error {
+	action := "list"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+
+	fs := c.newFlagSet("budgets")
+	category := fs.String("category", "", "category of the budget (default: the overall budget)")
+	output := addOutputFlag(fs)
+
+	// Positional arguments come before the flags
+	var positional []string
+	for len(args) > 0 && (args[0] == "" || args[0][0] != '-') {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+
+	switch action {
+	case "list":
+		format, err := parseOutputFormat(*output)
+		if err != nil {
+			return err
+		}
+		return c.listBudgets(ctx, format)
+
+	case "set":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: budgets set AMOUNT [--category NAME]")
+		}
+		amount, err := strconv.ParseFloat(positional[0], 64)
+		if err != nil {
+			return fmt.Errorf("invalid budget: %s", positional[0])
+		}
+		budget, err := c.app.BudgetService.Set(ctx, *category, amount)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Set %s budget to %.2f\n", budgetName(budget.Category), budget.Amount)
+		return c.warnBudgets(ctx)
+
+	case "delete":
+		if len(positional) != 0 {
+			return fmt.Errorf("usage: budgets delete [--category NAME]")
+		}
+		if err := c.app.BudgetService.Delete(ctx, *category); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Deleted %s budget\n", budgetName(*category))
+		return nil
+	}
+
+	return fmt.Errorf("unknown budgets action: %s (use list, set or delete)", action)
+}
+
+// budgetName names a budget by its category, or "overall"
+func budgetName(category string) string {
+	return service.BudgetStatus{Category: category}.Label()
+}
+
+// listBudgets prints every budget with its use in the current billing period
+func (c *CLI) listBudgets(ctx context.Context, format outputFormat) error {
+	summary, err := c.app.SpendingService.CalculateForCurrentMonth(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to calculate spending: %w", err)
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(NewBudgetOutputs(summary.Budgets))
+	case outputCSV:
+		var rows [][]string
+		for _, b := range NewBudgetOutputs(summary.Budgets) {
+			rows = append(rows, []string{
+				b.Category,
+				fmt.Sprintf("%.2f", b.Limit),
+				fmt.Sprintf("%.2f", b.Used),
+				fmt.Sprintf("%.2f", b.Remaining),
+				fmt.Sprintf("%.1f", b.Percent),
+				b.State,
+			})
+		}
+		return c.writeCSV([]string{"Category", "Limit", "Used", "Remaining", "Percent", "State"}, rows)
+	}
+
+	if len(summary.Budgets) == 0 {
+		fmt.Fprintln(c.stdout, "No budgets set")
+		return nil
+	}
+
+	fmt.Fprintf(c.stdout, "Budgets for %s - %s in %s\n\n",
+		summary.PeriodStart.Format("2006-01-02"), summary.PeriodEnd.Format("2006-01-02"), summary.BaseCurrency)
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BUDGET\tLIMIT\tUSED\tREMAINING\tPERCENT\tSTATE")
+	for _, b := range summary.Budgets {
+		fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%.2f\t%.0f%%\t%s\n",
+			b.Label(), b.Limit, b.Used, b.Remaining, b.Percent, b.State)
+	}
+	return tw.Flush()
+}
+
+// warnBudgets prints a warning for each budget that the current billing
+// period has nearly used up or exceeded
+func (c *CLI) warnBudgets(ctx context.Context) error {
+	summary, err := c.app.SpendingService.CalculateForCurrentMonth(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to calculate spending: %w", err)
+	}
+
+	for _, b := range service.BudgetAlerts(summary.Budgets) {
+		if b.State == service.BudgetOver {
+			fmt.Fprintf(c.stderr, "Warning: %s budget exceeded: %.2f of %.2f %s\n", b.Label(), b.Used, b.Limit, summary.BaseCurrency)
+		} else {
+			fmt.Fprintf(c.stderr, "Warning: %s budget %.0f%% used: %.2f of %.2f %s\n", b.Label(), b.Percent, b.Used, b.Limit, summary.BaseCurrency)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// runCategories lists and edits subscription categories
+func runCategories(ctx context.Context, c *CLI, args []string) error {
+	action := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "list":
+		fs := c.newFlagSet("categories")
+		output := addOutputFlag(fs)
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		format, err := parseOutputFormat(*output)
+		if err != nil {
+			return err
+		}
+		return c.listCategories(ctx, format)
+
+	case "add":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: categories add NAME")
+		}
+		category, err := c.app.CategoryService.Create(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Added category %d (%s)\n", category.ID, category.Name)
+		return nil
+
+	case "rename":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: categories rename ID NAME")
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %s", args[0])
+		}
+		category, err := c.app.CategoryService.Rename(ctx, id, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to rename category %d: %w", id, err)
+		}
+		fmt.Fprintf(c.stdout, "Renamed category %d to %s\n", category.ID, category.Name)
+		return nil
+
+	case "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: categories delete ID")
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %s", args[0])
+		}
+		if err := c.app.CategoryService.Delete(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+		fmt.Fprintf(c.stdout, "Deleted category %d\n", id)
+		return nil
+	}
+
+	return fmt.Errorf("unknown categories action: %s (use list, add, rename or delete)", action)
+}
+
+func (c *CLI) listCategories(ctx context.Context, format outputFormat) error {
+	categories, err := c.app.CategoryService.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	switch format {
+	case outputJSON:
+		out := make([]CategoryOutput, len(categories))
+		for i, category := range categories {
+			out[i] = CategoryOutput{ID: category.ID, Name: category.Name}
+		}
+		return c.writeJSON(out)
+	case outputCSV:
+		var rows [][]string
+		for _, category := range categories {
+			rows = append(rows, []string{strconv.FormatInt(category.ID, 10), category.Name})
+		}
+		return c.writeCSV([]string{"ID", "Name"}, rows)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME")
+	for _, category := range categories {
+		fmt.Fprintf(tw, "%d\t%s\n", category.ID, category.Name)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"subscription-tracker/internal/app"
+)
+
+// command is a headless subcommand
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *CLI, args []string) error
+}
+
+// commands is populated in init to avoid an initialization cycle through newFlagSet
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"list":       {"list [--cycle CYCLE] [--category NAME] [--tag TAG] [--status STATUS] [--output table|json|csv]", runList},
+		"add":        {"add --name NAME --amount AMOUNT --cycle CYCLE --renewal YYYY-MM-DD [--currency CUR] [--category NAME] [--tags a,b] [--trial-end YYYY-MM-DD] [--trial-price AMOUNT]", runAdd},
+		"edit":       {"edit ID [--name NAME] [--amount AMOUNT] [--currency CUR] [--cycle CYCLE] [--renewal YYYY-MM-DD] [--category NAME] [--tags a,b] [--effective YYYY-MM-DD]", runEdit},
+		"delete":     {"delete ID", runDelete},
+		"pause":      {"pause ID [--date YYYY-MM-DD]", runPause},
+		"resume":     {"resume ID", runResume},
+		"cancel":     {"cancel ID [--date YYYY-MM-DD]", runCancel},
+		"spending":   {"spending [--year YYYY] [--month MM] [--output table|json|csv]", runSpending},
+		"reminders":  {"reminders [list|set ID DAYS|reset ID|default DAYS] [--output table|json|csv]", runReminders},
+		"report":     {"report [--year YYYY] [--month MM] [--format html|markdown] [--file PATH] [--upcoming DAYS]", runReport},
+		"config":     {"config [--output table|json|csv]", runConfig},
+		"rates":      {"rates [list|set CUR RATE|delete CUR|base CUR|import FILE] [--format ecb|csv] [--reference CUR] [--output table|json|csv]", runRates},
+		"export":     {"export [--format csv|json|xlsx|ics|ledger|hledger|beancount] [--file PATH] [--by-category] [--year YYYY] [--recurring] [--months N] [--remind DAYS] [--periodic] [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--expense-account ACCOUNT] [--account ACCOUNT]", runExport},
+		"import":     {"import FILE [--format csv|json] [--mode merge|replace] [--dry-run] [--skip-invalid]", runImport},
+		"detect":     {"detect FILE [--format ofx|qif|csv] [--date COL] [--payee COL] [--amount COL | --debit COL --credit COL] [--date-format LAYOUT] [--delimiter C] [--decimal-comma] [--debits-positive] [--no-header] [--min N] [--accept all|1,3] [--output table|json|csv]", runDetect},
+		"categories": {"categories [list|add NAME|rename ID NAME|delete ID] [--output table|json|csv]", runCategories},
+		"budgets":    {"budgets [list|set AMOUNT|delete] [--category NAME] [--output table|json|csv]", runBudgets},
+		"trials":     {"trials [list|set ID YYYY-MM-DD] [--days N] [--price AMOUNT] [--output table|json|csv]", runTrials},
+		"forecast":   {"forecast [--months N] [--from YYYY-MM-DD --to YYYY-MM-DD] [--top N] [--output table|json|csv]", runForecast},
+		"prices":     {"prices [ID] [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--output table|json|csv]", runPrices},
+		"payments":   {"payments [list|add|delete ID] [--subscription ID] [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--name NAME] [--amount AMOUNT] [--currency CUR] [--date YYYY-MM-DD] [--note TEXT] [--output table|json|csv]", runPayments},
+		"serve-ical": {"serve-ical [--addr HOST:PORT] [--token TOKEN] [--new-token] [--recurring=false] [--months N] [--remind DAYS]", runServeICal},
+		"watch":      {"watch [--interval 1h] [--once] [--desktop] [--email ADDR,..] [--smtp HOST:PORT] [--smtp-user USER] [--from ADDR] [--exec COMMAND]", runWatch},
+		"webhooks":   {"webhooks [list|add URL|delete ID|log|retry] [--events a,b] [--days N] [--secret KEY] [--limit N] [--output table|json|csv]", runWebhooks},
+		"push":       {"push [--password PASS] [--force] [--backend gist|dir|webdav|s3|git] [--token TOKEN] [--gist-id ID] [--dir DIR] [--webdav-url URL] [--webdav-user USER] [--s3-endpoint URL] [--s3-region REGION] [--s3-bucket BUCKET] [--s3-prefix PREFIX] [--s3-path-style] [--s3-access-key KEY] [--s3-versioned] [--git-repo REPO] [--git-branch BRANCH] [--git-plaintext]", runPush},
+		"pull":       {"pull [--password PASS] [--backup NAME] [--resolve local|remote|both] [--backend gist|dir|webdav|s3|git] [--token TOKEN] [--gist-id ID] [--dir DIR] [--webdav-url URL] [--webdav-user USER] [--s3-endpoint URL] [--s3-region REGION] [--s3-bucket BUCKET] [--s3-prefix PREFIX] [--s3-path-style] [--s3-access-key KEY] [--s3-versioned] [--git-repo REPO] [--git-branch BRANCH] [--git-plaintext]", runPull},
+		"backups":    {"backups [--backend gist|dir|webdav|s3|git] [--token TOKEN] [--gist-id ID] [--dir DIR] [--webdav-url URL] [--webdav-user USER] [--s3-endpoint URL] [--s3-region REGION] [--s3-bucket BUCKET] [--s3-prefix PREFIX] [--s3-path-style] [--s3-access-key KEY] [--s3-versioned] [--git-repo REPO] [--git-branch BRANCH] [--git-plaintext]", runBackups},
+	}
+}
+
+// CLI runs headless subcommands against the application services
+type CLI struct {
+	app    *app.App
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// New creates a new CLI writing to the given streams
+func New(application *app.App, stdout, stderr io.Writer) *CLI {
+	return &CLI{
+		app:    application,
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+// IsCommand reports whether name is a known subcommand
+func IsCommand(name string) bool {
+	if name == "help" || name == "-h" || name == "--help" {
+		return true
+	}
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes the subcommand named by args[0]
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command given")
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		c.printUsage(c.stdout)
+		return nil
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		c.printUsage(c.stderr)
+		return &UsageError{Err: fmt.Errorf("unknown command: %s", name)}
+	}
+
+	queued := c.app.WebhookService.Queued()
+	if err := cmd.run(ctx, c, args[1:]); err != nil && !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	// A command that changed subscriptions posts the events it queued, and
+	// whatever else is due; read-only commands never post. A failed delivery
+	// is retried by watch or webhooks retry.
+	err := c.app.WebhookService.QueueErr()
+	if c.app.WebhookService.Queued() > queued {
+		err = errors.Join(err, c.retryWebhooks(ctx))
+	}
+	if err != nil {
+		fmt.Fprintf(c.stderr, "Warning: %v\n", err)
+	}
+	return nil
+}
+
+// UsageError is returned by Run for a command line that can't be run as
+// given, such as an unknown command or flag, or a flag value out of range
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode is the exit status for an error returned by Run: 2 for usage errors, 1 otherwise
+func ExitCode(err error) int {
+	var usage *UsageError
+	if errors.As(err, &usage) {
+		return 2
+	}
+	return 1
+}
+
+func (c *CLI) printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: subscription-tracker [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command the interactive TUI is started.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func (c *CLI) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: subscription-tracker %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of a command, reporting bad flags as usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return &UsageError{Err: err}
+	}
+	return nil
+}
+
+// invalidFlag reports a flag value that parsed but can't be used, along with
+// what the flag is for
+func invalidFlag(fs *flag.FlagSet, name, problem string) error {
+	f := fs.Lookup(name)
+	return &UsageError{Err: fmt.Errorf("invalid value %q for flag -%s: %s (%s)", f.Value.String(), name, problem, f.Usage)}
+}
+
+// parseID parses a subscription ID positional argument
+func parseID(args []string) (int64, []string, error) {
+	if len(args) == 0 {
+		return 0, nil, fmt.Errorf("subscription ID is required")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, nil, fmt.Errorf("invalid subscription ID: %s", args[0])
+	}
+	return id, args[1:], nil
+}
+
+// flagsSet returns the names of the flags that were explicitly given
+func flagsSet(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// cycleFlagUsage describes the accepted values of --cycle flags
+const cycleFlagUsage = "billing cycle (weekly, biweekly, monthly, quarterly, semiannual, yearly or 'every N days|weeks|months|years')"
+
+// envOr returns value if non-empty, otherwise the named environment variable
+func envOr(value, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
@@ -0,0 +1,227 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/app"
+	"subscription-tracker/internal/cli"
+)
+
+// testCLI runs commands against an in-memory database
+type testCLI struct {
+	t   *testing.T
+	app *app.App
+}
+
+// setupTestCLI opens an in-memory database with the migrated schema. Every
+// test gets its own database, shared by the connections of its pool.
+func setupTestCLI(t *testing.T) *testCLI {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	application, err := app.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", name))
+	if err != nil {
+		t.Fatalf("failed to open test app: %v", err)
+	}
+	t.Cleanup(func() {
+		application.Close()
+	})
+	return &testCLI{t: t, app: application}
+}
+
+// run runs one command line and returns what it printed to stdout and stderr
+func (tc *testCLI) run(args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	err := cli.New(tc.app, &stdout, &stderr).Run(context.Background(), args)
+	return stdout.String(), stderr.String(), err
+}
+
+// listJSON returns the subscriptions printed by `list --output json`
+func (tc *testCLI) listJSON() []map[string]any {
+	tc.t.Helper()
+	stdout, _, err := tc.run("list", "--output", "json")
+	if err != nil {
+		tc.t.Fatalf("list error = %v", err)
+	}
+	var subs []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &subs); err != nil {
+		tc.t.Fatalf("list printed invalid JSON: %v\n%s", err, stdout)
+	}
+	return subs
+}
+
+func TestRun_AddEditDelete(t *testing.T) {
+	tc := setupTestCLI(t)
+	renewal := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+
+	steps := []struct {
+		args []string
+		want string // printed to stdout
+		subs []string
+	}{
+		{
+			args: []string{"add", "--name", "Netflix", "--amount", "15.99", "--cycle", "monthly", "--renewal", renewal, "--category", "streaming", "--tags", "tv,family"},
+			want: "Added subscription 1 (Netflix)",
+			subs: []string{"Netflix 15.99 USD monthly streaming family,tv"},
+		},
+		{
+			args: []string{"add", "--name", "Spotify", "--amount", "9.99", "--currency", "eur", "--cycle", "every 4 weeks", "--renewal", renewal},
+			want: "Added subscription 2 (Spotify)",
+			subs: []string{"Netflix 15.99 USD monthly streaming family,tv", "Spotify 9.99 EUR every 4 weeks uncategorized "},
+		},
+		{
+			// Flags that aren't given keep their stored values
+			args: []string{"edit", "1", "--amount", "17.99", "--tags", "tv"},
+			want: "Updated subscription 1 (Netflix)",
+			subs: []string{"Netflix 17.99 USD monthly streaming tv", "Spotify 9.99 EUR every 4 weeks uncategorized "},
+		},
+		{
+			args: []string{"edit", "2", "--name", "Music", "--cycle", "yearly", "--category", "music"},
+			want: "Updated subscription 2 (Music)",
+			subs: []string{"Netflix 17.99 USD monthly streaming tv", "Music 9.99 EUR yearly music "},
+		},
+		{
+			args: []string{"delete", "1"},
+			want: "Deleted subscription 1 (Netflix)",
+			subs: []string{"Music 9.99 EUR yearly music "},
+		},
+	}
+	for _, step := range steps {
+		stdout, _, err := tc.run(step.args...)
+		if err != nil {
+			t.Fatalf("%s error = %v", strings.Join(step.args, " "), err)
+		}
+		if !strings.Contains(stdout, step.want) {
+			t.Errorf("%s printed %q, want %q", strings.Join(step.args, " "), stdout, step.want)
+		}
+
+		var subs []string
+		for _, sub := range tc.listJSON() {
+			var tags []string
+			for _, tag := range sub["tags"].([]any) {
+				tags = append(tags, tag.(string))
+			}
+			subs = append(subs, fmt.Sprintf("%s %v %s %s %s %s", sub["name"], sub["amount"], sub["currency"], sub["billing_cycle"], sub["category"], strings.Join(tags, ",")))
+		}
+		slices.Sort(subs)
+		want := slices.Clone(step.subs)
+		slices.Sort(want)
+		if !slices.Equal(subs, want) {
+			t.Errorf("after %s, list = %q, want %q", strings.Join(step.args, " "), subs, want)
+		}
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	tc := setupTestCLI(t)
+	if _, _, err := tc.run("add", "--name", "Netflix", "--amount", "15.99", "--renewal", "2099-01-15"); err != nil {
+		t.Fatalf("add error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		code  int
+		usage string // printed to stderr
+	}{
+		{"unknown command", []string{"frobnicate"}, 2, "Usage: subscription-tracker [command]"},
+		{"unknown flag", []string{"add", "--name", "Hulu", "--price", "5"}, 2, "Usage: subscription-tracker add"},
+		{"bad number", []string{"add", "--name", "Hulu", "--amount", "five"}, 2, "Usage: subscription-tracker add"},
+		{"unknown flag after ID", []string{"edit", "1", "--colour", "red"}, 2, "Usage: subscription-tracker edit"},
+		{"bad output format", []string{"list", "--output", "yaml"}, 2, ""},
+		{"negative top", []string{"forecast", "--top", "-1"}, 2, ""},
+		{"invalid subscription", []string{"add", "--name", "Hulu", "--amount", "-5"}, 1, ""},
+		{"missing subscription", []string{"edit", "99", "--amount", "5"}, 1, ""},
+		{"missing ID", []string{"delete"}, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, err := tc.run(tt.args...)
+			if err == nil {
+				t.Fatalf("%s should fail", strings.Join(tt.args, " "))
+			}
+			if code := cli.ExitCode(err); code != tt.code {
+				t.Errorf("ExitCode(%v) = %d, want %d", err, code, tt.code)
+			}
+			if !strings.Contains(stderr, tt.usage) {
+				t.Errorf("stderr = %q, want the usage %q", stderr, tt.usage)
+			}
+		})
+	}
+
+	// Failed commands change nothing
+	if subs := tc.listJSON(); len(subs) != 1 || subs[0]["amount"] != 15.99 {
+		t.Errorf("list = %v, want Netflix at 15.99 only", subs)
+	}
+}
+
+// jsonKeys returns the keys of a JSON object, or of the first object of an array
+func jsonKeys(t *testing.T, data string) []string {
+	t.Helper()
+	var value any
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	if array, ok := value.([]any); ok {
+		if len(array) == 0 {
+			t.Fatalf("empty JSON array, want at least one object")
+		}
+		value = array[0]
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		t.Fatalf("JSON %s is not an object", data)
+	}
+	var keys []string
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestRun_JSONOutput(t *testing.T) {
+	tc := setupTestCLI(t)
+	renewal := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+	for _, args := range [][]string{
+		{"add", "--name", "Netflix", "--amount", "15.99", "--cycle", "monthly", "--renewal", renewal, "--category", "streaming", "--tags", "tv"},
+		{"rates", "set", "EUR", "0.92"},
+		{"budgets", "set", "100"},
+	} {
+		if _, _, err := tc.run(args...); err != nil {
+			t.Fatalf("%s error = %v", strings.Join(args, " "), err)
+		}
+	}
+
+	// The keys documented in output.go and the README; omitted ones are unset
+	tests := []struct {
+		args []string
+		keys []string
+	}{
+		{[]string{"list"}, []string{"amount", "billing_cycle", "category", "created_at", "currency", "id", "name", "next_renewal_date", "status", "tags", "updated_at"}},
+		{[]string{"spending"}, []string{"average_monthly", "base_currency", "budgets", "categories", "charges", "cutoff_day", "grand_total", "missing_rates", "month", "monthly_items", "monthly_salary", "monthly_total", "other_items", "other_total", "period_end", "period_start", "remaining", "year", "yearly_items", "yearly_total"}},
+		{[]string{"forecast", "--months", "3"}, []string{"average", "base_currency", "cutoff_day", "missing_rates", "most_expensive", "periods", "total"}},
+		{[]string{"budgets"}, []string{"category", "limit", "percent", "remaining", "state", "used"}},
+		{[]string{"categories"}, []string{"id", "name"}},
+		{[]string{"config"}, []string{"base_currency", "month_cutoff_day", "monthly_salary", "reminder_days", "trial_warn_days"}},
+		{[]string{"rates"}, []string{"currency", "rate", "updated_at"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args[0], func(t *testing.T) {
+			stdout, _, err := tc.run(append(tt.args, "--output", "json")...)
+			if err != nil {
+				t.Fatalf("%s error = %v", tt.args[0], err)
+			}
+			if keys := jsonKeys(t, stdout); !slices.Equal(keys, tt.keys) {
+				t.Errorf("%s --output json keys = %q, want %q", tt.args[0], keys, tt.keys)
+			}
+		})
+	}
+}
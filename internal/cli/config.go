@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"subscription-tracker/internal/service"
+)
+
+func runConfig(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("config")
+	output := addOutputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	config, err := c.app.ConfigService.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(NewConfigOutput(config))
+	case outputCSV:
+		return c.writeCSV([]string{"Key", "Value"}, [][]string{
+			{"month_cutoff_day", strconv.Itoa(config.MonthCutoffDay)},
+			{"monthly_salary", strconv.FormatFloat(config.MonthlySalary, 'f', 2, 64)},
+			{"base_currency", config.BaseCurrency},
+			{"trial_warn_days", strconv.Itoa(config.TrialWarnDays)},
+			{"reminder_days", service.FormatReminderDays(config.ReminderDays)},
+		})
+	}
+
+	fmt.Fprintf(c.stdout, "Payday (month cutoff day): %d\n", config.MonthCutoffDay)
+	fmt.Fprintf(c.stdout, "Monthly salary:            %.2f\n", config.MonthlySalary)
+	fmt.Fprintf(c.stdout, "Base currency:             %s\n", config.BaseCurrency)
+	fmt.Fprintf(c.stdout, "Trial warning (days):      %d\n", config.TrialWarnDays)
+	fmt.Fprintf(c.stdout, "Reminders (days before):   %s\n", service.FormatReminderDays(config.ReminderDays))
+	return nil
+}
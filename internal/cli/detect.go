@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"subscription-tracker/internal/service"
+)
+
+// runDetect finds recurring charges in a bank statement and adds the accepted ones as subscriptions
+func runDetect(ctx context.Context, c *CLI, args []string) error {
+	var path string
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		path, args = args[0], args[1:]
+	}
+
+	fs := c.newFlagSet("detect")
+	format := fs.String("format", "", "statement format (ofx, qif or csv, default: from the file extension)")
+	date := fs.String("date", "", "CSV column of the transaction date, by header or number from 1 (default: guessed)")
+	payee := fs.String("payee", "", "CSV column of the payee or description (default: guessed)")
+	amount := fs.String("amount", "", "CSV column of the signed amount (default: guessed)")
+	debit := fs.String("debit", "", "CSV column of money going out, instead of --amount (default: guessed)")
+	credit := fs.String("credit", "", "CSV column of money coming in, with --debit (default: guessed)")
+	dateFormat := fs.String("date-format", "", "Go layout of CSV dates, such as 02.01.2006 (default: the first known layout that fits every date)")
+	delimiter := fs.String("delimiter", "", "CSV field delimiter, or tab (default: guessed from the first line)")
+	decimalComma := fs.Bool("decimal-comma", false, "CSV amounts are written as 1.234,56")
+	debitsPositive := fs.Bool("debits-positive", false, "the CSV amount column is positive for money going out")
+	noHeader := fs.Bool("no-header", false, "the CSV file has no header row; give columns by number")
+	minOccurrences := fs.Int("min", service.DefaultMinOccurrences, "charges needed to call a payee recurring (yearly ones need 2)")
+	accept := fs.String("accept", "", "add candidates as subscriptions: all, or numbers such as 1,3")
+	output := addOutputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if path == "" && fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+	if path == "" {
+		return fmt.Errorf("usage: detect FILE [--format ofx|qif|csv] [--accept all|1,3]")
+	}
+	outFormat, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = string(service.StatementFormatOf(path))
+	}
+	if *delimiter == `\t` || *delimiter == "tab" {
+		*delimiter = "\t"
+	}
+	if utf8.RuneCountInString(*delimiter) > 1 {
+		return fmt.Errorf("delimiter must be a single character: %q", *delimiter)
+	}
+	sep, _ := utf8.DecodeRuneInString(*delimiter)
+	if *delimiter == "" {
+		sep = 0
+	}
+	mapping := service.StatementMapping{
+		Date:           *date,
+		Payee:          *payee,
+		Amount:         *amount,
+		Debit:          *debit,
+		Credit:         *credit,
+		DateFormat:     *dateFormat,
+		Delimiter:      sep,
+		DecimalComma:   *decimalComma,
+		DebitsPositive: *debitsPositive,
+		NoHeader:       *noHeader,
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	candidates, err := c.app.StatementService.Detect(ctx, file, service.StatementFormat(*format), mapping, *minOccurrences)
+	if err != nil {
+		return err
+	}
+	selected, err := parseAccept(*accept, len(candidates))
+	if err != nil {
+		return err
+	}
+
+	if *accept == "" {
+		return c.listCandidates(candidates, outFormat)
+	}
+
+	created := 0
+	for _, n := range selected {
+		candidate := candidates[n-1]
+		if candidate.ExistingID != 0 && *accept == "all" {
+			continue
+		}
+		sub, err := c.app.StatementService.Accept(ctx, candidate)
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", candidate.Name, err)
+		}
+		fmt.Fprintf(c.stdout, "Added %s (ID %d): %.2f %s %s, next renewal %s\n",
+			sub.Name, sub.ID, sub.Amount, sub.Currency, sub.BillingCycle, sub.NextRenewalDate.String)
+		created++
+	}
+	fmt.Fprintf(c.stdout, "Added %d of %d candidates\n", created, len(candidates))
+	return c.warnBudgets(ctx)
+}
+
+// parseAccept parses the --accept flag into candidate numbers from 1
+func parseAccept(value string, count int) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var numbers []int
+	if value == "all" {
+		for n := 1; n <= count; n++ {
+			numbers = append(numbers, n)
+		}
+		return numbers, nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > count {
+			return nil, fmt.Errorf("invalid candidate number: %s (use 1 to %d)", part, count)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// listCandidates prints the recurring charges found in a statement
+func (c *CLI) listCandidates(candidates []service.RecurringCandidate, format outputFormat) error {
+	outputs := make([]CandidateOutput, len(candidates))
+	for i, candidate := range candidates {
+		outputs[i] = NewCandidateOutput(i+1, candidate)
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(outputs)
+	case outputCSV:
+		var rows [][]string
+		for _, o := range outputs {
+			existing := ""
+			if o.ExistingID != nil {
+				existing = strconv.FormatInt(*o.ExistingID, 10)
+			}
+			rows = append(rows, []string{
+				strconv.Itoa(o.Number),
+				o.Name,
+				fmt.Sprintf("%.2f", o.Amount),
+				o.Currency,
+				o.BillingCycle,
+				o.NextRenewalDate,
+				strconv.Itoa(o.Occurrences),
+				o.FirstCharge,
+				o.LastCharge,
+				fmt.Sprintf("%.2f", o.Confidence),
+				existing,
+			})
+		}
+		return c.writeCSV([]string{"Number", "Name", "Amount", "Currency", "Billing Cycle", "Next Renewal Date",
+			"Occurrences", "First Charge", "Last Charge", "Confidence", "Existing ID"}, rows)
+	}
+
+	if len(outputs) == 0 {
+		fmt.Fprintln(c.stdout, "No recurring charges found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tNAME\tAMOUNT\tCYCLE\tRENEWAL\tCHARGES\tSINCE\tTRACKED")
+	for _, o := range outputs {
+		tracked := ""
+		if o.ExistingID != nil {
+			tracked = fmt.Sprintf("ID %d", *o.ExistingID)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%.2f %s\t%s\t%s\t%d\t%s\t%s\n",
+			o.Number, o.Name, o.Amount, o.Currency, o.BillingCycle, o.NextRenewalDate, o.Occurrences, o.FirstCharge, tracked)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, "\nAdd candidates with --accept all or --accept 1,3; all skips the tracked ones")
+	return nil
+}
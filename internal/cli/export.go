@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func runExport(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("export")
+	format := fs.String("format", "csv", "export format (csv, json, xlsx, ics, ledger, hledger or beancount)")
+	path := fs.String("file", "", "write to this file instead of stdout")
+	byCategory := fs.Bool("by-category", false, "export annual totals per category instead of subscriptions")
+	recurring := fs.Bool("recurring", false, "ics: one repeating event per subscription instead of one event per renewal")
+	months := fs.Int("months", service.DefaultICSMonths, "ics: months of renewals to export")
+	remind := fs.Int("remind", 0, "ics: add a reminder this many days before each renewal")
+	year := fs.Int("year", 0, "xlsx: year of the monthly spending sheet (default: this year)")
+	periodic := fs.Bool("periodic", false, "ledger, hledger, beancount: periodic rules or custom entries instead of dated transactions")
+	from := fs.String("from", "", "ledger, hledger, beancount: first day of the transactions (YYYY-MM-DD, default: the first of this month)")
+	to := fs.String("to", "", "ledger, hledger, beancount: last day of the transactions (YYYY-MM-DD, default: the end of the month of --from)")
+	expenseAccount := fs.String("expense-account", service.DefaultExpenseAccount, "ledger, hledger, beancount: parent of an account per category")
+	account := fs.String("account", service.DefaultFundingAccount, "ledger, hledger, beancount: account the subscriptions are paid from")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if service.ExportFormat(*format) == service.FormatXLSX && *path == "" {
+		return fmt.Errorf("xlsx export needs --file")
+	}
+
+	var w io.Writer = c.stdout
+	if *path != "" {
+		file, err := os.Create(*path)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *byCategory {
+		totals, err := c.app.SpendingService.CalculateAnnualByCategory(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to calculate category totals: %w", err)
+		}
+		base, err := c.app.ConfigService.GetBaseCurrency(ctx)
+		if err != nil {
+			return err
+		}
+		if err := c.app.ExportService.ExportCategoryTotals(w, totals, base, service.ExportFormat(*format)); err != nil {
+			return err
+		}
+		if *path != "" {
+			fmt.Fprintf(c.stdout, "Exported %d category totals to %s\n", len(totals), *path)
+		}
+		return nil
+	}
+
+	if service.ExportFormat(*format) == service.FormatXLSX {
+		count, err := c.app.ExportService.ExportXLSX(ctx, w, service.XLSXOptions{Year: *year})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Exported %d subscriptions with spending summaries to %s\n", count, *path)
+		return nil
+	}
+
+	if service.ExportFormat(*format) == service.FormatICS {
+		count, err := c.app.ExportService.ExportICS(ctx, w, service.ICSOptions{
+			Recurring:  *recurring,
+			Months:     *months,
+			RemindDays: *remind,
+		})
+		if err != nil {
+			return err
+		}
+		if *path != "" {
+			fmt.Fprintf(c.stdout, "Exported %d calendar events to %s\n", count, *path)
+		}
+		return nil
+	}
+
+	if service.IsLedgerFormat(service.ExportFormat(*format)) {
+		opts := service.LedgerOptions{Periodic: *periodic, ExpenseAccount: *expenseAccount, FundingAccount: *account}
+		if *periodic && (*from != "" || *to != "") {
+			return fmt.Errorf("--from and --to only apply to dated transactions, not --periodic")
+		}
+		if *from != "" {
+			date, err := time.Parse("2006-01-02", *from)
+			if err != nil {
+				return fmt.Errorf("invalid --from date, use YYYY-MM-DD: %w", err)
+			}
+			opts.From = date
+		}
+		if *to != "" {
+			date, err := time.Parse("2006-01-02", *to)
+			if err != nil {
+				return fmt.Errorf("invalid --to date, use YYYY-MM-DD: %w", err)
+			}
+			opts.To = date
+		}
+
+		count, err := c.app.ExportService.ExportLedger(ctx, w, service.ExportFormat(*format), opts)
+		if err != nil {
+			return err
+		}
+		if *path != "" {
+			entries := "transactions"
+			if *periodic {
+				entries = "periodic entries"
+			}
+			fmt.Fprintf(c.stdout, "Exported %d %s to %s\n", count, entries, *path)
+		}
+		return nil
+	}
+
+	count, err := c.app.ExportService.Export(ctx, w, service.ExportFormat(*format))
+	if err != nil {
+		return err
+	}
+
+	if *path != "" {
+		fmt.Fprintf(c.stdout, "Exported %d subscriptions to %s\n", count, *path)
+	}
+	return nil
+}
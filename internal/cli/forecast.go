@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+// runForecast projects spending over the coming billing periods or a date range
+func runForecast(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("forecast")
+	months := fs.Int("months", 12, "number of billing periods to forecast, starting with the current one")
+	from := fs.String("from", "", "forecast the billing periods from this date (YYYY-MM-DD, requires --to)")
+	to := fs.String("to", "", "forecast the billing periods up to this date (YYYY-MM-DD, requires --from)")
+	top := fs.Int("top", 3, "number of most expensive periods to list")
+	output := addOutputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+	if *top < 0 {
+		return invalidFlag(fs, "top", "must not be negative")
+	}
+
+	var forecast *service.Forecast
+	if *from != "" || *to != "" {
+		if *from == "" || *to == "" {
+			return fmt.Errorf("--from and --to must be given together")
+		}
+		start, err := time.Parse("2006-01-02", *from)
+		if err != nil {
+			return fmt.Errorf("invalid --from date, use YYYY-MM-DD: %w", err)
+		}
+		end, err := time.Parse("2006-01-02", *to)
+		if err != nil {
+			return fmt.Errorf("invalid --to date, use YYYY-MM-DD: %w", err)
+		}
+		forecast, err = c.app.SpendingService.ForecastRange(ctx, start, end)
+		if err != nil {
+			return err
+		}
+	} else if forecast, err = c.app.SpendingService.Forecast(ctx, *months); err != nil {
+		return err
+	}
+
+	categories, err := c.app.CategoryService.Names(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(NewForecastOutput(forecast, *top, categories))
+	case outputCSV:
+		var rows [][]string
+		for _, p := range forecast.Periods {
+			rows = append(rows, []string{
+				strconv.Itoa(p.Year),
+				strconv.Itoa(p.Month),
+				p.PeriodStart.Format("2006-01-02"),
+				p.PeriodEnd.Format("2006-01-02"),
+				strconv.Itoa(len(p.Charges)),
+				forecast.BaseCurrency,
+				fmt.Sprintf("%.2f", p.Total),
+				fmt.Sprintf("%.2f", p.Cumulative),
+			})
+		}
+		return c.writeCSV([]string{"Year", "Month", "Period Start", "Period End", "Charges", "Base Currency", "Total", "Cumulative"}, rows)
+	}
+
+	first, last := forecast.Periods[0], forecast.Periods[len(forecast.Periods)-1]
+	fmt.Fprintf(c.stdout, "Forecast for %s - %s in %s\n\n",
+		first.PeriodStart.Format("2006-01-02"), last.PeriodEnd.Format("2006-01-02"), forecast.BaseCurrency)
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PERIOD\tSTART\tEND\tCHARGES\tTOTAL\tCUMULATIVE")
+	for _, p := range forecast.Periods {
+		fmt.Fprintf(tw, "%s %d\t%s\t%s\t%d\t%.2f\t%.2f\n",
+			time.Month(p.Month).String()[:3], p.Year, p.PeriodStart.Format("2006-01-02"), p.PeriodEnd.Format("2006-01-02"),
+			len(p.Charges), p.Total, p.Cumulative)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintf(c.stdout, "Total:     %.2f\n", forecast.Total)
+	fmt.Fprintf(c.stdout, "Average:   %.2f per period\n", forecast.Average)
+
+	if expensive := forecast.MostExpensive(*top); len(expensive) > 0 {
+		fmt.Fprintln(c.stdout)
+		fmt.Fprintln(c.stdout, "Most expensive periods:")
+		tw = tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		for _, p := range expensive {
+			fmt.Fprintf(tw, "  %s %d\t%.2f\t%s\n", time.Month(p.Month).String()[:3], p.Year, p.Total, largestCharges(p.Charges, 3))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(forecast.MissingRates) > 0 {
+		fmt.Fprintf(c.stderr, "Warning: no exchange rate for %s, counted unconverted\n", strings.Join(forecast.MissingRates, ", "))
+	}
+	return nil
+}
+
+// largestCharges names up to n of the largest charges, largest first
+func largestCharges(charges []service.Charge, n int) string {
+	sorted := append([]service.Charge{}, charges...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Converted > sorted[j].Converted
+	})
+	var names []string
+	for i := 0; i < len(sorted) && i < n; i++ {
+		names = append(names, fmt.Sprintf("%s %.2f", sorted[i].Subscription.Name, sorted[i].Converted))
+	}
+	return strings.Join(names, ", ")
+}
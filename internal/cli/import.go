@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"subscription-tracker/internal/service"
+)
+
+// runImport reads subscriptions back from a CSV or JSON export
+func runImport(ctx context.Context, c *CLI, args []string) error {
+	var path string
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		path, args = args[0], args[1:]
+	}
+
+	fs := c.newFlagSet("import")
+	format := fs.String("format", "", "file format (csv or json, default: from the file extension)")
+	mode := fs.String("mode", string(service.ImportMerge), "merge: update subscriptions with the same name; replace: delete the existing subscriptions once the file is imported")
+	dryRun := fs.Bool("dry-run", false, "only show what would be imported")
+	skipInvalid := fs.Bool("skip-invalid", false, "import the valid rows even if some have errors")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if path == "" && fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+	if path == "" {
+		return fmt.Errorf("usage: import FILE [--format csv|json] [--mode merge|replace]")
+	}
+
+	if *format == "" {
+		*format = string(service.ImportFormatOf(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	preview, err := c.app.ImportService.Preview(ctx, file, service.ExportFormat(*format), service.ImportMode(*mode))
+	if err != nil {
+		return err
+	}
+	if err := c.printImportPreview(preview); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		return nil
+	}
+	if preview.Errors > 0 && !*skipInvalid {
+		return fmt.Errorf("%d rows have errors; fix them or pass --skip-invalid to import the others", preview.Errors)
+	}
+
+	result, err := c.app.ImportService.Apply(ctx, preview)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Imported %d subscriptions: %d created, %d updated", result.Created+result.Updated, result.Created, result.Updated)
+	if result.Deleted > 0 {
+		fmt.Fprintf(c.stdout, ", %d deleted", result.Deleted)
+	}
+	if result.Skipped > 0 {
+		fmt.Fprintf(c.stdout, ", %d skipped", result.Skipped)
+	}
+	fmt.Fprintln(c.stdout)
+	return c.warnBudgets(ctx)
+}
+
+// printImportPreview prints what an import will do with each row
+func (c *CLI) printImportPreview(preview *service.ImportPreview) error {
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tACTION\tNAME\tAMOUNT\tCYCLE\tRENEWAL\tERROR")
+	for _, row := range preview.Rows {
+		name, amount, cycle, renewal := row.Subscription.Name, "", row.Subscription.BillingCycle, row.Subscription.NextRenewalDate
+		if row.Err == nil {
+			name, cycle = row.Input.Name, row.Input.BillingCycle
+			amount = fmt.Sprintf("%.2f %s", row.Input.Amount, row.Input.Currency)
+		}
+		errText := ""
+		if row.Err != nil {
+			errText = row.Err.Error()
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", row.Line, row.Action, name, amount, cycle, renewal, errText)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "\n%s\n", preview.Summary())
+	return nil
+}
@@ -0,0 +1,471 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
+)
+
+// outputFormat selects how query commands print their results
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputCSV   outputFormat = "csv"
+)
+
+// addOutputFlag registers the --output flag on a query command
+func addOutputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", string(outputTable), "output format (table, json or csv)")
+}
+
+// parseOutputFormat validates the value of the --output flag
+func parseOutputFormat(value string) (outputFormat, error) {
+	switch f := outputFormat(value); f {
+	case outputTable, outputJSON, outputCSV:
+		return f, nil
+	default:
+		return "", &UsageError{Err: fmt.Errorf("unsupported output format: %s (use table, json or csv)", value)}
+	}
+}
+
+// writeJSON prints v as indented JSON
+func (c *CLI) writeJSON(v interface{}) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// writeCSV prints a header row followed by the given rows
+func (c *CLI) writeCSV(header []string, rows [][]string) error {
+	writer := csv.NewWriter(c.stdout)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write rows: %w", err)
+	}
+	return nil
+}
+
+// The types below are the JSON schemas of the query commands. Field names are
+// part of the CLI's public interface: add new fields, but don't rename or
+// remove existing ones. Dates are YYYY-MM-DD strings and amounts are numbers.
+//
+// `list --output json` prints an array of service.ExportSubscription, the same
+// objects written by `export --format json`.
+
+// SpendingOutput is the schema of `spending --output json`
+type SpendingOutput struct {
+	Year           int                          `json:"year"`
+	Month          int                          `json:"month"`
+	CutoffDay      int                          `json:"cutoff_day"`
+	PeriodStart    string                       `json:"period_start"`
+	PeriodEnd      string                       `json:"period_end"`
+	BaseCurrency   string                       `json:"base_currency"` // currency of every total and converted amount
+	MissingRates   []string                     `json:"missing_rates"` // currencies counted unconverted for lack of a rate
+	MonthlyTotal   float64                      `json:"monthly_total"`
+	YearlyTotal    float64                      `json:"yearly_total"`
+	OtherTotal     float64                      `json:"other_total"` // all cycles other than monthly and yearly
+	GrandTotal     float64                      `json:"grand_total"`
+	AverageMonthly float64                      `json:"average_monthly"`
+	MonthlySalary  float64                      `json:"monthly_salary"` // 0 if no salary is configured
+	Remaining      float64                      `json:"remaining"`      // salary - grand_total, 0 if no salary is configured
+	MonthlyItems   []service.ExportSubscription `json:"monthly_items"`
+	YearlyItems    []service.ExportSubscription `json:"yearly_items"`
+	OtherItems     []service.ExportSubscription `json:"other_items"`
+	Charges        []ChargeOutput               `json:"charges"`    // every charge in the period, by date
+	Categories     []CategoryTotalOutput        `json:"categories"` // grand_total by category, largest first
+	Budgets        []BudgetOutput               `json:"budgets"`
+}
+
+// CategoryTotalOutput is the spending of one category
+type CategoryTotalOutput struct {
+	Category      string  `json:"category"`
+	Total         float64 `json:"total"`
+	Subscriptions int     `json:"subscriptions"`
+}
+
+// ChargeOutput is a single renewal within a billing period
+type ChargeOutput struct {
+	Date            string                     `json:"date"`
+	Amount          float64                    `json:"amount"`           // in the subscription's currency
+	ConvertedAmount float64                    `json:"converted_amount"` // in the base currency
+	Paid            bool                       `json:"paid"`             // from the payment ledger rather than projected
+	Subscription    service.ExportSubscription `json:"subscription"`
+}
+
+// NewSpendingOutput converts a spending summary to its JSON schema
+func NewSpendingOutput(summary *service.SpendingSummary, categories map[int64]string) SpendingOutput {
+	output := SpendingOutput{
+		Year:           summary.Year,
+		Month:          summary.Month,
+		CutoffDay:      summary.CutoffDay,
+		PeriodStart:    summary.PeriodStart.Format("2006-01-02"),
+		PeriodEnd:      summary.PeriodEnd.Format("2006-01-02"),
+		BaseCurrency:   summary.BaseCurrency,
+		MissingRates:   append([]string{}, summary.MissingRates...),
+		MonthlyTotal:   summary.MonthlyTotal,
+		YearlyTotal:    summary.YearlyTotal,
+		OtherTotal:     summary.OtherTotal,
+		GrandTotal:     summary.GrandTotal,
+		AverageMonthly: summary.AverageMonthly,
+		MonthlySalary:  summary.MonthlySalary,
+		Remaining:      summary.Remaining,
+		MonthlyItems:   service.ConvertToExportFormat(summary.MonthlyItems, categories),
+		YearlyItems:    service.ConvertToExportFormat(summary.YearlyItems, categories),
+		OtherItems:     service.ConvertToExportFormat(summary.OtherItems, categories),
+		Charges:        make([]ChargeOutput, len(summary.Charges)),
+		Categories:     make([]CategoryTotalOutput, len(summary.Categories)),
+		Budgets:        NewBudgetOutputs(summary.Budgets),
+	}
+	for i, charge := range summary.Charges {
+		output.Charges[i] = newChargeOutput(charge, categories)
+	}
+	for i, total := range summary.Categories {
+		output.Categories[i] = CategoryTotalOutput{
+			Category:      total.Category,
+			Total:         total.Total,
+			Subscriptions: total.Count,
+		}
+	}
+	return output
+}
+
+// newChargeOutput converts a charge to its JSON schema
+func newChargeOutput(charge service.Charge, categories map[int64]string) ChargeOutput {
+	return ChargeOutput{
+		Date:            charge.Date.Format("2006-01-02"),
+		Amount:          charge.Amount,
+		ConvertedAmount: charge.Converted,
+		Paid:            charge.Paid,
+		Subscription:    service.ConvertToExportFormat([]db.Subscription{charge.Subscription}, categories)[0],
+	}
+}
+
+// ForecastOutput is the schema of `forecast --output json`
+type ForecastOutput struct {
+	BaseCurrency  string                 `json:"base_currency"` // currency of every total
+	CutoffDay     int                    `json:"cutoff_day"`
+	MissingRates  []string               `json:"missing_rates"` // currencies counted unconverted for lack of a rate
+	Total         float64                `json:"total"`
+	Average       float64                `json:"average"` // total per period
+	Periods       []ForecastPeriodOutput `json:"periods"` // in date order
+	MostExpensive []ForecastPeriodOutput `json:"most_expensive"`
+}
+
+// ForecastPeriodOutput is the projected spending of one billing period
+type ForecastPeriodOutput struct {
+	Year        int            `json:"year"`
+	Month       int            `json:"month"`
+	PeriodStart string         `json:"period_start"`
+	PeriodEnd   string         `json:"period_end"`
+	Total       float64        `json:"total"`
+	Cumulative  float64        `json:"cumulative"` // total of this and every earlier period
+	Charges     []ChargeOutput `json:"charges,omitempty"`
+}
+
+// NewForecastOutput converts a forecast to its JSON schema, listing the top
+// most expensive periods without their charges
+func NewForecastOutput(forecast *service.Forecast, top int, categories map[int64]string) ForecastOutput {
+	output := ForecastOutput{
+		BaseCurrency: forecast.BaseCurrency,
+		CutoffDay:    forecast.CutoffDay,
+		MissingRates: append([]string{}, forecast.MissingRates...),
+		Total:        forecast.Total,
+		Average:      forecast.Average,
+		Periods:      make([]ForecastPeriodOutput, len(forecast.Periods)),
+	}
+	for i, period := range forecast.Periods {
+		output.Periods[i] = newForecastPeriodOutput(period)
+		output.Periods[i].Charges = make([]ChargeOutput, len(period.Charges))
+		for j, charge := range period.Charges {
+			output.Periods[i].Charges[j] = newChargeOutput(charge, categories)
+		}
+	}
+	for _, period := range forecast.MostExpensive(top) {
+		output.MostExpensive = append(output.MostExpensive, newForecastPeriodOutput(period))
+	}
+	return output
+}
+
+func newForecastPeriodOutput(period service.ForecastPeriod) ForecastPeriodOutput {
+	return ForecastPeriodOutput{
+		Year:        period.Year,
+		Month:       period.Month,
+		PeriodStart: period.PeriodStart.Format("2006-01-02"),
+		PeriodEnd:   period.PeriodEnd.Format("2006-01-02"),
+		Total:       period.Total,
+		Cumulative:  period.Cumulative,
+	}
+}
+
+// ConfigOutput is the schema of `config --output json`
+type ConfigOutput struct {
+	MonthCutoffDay int     `json:"month_cutoff_day"`
+	MonthlySalary  float64 `json:"monthly_salary"` // 0 if not set
+	BaseCurrency   string  `json:"base_currency"`
+	TrialWarnDays  int     `json:"trial_warn_days"`
+	ReminderDays   []int   `json:"reminder_days"` // Empty when reminders are off
+}
+
+// NewConfigOutput converts the application config to its JSON schema
+func NewConfigOutput(config *service.Config) ConfigOutput {
+	return ConfigOutput{
+		MonthCutoffDay: config.MonthCutoffDay,
+		MonthlySalary:  config.MonthlySalary,
+		BaseCurrency:   config.BaseCurrency,
+		TrialWarnDays:  config.TrialWarnDays,
+		ReminderDays:   config.ReminderDays,
+	}
+}
+
+// BudgetOutput is a budget's use in a billing period, an element of
+// `budgets --output json` and of the spending output
+type BudgetOutput struct {
+	Category  string  `json:"category"` // "overall" for the overall budget
+	Limit     float64 `json:"limit"`
+	Used      float64 `json:"used"`
+	Remaining float64 `json:"remaining"`
+	Percent   float64 `json:"percent"`
+	State     string  `json:"state"` // ok, near or over
+}
+
+// NewBudgetOutputs converts budget statuses to their JSON schema
+func NewBudgetOutputs(statuses []service.BudgetStatus) []BudgetOutput {
+	output := make([]BudgetOutput, len(statuses))
+	for i, b := range statuses {
+		output[i] = BudgetOutput{
+			Category:  b.Label(),
+			Limit:     b.Limit,
+			Used:      b.Used,
+			Remaining: b.Remaining,
+			Percent:   b.Percent,
+			State:     string(b.State),
+		}
+	}
+	return output
+}
+
+// CategoryOutput is an element of `categories --output json`
+type CategoryOutput struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// RateOutput is an element of `rates --output json`
+type RateOutput struct {
+	Currency  string  `json:"currency"`
+	Rate      float64 `json:"rate"` // units of currency per one unit of the base currency
+	UpdatedAt string  `json:"updated_at"`
+}
+
+// TrialOutput is a free trial that ends soon, an element of `trials --output json`
+type TrialOutput struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	TrialEndDate string  `json:"trial_end_date"`
+	DaysLeft     int     `json:"days_left"`
+	Price        float64 `json:"price"` // Charged per billing cycle once the trial ends
+	Currency     string  `json:"currency"`
+	BillingCycle string  `json:"billing_cycle"`
+}
+
+// ReminderOutput is the reminder lead times of a subscription, an element of
+// `reminders --output json`
+type ReminderOutput struct {
+	ID              int64  `json:"id"`
+	Name            string `json:"name"`
+	NextRenewalDate string `json:"next_renewal_date,omitempty"`
+	Days            []int  `json:"days"`    // Days before each renewal to remind; empty when off
+	Default         bool   `json:"default"` // The days are the configured default
+}
+
+// NewReminderOutput converts the reminder settings of a subscription to their JSON schema
+func NewReminderOutput(sub db.Subscription, settings service.ReminderSettings) ReminderOutput {
+	days := settings.Days
+	if days == nil {
+		days = []int{}
+	}
+	return ReminderOutput{
+		ID:              sub.ID,
+		Name:            sub.Name,
+		NextRenewalDate: sub.NextRenewalDate.String,
+		Days:            days,
+		Default:         !settings.Custom,
+	}
+}
+
+// WebhookOutput is a registered webhook, an element of `webhooks --output json`
+type WebhookOutput struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Days      int64    `json:"days"` // Days before a renewal renewal.upcoming is posted
+	CreatedAt string   `json:"created_at"`
+}
+
+// NewWebhookOutput converts a webhook to its JSON schema, leaving out its secret
+func NewWebhookOutput(hook db.Webhook) WebhookOutput {
+	return WebhookOutput{
+		ID:        hook.ID,
+		URL:       hook.Url,
+		Events:    service.WebhookEvents(hook),
+		Days:      hook.Days,
+		CreatedAt: hook.CreatedAt,
+	}
+}
+
+// DeliveryOutput is an entry of the webhook delivery log, an element of `webhooks log --output json`
+type DeliveryOutput struct {
+	ID            int64   `json:"id"`
+	WebhookID     int64   `json:"webhook_id"`
+	Event         string  `json:"event"`
+	Status        string  `json:"status"` // pending, delivered or failed
+	Attempts      int64   `json:"attempts"`
+	ResponseCode  int64   `json:"response_code"` // 0 when no response was received
+	Error         string  `json:"error,omitempty"`
+	NextAttemptAt *string `json:"next_attempt_at"` // null unless pending
+	CreatedAt     string  `json:"created_at"`
+	DeliveredAt   *string `json:"delivered_at"`
+}
+
+// NewDeliveryOutput converts a webhook delivery to its JSON schema
+func NewDeliveryOutput(d db.WebhookDelivery) DeliveryOutput {
+	output := DeliveryOutput{
+		ID:           d.ID,
+		WebhookID:    d.WebhookID,
+		Event:        d.Event,
+		Status:       d.Status,
+		Attempts:     d.Attempts,
+		ResponseCode: d.ResponseCode,
+		Error:        d.Error,
+		CreatedAt:    d.CreatedAt,
+	}
+	if d.Status == service.DeliveryPending {
+		output.NextAttemptAt = &d.NextAttemptAt
+	}
+	if d.DeliveredAt.Valid {
+		output.DeliveredAt = &d.DeliveredAt.String
+	}
+	return output
+}
+
+// CandidateOutput is a recurring charge found in a bank statement, an element of `detect --output json`
+type CandidateOutput struct {
+	Number          int     `json:"number"` // Selects the candidate for --accept
+	Name            string  `json:"name"`
+	Amount          float64 `json:"amount"` // The latest charge
+	Currency        string  `json:"currency"`
+	BillingCycle    string  `json:"billing_cycle"`
+	NextRenewalDate string  `json:"next_renewal_date"`
+	Occurrences     int     `json:"occurrences"`
+	FirstCharge     string  `json:"first_charge"`
+	LastCharge      string  `json:"last_charge"`
+	Confidence      float64 `json:"confidence"`  // Share of the intervals matching the billing cycle, 0-1
+	ExistingID      *int64  `json:"existing_id"` // null unless a subscription seems to track it already
+}
+
+// NewCandidateOutput converts a recurring charge candidate to its JSON schema
+func NewCandidateOutput(number int, candidate service.RecurringCandidate) CandidateOutput {
+	output := CandidateOutput{
+		Number:          number,
+		Name:            candidate.Name,
+		Amount:          candidate.Amount,
+		Currency:        candidate.Currency,
+		BillingCycle:    candidate.BillingCycle,
+		NextRenewalDate: candidate.NextRenewalDate,
+		Occurrences:     candidate.Occurrences,
+		FirstCharge:     candidate.FirstCharge.Format("2006-01-02"),
+		LastCharge:      candidate.LastCharge.Format("2006-01-02"),
+		Confidence:      candidate.Confidence,
+	}
+	if candidate.ExistingID != 0 {
+		id := candidate.ExistingID
+		output.ExistingID = &id
+	}
+	return output
+}
+
+// PaymentOutput is a charge in the payment ledger, an element of `payments --output json`
+type PaymentOutput struct {
+	ID             int64   `json:"id"`
+	SubscriptionID *int64  `json:"subscription_id"` // null for a one-off charge or a deleted subscription
+	Name           string  `json:"name"`
+	Date           string  `json:"date"`
+	Amount         float64 `json:"amount"` // negative for a refund
+	Currency       string  `json:"currency"`
+	Source         string  `json:"source"` // "renewal" or "manual"
+	Note           string  `json:"note"`
+}
+
+// NewPaymentOutput converts a payment to its JSON schema
+func NewPaymentOutput(payment db.Payment) PaymentOutput {
+	output := PaymentOutput{
+		ID:       payment.ID,
+		Name:     payment.Name,
+		Date:     payment.PaidOn,
+		Amount:   payment.Amount,
+		Currency: payment.Currency,
+		Source:   payment.Source,
+		Note:     payment.Note,
+	}
+	if payment.SubscriptionID.Valid {
+		id := payment.SubscriptionID.Int64
+		output.SubscriptionID = &id
+	}
+	return output
+}
+
+// PriceChangeOutput is a change of a subscription's price, an element of `prices --output json`
+type PriceChangeOutput struct {
+	SubscriptionID int64    `json:"subscription_id"`
+	Name           string   `json:"name"`
+	EffectiveDate  string   `json:"effective_date"`
+	OldAmount      float64  `json:"old_amount"`
+	OldCurrency    string   `json:"old_currency"`
+	Amount         float64  `json:"amount"`
+	Currency       string   `json:"currency"`
+	Change         *float64 `json:"change"`  // amount - old_amount, null when the currency changed
+	Percent        *float64 `json:"percent"` // change as a percentage of old_amount, null when the currency changed
+}
+
+// NewPriceChangeOutput converts a price change to its JSON schema
+func NewPriceChangeOutput(change service.PriceChange) PriceChangeOutput {
+	output := PriceChangeOutput{
+		SubscriptionID: change.SubscriptionID,
+		Name:           change.Name,
+		EffectiveDate:  change.EffectiveDate,
+		OldAmount:      change.OldAmount,
+		OldCurrency:    change.OldCurrency,
+		Amount:         change.Amount,
+		Currency:       change.Currency,
+	}
+	if diff, ok := change.Difference(); ok {
+		output.Change = &diff
+	}
+	if percent, ok := change.Percent(); ok {
+		output.Percent = &percent
+	}
+	return output
+}
+
+// NewTrialOutput converts a subscription on a free trial to its JSON schema
+func NewTrialOutput(sub db.Subscription, today time.Time) TrialOutput {
+	left, _ := service.TrialDaysLeft(sub, today)
+	return TrialOutput{
+		ID:           sub.ID,
+		Name:         sub.Name,
+		TrialEndDate: sub.TrialEndDate.String,
+		DaysLeft:     left,
+		Price:        service.BilledAmount(sub),
+		Currency:     sub.Currency,
+		BillingCycle: sub.BillingCycle,
+	}
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
+)
+
+// runPayments lists the payment ledger and records or removes charges by hand
+func runPayments(ctx context.Context, c *CLI, args []string) error {
+	action := "list"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+
+	fs := c.newFlagSet("payments")
+	subscription := fs.Int64("subscription", 0, "subscription ID of the payments (default: all, or a one-off charge when adding)")
+	from := fs.String("from", "", "list payments from this date (YYYY-MM-DD)")
+	to := fs.String("to", "", "list payments up to this date (YYYY-MM-DD)")
+	name := fs.String("name", "", "name of the charge (default: the subscription's name)")
+	amount := fs.Float64("amount", 0, "amount charged, negative for a refund")
+	currency := fs.String("currency", "", "currency of the charge (default: the subscription's currency)")
+	date := fs.String("date", "", "date of the charge (YYYY-MM-DD, default today)")
+	note := fs.String("note", "", "note about the charge")
+	output := addOutputFlag(fs)
+
+	// Positional arguments come before the flags
+	var positional []string
+	for len(args) > 0 && (args[0] == "" || args[0][0] != '-') {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+
+	switch action {
+	case "list":
+		format, err := parseOutputFormat(*output)
+		if err != nil {
+			return err
+		}
+		var payments []db.Payment
+		if *subscription != 0 {
+			payments, err = c.app.PaymentService.ListForSubscription(ctx, *subscription)
+		} else {
+			payments, err = c.app.PaymentService.List(ctx, *from, *to)
+		}
+		if err != nil {
+			return err
+		}
+		return c.listPayments(payments, format)
+
+	case "add":
+		if len(positional) != 0 {
+			return fmt.Errorf("usage: payments add --amount AMOUNT [--subscription ID | --name NAME] [--currency CUR] [--date YYYY-MM-DD] [--note TEXT]")
+		}
+		payment, err := c.app.PaymentService.Add(ctx, service.PaymentInput{
+			SubscriptionID: *subscription,
+			Name:           *name,
+			Amount:         *amount,
+			Currency:       *currency,
+			Date:           *date,
+			Note:           *note,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Recorded payment %d: %s %.2f %s on %s\n",
+			payment.ID, payment.Name, payment.Amount, payment.Currency, payment.PaidOn)
+		return nil
+
+	case "delete":
+		id, _, err := parseID(positional)
+		if err != nil {
+			return fmt.Errorf("usage: payments delete ID")
+		}
+		if err := c.app.PaymentService.Delete(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Deleted payment %d\n", id)
+		return nil
+	}
+
+	return fmt.Errorf("unknown payments action: %s (use list, add or delete)", action)
+}
+
+// listPayments prints payments from the ledger
+func (c *CLI) listPayments(payments []db.Payment, format outputFormat) error {
+	outputs := make([]PaymentOutput, len(payments))
+	for i, payment := range payments {
+		outputs[i] = NewPaymentOutput(payment)
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(outputs)
+	case outputCSV:
+		var rows [][]string
+		for _, p := range outputs {
+			subscriptionID := ""
+			if p.SubscriptionID != nil {
+				subscriptionID = strconv.FormatInt(*p.SubscriptionID, 10)
+			}
+			rows = append(rows, []string{
+				strconv.FormatInt(p.ID, 10),
+				p.Date,
+				subscriptionID,
+				p.Name,
+				fmt.Sprintf("%.2f", p.Amount),
+				p.Currency,
+				p.Source,
+				p.Note,
+			})
+		}
+		return c.writeCSV([]string{"ID", "Date", "Subscription ID", "Name", "Amount", "Currency", "Source", "Note"}, rows)
+	}
+
+	if len(outputs) == 0 {
+		fmt.Fprintln(c.stdout, "No payments recorded")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDATE\tNAME\tAMOUNT\tCURRENCY\tSOURCE\tNOTE")
+	for _, p := range outputs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%.2f\t%s\t%s\t%s\n",
+			p.ID, p.Date, p.Name, p.Amount, p.Currency, p.Source, p.Note)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+// runPrices lists price changes: those of every subscription in a date range,
+// this year by default, or the whole price history of one subscription
+func runPrices(ctx context.Context, c *CLI, args []string) error {
+	var id int64
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		var err error
+		if id, args, err = parseID(args); err != nil {
+			return err
+		}
+	}
+
+	year := time.Now().Year()
+	fs := c.newFlagSet("prices")
+	from := fs.String("from", fmt.Sprintf("%d-01-01", year), "list changes taking effect from this date (YYYY-MM-DD)")
+	to := fs.String("to", fmt.Sprintf("%d-12-31", year), "list changes taking effect up to this date (YYYY-MM-DD)")
+	output := addOutputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	var changes []service.PriceChange
+	if id != 0 {
+		sub, err := c.app.SubscriptionService.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("subscription %d not found: %w", id, err)
+		}
+		history, err := c.app.SubscriptionService.PriceHistory(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, change := range history {
+			changes = append(changes, service.PriceChange{PriceChange: change, Name: sub.Name})
+		}
+	} else if changes, err = c.app.SubscriptionService.PriceChanges(ctx, *from, *to); err != nil {
+		return err
+	}
+
+	outputs := make([]PriceChangeOutput, len(changes))
+	for i, change := range changes {
+		outputs[i] = NewPriceChangeOutput(change)
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(outputs)
+	case outputCSV:
+		var rows [][]string
+		for _, p := range outputs {
+			rows = append(rows, []string{
+				strconv.FormatInt(p.SubscriptionID, 10),
+				p.Name,
+				p.EffectiveDate,
+				fmt.Sprintf("%.2f", p.OldAmount),
+				p.OldCurrency,
+				fmt.Sprintf("%.2f", p.Amount),
+				p.Currency,
+				optionalAmount(p.Change, "%.2f"),
+				optionalAmount(p.Percent, "%.1f"),
+			})
+		}
+		return c.writeCSV([]string{"Subscription ID", "Name", "Effective Date", "Old Amount", "Old Currency", "Amount", "Currency", "Change", "Percent"}, rows)
+	}
+
+	if len(outputs) == 0 {
+		fmt.Fprintln(c.stdout, "No price changes")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EFFECTIVE\tID\tNAME\tOLD\tNEW\tCHANGE")
+	for _, p := range outputs {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.2f %s\t%.2f %s\t%s\n",
+			p.EffectiveDate, p.SubscriptionID, p.Name, p.OldAmount, p.OldCurrency, p.Amount, p.Currency, priceChangeText(p))
+	}
+	return tw.Flush()
+}
+
+// priceChangeText formats the change of a price as "+2.00 (+13.3%)", or
+// "currency" when the currency changed
+func priceChangeText(p PriceChangeOutput) string {
+	if p.Change == nil {
+		return "currency"
+	}
+	if p.Percent == nil {
+		return fmt.Sprintf("%+.2f", *p.Change)
+	}
+	return fmt.Sprintf("%+.2f (%+.1f%%)", *p.Change, *p.Percent)
+}
+
+// optionalAmount formats an amount that may be missing as an empty string
+func optionalAmount(amount *float64, format string) string {
+	if amount == nil {
+		return ""
+	}
+	return fmt.Sprintf(format, *amount)
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"subscription-tracker/internal/service"
+)
+
+// runRates lists and edits the exchange rates used to convert totals to the base currency
+func runRates(ctx context.Context, c *CLI, args []string) error {
+	action := "list"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+
+	fs := c.newFlagSet("rates")
+	format := fs.String("format", service.RateFormatECB, "rate file format for import (ecb or csv)")
+	reference := fs.String("reference", "", "currency the rates of a currency,rate CSV are quoted against (default: base currency)")
+	output := addOutputFlag(fs)
+
+	// Positional arguments come before the flags
+	var positional []string
+	for len(args) > 0 && (args[0] == "" || args[0][0] != '-') {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+
+	switch action {
+	case "list":
+		outFormat, err := parseOutputFormat(*output)
+		if err != nil {
+			return err
+		}
+		return c.listRates(ctx, outFormat)
+
+	case "set":
+		if len(positional) != 2 {
+			return fmt.Errorf("usage: rates set CUR RATE")
+		}
+		rate, err := strconv.ParseFloat(positional[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid exchange rate: %s", positional[1])
+		}
+		if err := c.app.CurrencyService.SetRate(ctx, positional[0], rate); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Set exchange rate for %s\n", positional[0])
+		return nil
+
+	case "delete":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: rates delete CUR")
+		}
+		if err := c.app.CurrencyService.DeleteRate(ctx, positional[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Deleted exchange rate for %s\n", positional[0])
+		return nil
+
+	case "base":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: rates base CUR")
+		}
+		if err := c.app.CurrencyService.SetBaseCurrency(ctx, positional[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Base currency set to %s\n", positional[0])
+		return nil
+
+	case "import":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: rates import FILE [--format ecb|csv]")
+		}
+		file, err := os.Open(positional[0])
+		if err != nil {
+			return fmt.Errorf("failed to open rate file: %w", err)
+		}
+		defer file.Close()
+
+		n, err := c.app.CurrencyService.ImportRates(ctx, file, *format, *reference)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Imported %d exchange rates\n", n)
+		return nil
+	}
+
+	return fmt.Errorf("unknown rates action: %s (use list, set, delete, base or import)", action)
+}
+
+func (c *CLI) listRates(ctx context.Context, format outputFormat) error {
+	rates, err := c.app.CurrencyService.ListRates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	base, err := c.app.ConfigService.GetBaseCurrency(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case outputJSON:
+		out := make([]RateOutput, len(rates))
+		for i, r := range rates {
+			out[i] = RateOutput{Currency: r.Currency, Rate: r.Rate, UpdatedAt: r.UpdatedAt}
+		}
+		return c.writeJSON(out)
+	case outputCSV:
+		var rows [][]string
+		for _, r := range rates {
+			rows = append(rows, []string{r.Currency, strconv.FormatFloat(r.Rate, 'f', -1, 64), r.UpdatedAt})
+		}
+		return c.writeCSV([]string{"Currency", "Rate", "Updated At"}, rows)
+	}
+
+	fmt.Fprintf(c.stdout, "Base currency: %s\n\n", base)
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CURRENCY\tPER 1 %s\tUPDATED\n", base)
+	for _, r := range rates {
+		fmt.Fprintf(tw, "%s\t%.6g\t%s\n", r.Currency, r.Rate, r.UpdatedAt)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"subscription-tracker/internal/service"
+)
+
+// runReminders lists and sets how many days before each renewal reminders are sent
+func runReminders(ctx context.Context, c *CLI, args []string) error {
+	action := "list"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+
+	fs := c.newFlagSet("reminders")
+	output := addOutputFlag(fs)
+
+	// Positional arguments come before the flags
+	var positional []string
+	for len(args) > 0 && (args[0] == "" || args[0][0] != '-') {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+
+	switch action {
+	case "list":
+		format, err := parseOutputFormat(*output)
+		if err != nil {
+			return err
+		}
+		return c.listReminders(ctx, format)
+
+	case "set":
+		if len(positional) != 2 {
+			return fmt.Errorf("usage: reminders set ID DAYS, such as 7,1 or off")
+		}
+		id, _, err := parseID(positional)
+		if err != nil {
+			return err
+		}
+		days, err := service.ParseReminderDays(positional[1])
+		if err != nil {
+			return err
+		}
+		if err := c.app.ReminderService.SetDays(ctx, id, days); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Reminders of subscription %d: %s\n", id, reminderDaysText(days))
+		return nil
+
+	case "reset":
+		id, _, err := parseID(positional)
+		if err != nil {
+			return err
+		}
+		if err := c.app.ReminderService.ResetDays(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Subscription %d uses the default reminders\n", id)
+		return nil
+
+	case "default":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: reminders default DAYS, such as 7,1 or off")
+		}
+		days, err := service.ParseReminderDays(positional[0])
+		if err != nil {
+			return err
+		}
+		if err := c.app.ConfigService.SetReminderDays(ctx, days); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Default reminders: %s\n", reminderDaysText(days))
+		return nil
+	}
+
+	return fmt.Errorf("unknown reminders action: %s (use list, set, reset or default)", action)
+}
+
+// listReminders prints the reminder lead times of every subscription
+func (c *CLI) listReminders(ctx context.Context, format outputFormat) error {
+	subs, err := c.app.SubscriptionService.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	settings, defaults, err := c.app.ReminderService.Settings(ctx)
+	if err != nil {
+		return err
+	}
+
+	reminders := make([]ReminderOutput, len(subs))
+	for i, sub := range subs {
+		setting, ok := settings[sub.ID]
+		if !ok {
+			setting.Days = defaults
+		}
+		reminders[i] = NewReminderOutput(sub, setting)
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(reminders)
+	case outputCSV:
+		var rows [][]string
+		for _, r := range reminders {
+			rows = append(rows, []string{
+				strconv.FormatInt(r.ID, 10),
+				r.Name,
+				r.NextRenewalDate,
+				service.FormatReminderDays(r.Days),
+				strconv.FormatBool(r.Default),
+			})
+		}
+		return c.writeCSV([]string{"ID", "Name", "Next Renewal Date", "Days", "Default"}, rows)
+	}
+
+	fmt.Fprintf(c.stdout, "Default reminders: %s\n", reminderDaysText(defaults))
+	if len(reminders) == 0 {
+		return nil
+	}
+	fmt.Fprintln(c.stdout)
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tRENEWAL\tREMINDERS")
+	for _, r := range reminders {
+		days := reminderDaysText(r.Days)
+		if r.Default {
+			days += " (default)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", r.ID, r.Name, r.NextRenewalDate, days)
+	}
+	return tw.Flush()
+}
+
+// reminderDaysText describes reminder lead times, such as "7, 1 days before"
+func reminderDaysText(days []int) string {
+	if len(days) == 0 {
+		return "off"
+	}
+	text := ""
+	for i, d := range days {
+		if i > 0 {
+			text += ", "
+		}
+		text += strconv.Itoa(d)
+	}
+	if len(days) == 1 && days[0] == 1 {
+		return text + " day before"
+	}
+	return text + " days before"
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"subscription-tracker/internal/service"
+)
+
+// runReport writes a spending report of a billing month or a whole year as HTML or Markdown
+func runReport(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("report")
+	year := fs.Int("year", 0, "year of the report; every billing period of it without --month (default: current period)")
+	month := fs.String("month", "", "month of the billing period, 1-12 or name (default: current period)")
+	format := fs.String("format", "", "report format, html or markdown (default: from the --file extension, markdown on stdout)")
+	path := fs.String("file", "", "write to this file instead of stdout")
+	upcoming := fs.Int("upcoming", service.DefaultReportUpcomingDays, "days of upcoming renewals to list")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts := service.ReportOptions{Year: *year, UpcomingDays: *upcoming}
+	if *month != "" {
+		m, err := service.ParseMonth(*month)
+		if err != nil {
+			return err
+		}
+		opts.Month = m
+	}
+	if *upcoming < 1 {
+		return fmt.Errorf("upcoming must be at least 1 day")
+	}
+
+	reportFormat := service.ReportFormat(*format)
+	switch {
+	case *format == "md":
+		reportFormat = service.ReportMarkdown
+	case *format == "" && *path != "":
+		reportFormat = service.ReportFormatOf(*path)
+	case *format == "":
+		reportFormat = service.ReportMarkdown
+	}
+	if reportFormat != service.ReportHTML && reportFormat != service.ReportMarkdown {
+		return fmt.Errorf("unsupported report format: %s", *format)
+	}
+
+	report, err := c.app.ReportService.Build(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to build report: %w", err)
+	}
+
+	var w io.Writer = c.stdout
+	if *path != "" {
+		file, err := os.Create(*path)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := report.Write(w, reportFormat); err != nil {
+		return err
+	}
+	if *path != "" {
+		fmt.Fprintf(c.stdout, "Wrote %s to %s\n", report.Title, *path)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+// envFeedToken is read when --token is not given
+const envFeedToken = "SUBSCRIPTION_TRACKER_ICAL_TOKEN"
+
+// feedPath is where serve-ical serves the calendar
+const feedPath = "/subscriptions.ics"
+
+// runServeICal serves the upcoming renewals as an ICS feed until interrupted
+func runServeICal(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("serve-ical")
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	token := fs.String("token", "", "feed token (or $"+envFeedToken+", or the saved token)")
+	newToken := fs.Bool("new-token", false, "replace the saved token, locking out subscribed clients")
+	recurring := fs.Bool("recurring", true, "one repeating event per subscription instead of one event per renewal")
+	months := fs.Int("months", service.DefaultICSMonths, "months of renewals to serve without --recurring")
+	remind := fs.Int("remind", 0, "add a reminder this many days before each renewal")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	feedToken := envOr(*token, envFeedToken)
+	var err error
+	switch {
+	case *newToken:
+		feedToken, err = c.app.ExportService.NewFeedToken(ctx)
+	case feedToken == "":
+		feedToken, err = c.app.ExportService.FeedToken(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	opts := service.ICSOptions{Recurring: *recurring, Months: *months, RemindDays: *remind}
+	// Check the options once instead of failing every request
+	if _, err := c.app.ExportService.ExportICS(ctx, io.Discard, opts); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(feedPath, c.app.ExportService.FeedHandler(feedToken, opts))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(c.stdout, "Serving renewals at http://%s%s?token=%s\n", listener.Addr(), feedPath, feedToken)
+	fmt.Fprintln(c.stdout, "Subscribe to this URL from your calendar app; press Ctrl+C to stop")
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func runSpending(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("spending")
+	year := fs.Int("year", 0, "year of the billing period (default: current period)")
+	month := fs.String("month", "", "month of the billing period, 1-12 or name (default: current period)")
+	output := addOutputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	var summary *service.SpendingSummary
+	if *year != 0 || *month != "" {
+		m, perr := service.ParseMonth(*month)
+		if perr != nil {
+			return perr
+		}
+		y := *year
+		if y == 0 {
+			y = time.Now().Year()
+		}
+		summary, err = c.app.SpendingService.CalculateForMonth(ctx, y, m)
+	} else {
+		summary, err = c.app.SpendingService.CalculateForCurrentMonth(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to calculate spending: %w", err)
+	}
+
+	categories, err := c.app.CategoryService.Names(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(NewSpendingOutput(summary, categories))
+	case outputCSV:
+		return c.writeSpendingCSV(summary, categories)
+	}
+
+	fmt.Fprintf(c.stdout, "Spending for %s %d (%s - %s)\n\n",
+		time.Month(summary.Month), summary.Year,
+		summary.PeriodStart.Format("2006-01-02"),
+		summary.PeriodEnd.Format("2006-01-02"))
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "DATE\tNAME\tCATEGORY\tCYCLE\tAMOUNT\tCURRENCY\t%s\tSTATE\n", summary.BaseCurrency)
+	for _, charge := range summary.Charges {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.2f\t%s\t%.2f\t%s\n",
+			charge.Date.Format("2006-01-02"), charge.Subscription.Name, service.CategoryName(charge.Subscription, categories),
+			charge.Subscription.BillingCycle, charge.Amount, charge.Subscription.Currency, charge.Converted, chargeState(charge))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintf(c.stdout, "Totals in %s\n", summary.BaseCurrency)
+	fmt.Fprintf(c.stdout, "Monthly:   %.2f\n", summary.MonthlyTotal)
+	fmt.Fprintf(c.stdout, "Yearly:    %.2f\n", summary.YearlyTotal)
+	if summary.OtherTotal > 0 {
+		fmt.Fprintf(c.stdout, "Other:     %.2f\n", summary.OtherTotal)
+	}
+	fmt.Fprintf(c.stdout, "Total:     %.2f\n", summary.GrandTotal)
+	if summary.MonthlySalary > 0 {
+		fmt.Fprintf(c.stdout, "Salary:    %.2f\n", summary.MonthlySalary)
+		fmt.Fprintf(c.stdout, "Remaining: %.2f\n", summary.Remaining)
+	}
+	if len(summary.Categories) > 0 {
+		fmt.Fprintln(c.stdout)
+		fmt.Fprintln(c.stdout, "By category:")
+		tw = tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		for _, total := range summary.Categories {
+			fmt.Fprintf(tw, "  %s\t%.2f\n", total.Category, total.Total)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(summary.Budgets) > 0 {
+		fmt.Fprintln(c.stdout)
+		fmt.Fprintln(c.stdout, "Budgets:")
+		tw = tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+		for _, b := range summary.Budgets {
+			fmt.Fprintf(tw, "  %s	%.2f / %.2f	%.0f%%	%s\n", b.Label(), b.Used, b.Limit, b.Percent, b.State)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(summary.MissingRates) > 0 {
+		fmt.Fprintf(c.stderr, "Warning: no exchange rate for %s, counted unconverted\n", strings.Join(summary.MissingRates, ", "))
+	}
+	return nil
+}
+
+// spendingCSVHeader is the header row of `spending --output csv`, one row per charge in the period
+var spendingCSVHeader = []string{"Period Start", "Period End", "ID", "Name", "Amount", "Currency", "Billing Cycle", "Next Renewal Date", "Charge Date", "Base Currency", "Converted Amount", "Category", "Paid"}
+
+// chargeState tells whether a charge was paid or is projected from the subscription
+func chargeState(charge service.Charge) string {
+	if charge.Paid {
+		return "paid"
+	}
+	return "projected"
+}
+
+func (c *CLI) writeSpendingCSV(summary *service.SpendingSummary, categories map[int64]string) error {
+	start := summary.PeriodStart.Format("2006-01-02")
+	end := summary.PeriodEnd.Format("2006-01-02")
+
+	var rows [][]string
+	for _, charge := range NewSpendingOutput(summary, categories).Charges {
+		rows = append(rows, []string{
+			start,
+			end,
+			fmt.Sprintf("%d", charge.Subscription.ID),
+			charge.Subscription.Name,
+			fmt.Sprintf("%.2f", charge.Amount),
+			charge.Subscription.Currency,
+			charge.Subscription.BillingCycle,
+			charge.Subscription.NextRenewalDate,
+			charge.Date,
+			summary.BaseCurrency,
+			fmt.Sprintf("%.2f", charge.ConvertedAmount),
+			charge.Subscription.Category,
+			strconv.FormatBool(charge.Paid),
+		})
+	}
+	return c.writeCSV(spendingCSVHeader, rows)
+}
@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
+)
+
+func runList(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("list")
+	cycle := fs.String("cycle", "", "only list subscriptions with this "+cycleFlagUsage)
+	category := fs.String("category", "", "only list subscriptions in this category ('"+service.Uncategorized+"' for none)")
+	tag := fs.String("tag", "", "only list subscriptions with this tag")
+	status := fs.String("status", "", "only list subscriptions in this state ("+strings.Join(service.Statuses, ", ")+")")
+	output := addOutputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	if *cycle != "" {
+		if *cycle, err = service.NormalizeBillingCycle(*cycle); err != nil {
+			return err
+		}
+	}
+	if *status != "" && !slices.Contains(service.Statuses, strings.ToLower(*status)) {
+		return fmt.Errorf("invalid status: %s (use %s)", *status, strings.Join(service.Statuses, ", "))
+	}
+
+	subs, err := c.app.SubscriptionService.Filter(ctx, service.SubscriptionFilter{
+		BillingCycle: *cycle,
+		Category:     *category,
+		Tag:          *tag,
+		Status:       *status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	categories, err := c.app.CategoryService.Names(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(service.ConvertToExportFormat(subs, categories))
+	case outputCSV:
+		var rows [][]string
+		for _, sub := range service.ConvertToExportFormat(subs, categories) {
+			rows = append(rows, sub.CSVRecord())
+		}
+		return c.writeCSV(service.ExportCSVHeader, rows)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tAMOUNT\tCURRENCY\tCYCLE\tRENEWAL\tSTATUS\tCATEGORY\tTAGS")
+	for _, sub := range subs {
+		renewal := "-"
+		if sub.NextRenewalDate.Valid {
+			renewal = sub.NextRenewalDate.String
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			sub.ID, sub.Name, sub.Amount, sub.Currency, sub.BillingCycle, renewal,
+			statusLabel(sub), service.CategoryName(sub, categories), sub.Tags)
+	}
+	return tw.Flush()
+}
+
+func runAdd(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("add")
+	name := fs.String("name", "", "subscription name")
+	amount := fs.Float64("amount", 0, "amount charged per billing cycle")
+	currency := fs.String("currency", "USD", "currency code")
+	cycle := fs.String("cycle", "monthly", cycleFlagUsage)
+	renewal := fs.String("renewal", "", "next renewal date (YYYY-MM-DD)")
+	category := fs.String("category", "", "category name, created if it doesn't exist")
+	tags := fs.String("tags", "", "comma separated tags")
+	trialEnd := fs.String("trial-end", "", "end of the free trial (YYYY-MM-DD); nothing is charged until then")
+	trialPrice := fs.Float64("trial-price", 0, "amount charged per billing cycle once the trial ends (default: --amount)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	sub, err := c.app.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name:            *name,
+		Amount:          *amount,
+		Currency:        strings.ToUpper(*currency),
+		BillingCycle:    *cycle,
+		NextRenewalDate: *renewal,
+		Category:        *category,
+		Tags:            service.ParseTags(*tags),
+		TrialEndDate:    *trialEnd,
+		TrialPrice:      *trialPrice,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Added subscription %d (%s)\n", sub.ID, sub.Name)
+	return c.warnBudgets(ctx)
+}
+
+func runEdit(ctx context.Context, c *CLI, args []string) error {
+	id, args, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := c.newFlagSet("edit")
+	name := fs.String("name", "", "subscription name")
+	amount := fs.Float64("amount", 0, "amount charged per billing cycle")
+	currency := fs.String("currency", "", "currency code")
+	cycle := fs.String("cycle", "", cycleFlagUsage)
+	renewal := fs.String("renewal", "", "next renewal date (YYYY-MM-DD)")
+	category := fs.String("category", "", "category name, created if it doesn't exist (empty for none)")
+	tags := fs.String("tags", "", "comma separated tags, replacing the current ones")
+	effective := fs.String("effective", "", "date a changed amount or currency takes effect (YYYY-MM-DD, default today)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	sub, err := c.app.SubscriptionService.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("subscription %d not found: %w", id, err)
+	}
+
+	categories, err := c.app.CategoryService.Names(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Start from the stored values and only override what was given
+	input := service.UpdateSubscriptionInput{
+		ID:             sub.ID,
+		Name:           sub.Name,
+		Amount:         sub.Amount,
+		Currency:       sub.Currency,
+		BillingCycle:   sub.BillingCycle,
+		Tags:           service.ParseTags(sub.Tags),
+		PriceEffective: *effective,
+	}
+	if sub.CategoryID.Valid {
+		input.Category = categories[sub.CategoryID.Int64]
+	}
+	if sub.NextRenewalDate.Valid {
+		input.NextRenewalDate = sub.NextRenewalDate.String
+	}
+
+	set := flagsSet(fs)
+	if set["name"] {
+		input.Name = *name
+	}
+	if set["amount"] {
+		input.Amount = *amount
+	}
+	if set["currency"] {
+		input.Currency = strings.ToUpper(*currency)
+	}
+	if set["cycle"] {
+		input.BillingCycle = *cycle
+	}
+	if set["renewal"] {
+		input.NextRenewalDate = *renewal
+	}
+	if set["category"] {
+		input.Category = *category
+	}
+	if set["tags"] {
+		input.Tags = service.ParseTags(*tags)
+	}
+
+	updated, err := c.app.SubscriptionService.Update(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Updated subscription %d (%s)\n", updated.ID, updated.Name)
+	return c.warnBudgets(ctx)
+}
+
+func runDelete(ctx context.Context, c *CLI, args []string) error {
+	id, _, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	sub, err := c.app.SubscriptionService.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("subscription %d not found: %w", id, err)
+	}
+
+	if err := c.app.SubscriptionService.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+
+	fmt.Fprintf(c.stdout, "Deleted subscription %d (%s)\n", sub.ID, sub.Name)
+	return nil
+}
+
+func runPause(ctx context.Context, c *CLI, args []string) error {
+	id, args, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := c.newFlagSet("pause")
+	date := fs.String("date", "", "first day without charges (YYYY-MM-DD, default today)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	sub, err := c.app.SubscriptionService.Pause(ctx, id, *date)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Paused subscription %d (%s) from %s\n", sub.ID, sub.Name, sub.PauseDate.String)
+	return nil
+}
+
+func runResume(ctx context.Context, c *CLI, args []string) error {
+	id, _, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	sub, err := c.app.SubscriptionService.Resume(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Resumed subscription %d (%s), next renewal %s\n", sub.ID, sub.Name, sub.NextRenewalDate.String)
+	return c.warnBudgets(ctx)
+}
+
+func runCancel(ctx context.Context, c *CLI, args []string) error {
+	id, args, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := c.newFlagSet("cancel")
+	date := fs.String("date", "", "first day without charges (YYYY-MM-DD, default today)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	sub, err := c.app.SubscriptionService.Cancel(ctx, id, *date)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Cancelled subscription %d (%s) from %s\n", sub.ID, sub.Name, sub.CancelDate.String)
+	return nil
+}
+
+// statusLabel describes the state of a subscription along with the date it applies from
+func statusLabel(sub db.Subscription) string {
+	switch sub.Status {
+	case service.StatusTrial:
+		if left, ok := service.TrialDaysLeft(sub, time.Now()); ok && left >= 0 {
+			return fmt.Sprintf("trial until %s (%s left)", sub.TrialEndDate.String, daysText(left))
+		}
+		return "trial until " + sub.TrialEndDate.String
+	case service.StatusPaused:
+		return "paused " + sub.PauseDate.String
+	case service.StatusCancelled:
+		return "cancelled " + sub.CancelDate.String
+	}
+	return sub.Status
+}
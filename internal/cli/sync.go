@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"subscription-tracker/internal/service"
+)
+
+// Environment variables read when the matching flag is not given, so that
+// secrets don't have to appear in shell history or crontabs
+const (
+	envSyncPassword   = "SUBSCRIPTION_TRACKER_PASSWORD"
+	envGistToken      = "SUBSCRIPTION_TRACKER_GIST_TOKEN"
+	envWebDAVPassword = "SUBSCRIPTION_TRACKER_WEBDAV_PASSWORD"
+	envS3SecretKey    = "SUBSCRIPTION_TRACKER_S3_SECRET_KEY"
+)
+
+// syncFlags holds the flags shared by push and pull
+type syncFlags struct {
+	password    *string
+	backend     *string
+	token       *string
+	gistID      *string
+	dir         *string
+	webdavURL   *string
+	webdavUser  *string
+	s3Endpoint  *string
+	s3Region    *string
+	s3Bucket    *string
+	s3Prefix    *string
+	s3PathStyle *bool
+	s3AccessKey *string
+	s3Versioned *bool
+	gitRepo     *string
+	gitBranch   *string
+	gitPlain    *bool
+	set         map[string]bool
+}
+
+// addSyncFlags registers the flags shared by push, pull and backups
+func addSyncFlags(fs *flag.FlagSet) *syncFlags {
+	return &syncFlags{
+		password:    fs.String("password", "", "encryption password (or $"+envSyncPassword+")"),
+		backend:     fs.String("backend", "", "where backups go: "+strings.Join(service.SyncBackends, ", ")+" (default: the saved backend, or gist)"),
+		token:       fs.String("token", "", "GitHub token (or $"+envGistToken+", or the saved token)"),
+		gistID:      fs.String("gist-id", "", "gist ID (default: the saved gist)"),
+		dir:         fs.String("dir", "", "folder for the dir backend, e.g. a Syncthing or Dropbox folder (default: the saved folder)"),
+		webdavURL:   fs.String("webdav-url", "", "WebDAV folder URL (default: the saved URL)"),
+		webdavUser:  fs.String("webdav-user", "", "WebDAV user; the password is read from $"+envWebDAVPassword+" and never saved (default: the saved user)"),
+		s3Endpoint:  fs.String("s3-endpoint", "", "S3 endpoint, e.g. https://s3.eu-central-1.amazonaws.com (default: the saved endpoint)"),
+		s3Region:    fs.String("s3-region", "", "S3 region (default: the saved region, or "+service.DefaultS3Region+")"),
+		s3Bucket:    fs.String("s3-bucket", "", "S3 bucket (default: the saved bucket)"),
+		s3Prefix:    fs.String("s3-prefix", "", "folder in the S3 bucket (default: the saved prefix)"),
+		s3PathStyle: fs.Bool("s3-path-style", false, "address the bucket as ENDPOINT/BUCKET, as MinIO needs (default: the saved setting)"),
+		s3AccessKey: fs.String("s3-access-key", "", "S3 access key; the secret key is read from $"+envS3SecretKey+" and never saved (default: the saved key)"),
+		s3Versioned: fs.Bool("s3-versioned", false, "also keep every push under a timestamped name (default: the saved setting)"),
+		gitRepo:     fs.String("git-repo", "", "git repository: a local folder, URL or user@host:path (default: the saved repository)"),
+		gitBranch:   fs.String("git-branch", "", "git branch (default: the saved branch, or "+service.DefaultGitBranch+")"),
+		gitPlain:    fs.Bool("git-plaintext", false, "commit unencrypted JSON to get readable diffs (default: the saved setting)"),
+	}
+}
+
+// syncPassword returns the encryption password from the flag or environment.
+// It is only required if the backup is encrypted.
+func syncPassword(flags *syncFlags, encrypted bool) (string, error) {
+	password := envOr(*flags.password, envSyncPassword)
+	if password == "" && encrypted {
+		return "", fmt.Errorf("password is required")
+	}
+	return password, nil
+}
+
+// syncConfig resolves the backend settings from flags, environment and
+// saved config
+func (c *CLI) syncConfig(ctx context.Context, flags *syncFlags) (*service.SyncConfig, error) {
+	config, err := c.app.SyncService.GetSyncConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	override := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+	override(&config.Backend, *flags.backend)
+	override(&config.GistToken, envOr(*flags.token, envGistToken))
+	override(&config.GistID, *flags.gistID)
+	override(&config.Dir, *flags.dir)
+	override(&config.WebDAVURL, *flags.webdavURL)
+	override(&config.WebDAVUser, *flags.webdavUser)
+	override(&config.WebDAVPassword, envOr("", envWebDAVPassword))
+	override(&config.S3Endpoint, *flags.s3Endpoint)
+	override(&config.S3Region, *flags.s3Region)
+	override(&config.S3Bucket, *flags.s3Bucket)
+	override(&config.S3Prefix, *flags.s3Prefix)
+	override(&config.S3AccessKey, *flags.s3AccessKey)
+	override(&config.S3SecretKey, envOr("", envS3SecretKey))
+	override(&config.GitRepo, *flags.gitRepo)
+	override(&config.GitBranch, *flags.gitBranch)
+	if flags.set["s3-path-style"] {
+		config.S3PathStyle = *flags.s3PathStyle
+	}
+	if flags.set["s3-versioned"] {
+		config.S3Versioned = *flags.s3Versioned
+	}
+	if flags.set["git-plaintext"] {
+		config.GitPlaintext = *flags.gitPlain
+	}
+	return config, nil
+}
+
+func runPush(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("push")
+	flags := addSyncFlags(fs)
+	force := fs.Bool("force", false, "overwrite changes another device pushed since the last sync")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	flags.set = flagsSet(fs)
+
+	config, err := c.syncConfig(ctx, flags)
+	if err != nil {
+		return err
+	}
+	config.Force = *force
+	password, err := syncPassword(flags, config.Encrypted())
+	if err != nil {
+		return err
+	}
+
+	location, err := c.app.SyncService.Push(ctx, password, config)
+	if errors.Is(err, service.ErrBackupChanged) {
+		return fmt.Errorf("%w, or push with --force to overwrite them", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Pushed to %s\n", location)
+	return nil
+}
+
+func runPull(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("pull")
+	flags := addSyncFlags(fs)
+	backup := fs.String("backup", service.SyncBackupName, "backup to import, e.g. an older version listed by backups")
+	resolve := fs.String("resolve", "", "resolve conflicting changes by keeping the local, remote or both versions")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	flags.set = flagsSet(fs)
+
+	config, err := c.syncConfig(ctx, flags)
+	if err != nil {
+		return err
+	}
+	// The latest backup of a plaintext git backend, or any *.json backup,
+	// isn't encrypted
+	plaintext := !config.Encrypted() && *backup == service.SyncBackupName || strings.HasSuffix(*backup, ".json")
+	password, err := syncPassword(flags, !plaintext)
+	if err != nil {
+		return err
+	}
+
+	err = c.app.SyncService.PullBackup(ctx, password, config, *backup)
+	var conflicts *service.MergeConflictError
+	if errors.As(err, &conflicts) {
+		if *resolve == "" {
+			return fmt.Errorf("%w; pull with --resolve %s to resolve them", err, strings.Join(service.SyncResolutions, "|"))
+		}
+		if err := conflicts.Merge.ResolveAll(*resolve); err != nil {
+			return err
+		}
+		err = c.app.SyncService.ApplyMerge(ctx, conflicts.Merge)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *backup != service.SyncBackupName {
+		fmt.Fprintln(c.stdout, "Data pulled and imported successfully")
+		return nil
+	}
+	fmt.Fprintln(c.stdout, "Data pulled and merged successfully")
+	return nil
+}
+
+// runBackups lists the backups in the sync backend
+func runBackups(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("backups")
+	flags := addSyncFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	flags.set = flagsSet(fs)
+
+	config, err := c.syncConfig(ctx, flags)
+	if err != nil {
+		return err
+	}
+	names, err := c.app.SyncService.Backups(ctx, config)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		fmt.Fprintln(c.stdout, name)
+	}
+	return nil
+}
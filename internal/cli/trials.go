@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+// runTrials lists free trials that end soon and puts subscriptions on a trial
+func runTrials(ctx context.Context, c *CLI, args []string) error {
+	action := "list"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+
+	fs := c.newFlagSet("trials")
+	days := fs.Int("days", -1, "list trials ending within this many days (default: the configured trial warning)")
+	price := fs.Float64("price", 0, "amount charged per billing cycle once the trial ends (default: the current amount)")
+	output := addOutputFlag(fs)
+
+	// Positional arguments come before the flags
+	var positional []string
+	for len(args) > 0 && (args[0] == "" || args[0][0] != '-') {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+
+	switch action {
+	case "list":
+		format, err := parseOutputFormat(*output)
+		if err != nil {
+			return err
+		}
+		if *days < 0 {
+			if *days, err = c.app.ConfigService.GetTrialWarnDays(ctx); err != nil {
+				return err
+			}
+		}
+		return c.listTrials(ctx, *days, format)
+
+	case "set":
+		if len(positional) != 2 {
+			return fmt.Errorf("usage: trials set ID YYYY-MM-DD [--price AMOUNT]")
+		}
+		id, _, err := parseID(positional)
+		if err != nil {
+			return err
+		}
+		sub, err := c.app.SubscriptionService.SetTrial(ctx, id, positional[1], *price)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "%s is on a free trial until %s, then %.2f %s\n",
+			sub.Name, sub.TrialEndDate.String, service.BilledAmount(sub), sub.Currency)
+		return nil
+	}
+
+	return fmt.Errorf("unknown trials action: %s (use list or set)", action)
+}
+
+// listTrials prints the free trials that end within days days
+func (c *CLI) listTrials(ctx context.Context, days int, format outputFormat) error {
+	subs, err := c.app.SubscriptionService.EndingTrials(ctx, days)
+	if err != nil {
+		return err
+	}
+
+	trials := make([]TrialOutput, len(subs))
+	for i, sub := range subs {
+		trials[i] = NewTrialOutput(sub, time.Now())
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(trials)
+	case outputCSV:
+		var rows [][]string
+		for _, t := range trials {
+			rows = append(rows, []string{
+				strconv.FormatInt(t.ID, 10),
+				t.Name,
+				t.TrialEndDate,
+				strconv.Itoa(t.DaysLeft),
+				fmt.Sprintf("%.2f", t.Price),
+				t.Currency,
+				t.BillingCycle,
+			})
+		}
+		return c.writeCSV([]string{"ID", "Name", "Trial End Date", "Days Left", "Price", "Currency", "Billing Cycle"}, rows)
+	}
+
+	if len(trials) == 0 {
+		fmt.Fprintf(c.stdout, "No free trials end in the next %s\n", daysText(days))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tENDS\tLEFT\tTHEN\tCYCLE")
+	for _, t := range trials {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%.2f %s\t%s\n",
+			t.ID, t.Name, t.TrialEndDate, daysText(t.DaysLeft), t.Price, t.Currency, t.BillingCycle)
+	}
+	return tw.Flush()
+}
+
+// daysText formats a number of days as "1 day" or "N days"
+func daysText(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+// envSMTPPassword is the password of --smtp-user
+const envSMTPPassword = "SUBSCRIPTION_TRACKER_SMTP_PASSWORD"
+
+// runWatch advances renewal dates and sends renewal reminders on a timer until
+// interrupted, retrying failed webhook deliveries on every check
+func runWatch(ctx context.Context, c *CLI, args []string) error {
+	fs := c.newFlagSet("watch")
+	interval := fs.Duration("interval", service.DefaultReminderInterval, "how often to check for reminders to send")
+	once := fs.Bool("once", false, "check once and exit, e.g. from cron")
+	desktop := fs.Bool("desktop", false, "show desktop notifications with notify-send or D-Bus")
+	email := fs.String("email", "", "mail reminders to these comma separated addresses")
+	smtpAddr := fs.String("smtp", "localhost:25", "SMTP server as HOST:PORT")
+	smtpUser := fs.String("smtp-user", "", "SMTP user; the password is read from $"+envSMTPPassword)
+	from := fs.String("from", "subscription-tracker@localhost", "sender of reminder mails")
+	command := fs.String("exec", "", "run this shell command for each reminder")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var notifiers []service.Notifier
+	if *desktop {
+		notifiers = append(notifiers, service.DesktopNotifier{})
+	}
+	if *email != "" {
+		var to []string
+		for _, addr := range strings.Split(*email, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		notifiers = append(notifiers, service.EmailNotifier{
+			Addr:     *smtpAddr,
+			Username: *smtpUser,
+			Password: os.Getenv(envSMTPPassword),
+			From:     *from,
+			To:       to,
+		})
+	}
+	if *command != "" {
+		notifiers = append(notifiers, service.CommandNotifier{Command: *command})
+	}
+	if len(notifiers) == 0 {
+		// Renewal events still go out to webhooks
+		hooks, err := c.app.WebhookService.List(ctx)
+		if err != nil {
+			return err
+		}
+		if len(hooks) == 0 {
+			return fmt.Errorf("choose how to deliver reminders with --desktop, --email or --exec")
+		}
+	}
+
+	if *once {
+		reminders, err := c.app.ReminderService.Check(ctx, notifiers, time.Now())
+		c.printReminders(reminders)
+		return errors.Join(err, c.retryWebhooks(ctx))
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(c.stdout, "Checking for renewal reminders every %s; press Ctrl+C to stop\n", *interval)
+	return c.app.ReminderService.Watch(ctx, notifiers, *interval, func(reminders []service.Reminder, err error) {
+		c.printReminders(reminders)
+		if err = errors.Join(err, c.retryWebhooks(ctx)); err != nil {
+			fmt.Fprintf(c.stderr, "%s %v\n", time.Now().Format("2006-01-02 15:04"), err)
+		}
+	})
+}
+
+// retryWebhooks retries the webhook deliveries that are due, and reports
+// events that couldn't be queued
+func (c *CLI) retryWebhooks(ctx context.Context) error {
+	_, err := c.app.WebhookService.DeliverDue(ctx)
+	return errors.Join(c.app.WebhookService.QueueErr(), err)
+}
+
+// printReminders logs the reminders of a check
+func (c *CLI) printReminders(reminders []service.Reminder) {
+	now := time.Now().Format("2006-01-02 15:04")
+	for _, r := range reminders {
+		fmt.Fprintf(c.stdout, "%s %s: %s\n", now, r.Title(), r.Message())
+	}
+}
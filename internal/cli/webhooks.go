@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"subscription-tracker/internal/service"
+)
+
+// runWebhooks registers webhooks and shows their delivery log
+func runWebhooks(ctx context.Context, c *CLI, args []string) error {
+	action := "list"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+
+	fs := c.newFlagSet("webhooks")
+	events := fs.String("events", "", "comma separated events to post (default: all of "+strings.Join(service.EventTypes, ", ")+")")
+	days := fs.Int("days", service.DefaultWebhookDays, "post renewal.upcoming this many days before each renewal")
+	secret := fs.String("secret", "", "key to sign payloads with (default: generated)")
+	limit := fs.Int("limit", 20, "number of deliveries to show")
+	output := addOutputFlag(fs)
+
+	// Positional arguments come before the flags
+	var positional []string
+	for len(args) > 0 && (args[0] == "" || args[0][0] != '-') {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	positional = append(positional, fs.Args()...)
+
+	switch action {
+	case "list":
+		format, err := parseOutputFormat(*output)
+		if err != nil {
+			return err
+		}
+		return c.listWebhooks(ctx, format)
+
+	case "add":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: webhooks add URL [--events a,b] [--days N] [--secret KEY]")
+		}
+		input := service.WebhookInput{URL: positional[0], Secret: *secret, Days: *days}
+		for _, event := range strings.Split(*events, ",") {
+			if event = strings.TrimSpace(event); event != "" {
+				input.Events = append(input.Events, event)
+			}
+		}
+		hook, err := c.app.WebhookService.Add(ctx, input)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Added webhook %d for %s\n", hook.ID, hook.Url)
+		fmt.Fprintf(c.stdout, "Payloads are signed in the %s header with the secret %s\n", service.SignatureHeader, hook.Secret)
+		return nil
+
+	case "delete":
+		id, err := parseWebhookID(positional)
+		if err != nil {
+			return err
+		}
+		if err := c.app.WebhookService.Delete(ctx, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Deleted webhook %d\n", id)
+		return nil
+
+	case "log":
+		format, err := parseOutputFormat(*output)
+		if err != nil {
+			return err
+		}
+		return c.listDeliveries(ctx, *limit, format)
+
+	case "retry":
+		delivered, err := c.app.WebhookService.DeliverDue(ctx)
+		fmt.Fprintf(c.stdout, "Delivered %d of the due webhook deliveries\n", delivered)
+		return err
+	}
+
+	return fmt.Errorf("unknown webhooks action: %s (use list, add, delete, log or retry)", action)
+}
+
+// parseWebhookID parses a webhook ID positional argument
+func parseWebhookID(args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("webhook ID is required")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid webhook ID: %s", args[0])
+	}
+	return id, nil
+}
+
+// listWebhooks prints the registered webhooks
+func (c *CLI) listWebhooks(ctx context.Context, format outputFormat) error {
+	hooks, err := c.app.WebhookService.List(ctx)
+	if err != nil {
+		return err
+	}
+	webhooks := make([]WebhookOutput, len(hooks))
+	for i, hook := range hooks {
+		webhooks[i] = NewWebhookOutput(hook)
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(webhooks)
+	case outputCSV:
+		var rows [][]string
+		for _, w := range webhooks {
+			rows = append(rows, []string{
+				strconv.FormatInt(w.ID, 10),
+				w.URL,
+				strings.Join(w.Events, " "),
+				strconv.FormatInt(w.Days, 10),
+				w.CreatedAt,
+			})
+		}
+		return c.writeCSV([]string{"ID", "URL", "Events", "Days", "Created At"}, rows)
+	}
+
+	if len(webhooks) == 0 {
+		fmt.Fprintln(c.stdout, "No webhooks. Add one with: webhooks add URL")
+		return nil
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tURL\tEVENTS\tDAYS")
+	for _, w := range webhooks {
+		events := strings.Join(w.Events, ",")
+		if len(w.Events) == len(service.EventTypes) {
+			events = "all"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\n", w.ID, w.URL, events, w.Days)
+	}
+	return tw.Flush()
+}
+
+// listDeliveries prints the latest webhook deliveries
+func (c *CLI) listDeliveries(ctx context.Context, limit int, format outputFormat) error {
+	log, err := c.app.WebhookService.Deliveries(ctx, limit)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]DeliveryOutput, len(log))
+	for i, d := range log {
+		deliveries[i] = NewDeliveryOutput(d)
+	}
+
+	switch format {
+	case outputJSON:
+		return c.writeJSON(deliveries)
+	case outputCSV:
+		var rows [][]string
+		for _, d := range deliveries {
+			rows = append(rows, []string{
+				strconv.FormatInt(d.ID, 10),
+				strconv.FormatInt(d.WebhookID, 10),
+				d.Event,
+				d.Status,
+				strconv.FormatInt(d.Attempts, 10),
+				strconv.FormatInt(d.ResponseCode, 10),
+				d.Error,
+				d.CreatedAt,
+			})
+		}
+		return c.writeCSV([]string{"ID", "Webhook ID", "Event", "Status", "Attempts", "Response Code", "Error", "Created At"}, rows)
+	}
+
+	if len(deliveries) == 0 {
+		fmt.Fprintln(c.stdout, "No webhook deliveries")
+		return nil
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tWEBHOOK\tEVENT\tCREATED\tSTATUS\tATTEMPTS\tRESULT")
+	for _, d := range deliveries {
+		result := d.Error
+		if d.ResponseCode != 0 && result == "" {
+			result = strconv.FormatInt(d.ResponseCode, 10)
+		}
+		if d.NextAttemptAt != nil {
+			result += " (retry at " + *d.NextAttemptAt + ")"
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%d\t%s\n", d.ID, d.WebhookID, d.Event, d.CreatedAt, d.Status, d.Attempts, strings.TrimSpace(result))
+	}
+	return tw.Flush()
+}
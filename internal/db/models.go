@@ -8,11 +8,67 @@ import (
 	"database/sql"
 )
 
+type Budget struct {
+	ID         int64
+	CategoryID sql.NullInt64
+	Amount     float64
+	CreatedAt  string
+	UpdatedAt  string
+}
+
+type Category struct {
+	ID        int64
+	Name      string
+	CreatedAt string
+}
+
 type Config struct {
 	Key   string
 	Value string
 }
 
+type ExchangeRate struct {
+	Currency  string
+	Rate      float64
+	UpdatedAt string
+}
+
+type Payment struct {
+	ID             int64
+	SubscriptionID sql.NullInt64
+	Name           string
+	Amount         float64
+	Currency       string
+	PaidOn         string
+	Source         string
+	Note           string
+	CreatedAt      string
+}
+
+type PriceChange struct {
+	ID             int64
+	SubscriptionID int64
+	OldAmount      float64
+	OldCurrency    string
+	Amount         float64
+	Currency       string
+	EffectiveDate  string
+	CreatedAt      string
+}
+
+type ReminderSetting struct {
+	SubscriptionID int64
+	LeadDays       string
+}
+
+type SentReminder struct {
+	ID             int64
+	SubscriptionID int64
+	RenewalDate    string
+	Days           int64
+	SentAt         string
+}
+
 type Subscription struct {
 	ID              int64
 	Name            string
@@ -22,4 +78,36 @@ type Subscription struct {
 	NextRenewalDate sql.NullString
 	CreatedAt       string
 	UpdatedAt       string
+	CategoryID      sql.NullInt64
+	Tags            string
+	Status          string
+	TrialEndDate    sql.NullString
+	PauseDate       sql.NullString
+	CancelDate      sql.NullString
+	TrialPrice      sql.NullFloat64
+	Uuid            string
+}
+
+type Webhook struct {
+	ID        int64
+	Url       string
+	Secret    string
+	Events    string
+	Days      int64
+	CreatedAt string
+}
+
+type WebhookDelivery struct {
+	ID            int64
+	WebhookID     int64
+	Event         string
+	EventKey      sql.NullString
+	Payload       string
+	Status        string
+	Attempts      int64
+	ResponseCode  int64
+	Error         string
+	NextAttemptAt string
+	CreatedAt     string
+	DeliveredAt   sql.NullString
 }
@@ -10,10 +10,142 @@ import (
 	"database/sql"
 )
 
+const clearPaymentSubscription = `-- name: ClearPaymentSubscription :exec
+UPDATE payments SET subscription_id = NULL WHERE subscription_id = ?
+`
+
+func (q *Queries) ClearPaymentSubscription(ctx context.Context, subscriptionID sql.NullInt64) error {
+	_, err := q.db.ExecContext(ctx, clearPaymentSubscription, subscriptionID)
+	return err
+}
+
+const clearSubscriptionCategory = `-- name: ClearSubscriptionCategory :exec
+UPDATE subscriptions SET category_id = NULL, updated_at = datetime('now') WHERE category_id = ?
+`
+
+func (q *Queries) ClearSubscriptionCategory(ctx context.Context, categoryID sql.NullInt64) error {
+	_, err := q.db.ExecContext(ctx, clearSubscriptionCategory, categoryID)
+	return err
+}
+
+const createBudget = `-- name: CreateBudget :one
+INSERT INTO budgets (category_id, amount) VALUES (?, ?)
+RETURNING id, category_id, amount, created_at, updated_at
+`
+
+type CreateBudgetParams struct {
+	CategoryID sql.NullInt64
+	Amount     float64
+}
+
+func (q *Queries) CreateBudget(ctx context.Context, arg CreateBudgetParams) (Budget, error) {
+	row := q.db.QueryRowContext(ctx, createBudget, arg.CategoryID, arg.Amount)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createCategory = `-- name: CreateCategory :one
+INSERT INTO categories (name) VALUES (?)
+RETURNING id, name, created_at
+`
+
+func (q *Queries) CreateCategory(ctx context.Context, name string) (Category, error) {
+	row := q.db.QueryRowContext(ctx, createCategory, name)
+	var i Category
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const createPayment = `-- name: CreatePayment :one
+INSERT INTO payments (subscription_id, name, amount, currency, paid_on, source, note)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id, subscription_id, name, amount, currency, paid_on, source, note, created_at
+`
+
+type CreatePaymentParams struct {
+	SubscriptionID sql.NullInt64
+	Name           string
+	Amount         float64
+	Currency       string
+	PaidOn         string
+	Source         string
+	Note           string
+}
+
+func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error) {
+	row := q.db.QueryRowContext(ctx, createPayment,
+		arg.SubscriptionID,
+		arg.Name,
+		arg.Amount,
+		arg.Currency,
+		arg.PaidOn,
+		arg.Source,
+		arg.Note,
+	)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.PaidOn,
+		&i.Source,
+		&i.Note,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPriceChange = `-- name: CreatePriceChange :one
+INSERT INTO price_changes (subscription_id, old_amount, old_currency, amount, currency, effective_date)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, subscription_id, old_amount, old_currency, amount, currency, effective_date, created_at
+`
+
+type CreatePriceChangeParams struct {
+	SubscriptionID int64
+	OldAmount      float64
+	OldCurrency    string
+	Amount         float64
+	Currency       string
+	EffectiveDate  string
+}
+
+func (q *Queries) CreatePriceChange(ctx context.Context, arg CreatePriceChangeParams) (PriceChange, error) {
+	row := q.db.QueryRowContext(ctx, createPriceChange,
+		arg.SubscriptionID,
+		arg.OldAmount,
+		arg.OldCurrency,
+		arg.Amount,
+		arg.Currency,
+		arg.EffectiveDate,
+	)
+	var i PriceChange
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.OldAmount,
+		&i.OldCurrency,
+		&i.Amount,
+		&i.Currency,
+		&i.EffectiveDate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createSubscription = `-- name: CreateSubscription :one
-INSERT INTO subscriptions (name, amount, currency, billing_cycle, next_renewal_date)
-VALUES (?, ?, ?, ?, ?)
-RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at
+INSERT INTO subscriptions (name, amount, currency, billing_cycle, next_renewal_date, category_id, tags, status, trial_end_date, trial_price, uuid)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid
 `
 
 type CreateSubscriptionParams struct {
@@ -22,6 +154,12 @@ type CreateSubscriptionParams struct {
 	Currency        string
 	BillingCycle    string
 	NextRenewalDate sql.NullString
+	CategoryID      sql.NullInt64
+	Tags            string
+	Status          string
+	TrialEndDate    sql.NullString
+	TrialPrice      sql.NullFloat64
+	Uuid            string
 }
 
 func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) (Subscription, error) {
@@ -31,7 +169,233 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		arg.Currency,
 		arg.BillingCycle,
 		arg.NextRenewalDate,
+		arg.CategoryID,
+		arg.Tags,
+		arg.Status,
+		arg.TrialEndDate,
+		arg.TrialPrice,
+		arg.Uuid,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.BillingCycle,
+		&i.NextRenewalDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.Tags,
+		&i.Status,
+		&i.TrialEndDate,
+		&i.PauseDate,
+		&i.CancelDate,
+		&i.TrialPrice,
+		&i.Uuid,
+	)
+	return i, err
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (url, secret, events, days) VALUES (?, ?, ?, ?)
+RETURNING id, url, secret, events, days, created_at
+`
+
+type CreateWebhookParams struct {
+	Url    string
+	Secret string
+	Events string
+	Days   int64
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, createWebhook,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+		arg.Days,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Days,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event, event_key, payload, next_attempt_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(webhook_id, event_key) DO NOTHING
+RETURNING id, webhook_id, event, event_key, payload, status, attempts, response_code, error, next_attempt_at, created_at, delivered_at
+`
+
+type CreateWebhookDeliveryParams struct {
+	WebhookID     int64
+	Event         string
+	EventKey      sql.NullString
+	Payload       string
+	NextAttemptAt string
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRowContext(ctx, createWebhookDelivery,
+		arg.WebhookID,
+		arg.Event,
+		arg.EventKey,
+		arg.Payload,
+		arg.NextAttemptAt,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.EventKey,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.ResponseCode,
+		&i.Error,
+		&i.NextAttemptAt,
+		&i.CreatedAt,
+		&i.DeliveredAt,
 	)
+	return i, err
+}
+
+const deleteAllExchangeRates = `-- name: DeleteAllExchangeRates :exec
+DELETE FROM exchange_rates
+`
+
+func (q *Queries) DeleteAllExchangeRates(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllExchangeRates)
+	return err
+}
+
+const deleteAllPayments = `-- name: DeleteAllPayments :exec
+DELETE FROM payments
+`
+
+func (q *Queries) DeleteAllPayments(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPayments)
+	return err
+}
+
+const deleteBudget = `-- name: DeleteBudget :exec
+DELETE FROM budgets WHERE id = ?
+`
+
+func (q *Queries) DeleteBudget(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteBudget, id)
+	return err
+}
+
+const deleteCategory = `-- name: DeleteCategory :exec
+DELETE FROM categories WHERE id = ?
+`
+
+func (q *Queries) DeleteCategory(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteCategory, id)
+	return err
+}
+
+const deleteCategoryBudget = `-- name: DeleteCategoryBudget :exec
+DELETE FROM budgets WHERE category_id = ?
+`
+
+func (q *Queries) DeleteCategoryBudget(ctx context.Context, categoryID sql.NullInt64) error {
+	_, err := q.db.ExecContext(ctx, deleteCategoryBudget, categoryID)
+	return err
+}
+
+const deleteExchangeRate = `-- name: DeleteExchangeRate :exec
+DELETE FROM exchange_rates WHERE currency = ?
+`
+
+func (q *Queries) DeleteExchangeRate(ctx context.Context, currency string) error {
+	_, err := q.db.ExecContext(ctx, deleteExchangeRate, currency)
+	return err
+}
+
+const deletePayment = `-- name: DeletePayment :exec
+DELETE FROM payments WHERE id = ?
+`
+
+func (q *Queries) DeletePayment(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deletePayment, id)
+	return err
+}
+
+const deleteReminderSetting = `-- name: DeleteReminderSetting :exec
+DELETE FROM reminder_settings WHERE subscription_id = ?
+`
+
+func (q *Queries) DeleteReminderSetting(ctx context.Context, subscriptionID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteReminderSetting, subscriptionID)
+	return err
+}
+
+const deleteSubscription = `-- name: DeleteSubscription :exec
+DELETE FROM subscriptions WHERE id = ?
+`
+
+func (q *Queries) DeleteSubscription(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSubscription, id)
+	return err
+}
+
+const deleteSubscriptionPriceChanges = `-- name: DeleteSubscriptionPriceChanges :exec
+DELETE FROM price_changes WHERE subscription_id = ?
+`
+
+func (q *Queries) DeleteSubscriptionPriceChanges(ctx context.Context, subscriptionID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSubscriptionPriceChanges, subscriptionID)
+	return err
+}
+
+const deleteSubscriptionSentReminders = `-- name: DeleteSubscriptionSentReminders :exec
+DELETE FROM sent_reminders WHERE subscription_id = ?
+`
+
+func (q *Queries) DeleteSubscriptionSentReminders(ctx context.Context, subscriptionID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSubscriptionSentReminders, subscriptionID)
+	return err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :exec
+DELETE FROM webhooks WHERE id = ?
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWebhook, id)
+	return err
+}
+
+const deleteWebhookDeliveries = `-- name: DeleteWebhookDeliveries :exec
+DELETE FROM webhook_deliveries WHERE webhook_id = ?
+`
+
+func (q *Queries) DeleteWebhookDeliveries(ctx context.Context, webhookID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteWebhookDeliveries, webhookID)
+	return err
+}
+
+const endTrial = `-- name: EndTrial :one
+UPDATE subscriptions
+SET status = 'active', amount = COALESCE(trial_price, amount), updated_at = datetime('now')
+WHERE id = ?
+RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid
+`
+
+func (q *Queries) EndTrial(ctx context.Context, id int64) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, endTrial, id)
 	var i Subscription
 	err := row.Scan(
 		&i.ID,
@@ -42,33 +406,684 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		&i.NextRenewalDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.Tags,
+		&i.Status,
+		&i.TrialEndDate,
+		&i.PauseDate,
+		&i.CancelDate,
+		&i.TrialPrice,
+		&i.Uuid,
+	)
+	return i, err
+}
+
+const getAllConfig = `-- name: GetAllConfig :many
+SELECT key, value FROM config ORDER BY key
+`
+
+func (q *Queries) GetAllConfig(ctx context.Context) ([]Config, error) {
+	rows, err := q.db.QueryContext(ctx, getAllConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Config
+	for rows.Next() {
+		var i Config
+		if err := rows.Scan(&i.Key, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllSubscriptionsForExport = `-- name: GetAllSubscriptionsForExport :many
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid
+FROM subscriptions
+ORDER BY name ASC
+`
+
+func (q *Queries) GetAllSubscriptionsForExport(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, getAllSubscriptionsForExport)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Amount,
+			&i.Currency,
+			&i.BillingCycle,
+			&i.NextRenewalDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.Tags,
+			&i.Status,
+			&i.TrialEndDate,
+			&i.PauseDate,
+			&i.CancelDate,
+			&i.TrialPrice,
+			&i.Uuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategory = `-- name: GetCategory :one
+SELECT id, name, created_at FROM categories WHERE id = ?
+`
+
+func (q *Queries) GetCategory(ctx context.Context, id int64) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategory, id)
+	var i Category
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const getCategoryBudget = `-- name: GetCategoryBudget :one
+SELECT id, category_id, amount, created_at, updated_at FROM budgets WHERE category_id = ?
+`
+
+func (q *Queries) GetCategoryBudget(ctx context.Context, categoryID sql.NullInt64) (Budget, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryBudget, categoryID)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const deleteSubscription = `-- name: DeleteSubscription :exec
-DELETE FROM subscriptions WHERE id = ?
+const getCategoryByName = `-- name: GetCategoryByName :one
+SELECT id, name, created_at FROM categories WHERE name = ?
+`
+
+func (q *Queries) GetCategoryByName(ctx context.Context, name string) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryByName, name)
+	var i Category
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
+const getConfig = `-- name: GetConfig :one
+SELECT value FROM config WHERE key = ?
+`
+
+// Config queries
+func (q *Queries) GetConfig(ctx context.Context, key string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getConfig, key)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
+const getExchangeRate = `-- name: GetExchangeRate :one
+SELECT currency, rate, updated_at FROM exchange_rates WHERE currency = ?
+`
+
+func (q *Queries) GetExchangeRate(ctx context.Context, currency string) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, getExchangeRate, currency)
+	var i ExchangeRate
+	err := row.Scan(&i.Currency, &i.Rate, &i.UpdatedAt)
+	return i, err
+}
+
+const getOverallBudget = `-- name: GetOverallBudget :one
+SELECT id, category_id, amount, created_at, updated_at FROM budgets WHERE category_id IS NULL
+`
+
+func (q *Queries) GetOverallBudget(ctx context.Context) (Budget, error) {
+	row := q.db.QueryRowContext(ctx, getOverallBudget)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPayment = `-- name: GetPayment :one
+SELECT id, subscription_id, name, amount, currency, paid_on, source, note, created_at FROM payments WHERE id = ?
+`
+
+func (q *Queries) GetPayment(ctx context.Context, id int64) (Payment, error) {
+	row := q.db.QueryRowContext(ctx, getPayment, id)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.PaidOn,
+		&i.Source,
+		&i.Note,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSubscription = `-- name: GetSubscription :one
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid FROM subscriptions WHERE id = ?
+`
+
+func (q *Queries) GetSubscription(ctx context.Context, id int64) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, getSubscription, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.BillingCycle,
+		&i.NextRenewalDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.Tags,
+		&i.Status,
+		&i.TrialEndDate,
+		&i.PauseDate,
+		&i.CancelDate,
+		&i.TrialPrice,
+		&i.Uuid,
+	)
+	return i, err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, url, secret, events, days, created_at FROM webhooks WHERE id = ?
+`
+
+func (q *Queries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	row := q.db.QueryRowContext(ctx, getWebhook, id)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Days,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getYearlySubscriptionsRenewingInMonth = `-- name: GetYearlySubscriptionsRenewingInMonth :many
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid FROM subscriptions
+WHERE billing_cycle = 'yearly' AND strftime('%Y-%m', next_renewal_date) = ?
+ORDER BY next_renewal_date ASC
+`
+
+func (q *Queries) GetYearlySubscriptionsRenewingInMonth(ctx context.Context, nextRenewalDate sql.NullString) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, getYearlySubscriptionsRenewingInMonth, nextRenewalDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Amount,
+			&i.Currency,
+			&i.BillingCycle,
+			&i.NextRenewalDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.Tags,
+			&i.Status,
+			&i.TrialEndDate,
+			&i.PauseDate,
+			&i.CancelDate,
+			&i.TrialPrice,
+			&i.Uuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBudgets = `-- name: ListBudgets :many
+SELECT id, category_id, amount, created_at, updated_at FROM budgets ORDER BY category_id IS NOT NULL, category_id
+`
+
+func (q *Queries) ListBudgets(ctx context.Context) ([]Budget, error) {
+	rows, err := q.db.QueryContext(ctx, listBudgets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Budget
+	for rows.Next() {
+		var i Budget
+		if err := rows.Scan(
+			&i.ID,
+			&i.CategoryID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCategories = `-- name: ListCategories :many
+SELECT id, name, created_at FROM categories ORDER BY name ASC
+`
+
+func (q *Queries) ListCategories(ctx context.Context) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, listCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDueWebhookDeliveries = `-- name: ListDueWebhookDeliveries :many
+SELECT id, webhook_id, event, event_key, payload, status, attempts, response_code, error, next_attempt_at, created_at, delivered_at FROM webhook_deliveries
+WHERE status = 'pending' AND next_attempt_at <= ?
+ORDER BY next_attempt_at, id
+`
+
+func (q *Queries) ListDueWebhookDeliveries(ctx context.Context, nextAttemptAt string) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listDueWebhookDeliveries, nextAttemptAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.EventKey,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.Error,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExchangeRates = `-- name: ListExchangeRates :many
+SELECT currency, rate, updated_at FROM exchange_rates ORDER BY currency
+`
+
+func (q *Queries) ListExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	rows, err := q.db.QueryContext(ctx, listExchangeRates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExchangeRate
+	for rows.Next() {
+		var i ExchangeRate
+		if err := rows.Scan(&i.Currency, &i.Rate, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMonthlySubscriptions = `-- name: ListMonthlySubscriptions :many
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid FROM subscriptions WHERE billing_cycle = 'monthly' ORDER BY name ASC
+`
+
+func (q *Queries) ListMonthlySubscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listMonthlySubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Amount,
+			&i.Currency,
+			&i.BillingCycle,
+			&i.NextRenewalDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.Tags,
+			&i.Status,
+			&i.TrialEndDate,
+			&i.PauseDate,
+			&i.CancelDate,
+			&i.TrialPrice,
+			&i.Uuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOtherCycleSubscriptions = `-- name: ListOtherCycleSubscriptions :many
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid FROM subscriptions WHERE billing_cycle NOT IN ('monthly', 'yearly') ORDER BY name ASC
+`
+
+func (q *Queries) ListOtherCycleSubscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listOtherCycleSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Amount,
+			&i.Currency,
+			&i.BillingCycle,
+			&i.NextRenewalDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.Tags,
+			&i.Status,
+			&i.TrialEndDate,
+			&i.PauseDate,
+			&i.CancelDate,
+			&i.TrialPrice,
+			&i.Uuid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentsInPeriod = `-- name: ListPaymentsInPeriod :many
+SELECT id, subscription_id, name, amount, currency, paid_on, source, note, created_at FROM payments
+WHERE paid_on >= ? AND paid_on <= ?
+ORDER BY paid_on ASC, id ASC
+`
+
+type ListPaymentsInPeriodParams struct {
+	StartDate string
+	EndDate   string
+}
+
+func (q *Queries) ListPaymentsInPeriod(ctx context.Context, arg ListPaymentsInPeriodParams) ([]Payment, error) {
+	rows, err := q.db.QueryContext(ctx, listPaymentsInPeriod, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Payment
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.Name,
+			&i.Amount,
+			&i.Currency,
+			&i.PaidOn,
+			&i.Source,
+			&i.Note,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPriceChanges = `-- name: ListPriceChanges :many
+SELECT id, subscription_id, old_amount, old_currency, amount, currency, effective_date, created_at FROM price_changes ORDER BY subscription_id, effective_date, id
+`
+
+func (q *Queries) ListPriceChanges(ctx context.Context) ([]PriceChange, error) {
+	rows, err := q.db.QueryContext(ctx, listPriceChanges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PriceChange
+	for rows.Next() {
+		var i PriceChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.OldAmount,
+			&i.OldCurrency,
+			&i.Amount,
+			&i.Currency,
+			&i.EffectiveDate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPriceChangesInPeriod = `-- name: ListPriceChangesInPeriod :many
+SELECT price_changes.id, price_changes.subscription_id, price_changes.old_amount, price_changes.old_currency, price_changes.amount, price_changes.currency, price_changes.effective_date, price_changes.created_at, subscriptions.name FROM price_changes
+JOIN subscriptions ON subscriptions.id = price_changes.subscription_id
+WHERE price_changes.effective_date >= ? AND price_changes.effective_date <= ?
+ORDER BY price_changes.effective_date, price_changes.id
+`
+
+type ListPriceChangesInPeriodParams struct {
+	StartDate string
+	EndDate   string
+}
+
+type ListPriceChangesInPeriodRow struct {
+	ID             int64
+	SubscriptionID int64
+	OldAmount      float64
+	OldCurrency    string
+	Amount         float64
+	Currency       string
+	EffectiveDate  string
+	CreatedAt      string
+	Name           string
+}
+
+func (q *Queries) ListPriceChangesInPeriod(ctx context.Context, arg ListPriceChangesInPeriodParams) ([]ListPriceChangesInPeriodRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPriceChangesInPeriod, arg.StartDate, arg.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPriceChangesInPeriodRow
+	for rows.Next() {
+		var i ListPriceChangesInPeriodRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.OldAmount,
+			&i.OldCurrency,
+			&i.Amount,
+			&i.Currency,
+			&i.EffectiveDate,
+			&i.CreatedAt,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReminderSettings = `-- name: ListReminderSettings :many
+SELECT subscription_id, lead_days FROM reminder_settings ORDER BY subscription_id
 `
 
-func (q *Queries) DeleteSubscription(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteSubscription, id)
-	return err
+func (q *Queries) ListReminderSettings(ctx context.Context) ([]ReminderSetting, error) {
+	rows, err := q.db.QueryContext(ctx, listReminderSettings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReminderSetting
+	for rows.Next() {
+		var i ReminderSetting
+		if err := rows.Scan(&i.SubscriptionID, &i.LeadDays); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getAllConfig = `-- name: GetAllConfig :many
-SELECT key, value FROM config ORDER BY key
+const listSentReminders = `-- name: ListSentReminders :many
+SELECT id, subscription_id, renewal_date, days, sent_at FROM sent_reminders WHERE renewal_date >= ? ORDER BY subscription_id, renewal_date, days
 `
 
-func (q *Queries) GetAllConfig(ctx context.Context) ([]Config, error) {
-	rows, err := q.db.QueryContext(ctx, getAllConfig)
+func (q *Queries) ListSentReminders(ctx context.Context, renewalDate string) ([]SentReminder, error) {
+	rows, err := q.db.QueryContext(ctx, listSentReminders, renewalDate)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Config
+	var items []SentReminder
 	for rows.Next() {
-		var i Config
-		if err := rows.Scan(&i.Key, &i.Value); err != nil {
+		var i SentReminder
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.RenewalDate,
+			&i.Days,
+			&i.SentAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -82,30 +1097,29 @@ func (q *Queries) GetAllConfig(ctx context.Context) ([]Config, error) {
 	return items, nil
 }
 
-const getAllSubscriptionsForExport = `-- name: GetAllSubscriptionsForExport :many
-SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at
-FROM subscriptions
-ORDER BY name ASC
+const listSubscriptionPayments = `-- name: ListSubscriptionPayments :many
+SELECT id, subscription_id, name, amount, currency, paid_on, source, note, created_at FROM payments WHERE subscription_id = ? ORDER BY paid_on DESC, id DESC
 `
 
-func (q *Queries) GetAllSubscriptionsForExport(ctx context.Context) ([]Subscription, error) {
-	rows, err := q.db.QueryContext(ctx, getAllSubscriptionsForExport)
+func (q *Queries) ListSubscriptionPayments(ctx context.Context, subscriptionID sql.NullInt64) ([]Payment, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionPayments, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Subscription
+	var items []Payment
 	for rows.Next() {
-		var i Subscription
+		var i Payment
 		if err := rows.Scan(
 			&i.ID,
+			&i.SubscriptionID,
 			&i.Name,
 			&i.Amount,
 			&i.Currency,
-			&i.BillingCycle,
-			&i.NextRenewalDate,
+			&i.PaidOn,
+			&i.Source,
+			&i.Note,
 			&i.CreatedAt,
-			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -120,46 +1134,48 @@ func (q *Queries) GetAllSubscriptionsForExport(ctx context.Context) ([]Subscript
 	return items, nil
 }
 
-const getConfig = `-- name: GetConfig :one
-SELECT value FROM config WHERE key = ?
-`
-
-// Config queries
-func (q *Queries) GetConfig(ctx context.Context, key string) (string, error) {
-	row := q.db.QueryRowContext(ctx, getConfig, key)
-	var value string
-	err := row.Scan(&value)
-	return value, err
-}
-
-const getSubscription = `-- name: GetSubscription :one
-SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at FROM subscriptions WHERE id = ?
+const listSubscriptionPriceChanges = `-- name: ListSubscriptionPriceChanges :many
+SELECT id, subscription_id, old_amount, old_currency, amount, currency, effective_date, created_at FROM price_changes WHERE subscription_id = ? ORDER BY effective_date, id
 `
 
-func (q *Queries) GetSubscription(ctx context.Context, id int64) (Subscription, error) {
-	row := q.db.QueryRowContext(ctx, getSubscription, id)
-	var i Subscription
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Amount,
-		&i.Currency,
-		&i.BillingCycle,
-		&i.NextRenewalDate,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) ListSubscriptionPriceChanges(ctx context.Context, subscriptionID int64) ([]PriceChange, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionPriceChanges, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PriceChange
+	for rows.Next() {
+		var i PriceChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.OldAmount,
+			&i.OldCurrency,
+			&i.Amount,
+			&i.Currency,
+			&i.EffectiveDate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getYearlySubscriptionsRenewingInMonth = `-- name: GetYearlySubscriptionsRenewingInMonth :many
-SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at FROM subscriptions
-WHERE billing_cycle = 'yearly' AND strftime('%Y-%m', next_renewal_date) = ?
-ORDER BY next_renewal_date ASC
+const listSubscriptions = `-- name: ListSubscriptions :many
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid FROM subscriptions ORDER BY name ASC
 `
 
-func (q *Queries) GetYearlySubscriptionsRenewingInMonth(ctx context.Context, nextRenewalDate sql.NullString) ([]Subscription, error) {
-	rows, err := q.db.QueryContext(ctx, getYearlySubscriptionsRenewingInMonth, nextRenewalDate)
+func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptions)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +1192,14 @@ func (q *Queries) GetYearlySubscriptionsRenewingInMonth(ctx context.Context, nex
 			&i.NextRenewalDate,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.Tags,
+			&i.Status,
+			&i.TrialEndDate,
+			&i.PauseDate,
+			&i.CancelDate,
+			&i.TrialPrice,
+			&i.Uuid,
 		); err != nil {
 			return nil, err
 		}
@@ -190,12 +1214,12 @@ func (q *Queries) GetYearlySubscriptionsRenewingInMonth(ctx context.Context, nex
 	return items, nil
 }
 
-const listMonthlySubscriptions = `-- name: ListMonthlySubscriptions :many
-SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at FROM subscriptions WHERE billing_cycle = 'monthly' ORDER BY name ASC
+const listSubscriptionsByBillingCycle = `-- name: ListSubscriptionsByBillingCycle :many
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid FROM subscriptions WHERE billing_cycle = ? ORDER BY name ASC
 `
 
-func (q *Queries) ListMonthlySubscriptions(ctx context.Context) ([]Subscription, error) {
-	rows, err := q.db.QueryContext(ctx, listMonthlySubscriptions)
+func (q *Queries) ListSubscriptionsByBillingCycle(ctx context.Context, billingCycle string) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionsByBillingCycle, billingCycle)
 	if err != nil {
 		return nil, err
 	}
@@ -212,6 +1236,14 @@ func (q *Queries) ListMonthlySubscriptions(ctx context.Context) ([]Subscription,
 			&i.NextRenewalDate,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.Tags,
+			&i.Status,
+			&i.TrialEndDate,
+			&i.PauseDate,
+			&i.CancelDate,
+			&i.TrialPrice,
+			&i.Uuid,
 		); err != nil {
 			return nil, err
 		}
@@ -226,12 +1258,12 @@ func (q *Queries) ListMonthlySubscriptions(ctx context.Context) ([]Subscription,
 	return items, nil
 }
 
-const listSubscriptions = `-- name: ListSubscriptions :many
-SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at FROM subscriptions ORDER BY name ASC
+const listSubscriptionsByCategory = `-- name: ListSubscriptionsByCategory :many
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid FROM subscriptions WHERE category_id = ? ORDER BY name ASC
 `
 
-func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
-	rows, err := q.db.QueryContext(ctx, listSubscriptions)
+func (q *Queries) ListSubscriptionsByCategory(ctx context.Context, categoryID sql.NullInt64) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionsByCategory, categoryID)
 	if err != nil {
 		return nil, err
 	}
@@ -248,6 +1280,14 @@ func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error)
 			&i.NextRenewalDate,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.Tags,
+			&i.Status,
+			&i.TrialEndDate,
+			&i.PauseDate,
+			&i.CancelDate,
+			&i.TrialPrice,
+			&i.Uuid,
 		); err != nil {
 			return nil, err
 		}
@@ -262,28 +1302,66 @@ func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error)
 	return items, nil
 }
 
-const listSubscriptionsByBillingCycle = `-- name: ListSubscriptionsByBillingCycle :many
-SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at FROM subscriptions WHERE billing_cycle = ? ORDER BY name ASC
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event, event_key, payload, status, attempts, response_code, error, next_attempt_at, created_at, delivered_at FROM webhook_deliveries ORDER BY id DESC LIMIT ?
 `
 
-func (q *Queries) ListSubscriptionsByBillingCycle(ctx context.Context, billingCycle string) ([]Subscription, error) {
-	rows, err := q.db.QueryContext(ctx, listSubscriptionsByBillingCycle, billingCycle)
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, limit int64) ([]WebhookDelivery, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhookDeliveries, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Subscription
+	var items []WebhookDelivery
 	for rows.Next() {
-		var i Subscription
+		var i WebhookDelivery
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
-			&i.Amount,
-			&i.Currency,
-			&i.BillingCycle,
-			&i.NextRenewalDate,
+			&i.WebhookID,
+			&i.Event,
+			&i.EventKey,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.ResponseCode,
+			&i.Error,
+			&i.NextAttemptAt,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, url, secret, events, days, created_at FROM webhooks ORDER BY id
+`
+
+func (q *Queries) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	rows, err := q.db.QueryContext(ctx, listWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Days,
 			&i.CreatedAt,
-			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -299,7 +1377,7 @@ func (q *Queries) ListSubscriptionsByBillingCycle(ctx context.Context, billingCy
 }
 
 const listYearlySubscriptions = `-- name: ListYearlySubscriptions :many
-SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at FROM subscriptions WHERE billing_cycle = 'yearly' ORDER BY next_renewal_date ASC
+SELECT id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid FROM subscriptions WHERE billing_cycle = 'yearly' ORDER BY next_renewal_date ASC
 `
 
 func (q *Queries) ListYearlySubscriptions(ctx context.Context) ([]Subscription, error) {
@@ -320,6 +1398,14 @@ func (q *Queries) ListYearlySubscriptions(ctx context.Context) ([]Subscription,
 			&i.NextRenewalDate,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CategoryID,
+			&i.Tags,
+			&i.Status,
+			&i.TrialEndDate,
+			&i.PauseDate,
+			&i.CancelDate,
+			&i.TrialPrice,
+			&i.Uuid,
 		); err != nil {
 			return nil, err
 		}
@@ -334,6 +1420,68 @@ func (q *Queries) ListYearlySubscriptions(ctx context.Context) ([]Subscription,
 	return items, nil
 }
 
+const recordRenewalPayment = `-- name: RecordRenewalPayment :exec
+INSERT INTO payments (subscription_id, name, amount, currency, paid_on, source)
+VALUES (?, ?, ?, ?, ?, 'renewal')
+ON CONFLICT DO NOTHING
+`
+
+type RecordRenewalPaymentParams struct {
+	SubscriptionID sql.NullInt64
+	Name           string
+	Amount         float64
+	Currency       string
+	PaidOn         string
+}
+
+func (q *Queries) RecordRenewalPayment(ctx context.Context, arg RecordRenewalPaymentParams) error {
+	_, err := q.db.ExecContext(ctx, recordRenewalPayment,
+		arg.SubscriptionID,
+		arg.Name,
+		arg.Amount,
+		arg.Currency,
+		arg.PaidOn,
+	)
+	return err
+}
+
+const recordSentReminder = `-- name: RecordSentReminder :exec
+INSERT INTO sent_reminders (subscription_id, renewal_date, days) VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type RecordSentReminderParams struct {
+	SubscriptionID int64
+	RenewalDate    string
+	Days           int64
+}
+
+func (q *Queries) RecordSentReminder(ctx context.Context, arg RecordSentReminderParams) error {
+	_, err := q.db.ExecContext(ctx, recordSentReminder,
+		arg.SubscriptionID,
+		arg.RenewalDate,
+		arg.Days,
+	)
+	return err
+}
+
+const renameCategory = `-- name: RenameCategory :one
+UPDATE categories SET name = ? WHERE id = ?
+RETURNING id, name, created_at
+`
+
+type RenameCategoryParams struct {
+	Name string
+	ID   int64
+}
+
+func (q *Queries) RenameCategory(ctx context.Context, arg RenameCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, renameCategory, arg.Name, arg.ID)
+	var i Category
+	err := row.Scan(&i.ID, &i.Name, &i.CreatedAt)
+	return i, err
+}
+
 const setConfig = `-- name: SetConfig :exec
 INSERT INTO config (key, value) VALUES (?, ?)
 ON CONFLICT(key) DO UPDATE SET value = excluded.value
@@ -349,11 +1497,78 @@ func (q *Queries) SetConfig(ctx context.Context, arg SetConfigParams) error {
 	return err
 }
 
+const setExchangeRate = `-- name: SetExchangeRate :exec
+INSERT INTO exchange_rates (currency, rate) VALUES (?, ?)
+ON CONFLICT(currency) DO UPDATE SET rate = excluded.rate, updated_at = datetime('now')
+`
+
+type SetExchangeRateParams struct {
+	Currency string
+	Rate     float64
+}
+
+func (q *Queries) SetExchangeRate(ctx context.Context, arg SetExchangeRateParams) error {
+	_, err := q.db.ExecContext(ctx, setExchangeRate, arg.Currency, arg.Rate)
+	return err
+}
+
+const setReminderSetting = `-- name: SetReminderSetting :exec
+INSERT INTO reminder_settings (subscription_id, lead_days) VALUES (?, ?)
+ON CONFLICT(subscription_id) DO UPDATE SET lead_days = excluded.lead_days
+`
+
+type SetReminderSettingParams struct {
+	SubscriptionID int64
+	LeadDays       string
+}
+
+func (q *Queries) SetReminderSetting(ctx context.Context, arg SetReminderSettingParams) error {
+	_, err := q.db.ExecContext(ctx, setReminderSetting, arg.SubscriptionID, arg.LeadDays)
+	return err
+}
+
+const setSubscriptionUUID = `-- name: SetSubscriptionUUID :exec
+UPDATE subscriptions SET uuid = ? WHERE id = ?
+`
+
+type SetSubscriptionUUIDParams struct {
+	Uuid string
+	ID   int64
+}
+
+func (q *Queries) SetSubscriptionUUID(ctx context.Context, arg SetSubscriptionUUIDParams) error {
+	_, err := q.db.ExecContext(ctx, setSubscriptionUUID, arg.Uuid, arg.ID)
+	return err
+}
+
+const updateBudgetAmount = `-- name: UpdateBudgetAmount :one
+UPDATE budgets SET amount = ?, updated_at = datetime('now') WHERE id = ?
+RETURNING id, category_id, amount, created_at, updated_at
+`
+
+type UpdateBudgetAmountParams struct {
+	Amount float64
+	ID     int64
+}
+
+func (q *Queries) UpdateBudgetAmount(ctx context.Context, arg UpdateBudgetAmountParams) (Budget, error) {
+	row := q.db.QueryRowContext(ctx, updateBudgetAmount, arg.Amount, arg.ID)
+	var i Budget
+	err := row.Scan(
+		&i.ID,
+		&i.CategoryID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateRenewalDate = `-- name: UpdateRenewalDate :one
 UPDATE subscriptions
 SET next_renewal_date = ?, updated_at = datetime('now')
 WHERE id = ?
-RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at
+RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid
 `
 
 type UpdateRenewalDateParams struct {
@@ -373,15 +1588,23 @@ func (q *Queries) UpdateRenewalDate(ctx context.Context, arg UpdateRenewalDatePa
 		&i.NextRenewalDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.Tags,
+		&i.Status,
+		&i.TrialEndDate,
+		&i.PauseDate,
+		&i.CancelDate,
+		&i.TrialPrice,
+		&i.Uuid,
 	)
 	return i, err
 }
 
 const updateSubscription = `-- name: UpdateSubscription :one
 UPDATE subscriptions
-SET name = ?, amount = ?, currency = ?, billing_cycle = ?, next_renewal_date = ?, updated_at = datetime('now')
+SET name = ?, amount = ?, currency = ?, billing_cycle = ?, next_renewal_date = ?, category_id = ?, tags = ?, updated_at = datetime('now')
 WHERE id = ?
-RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at
+RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid
 `
 
 type UpdateSubscriptionParams struct {
@@ -390,6 +1613,8 @@ type UpdateSubscriptionParams struct {
 	Currency        string
 	BillingCycle    string
 	NextRenewalDate sql.NullString
+	CategoryID      sql.NullInt64
+	Tags            string
 	ID              int64
 }
 
@@ -400,6 +1625,53 @@ func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscription
 		arg.Currency,
 		arg.BillingCycle,
 		arg.NextRenewalDate,
+		arg.CategoryID,
+		arg.Tags,
+		arg.ID,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.BillingCycle,
+		&i.NextRenewalDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.Tags,
+		&i.Status,
+		&i.TrialEndDate,
+		&i.PauseDate,
+		&i.CancelDate,
+		&i.TrialPrice,
+		&i.Uuid,
+	)
+	return i, err
+}
+
+const updateSubscriptionStatus = `-- name: UpdateSubscriptionStatus :one
+UPDATE subscriptions
+SET status = ?, trial_end_date = ?, pause_date = ?, cancel_date = ?, updated_at = datetime('now')
+WHERE id = ?
+RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid
+`
+
+type UpdateSubscriptionStatusParams struct {
+	Status       string
+	TrialEndDate sql.NullString
+	PauseDate    sql.NullString
+	CancelDate   sql.NullString
+	ID           int64
+}
+
+func (q *Queries) UpdateSubscriptionStatus(ctx context.Context, arg UpdateSubscriptionStatusParams) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, updateSubscriptionStatus,
+		arg.Status,
+		arg.TrialEndDate,
+		arg.PauseDate,
+		arg.CancelDate,
 		arg.ID,
 	)
 	var i Subscription
@@ -412,6 +1684,86 @@ func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscription
 		&i.NextRenewalDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.Tags,
+		&i.Status,
+		&i.TrialEndDate,
+		&i.PauseDate,
+		&i.CancelDate,
+		&i.TrialPrice,
+		&i.Uuid,
 	)
 	return i, err
 }
+
+const updateSubscriptionTrial = `-- name: UpdateSubscriptionTrial :one
+UPDATE subscriptions
+SET status = ?, trial_end_date = ?, trial_price = ?, updated_at = datetime('now')
+WHERE id = ?
+RETURNING id, name, amount, currency, billing_cycle, next_renewal_date, created_at, updated_at, category_id, tags, status, trial_end_date, pause_date, cancel_date, trial_price, uuid
+`
+
+type UpdateSubscriptionTrialParams struct {
+	Status       string
+	TrialEndDate sql.NullString
+	TrialPrice   sql.NullFloat64
+	ID           int64
+}
+
+func (q *Queries) UpdateSubscriptionTrial(ctx context.Context, arg UpdateSubscriptionTrialParams) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, updateSubscriptionTrial,
+		arg.Status,
+		arg.TrialEndDate,
+		arg.TrialPrice,
+		arg.ID,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Amount,
+		&i.Currency,
+		&i.BillingCycle,
+		&i.NextRenewalDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CategoryID,
+		&i.Tags,
+		&i.Status,
+		&i.TrialEndDate,
+		&i.PauseDate,
+		&i.CancelDate,
+		&i.TrialPrice,
+		&i.Uuid,
+	)
+	return i, err
+}
+
+const updateWebhookDelivery = `-- name: UpdateWebhookDelivery :exec
+UPDATE webhook_deliveries
+SET status = ?, attempts = ?, response_code = ?, error = ?, next_attempt_at = ?, delivered_at = ?
+WHERE id = ?
+`
+
+type UpdateWebhookDeliveryParams struct {
+	Status        string
+	Attempts      int64
+	ResponseCode  int64
+	Error         string
+	NextAttemptAt string
+	DeliveredAt   sql.NullString
+	ID            int64
+}
+
+func (q *Queries) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, updateWebhookDelivery,
+		arg.Status,
+		arg.Attempts,
+		arg.ResponseCode,
+		arg.Error,
+		arg.NextAttemptAt,
+		arg.DeliveredAt,
+		arg.ID,
+	)
+	return err
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"subscription-tracker/internal/db"
+)
+
+// BudgetWarnPercent is how much of a budget can be used before it is flagged as near its limit
+const BudgetWarnPercent = 80
+
+// BudgetState tells how much of a budget a billing period has used
+type BudgetState string
+
+const (
+	BudgetOK   BudgetState = "ok"
+	BudgetNear BudgetState = "near" // At least BudgetWarnPercent used
+	BudgetOver BudgetState = "over" // More than the limit used
+)
+
+// BudgetService handles spending limits per billing period
+type BudgetService struct {
+	queries *db.Queries
+}
+
+// NewBudgetService creates a new budget service
+func NewBudgetService(queries *db.Queries) *BudgetService {
+	return &BudgetService{queries: queries}
+}
+
+// Budget is a spending limit per billing period in the base currency
+type Budget struct {
+	ID       int64
+	Category string // Category name; empty for the overall budget
+	Amount   float64
+}
+
+// List returns the overall budget, if set, followed by the category budgets
+func (s *BudgetService) List(ctx context.Context) ([]Budget, error) {
+	return listBudgets(ctx, s.queries)
+}
+
+// Set creates or changes the budget of a category, or the overall budget
+// when category is empty. The amount is per billing period in the base currency.
+func (s *BudgetService) Set(ctx context.Context, category string, amount float64) (Budget, error) {
+	if amount <= 0 {
+		return Budget{}, fmt.Errorf("budget must be greater than zero")
+	}
+	category = strings.ToLower(strings.TrimSpace(category))
+
+	categoryID, err := s.categoryID(ctx, category)
+	if err != nil {
+		return Budget{}, err
+	}
+
+	var existing db.Budget
+	if categoryID.Valid {
+		existing, err = s.queries.GetCategoryBudget(ctx, categoryID)
+	} else {
+		existing, err = s.queries.GetOverallBudget(ctx)
+	}
+
+	var budget db.Budget
+	switch {
+	case err == nil:
+		budget, err = s.queries.UpdateBudgetAmount(ctx, db.UpdateBudgetAmountParams{ID: existing.ID, Amount: amount})
+	case errors.Is(err, sql.ErrNoRows):
+		budget, err = s.queries.CreateBudget(ctx, db.CreateBudgetParams{CategoryID: categoryID, Amount: amount})
+	}
+	if err != nil {
+		return Budget{}, fmt.Errorf("failed to set budget: %w", err)
+	}
+
+	return Budget{ID: budget.ID, Category: category, Amount: budget.Amount}, nil
+}
+
+// Delete removes the budget of a category, or the overall budget when category is empty
+func (s *BudgetService) Delete(ctx context.Context, category string) error {
+	category = strings.ToLower(strings.TrimSpace(category))
+	categoryID, err := s.categoryID(ctx, category)
+	if err != nil {
+		return err
+	}
+
+	var budget db.Budget
+	if categoryID.Valid {
+		budget, err = s.queries.GetCategoryBudget(ctx, categoryID)
+	} else {
+		budget, err = s.queries.GetOverallBudget(ctx)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no budget set for %s", budgetLabel(category))
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.queries.DeleteBudget(ctx, budget.ID)
+}
+
+// categoryID looks up an existing category for a budget
+func (s *BudgetService) categoryID(ctx context.Context, category string) (sql.NullInt64, error) {
+	if category == "" {
+		return sql.NullInt64{}, nil
+	}
+	found, err := s.queries.GetCategoryByName(ctx, category)
+	if err != nil {
+		return sql.NullInt64{}, fmt.Errorf("category %s not found", category)
+	}
+	return sql.NullInt64{Int64: found.ID, Valid: true}, nil
+}
+
+// listBudgets returns the overall budget, if set, followed by the category budgets
+func listBudgets(ctx context.Context, queries *db.Queries) ([]Budget, error) {
+	budgets, err := queries.ListBudgets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+
+	names, err := categoryNames(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Budget, len(budgets))
+	for i, b := range budgets {
+		result[i] = Budget{ID: b.ID, Amount: b.Amount}
+		if b.CategoryID.Valid {
+			result[i].Category = names[b.CategoryID.Int64]
+		}
+	}
+	return result, nil
+}
+
+// BudgetStatus is how much of a budget one billing period uses
+type BudgetStatus struct {
+	Category  string // Category name; empty for the overall budget
+	Limit     float64
+	Used      float64
+	Remaining float64 // Negative when over budget
+	Percent   float64 // Used as a percentage of Limit
+	State     BudgetState
+}
+
+// Label returns the category name, or "overall" for the overall budget
+func (b BudgetStatus) Label() string {
+	return budgetLabel(b.Category)
+}
+
+func budgetLabel(category string) string {
+	if category == "" {
+		return "overall"
+	}
+	return category
+}
+
+// budgetStatuses compares budgets to a period's total and category totals
+func budgetStatuses(budgets []Budget, total float64, categories []CategoryTotal) []BudgetStatus {
+	used := make(map[string]float64, len(categories))
+	for _, c := range categories {
+		used[c.Category] = c.Total
+	}
+
+	statuses := make([]BudgetStatus, len(budgets))
+	for i, b := range budgets {
+		status := BudgetStatus{Category: b.Category, Limit: b.Amount, Used: total}
+		if b.Category != "" {
+			status.Used = used[b.Category]
+		}
+		status.Remaining = status.Limit - status.Used
+		status.Percent = status.Used / status.Limit * 100
+		switch {
+		case status.Used > status.Limit:
+			status.State = BudgetOver
+		case status.Percent >= BudgetWarnPercent:
+			status.State = BudgetNear
+		default:
+			status.State = BudgetOK
+		}
+		statuses[i] = status
+	}
+	return statuses
+}
+
+// BudgetAlerts returns the budgets that are near or over their limit
+func BudgetAlerts(statuses []BudgetStatus) []BudgetStatus {
+	var alerts []BudgetStatus
+	for _, status := range statuses {
+		if status.State != BudgetOK {
+			alerts = append(alerts, status)
+		}
+	}
+	return alerts
+}
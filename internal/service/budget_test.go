@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestBudgetService_SetListDelete(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := tdb.BudgetService.Set(ctx, "", 0); err == nil {
+		t.Error("Set() should reject a zero budget")
+	}
+	if _, err := tdb.BudgetService.Set(ctx, "missing", 10); err == nil {
+		t.Error("Set() should reject an unknown category")
+	}
+
+	if _, err := tdb.BudgetService.Set(ctx, "Software", 50); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := tdb.BudgetService.Set(ctx, "", 100); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// Setting again changes the existing budget
+	if _, err := tdb.BudgetService.Set(ctx, "", 120); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	budgets, err := tdb.BudgetService.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(budgets) != 2 {
+		t.Fatalf("List() = %+v, want 2 budgets", budgets)
+	}
+	if budgets[0].Category != "" || budgets[0].Amount != 120 {
+		t.Errorf("List()[0] = %+v, want the overall budget of 120", budgets[0])
+	}
+	if budgets[1].Category != "software" || budgets[1].Amount != 50 {
+		t.Errorf("List()[1] = %+v, want the software budget of 50", budgets[1])
+	}
+
+	if err := tdb.BudgetService.Delete(ctx, ""); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := tdb.BudgetService.Delete(ctx, ""); err == nil {
+		t.Error("Delete() should fail when no budget is set")
+	}
+
+	// Deleting a category deletes its budget
+	software, err := tdb.CategoryService.GetByName(ctx, "software")
+	if err != nil {
+		t.Fatalf("GetByName() error = %v", err)
+	}
+	if err := tdb.CategoryService.Delete(ctx, software.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	budgets, err = tdb.BudgetService.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(budgets) != 0 {
+		t.Errorf("List() = %+v, want none", budgets)
+	}
+}
+
+func TestSpendingService_Budgets(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15", Category: "streaming"},
+		{Name: "Disney+", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-20", Category: "streaming"},
+		{Name: "GitHub", Amount: 4.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", Category: "software"},
+		{Name: "Gym", Amount: 30.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-01"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+
+	budgets := []struct {
+		category string
+		amount   float64
+	}{
+		{"", 100},
+		{"streaming", 20},
+		{"software", 5},
+		{"news", 10},
+	}
+	for _, b := range budgets {
+		if _, err := tdb.BudgetService.Set(ctx, b.category, b.amount); err != nil {
+			t.Fatalf("Set(%q) error = %v", b.category, err)
+		}
+	}
+
+	summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, 2)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+
+	want := []service.BudgetStatus{
+		{Category: "", Limit: 100, Used: 59, Remaining: 41, Percent: 59, State: service.BudgetOK},
+		{Category: "streaming", Limit: 20, Used: 25, Remaining: -5, Percent: 125, State: service.BudgetOver},
+		{Category: "software", Limit: 5, Used: 4, Remaining: 1, Percent: 80, State: service.BudgetNear},
+		{Category: "news", Limit: 10, Used: 0, Remaining: 10, Percent: 0, State: service.BudgetOK},
+	}
+	if len(summary.Budgets) != len(want) {
+		t.Fatalf("Budgets = %+v, want %+v", summary.Budgets, want)
+	}
+	// Category budgets are ordered by category ID
+	got := make(map[string]service.BudgetStatus)
+	for _, b := range summary.Budgets {
+		got[b.Category] = b
+	}
+	if summary.Budgets[0].Category != "" {
+		t.Errorf("Budgets[0] = %+v, want the overall budget first", summary.Budgets[0])
+	}
+	for _, w := range want {
+		b := got[w.Category]
+		if b.State != w.State || !almostEqual(b.Used, w.Used) || !almostEqual(b.Remaining, w.Remaining) || !almostEqual(b.Percent, w.Percent) {
+			t.Errorf("budget %s = %+v, want %+v", w.Label(), b, w)
+		}
+	}
+
+	alerts := service.BudgetAlerts(summary.Budgets)
+	if len(alerts) != 2 {
+		t.Errorf("BudgetAlerts() = %+v, want streaming and software", alerts)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"subscription-tracker/internal/db"
+)
+
+// Uncategorized is the name subscriptions without a category are grouped under
+const Uncategorized = "uncategorized"
+
+// CategoryService handles subscription categories
+type CategoryService struct {
+	queries *db.Queries
+}
+
+// NewCategoryService creates a new category service
+func NewCategoryService(queries *db.Queries) *CategoryService {
+	return &CategoryService{queries: queries}
+}
+
+// List returns all categories ordered by name
+func (s *CategoryService) List(ctx context.Context) ([]db.Category, error) {
+	return s.queries.ListCategories(ctx)
+}
+
+// Create adds a new category
+func (s *CategoryService) Create(ctx context.Context, name string) (db.Category, error) {
+	name, err := normalizeCategoryName(name)
+	if err != nil {
+		return db.Category{}, err
+	}
+	if _, err := s.queries.GetCategoryByName(ctx, name); err == nil {
+		return db.Category{}, fmt.Errorf("category %s already exists", name)
+	}
+	return s.queries.CreateCategory(ctx, name)
+}
+
+// Rename changes the name of a category
+func (s *CategoryService) Rename(ctx context.Context, id int64, name string) (db.Category, error) {
+	name, err := normalizeCategoryName(name)
+	if err != nil {
+		return db.Category{}, err
+	}
+	if existing, err := s.queries.GetCategoryByName(ctx, name); err == nil && existing.ID != id {
+		return db.Category{}, fmt.Errorf("category %s already exists", name)
+	}
+	return s.queries.RenameCategory(ctx, db.RenameCategoryParams{ID: id, Name: name})
+}
+
+// Delete removes a category and its budget; its subscriptions become uncategorized
+func (s *CategoryService) Delete(ctx context.Context, id int64) error {
+	if err := s.queries.ClearSubscriptionCategory(ctx, sql.NullInt64{Int64: id, Valid: true}); err != nil {
+		return fmt.Errorf("failed to uncategorize subscriptions: %w", err)
+	}
+	if err := s.queries.DeleteCategoryBudget(ctx, sql.NullInt64{Int64: id, Valid: true}); err != nil {
+		return fmt.Errorf("failed to delete category budget: %w", err)
+	}
+	return s.queries.DeleteCategory(ctx, id)
+}
+
+// GetByName looks up a category by name, ignoring case
+func (s *CategoryService) GetByName(ctx context.Context, name string) (db.Category, error) {
+	return s.queries.GetCategoryByName(ctx, strings.TrimSpace(name))
+}
+
+// Names returns the name of every category by ID
+func (s *CategoryService) Names(ctx context.Context) (map[int64]string, error) {
+	return categoryNames(ctx, s.queries)
+}
+
+// categoryNames returns the name of every category by ID
+func categoryNames(ctx context.Context, queries *db.Queries) (map[int64]string, error) {
+	categories, err := queries.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	names := make(map[int64]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+	return names, nil
+}
+
+// resolveCategory returns the ID of the named category, creating it if it
+// doesn't exist yet. An empty name means no category.
+func resolveCategory(ctx context.Context, queries *db.Queries, name string) (sql.NullInt64, error) {
+	if strings.TrimSpace(name) == "" {
+		return sql.NullInt64{}, nil
+	}
+
+	name, err := normalizeCategoryName(name)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	category, err := queries.GetCategoryByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		category, err = queries.CreateCategory(ctx, name)
+	}
+	if err != nil {
+		return sql.NullInt64{}, fmt.Errorf("failed to resolve category %s: %w", name, err)
+	}
+	return sql.NullInt64{Int64: category.ID, Valid: true}, nil
+}
+
+// normalizeCategoryName trims and lower-cases a category name
+func normalizeCategoryName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", fmt.Errorf("category name is required")
+	}
+	if name == Uncategorized {
+		return "", fmt.Errorf("%s is reserved for subscriptions without a category", Uncategorized)
+	}
+	if strings.Contains(name, ",") {
+		return "", fmt.Errorf("category name cannot contain a comma")
+	}
+	return name, nil
+}
+
+// CategoryName returns the name of a subscription's category, or Uncategorized
+func CategoryName(sub db.Subscription, names map[int64]string) string {
+	if name, ok := names[sub.CategoryID.Int64]; ok && sub.CategoryID.Valid {
+		return name
+	}
+	return Uncategorized
+}
+
+// ParseTags splits a comma or space separated list of tags
+func ParseTags(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// NormalizeTags lower-cases, de-duplicates and sorts tags and joins them
+// with commas, the form they are stored in
+func NormalizeTags(tags []string) string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, tag := range tags {
+		for _, t := range ParseTags(tag) {
+			t = strings.ToLower(t)
+			if !seen[t] {
+				seen[t] = true
+				result = append(result, t)
+			}
+		}
+	}
+	sort.Strings(result)
+	return strings.Join(result, ",")
+}
+
+// HasTag reports whether a subscription is tagged with tag
+func HasTag(sub db.Subscription, tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range ParseTags(sub.Tags) {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// CategoryTotal is the spending of one category
+type CategoryTotal struct {
+	Category string
+	Total    float64 // In the base currency
+	Count    int     // Number of subscriptions
+}
+
+// CurrencyTotal is the spending of the subscriptions billed in one currency
+type CurrencyTotal struct {
+	Currency  string
+	Total     float64 // In Currency
+	Converted float64 // In the base currency; Total unconverted when HasRate is false
+	HasRate   bool
+	Count     int // Number of subscriptions
+}
+
+// sortCategoryTotals orders totals from largest to smallest, then by name
+func sortCategoryTotals(totals []CategoryTotal) {
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Total != totals[j].Total {
+			return totals[i].Total > totals[j].Total
+		}
+		return totals[i].Category < totals[j].Category
+	})
+}
@@ -0,0 +1,204 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{name: "empty", tags: nil, want: ""},
+		{name: "sorted and lower-cased", tags: []string{"Work", "family"}, want: "family,work"},
+		{name: "duplicates removed", tags: []string{"work", "WORK", "dev"}, want: "dev,work"},
+		{name: "comma and space separated", tags: []string{"dev, work tools"}, want: "dev,tools,work"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.NormalizeTags(tt.tags); got != tt.want {
+				t.Errorf("NormalizeTags(%v) = %q, want %q", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCategoryService_CreateRenameDelete(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	category, err := tdb.CategoryService.Create(ctx, " Dev Tools ")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if category.Name != "dev tools" {
+		t.Errorf("Create() name = %q, want %q", category.Name, "dev tools")
+	}
+
+	if _, err := tdb.CategoryService.Create(ctx, "DEV TOOLS"); err == nil {
+		t.Error("Create() should reject a duplicate name")
+	}
+	if _, err := tdb.CategoryService.Create(ctx, service.Uncategorized); err == nil {
+		t.Error("Create() should reject the reserved name")
+	}
+	if _, err := tdb.CategoryService.Rename(ctx, category.ID, "streaming"); err == nil {
+		t.Error("Rename() should reject the name of another category")
+	}
+
+	renamed, err := tdb.CategoryService.Rename(ctx, category.ID, "devtools")
+	if err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if renamed.Name != "devtools" {
+		t.Errorf("Rename() name = %q, want devtools", renamed.Name)
+	}
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "GitHub", Amount: 4.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", Category: "devtools",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if !sub.CategoryID.Valid || sub.CategoryID.Int64 != category.ID {
+		t.Errorf("subscription category = %v, want %d", sub.CategoryID, category.ID)
+	}
+
+	// Deleting the category leaves its subscriptions uncategorized
+	if err := tdb.CategoryService.Delete(ctx, category.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	sub, err = tdb.SubscriptionService.Get(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if sub.CategoryID.Valid {
+		t.Errorf("subscription category = %d, want none", sub.CategoryID.Int64)
+	}
+}
+
+func TestSubscriptionService_Filter(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15", Category: "streaming", Tags: []string{"family"}},
+		{Name: "GitHub", Amount: 4.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", Category: "Software", Tags: []string{"work", "dev"}},
+		{Name: "JetBrains", Amount: 250.00, Currency: "USD", BillingCycle: "yearly", NextRenewalDate: "2026-06-01", Category: "software", Tags: []string{"dev"}},
+		{Name: "Gym", Amount: 30.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-01"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter service.SubscriptionFilter
+		want   []string
+	}{
+		{name: "no filter", filter: service.SubscriptionFilter{}, want: []string{"GitHub", "Gym", "JetBrains", "Netflix"}},
+		{name: "category", filter: service.SubscriptionFilter{Category: "SOFTWARE"}, want: []string{"GitHub", "JetBrains"}},
+		{name: "uncategorized", filter: service.SubscriptionFilter{Category: service.Uncategorized}, want: []string{"Gym"}},
+		{name: "tag", filter: service.SubscriptionFilter{Tag: "dev"}, want: []string{"GitHub", "JetBrains"}},
+		{name: "category, tag and cycle", filter: service.SubscriptionFilter{BillingCycle: "monthly", Category: "software", Tag: "dev"}, want: []string{"GitHub"}},
+		{name: "no match", filter: service.SubscriptionFilter{Tag: "missing"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs, err := tdb.SubscriptionService.Filter(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Filter() error = %v", err)
+			}
+			var got []string
+			for _, sub := range subs {
+				got = append(got, sub.Name)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Filter() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Filter() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSpendingService_Categories(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15", Category: "streaming"},
+		{Name: "Spotify", Amount: 9.00, Currency: "EUR", BillingCycle: "monthly", NextRenewalDate: "2026-01-20", Category: "streaming"},
+		{Name: "GitHub", Amount: 4.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", Category: "software"},
+		{Name: "JetBrains", Amount: 250.00, Currency: "USD", BillingCycle: "yearly", NextRenewalDate: "2026-06-01", Category: "software"},
+		{Name: "Newspaper", Amount: 2.00, Currency: "USD", BillingCycle: "weekly", NextRenewalDate: "2026-01-05"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+	if err := tdb.CurrencyService.SetRate(ctx, "EUR", 0.90); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+
+	// February 2026 has four Monday newspaper charges and no JetBrains renewal
+	summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, 2)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+	want := []service.CategoryTotal{
+		{Category: "streaming", Total: 25.00, Count: 2},
+		{Category: service.Uncategorized, Total: 8.00, Count: 1},
+		{Category: "software", Total: 4.00, Count: 1},
+	}
+	assertCategoryTotals(t, "CalculateForMonth()", summary.Categories, want)
+
+	annual, err := tdb.SpendingService.CalculateAnnualByCategory(ctx)
+	if err != nil {
+		t.Fatalf("CalculateAnnualByCategory() error = %v", err)
+	}
+	want = []service.CategoryTotal{
+		{Category: "streaming", Total: 300.00, Count: 2},
+		{Category: "software", Total: 298.00, Count: 2},
+		{Category: service.Uncategorized, Total: 104.00, Count: 1},
+	}
+	assertCategoryTotals(t, "CalculateAnnualByCategory()", annual, want)
+
+	var buf bytes.Buffer
+	if err := tdb.ExportService.ExportCategoryTotals(&buf, annual, "USD", service.FormatJSON); err != nil {
+		t.Fatalf("ExportCategoryTotals() error = %v", err)
+	}
+	var exported []service.ExportCategoryTotal
+	if err := json.Unmarshal(buf.Bytes(), &exported); err != nil {
+		t.Fatalf("failed to parse exported totals: %v", err)
+	}
+	if len(exported) != 3 || exported[1].Category != "software" || exported[1].AnnualTotal != 298.00 || exported[1].Currency != "USD" {
+		t.Errorf("ExportCategoryTotals() = %+v", exported)
+	}
+}
+
+func assertCategoryTotals(t *testing.T, call string, got, want []service.CategoryTotal) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %+v, want %+v", call, got, want)
+	}
+	for i := range want {
+		if got[i].Category != want[i].Category || got[i].Count != want[i].Count || !almostEqual(got[i].Total, want[i].Total) {
+			t.Errorf("%s[%d] = %+v, want %+v", call, i, got[i], want[i])
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"subscription-tracker/internal/db"
 )
@@ -11,6 +12,22 @@ import (
 const (
 	ConfigKeyMonthCutoffDay = "month_cutoff_day"
 	ConfigKeyMonthlySalary  = "monthly_salary"
+	ConfigKeyBaseCurrency   = "base_currency"
+	ConfigKeyTrialWarnDays  = "trial_warn_days"
+	ConfigKeyReminderDays   = "reminder_days"
+
+	// ConfigKeyPaymentsRecordedThrough is the day renewals have been recorded
+	// in the payment ledger up to; it is kept up to date by AdvanceRenewalDates
+	ConfigKeyPaymentsRecordedThrough = "payments_recorded_through"
+
+	// DefaultBaseCurrency is used until a base currency is configured
+	DefaultBaseCurrency = "USD"
+
+	// DefaultTrialWarnDays is how many days ahead ending trials are shown until configured
+	DefaultTrialWarnDays = 7
+
+	// DefaultReminderDays are the days before a renewal reminders are sent until configured
+	DefaultReminderDays = "3"
 )
 
 // ConfigService handles configuration
@@ -84,10 +101,92 @@ func (s *ConfigService) SetMonthlySalary(ctx context.Context, salary float64) er
 	})
 }
 
+// GetBaseCurrency returns the currency all totals are converted to
+// Default is USD
+func (s *ConfigService) GetBaseCurrency(ctx context.Context) (string, error) {
+	value, err := s.queries.GetConfig(ctx, ConfigKeyBaseCurrency)
+	if err != nil || value == "" {
+		return DefaultBaseCurrency, nil
+	}
+
+	return value, nil
+}
+
+// setBaseCurrency stores the base currency. Use CurrencyService.SetBaseCurrency,
+// which also rebases the stored exchange rates.
+func (s *ConfigService) setBaseCurrency(ctx context.Context, currency string) error {
+	return s.queries.SetConfig(ctx, db.SetConfigParams{
+		Key:   ConfigKeyBaseCurrency,
+		Value: strings.ToUpper(currency),
+	})
+}
+
+// GetTrialWarnDays returns how many days before a free trial ends it is shown
+// as ending. Default is DefaultTrialWarnDays.
+func (s *ConfigService) GetTrialWarnDays(ctx context.Context) (int, error) {
+	value, err := s.queries.GetConfig(ctx, ConfigKeyTrialWarnDays)
+	if err != nil {
+		return DefaultTrialWarnDays, nil
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return DefaultTrialWarnDays, nil
+	}
+
+	return days, nil
+}
+
+// SetTrialWarnDays sets how many days before a free trial ends it is shown as ending
+func (s *ConfigService) SetTrialWarnDays(ctx context.Context, days int) error {
+	if days < 0 || days > 365 {
+		return fmt.Errorf("trial warning days must be between 0 and 365")
+	}
+
+	return s.queries.SetConfig(ctx, db.SetConfigParams{
+		Key:   ConfigKeyTrialWarnDays,
+		Value: strconv.Itoa(days),
+	})
+}
+
+// GetReminderDays returns the days before a renewal that reminders are sent,
+// largest first, for subscriptions without their own. Default is DefaultReminderDays.
+func (s *ConfigService) GetReminderDays(ctx context.Context) ([]int, error) {
+	value, err := s.queries.GetConfig(ctx, ConfigKeyReminderDays)
+	if err != nil {
+		value = DefaultReminderDays
+	}
+
+	days, err := ParseReminderDays(value)
+	if err != nil {
+		days, _ = ParseReminderDays(DefaultReminderDays)
+	}
+
+	return days, nil
+}
+
+// SetReminderDays sets the days before a renewal that reminders are sent; no
+// days turns reminders off for subscriptions without their own
+func (s *ConfigService) SetReminderDays(ctx context.Context, days []int) error {
+	for _, d := range days {
+		if d < 0 || d > MaxReminderDays {
+			return fmt.Errorf("reminder days must be between 0 and %d", MaxReminderDays)
+		}
+	}
+
+	return s.queries.SetConfig(ctx, db.SetConfigParams{
+		Key:   ConfigKeyReminderDays,
+		Value: FormatReminderDays(days),
+	})
+}
+
 // Config represents the application configuration
 type Config struct {
 	MonthCutoffDay int
 	MonthlySalary  float64
+	BaseCurrency   string
+	TrialWarnDays  int
+	ReminderDays   []int
 }
 
 // GetAll returns all configuration values
@@ -102,8 +201,26 @@ func (s *ConfigService) GetAll(ctx context.Context) (*Config, error) {
 		return nil, err
 	}
 
+	baseCurrency, err := s.GetBaseCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trialWarnDays, err := s.GetTrialWarnDays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderDays, err := s.GetReminderDays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		MonthCutoffDay: cutoffDay,
 		MonthlySalary:  salary,
+		BaseCurrency:   baseCurrency,
+		TrialWarnDays:  trialWarnDays,
+		ReminderDays:   reminderDays,
 	}, nil
 }
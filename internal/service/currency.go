@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"subscription-tracker/internal/db"
+)
+
+// Rate file formats accepted by ImportRates
+const (
+	RateFormatECB = "ecb" // ECB eurofxref XML, rates per 1 EUR
+	RateFormatCSV = "csv" // currency,rate rows, or the ECB wide CSV (Date, USD, JPY, ...)
+)
+
+// CurrencyService handles exchange rates and conversion to the base currency.
+// Rates are stored as units of a currency per one unit of the base currency,
+// so 0.92 for EUR with a USD base means 1 USD = 0.92 EUR.
+type CurrencyService struct {
+	queries       *db.Queries
+	configService *ConfigService
+}
+
+// NewCurrencyService creates a new currency service
+func NewCurrencyService(queries *db.Queries, configService *ConfigService) *CurrencyService {
+	return &CurrencyService{
+		queries:       queries,
+		configService: configService,
+	}
+}
+
+// ListRates returns every stored exchange rate ordered by currency
+func (s *CurrencyService) ListRates(ctx context.Context) ([]db.ExchangeRate, error) {
+	return s.queries.ListExchangeRates(ctx)
+}
+
+// SetRate stores how many units of currency one unit of the base currency buys
+func (s *CurrencyService) SetRate(ctx context.Context, currency string, rate float64) error {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return fmt.Errorf("exchange rate must be a positive number")
+	}
+
+	base, err := s.configService.GetBaseCurrency(ctx)
+	if err != nil {
+		return err
+	}
+	if currency == base {
+		return fmt.Errorf("%s is the base currency, its rate is always 1", base)
+	}
+
+	return s.queries.SetExchangeRate(ctx, db.SetExchangeRateParams{Currency: currency, Rate: rate})
+}
+
+// DeleteRate removes the exchange rate of a currency
+func (s *CurrencyService) DeleteRate(ctx context.Context, currency string) error {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	return s.queries.DeleteExchangeRate(ctx, currency)
+}
+
+// SetBaseCurrency changes the base currency and rebases the stored rates so
+// they stay correct. The new base needs a stored rate unless no rates exist.
+func (s *CurrencyService) SetBaseCurrency(ctx context.Context, currency string) error {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+
+	oldBase, err := s.configService.GetBaseCurrency(ctx)
+	if err != nil {
+		return err
+	}
+	if currency == oldBase {
+		return nil
+	}
+
+	rates, err := s.queries.ListExchangeRates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+
+	if len(rates) > 0 {
+		table := map[string]float64{oldBase: 1}
+		for _, r := range rates {
+			table[r.Currency] = r.Rate
+		}
+		if _, ok := table[currency]; !ok {
+			return fmt.Errorf("no exchange rate for %s, add one before making it the base currency", currency)
+		}
+		if err := replaceRates(ctx, s.queries, rebaseRates(table, currency)); err != nil {
+			return err
+		}
+	}
+
+	return s.configService.setBaseCurrency(ctx, currency)
+}
+
+// ImportRates reads exchange rates from r and replaces the stored rates with them.
+// Rates in the file may be quoted against any reference currency (EUR for ECB
+// files, otherwise reference); they are rebased to the base currency, which must
+// therefore appear in the file. Returns the number of rates stored.
+func (s *CurrencyService) ImportRates(ctx context.Context, r io.Reader, format, reference string) (int, error) {
+	var table map[string]float64
+	var err error
+	switch strings.ToLower(format) {
+	case RateFormatECB:
+		table, err = parseECBXML(r)
+		reference = "EUR"
+	case RateFormatCSV:
+		var ecb bool
+		table, ecb, err = parseRatesCSV(r)
+		if ecb {
+			reference = "EUR"
+		}
+	default:
+		return 0, fmt.Errorf("unsupported rate format: %s (use ecb or csv)", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	base, err := s.configService.GetBaseCurrency(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if reference == "" {
+		reference = base
+	}
+	if reference, err = normalizeCurrency(reference); err != nil {
+		return 0, err
+	}
+	table[reference] = 1
+
+	if _, ok := table[base]; !ok {
+		return 0, fmt.Errorf("rate file has no rate for the base currency %s", base)
+	}
+
+	rebased := rebaseRates(table, base)
+	if err := replaceRates(ctx, s.queries, rebased); err != nil {
+		return 0, err
+	}
+	return len(rebased), nil
+}
+
+// replaceRates stores the given rates and then deletes the other stored ones,
+// so that a failure partway never leaves currencies without a rate
+func replaceRates(ctx context.Context, queries *db.Queries, rates map[string]float64) error {
+	for currency, rate := range rates {
+		if err := queries.SetExchangeRate(ctx, db.SetExchangeRateParams{Currency: currency, Rate: rate}); err != nil {
+			return fmt.Errorf("failed to store exchange rate for %s: %w", currency, err)
+		}
+	}
+	stored, err := queries.ListExchangeRates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	for _, r := range stored {
+		if _, ok := rates[r.Currency]; ok {
+			continue
+		}
+		if err := queries.DeleteExchangeRate(ctx, r.Currency); err != nil {
+			return fmt.Errorf("failed to delete exchange rate for %s: %w", r.Currency, err)
+		}
+	}
+	return nil
+}
+
+// rebaseRates converts rates quoted against any reference to rates per one
+// unit of base. The base itself is left out since its rate is implied.
+func rebaseRates(table map[string]float64, base string) map[string]float64 {
+	baseRate := table[base]
+	rebased := make(map[string]float64, len(table))
+	for currency, rate := range table {
+		if currency == base {
+			continue
+		}
+		rebased[currency] = rate / baseRate
+	}
+	return rebased
+}
+
+// Converter converts amounts to the base currency using a snapshot of the rates
+type Converter struct {
+	Base  string
+	rates map[string]float64
+}
+
+// Converter loads the current base currency and exchange rates
+func (s *CurrencyService) Converter(ctx context.Context) (*Converter, error) {
+	base, err := s.configService.GetBaseCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := s.queries.ListExchangeRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+
+	c := &Converter{Base: base, rates: map[string]float64{base: 1}}
+	for _, r := range rates {
+		c.rates[r.Currency] = r.Rate
+	}
+	return c, nil
+}
+
+// Convert converts amount in currency to the base currency. If no rate is
+// known the amount is returned unchanged and ok is false.
+func (c *Converter) Convert(amount float64, currency string) (converted float64, ok bool) {
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = c.Base
+	}
+	rate, ok := c.rates[currency]
+	if !ok {
+		return amount, false
+	}
+	return amount / rate, true
+}
+
+// MissingRates returns the sorted, de-duplicated currencies of subs that have no rate
+func (c *Converter) MissingRates(subs []db.Subscription) []string {
+	seen := make(map[string]bool)
+	var missing []string
+	for _, sub := range subs {
+		if _, ok := c.Convert(0, sub.Currency); ok || seen[sub.Currency] {
+			continue
+		}
+		seen[sub.Currency] = true
+		missing = append(missing, sub.Currency)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// normalizeCurrency validates a three letter currency code and upper-cases it
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 {
+		return "", fmt.Errorf("currency must be a three letter code: %q", currency)
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("currency must be a three letter code: %q", currency)
+		}
+	}
+	return currency, nil
+}
+
+// ecbEnvelope matches the eurofxref XML published by the European Central Bank
+type ecbEnvelope struct {
+	Rates []struct {
+		Currency string `xml:"currency,attr"`
+		Rate     string `xml:"rate,attr"`
+	} `xml:"Cube>Cube>Cube"`
+}
+
+// parseECBXML parses an ECB eurofxref-daily.xml file
+func parseECBXML(r io.Reader) (map[string]float64, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse ECB XML: %w", err)
+	}
+
+	table := make(map[string]float64)
+	for _, entry := range envelope.Rates {
+		if err := addRate(table, entry.Currency, entry.Rate); err != nil {
+			return nil, err
+		}
+	}
+	if len(table) == 0 {
+		return nil, fmt.Errorf("no exchange rates found in ECB XML")
+	}
+	return table, nil
+}
+
+// parseRatesCSV parses either currency,rate rows (with an optional header) or
+// the ECB wide CSV, whose header starts with Date. ecb reports the latter.
+func parseRatesCSV(r io.Reader) (table map[string]float64, ecb bool, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, false, fmt.Errorf("no exchange rates found in CSV")
+	}
+
+	table = make(map[string]float64)
+	if strings.EqualFold(strings.TrimSpace(records[0][0]), "date") {
+		// ECB wide format: a header of currencies and one row of rates
+		if len(records) < 2 {
+			return nil, false, fmt.Errorf("ECB CSV has no rates row")
+		}
+		header, values := records[0], records[1]
+		for i := 1; i < len(header) && i < len(values); i++ {
+			if strings.TrimSpace(header[i]) == "" || strings.TrimSpace(values[i]) == "N/A" {
+				continue
+			}
+			if err := addRate(table, header[i], values[i]); err != nil {
+				return nil, false, err
+			}
+		}
+		ecb = true
+	} else {
+		for i, record := range records {
+			if len(record) < 2 {
+				return nil, false, fmt.Errorf("row %d: expected currency,rate", i+1)
+			}
+			if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+				continue
+			}
+			if err := addRate(table, record[0], record[1]); err != nil {
+				return nil, false, fmt.Errorf("row %d: %w", i+1, err)
+			}
+		}
+	}
+
+	if len(table) == 0 {
+		return nil, false, fmt.Errorf("no exchange rates found in CSV")
+	}
+	return table, ecb, nil
+}
+
+// addRate validates and adds a single currency rate to table
+func addRate(table map[string]float64, currency, rate string) error {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || value <= 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("invalid exchange rate for %s: %s", currency, rate)
+	}
+	table[currency] = value
+	return nil
+}
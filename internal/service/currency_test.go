@@ -0,0 +1,182 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const ecbXML = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-01-02">
+			<Cube currency="USD" rate="1.2500"/>
+			<Cube currency="GBP" rate="0.8000"/>
+			<Cube currency="JPY" rate="150.00"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func rateTable(t *testing.T, tdb *testDB) map[string]float64 {
+	t.Helper()
+	rates, err := tdb.CurrencyService.ListRates(context.Background())
+	if err != nil {
+		t.Fatalf("ListRates() error = %v", err)
+	}
+	table := make(map[string]float64)
+	for _, r := range rates {
+		table[r.Currency] = r.Rate
+	}
+	return table
+}
+
+func TestCurrencyService_ImportRates(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		reference string
+		data      string
+	}{
+		{name: "ECB XML", format: "ecb", data: ecbXML},
+		{name: "ECB CSV", format: "csv", data: "Date, USD, GBP, JPY, \n02 January 2026, 1.2500, 0.8000, 150.00, \n"},
+		{name: "long CSV with reference", format: "csv", reference: "EUR", data: "currency,rate\nUSD,1.25\nGBP,0.80\nJPY,150\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tdb := setupTestDB(t)
+			ctx := context.Background()
+			if err := tdb.CurrencyService.SetRate(ctx, "CHF", 0.90); err != nil {
+				t.Fatalf("SetRate() error = %v", err)
+			}
+
+			n, err := tdb.CurrencyService.ImportRates(ctx, strings.NewReader(tt.data), tt.format, tt.reference)
+			if err != nil {
+				t.Fatalf("ImportRates() error = %v", err)
+			}
+			if n != 3 {
+				t.Errorf("ImportRates() = %d rates, want 3", n)
+			}
+
+			// Rebased from EUR to the USD base currency
+			table := rateTable(t, tdb)
+			want := map[string]float64{"EUR": 0.80, "GBP": 0.64, "JPY": 120.00}
+			for currency, rate := range want {
+				if !almostEqual(table[currency], rate) {
+					t.Errorf("rate %s = %.4f, want %.4f", currency, table[currency], rate)
+				}
+			}
+			if _, ok := table["USD"]; ok {
+				t.Error("base currency should not be stored as a rate")
+			}
+			if _, ok := table["CHF"]; ok {
+				t.Error("rates missing from the file should be deleted")
+			}
+		})
+	}
+}
+
+func TestCurrencyService_ImportRatesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+	}{
+		{name: "unknown format", format: "xlsx", data: ecbXML},
+		{name: "base currency missing", format: "csv", data: "GBP,0.8\n"},
+		{name: "invalid rate", format: "csv", data: "USD,abc\n"},
+		{name: "NaN rate", format: "csv", data: "USD,1.1\nGBP,NaN\n"},
+		{name: "infinite rate", format: "csv", data: "USD,1.1\nGBP,+Inf\n"},
+		{name: "infinite ECB rate", format: "ecb", data: strings.Replace(ecbXML, `rate="0.8000"`, `rate="Inf"`, 1)},
+		{name: "invalid currency", format: "csv", data: "DOLLAR,1\n"},
+		{name: "empty XML", format: "ecb", data: "<Envelope></Envelope>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tdb := setupTestDB(t)
+			ctx := context.Background()
+
+			if _, err := tdb.CurrencyService.ImportRates(ctx, strings.NewReader(tt.data), tt.format, "EUR"); err == nil {
+				t.Error("ImportRates() expected error")
+			}
+		})
+	}
+}
+
+func TestCurrencyService_SetBaseCurrency(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	if err := tdb.CurrencyService.SetRate(ctx, "EUR", 0.80); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+	if err := tdb.CurrencyService.SetRate(ctx, "GBP", 0.64); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+
+	if err := tdb.CurrencyService.SetBaseCurrency(ctx, "JPY"); err == nil {
+		t.Error("SetBaseCurrency() without a rate expected error")
+	}
+
+	if err := tdb.CurrencyService.SetBaseCurrency(ctx, "eur"); err != nil {
+		t.Fatalf("SetBaseCurrency() error = %v", err)
+	}
+
+	base, err := tdb.ConfigService.GetBaseCurrency(ctx)
+	if err != nil {
+		t.Fatalf("GetBaseCurrency() error = %v", err)
+	}
+	if base != "EUR" {
+		t.Errorf("GetBaseCurrency() = %q, want EUR", base)
+	}
+
+	table := rateTable(t, tdb)
+	want := map[string]float64{"USD": 1.25, "GBP": 0.80}
+	if len(table) != len(want) {
+		t.Errorf("rates = %v, want %v", table, want)
+	}
+	for currency, rate := range want {
+		if !almostEqual(table[currency], rate) {
+			t.Errorf("rate %s = %.4f, want %.4f", currency, table[currency], rate)
+		}
+	}
+
+	if err := tdb.CurrencyService.SetRate(ctx, "EUR", 2); err == nil {
+		t.Error("SetRate() for the base currency expected error")
+	}
+	if err := tdb.CurrencyService.SetRate(ctx, "GBP", 0); err == nil {
+		t.Error("SetRate() with zero rate expected error")
+	}
+}
+
+func TestConverter_Convert(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	if err := tdb.CurrencyService.SetRate(ctx, "EUR", 0.80); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+
+	converter, err := tdb.CurrencyService.Converter(ctx)
+	if err != nil {
+		t.Fatalf("Converter() error = %v", err)
+	}
+
+	tests := []struct {
+		currency string
+		want     float64
+		wantOK   bool
+	}{
+		{"USD", 8.00, true},
+		{"eur", 10.00, true},
+		{"CHF", 8.00, false},
+	}
+	for _, tt := range tests {
+		got, ok := converter.Convert(8.00, tt.currency)
+		if !almostEqual(got, tt.want) || ok != tt.wantOK {
+			t.Errorf("Convert(8, %s) = %.2f, %v, want %.2f, %v", tt.currency, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
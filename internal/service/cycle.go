@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Named billing cycles
+const (
+	CycleWeekly     = "weekly"
+	CycleBiweekly   = "biweekly"
+	CycleMonthly    = "monthly"
+	CycleQuarterly  = "quarterly"
+	CycleSemiannual = "semiannual"
+	CycleYearly     = "yearly"
+)
+
+// BillingCycles lists the named billing cycles from shortest to longest.
+// Any other interval is written as "every N days|weeks|months|years".
+var BillingCycles = []string{CycleWeekly, CycleBiweekly, CycleMonthly, CycleQuarterly, CycleSemiannual, CycleYearly}
+
+// CycleUnit is the unit of a billing cycle interval
+type CycleUnit string
+
+const (
+	UnitDay   CycleUnit = "day"
+	UnitWeek  CycleUnit = "week"
+	UnitMonth CycleUnit = "month"
+	UnitYear  CycleUnit = "year"
+)
+
+// BillingCycle is a parsed billing cycle: one charge every Count Units
+type BillingCycle struct {
+	Count int
+	Unit  CycleUnit
+}
+
+var namedCycles = map[string]BillingCycle{
+	CycleWeekly:     {1, UnitWeek},
+	CycleBiweekly:   {2, UnitWeek},
+	CycleMonthly:    {1, UnitMonth},
+	CycleQuarterly:  {3, UnitMonth},
+	CycleSemiannual: {6, UnitMonth},
+	CycleYearly:     {1, UnitYear},
+}
+
+// ParseBillingCycle parses a named cycle or an "every N units" interval
+func ParseBillingCycle(s string) (BillingCycle, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if cycle, ok := namedCycles[s]; ok {
+		return cycle, nil
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 3 || fields[0] != "every" {
+		return BillingCycle{}, fmt.Errorf("billing cycle must be one of %s, or 'every N days|weeks|months|years'", strings.Join(BillingCycles, ", "))
+	}
+
+	count, err := strconv.Atoi(fields[1])
+	if err != nil || count < 1 {
+		return BillingCycle{}, fmt.Errorf("billing cycle interval must be a positive number: %s", fields[1])
+	}
+
+	unit := CycleUnit(strings.TrimSuffix(fields[2], "s"))
+	switch unit {
+	case UnitDay, UnitWeek, UnitMonth, UnitYear:
+	default:
+		return BillingCycle{}, fmt.Errorf("billing cycle unit must be days, weeks, months or years: %s", fields[2])
+	}
+
+	return BillingCycle{Count: count, Unit: unit}, nil
+}
+
+// NormalizeBillingCycle validates a billing cycle and returns its canonical form
+func NormalizeBillingCycle(s string) (string, error) {
+	cycle, err := ParseBillingCycle(s)
+	if err != nil {
+		return "", err
+	}
+	return cycle.String(), nil
+}
+
+// String returns the cycle's name, or "every N units" if it has none
+func (c BillingCycle) String() string {
+	for _, name := range BillingCycles {
+		if namedCycles[name] == c {
+			return name
+		}
+	}
+	if c.Unit == UnitDay && c.Count%7 == 0 {
+		return BillingCycle{Count: c.Count / 7, Unit: UnitWeek}.String()
+	}
+	if c.Unit == UnitMonth && c.Count%12 == 0 {
+		return BillingCycle{Count: c.Count / 12, Unit: UnitYear}.String()
+	}
+	if c.Count == 1 {
+		return fmt.Sprintf("every 1 %s", c.Unit)
+	}
+	return fmt.Sprintf("every %d %ss", c.Count, c.Unit)
+}
+
+// ChargesPerYear returns how many times a year the cycle charges
+func (c BillingCycle) ChargesPerYear() float64 {
+	switch c.Unit {
+	case UnitDay:
+		return 365 / float64(c.Count)
+	case UnitWeek:
+		return 52 / float64(c.Count)
+	case UnitMonth:
+		return 12 / float64(c.Count)
+	default:
+		return 1 / float64(c.Count)
+	}
+}
+
+// AddTo returns the date of the n-th charge after anchor (n may be negative).
+// Month and year based cycles keep the anchor's day, clamped to the end of
+// shorter months, so a Jan 31 anchor renews on Apr 30 and then Jul 31.
+func (c BillingCycle) AddTo(anchor time.Time, n int) time.Time {
+	switch c.Unit {
+	case UnitDay:
+		return anchor.AddDate(0, 0, n*c.Count)
+	case UnitWeek:
+		return anchor.AddDate(0, 0, n*c.Count*7)
+	case UnitMonth:
+		return addMonthsClamped(anchor, n*c.Count)
+	default:
+		return addMonthsClamped(anchor, n*c.Count*12)
+	}
+}
+
+// DatesInPeriod returns every charge date within [start, end] for a subscription
+// that renews on anchor, counting charges both before and after the anchor
+func (c BillingCycle) DatesInPeriod(anchor, start, end time.Time) []time.Time {
+	// Estimate the first charge index at or before start, then walk forward
+	var n int
+	switch c.Unit {
+	case UnitDay, UnitWeek:
+		days := c.Count
+		if c.Unit == UnitWeek {
+			days *= 7
+		}
+		n = floorDiv(int(start.Sub(anchor).Hours()/24), days) - 1
+	default:
+		months := c.Count
+		if c.Unit == UnitYear {
+			months *= 12
+		}
+		diff := (start.Year()-anchor.Year())*12 + int(start.Month()) - int(anchor.Month())
+		n = floorDiv(diff, months) - 1
+	}
+
+	var dates []time.Time
+	for date := c.AddTo(anchor, n); !date.After(end); date = c.AddTo(anchor, n) {
+		if isDateInPeriod(date, start, end) {
+			dates = append(dates, date)
+		}
+		n++
+	}
+	return dates
+}
+
+// addMonthsClamped adds months to t, clamping the day to the last day of the resulting month
+func addMonthsClamped(t time.Time, months int) time.Time {
+	total := int(t.Month()) - 1 + months
+	year := t.Year() + floorDiv(total, 12)
+	month := time.Month(total - floorDiv(total, 12)*12 + 1)
+
+	day := t.Day()
+	lastDayOfMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > lastDayOfMonth {
+		day = lastDayOfMonth
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"testing"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestNormalizeBillingCycle(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"monthly", "monthly", false},
+		{"Quarterly", "quarterly", false},
+		{"every 1 month", "monthly", false},
+		{"every 2 weeks", "biweekly", false},
+		{"every 14 days", "biweekly", false},
+		{"every 6 months", "semiannual", false},
+		{"every 12 months", "yearly", false},
+		{"every 3 years", "every 3 years", false},
+		{"every 10 day", "every 10 days", false},
+		{"every 1 day", "every 1 day", false},
+		{"every 0 months", "", true},
+		{"every two weeks", "", true},
+		{"every 3 fortnights", "", true},
+		{"daily", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := service.NormalizeBillingCycle(tt.input)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NormalizeBillingCycle(%q) expected error, got %q", tt.input, result)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NormalizeBillingCycle(%q) unexpected error: %v", tt.input, err)
+			}
+			if result != tt.expected {
+				t.Errorf("NormalizeBillingCycle(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBillingCycle_ChargesPerYear(t *testing.T) {
+	tests := []struct {
+		cycle    string
+		expected float64
+	}{
+		{"weekly", 52},
+		{"biweekly", 26},
+		{"monthly", 12},
+		{"quarterly", 4},
+		{"semiannual", 2},
+		{"yearly", 1},
+		{"every 2 years", 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cycle, func(t *testing.T) {
+			cycle, err := service.ParseBillingCycle(tt.cycle)
+			if err != nil {
+				t.Fatalf("ParseBillingCycle() error = %v", err)
+			}
+			if !almostEqual(cycle.ChargesPerYear(), tt.expected) {
+				t.Errorf("ChargesPerYear() = %.2f, want %.2f", cycle.ChargesPerYear(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestBillingCycle_DatesInPeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		cycle    string
+		anchor   string
+		start    string
+		end      string
+		expected []string
+	}{
+		{
+			name:     "weekly charges several times",
+			cycle:    "weekly",
+			anchor:   "2026-01-05",
+			start:    "2026-01-01",
+			end:      "2026-01-31",
+			expected: []string{"2026-01-05", "2026-01-12", "2026-01-19", "2026-01-26"},
+		},
+		{
+			name:     "weekly counts charges before the anchor",
+			cycle:    "weekly",
+			anchor:   "2026-03-02",
+			start:    "2026-01-01",
+			end:      "2026-01-14",
+			expected: []string{"2026-01-05", "2026-01-12"},
+		},
+		{
+			name:     "quarterly charge in period",
+			cycle:    "quarterly",
+			anchor:   "2026-01-31",
+			start:    "2026-04-01",
+			end:      "2026-04-30",
+			expected: []string{"2026-04-30"},
+		},
+		{
+			name:     "quarterly no charge in period",
+			cycle:    "quarterly",
+			anchor:   "2026-01-15",
+			start:    "2026-02-01",
+			end:      "2026-02-28",
+			expected: nil,
+		},
+		{
+			name:     "every 2 years from an earlier anchor",
+			cycle:    "every 2 years",
+			anchor:   "2024-06-10",
+			start:    "2028-06-01",
+			end:      "2028-06-30",
+			expected: []string{"2028-06-10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle, err := service.ParseBillingCycle(tt.cycle)
+			if err != nil {
+				t.Fatalf("ParseBillingCycle() error = %v", err)
+			}
+
+			dates := cycle.DatesInPeriod(parseDate(tt.anchor), parseDate(tt.start), parseDate(tt.end))
+			if len(dates) != len(tt.expected) {
+				t.Fatalf("DatesInPeriod() returned %d dates, want %d: %v", len(dates), len(tt.expected), dates)
+			}
+			for i, date := range dates {
+				if date.Format("2006-01-02") != tt.expected[i] {
+					t.Errorf("date[%d] = %s, want %s", i, date.Format("2006-01-02"), tt.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// Event types emitted by SubscriptionService
+const (
+	EventSubscriptionCreated = "subscription.created"
+	EventSubscriptionUpdated = "subscription.updated"
+	EventSubscriptionDeleted = "subscription.deleted"
+	EventRenewalAdvanced     = "renewal.advanced" // A renewal date passed and was moved to the next one
+	EventRenewalUpcoming     = "renewal.upcoming" // The next renewal, on every renewal date check
+)
+
+// EventTypes lists the event types
+var EventTypes = []string{
+	EventSubscriptionCreated, EventSubscriptionUpdated, EventSubscriptionDeleted,
+	EventRenewalAdvanced, EventRenewalUpcoming,
+}
+
+// Event is a change to a subscription or a renewal coming up
+type Event struct {
+	Type         string
+	Time         time.Time
+	Subscription db.Subscription // As it is after the change, or was before deletion
+	PreviousDate time.Time       // renewal.advanced: the renewal date that passed
+	RenewalDate  time.Time       // renewal.advanced and renewal.upcoming: the next renewal
+	DaysLeft     int             // renewal.upcoming: days from the check to RenewalDate
+}
+
+// EventHandler is told about the events of a SubscriptionService. Handlers
+// run synchronously after the change has been saved and can't undo it.
+type EventHandler interface {
+	HandleEvent(ctx context.Context, event Event)
+}
+
+// AddEventHandler has h told about every event from now on
+func (s *SubscriptionService) AddEventHandler(h EventHandler) {
+	s.handlers = append(s.handlers, h)
+}
+
+// emit tells the handlers about event
+func (s *SubscriptionService) emit(ctx context.Context, event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, h := range s.handlers {
+		h.HandleEvent(ctx, event)
+	}
+}
+
+// updated emits subscription.updated for sub, unless err is set, and
+// passes both through
+func (s *SubscriptionService) updated(ctx context.Context, sub db.Subscription, err error) (db.Subscription, error) {
+	if err != nil {
+		return sub, err
+	}
+	s.emit(ctx, Event{Type: EventSubscriptionUpdated, Subscription: sub})
+	return sub, nil
+}
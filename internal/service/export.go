@@ -6,18 +6,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"strings"
 
 	"subscription-tracker/internal/db"
 )
 
 // ExportService handles export functionality
 type ExportService struct {
-	queries *db.Queries
+	queries  *db.Queries
+	spending *SpendingService
 }
 
 // NewExportService creates a new export service
-func NewExportService(queries *db.Queries) *ExportService {
-	return &ExportService{queries: queries}
+func NewExportService(queries *db.Queries, spending *SpendingService) *ExportService {
+	return &ExportService{queries: queries, spending: spending}
 }
 
 // ExportFormat represents the export format
@@ -26,21 +29,37 @@ type ExportFormat string
 const (
 	FormatCSV  ExportFormat = "csv"
 	FormatJSON ExportFormat = "json"
+	FormatICS  ExportFormat = "ics"  // Upcoming renewals, see ExportICS
+	FormatXLSX ExportFormat = "xlsx" // Workbook with spending summaries, see ExportXLSX
+
+	// Plain-text accounting journals, see ExportLedger
+	FormatLedger    ExportFormat = "ledger"
+	FormatHledger   ExportFormat = "hledger"
+	FormatBeancount ExportFormat = "beancount"
 )
 
 // ExportSubscription represents a subscription for export
 type ExportSubscription struct {
-	ID              int64   `json:"id"`
-	Name            string  `json:"name"`
-	Amount          float64 `json:"amount"`
-	Currency        string  `json:"currency"`
-	BillingCycle    string  `json:"billing_cycle"`
-	NextRenewalDate string  `json:"next_renewal_date,omitempty"`
-	CreatedAt       string  `json:"created_at"`
-	UpdatedAt       string  `json:"updated_at"`
+	ID              int64    `json:"id"`
+	Name            string   `json:"name"`
+	Amount          float64  `json:"amount"`
+	Currency        string   `json:"currency"`
+	BillingCycle    string   `json:"billing_cycle"`
+	NextRenewalDate string   `json:"next_renewal_date,omitempty"`
+	CreatedAt       string   `json:"created_at"`
+	UpdatedAt       string   `json:"updated_at"`
+	Category        string   `json:"category"`
+	Tags            []string `json:"tags"`
+	Status          string   `json:"status"`
+	TrialEndDate    string   `json:"trial_end_date,omitempty"`
+	TrialPrice      float64  `json:"trial_price,omitempty"`
+	PauseDate       string   `json:"pause_date,omitempty"`
+	CancelDate      string   `json:"cancel_date,omitempty"`
 }
 
-// Export exports subscriptions to the given writer in the specified format
+// Export exports subscriptions to the given writer in the specified format and
+// returns how many it wrote; FormatICS writes and counts their upcoming renewals,
+// and the journal formats this month's charges
 func (s *ExportService) Export(ctx context.Context, w io.Writer, format ExportFormat) (int, error) {
 	subs, err := s.queries.GetAllSubscriptionsForExport(ctx)
 	if err != nil {
@@ -51,44 +70,68 @@ func (s *ExportService) Export(ctx context.Context, w io.Writer, format ExportFo
 		return 0, nil
 	}
 
+	names, err := categoryNames(ctx, s.queries)
+	if err != nil {
+		return 0, err
+	}
+	exported := ConvertToExportFormat(subs, names)
+
 	switch format {
 	case FormatCSV:
-		return len(subs), s.exportCSV(w, subs)
+		return len(subs), s.exportCSV(w, exported)
 	case FormatJSON:
-		return len(subs), s.exportJSON(w, subs)
+		return len(subs), s.exportJSON(w, exported)
+	case FormatICS:
+		return s.ExportICS(ctx, w, ICSOptions{})
+	case FormatXLSX:
+		return s.ExportXLSX(ctx, w, XLSXOptions{})
+	case FormatLedger, FormatHledger, FormatBeancount:
+		return s.ExportLedger(ctx, w, format, LedgerOptions{})
 	default:
 		return 0, fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
-func (s *ExportService) exportCSV(w io.Writer, subs []db.Subscription) error {
+// ExportCSVHeader is the header row of the CSV export format
+var ExportCSVHeader = []string{"ID", "Name", "Amount", "Currency", "Billing Cycle", "Next Renewal Date", "Created At", "Updated At", "Category", "Tags", "Status", "Trial End Date", "Pause Date", "Cancel Date", "Trial Price"}
+
+// CSVRecord returns the subscription as a row matching ExportCSVHeader
+func (e ExportSubscription) CSVRecord() []string {
+	trialPrice := ""
+	if e.TrialPrice > 0 {
+		trialPrice = fmt.Sprintf("%.2f", e.TrialPrice)
+	}
+	return []string{
+		fmt.Sprintf("%d", e.ID),
+		e.Name,
+		fmt.Sprintf("%.2f", e.Amount),
+		e.Currency,
+		e.BillingCycle,
+		e.NextRenewalDate,
+		e.CreatedAt,
+		e.UpdatedAt,
+		e.Category,
+		strings.Join(e.Tags, ","),
+		e.Status,
+		e.TrialEndDate,
+		e.PauseDate,
+		e.CancelDate,
+		trialPrice,
+	}
+}
+
+func (s *ExportService) exportCSV(w io.Writer, subs []ExportSubscription) error {
 	writer := csv.NewWriter(w)
 	defer writer.Flush()
 
 	// Header
-	header := []string{"ID", "Name", "Amount", "Currency", "Billing Cycle", "Next Renewal Date", "Created At", "Updated At"}
-	if err := writer.Write(header); err != nil {
+	if err := writer.Write(ExportCSVHeader); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
 	// Rows
 	for _, sub := range subs {
-		renewalDate := ""
-		if sub.NextRenewalDate.Valid {
-			renewalDate = sub.NextRenewalDate.String
-		}
-
-		row := []string{
-			fmt.Sprintf("%d", sub.ID),
-			sub.Name,
-			fmt.Sprintf("%.2f", sub.Amount),
-			sub.Currency,
-			sub.BillingCycle,
-			renewalDate,
-			sub.CreatedAt,
-			sub.UpdatedAt,
-		}
-		if err := writer.Write(row); err != nil {
+		if err := writer.Write(sub.CSVRecord()); err != nil {
 			return fmt.Errorf("failed to write row: %w", err)
 		}
 	}
@@ -96,34 +139,62 @@ func (s *ExportService) exportCSV(w io.Writer, subs []db.Subscription) error {
 	return nil
 }
 
-func (s *ExportService) exportJSON(w io.Writer, subs []db.Subscription) error {
-	var exportData []ExportSubscription
+func (s *ExportService) exportJSON(w io.Writer, subs []ExportSubscription) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(subs)
+}
 
-	for _, sub := range subs {
-		renewalDate := ""
-		if sub.NextRenewalDate.Valid {
-			renewalDate = sub.NextRenewalDate.String
-		}
+// CategoryTotalsCSVHeader is the header row of the category totals export
+var CategoryTotalsCSVHeader = []string{"Category", "Subscriptions", "Annual Total", "Currency"}
 
-		exportData = append(exportData, ExportSubscription{
-			ID:              sub.ID,
-			Name:            sub.Name,
-			Amount:          sub.Amount,
-			Currency:        sub.Currency,
-			BillingCycle:    sub.BillingCycle,
-			NextRenewalDate: renewalDate,
-			CreatedAt:       sub.CreatedAt,
-			UpdatedAt:       sub.UpdatedAt,
-		})
+// ExportCategoryTotal is a category's annual spending for export
+type ExportCategoryTotal struct {
+	Category      string  `json:"category"`
+	Subscriptions int     `json:"subscriptions"`
+	AnnualTotal   float64 `json:"annual_total"`
+	Currency      string  `json:"currency"`
+}
+
+// ExportCategoryTotals writes per-category annual totals, as calculated by
+// SpendingService.CalculateAnnualByCategory, in the specified format
+func (s *ExportService) ExportCategoryTotals(w io.Writer, totals []CategoryTotal, currency string, format ExportFormat) error {
+	exported := make([]ExportCategoryTotal, len(totals))
+	for i, t := range totals {
+		exported[i] = ExportCategoryTotal{
+			Category:      t.Category,
+			Subscriptions: t.Count,
+			AnnualTotal:   math.Round(t.Total*100) / 100,
+			Currency:      currency,
+		}
 	}
 
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(exportData)
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		defer writer.Flush()
+		if err := writer.Write(CategoryTotalsCSVHeader); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+		for _, t := range exported {
+			row := []string{t.Category, fmt.Sprintf("%d", t.Subscriptions), fmt.Sprintf("%.2f", t.AnnualTotal), t.Currency}
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("failed to write row: %w", err)
+			}
+		}
+		return nil
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exported)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
 }
 
-// ConvertToExportFormat converts db subscriptions to export format
-func ConvertToExportFormat(subs []db.Subscription) []ExportSubscription {
+// ConvertToExportFormat converts db subscriptions to export format, naming
+// their categories from categories (see CategoryService.Names)
+func ConvertToExportFormat(subs []db.Subscription, categories map[int64]string) []ExportSubscription {
 	result := make([]ExportSubscription, len(subs))
 	for i, sub := range subs {
 		renewalDate := ""
@@ -140,6 +211,16 @@ func ConvertToExportFormat(subs []db.Subscription) []ExportSubscription {
 			NextRenewalDate: renewalDate,
 			CreatedAt:       sub.CreatedAt,
 			UpdatedAt:       sub.UpdatedAt,
+			Category:        CategoryName(sub, categories),
+			Tags:            ParseTags(sub.Tags),
+			Status:          sub.Status,
+			TrialEndDate:    sub.TrialEndDate.String,
+			TrialPrice:      sub.TrialPrice.Float64,
+			PauseDate:       sub.PauseDate.String,
+			CancelDate:      sub.CancelDate.String,
+		}
+		if result[i].Tags == nil {
+			result[i].Tags = []string{}
 		}
 	}
 	return result
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxForecastMonths is how many billing periods a forecast can cover at most
+const MaxForecastMonths = 120
+
+// Forecast is the projected spending of consecutive billing periods.
+// All totals are in BaseCurrency.
+type Forecast struct {
+	BaseCurrency string
+	CutoffDay    int
+	Periods      []ForecastPeriod // In date order
+	Total        float64
+	Average      float64  // Total per period
+	MissingRates []string // Currencies without an exchange rate; their amounts are counted unconverted
+}
+
+// ForecastPeriod is the projected spending of one billing period
+type ForecastPeriod struct {
+	Year        int
+	Month       int
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Charges     []Charge // Every charge in the period by date
+	Total       float64
+	Cumulative  float64 // Total of this and every earlier period of the forecast
+}
+
+// MostExpensive returns up to n periods with the highest totals, highest first;
+// none if n isn't positive. Periods with the same total keep their date order.
+func (f *Forecast) MostExpensive(n int) []ForecastPeriod {
+	periods := append([]ForecastPeriod{}, f.Periods...)
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].Total > periods[j].Total
+	})
+	return periods[:min(max(n, 0), len(periods))]
+}
+
+// Forecast projects spending over the current billing period and the months-1 after it
+func (s *SpendingService) Forecast(ctx context.Context, months int) (*Forecast, error) {
+	cutoffDay, err := s.configService.GetMonthCutoffDay(ctx)
+	if err != nil {
+		cutoffDay = 1
+	}
+	year, month := billingMonth(time.Now(), cutoffDay)
+	return s.ForecastFrom(ctx, year, month, months)
+}
+
+// ForecastRange projects spending over every billing period from the one that
+// from falls in through the one that to falls in
+func (s *SpendingService) ForecastRange(ctx context.Context, from, to time.Time) (*Forecast, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("forecast must end after it starts")
+	}
+	cutoffDay, err := s.configService.GetMonthCutoffDay(ctx)
+	if err != nil {
+		cutoffDay = 1
+	}
+	startYear, startMonth := billingMonth(from, cutoffDay)
+	endYear, endMonth := billingMonth(to, cutoffDay)
+	months := (endYear-startYear)*12 + endMonth - startMonth + 1
+	return s.ForecastFrom(ctx, startYear, startMonth, months)
+}
+
+// ForecastFrom projects spending over months billing periods, starting with the
+// period of the given month (see CalculateForMonth)
+func (s *SpendingService) ForecastFrom(ctx context.Context, year, month, months int) (*Forecast, error) {
+	if months < 1 || months > MaxForecastMonths {
+		return nil, fmt.Errorf("forecast must cover 1 to %d months", MaxForecastMonths)
+	}
+
+	forecast := &Forecast{Periods: make([]ForecastPeriod, 0, months)}
+	missing := make(map[string]bool)
+	for i := 0; i < months; i++ {
+		summary, err := s.CalculateForMonth(ctx, year, month)
+		if err != nil {
+			return nil, err
+		}
+
+		forecast.BaseCurrency = summary.BaseCurrency
+		forecast.CutoffDay = summary.CutoffDay
+		forecast.Total += summary.GrandTotal
+		forecast.Periods = append(forecast.Periods, ForecastPeriod{
+			Year:        summary.Year,
+			Month:       summary.Month,
+			PeriodStart: summary.PeriodStart,
+			PeriodEnd:   summary.PeriodEnd,
+			Charges:     summary.Charges,
+			Total:       summary.GrandTotal,
+			Cumulative:  forecast.Total,
+		})
+		for _, currency := range summary.MissingRates {
+			if !missing[currency] {
+				missing[currency] = true
+				forecast.MissingRates = append(forecast.MissingRates, currency)
+			}
+		}
+
+		month++
+		if month > 12 {
+			month = 1
+			year++
+		}
+	}
+	sort.Strings(forecast.MissingRates)
+	forecast.Average = forecast.Total / float64(months)
+
+	return forecast, nil
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestSpendingService_ForecastFrom(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15"},
+		{Name: "Domain", Amount: 120.00, Currency: "USD", BillingCycle: "yearly", NextRenewalDate: "2026-03-10"},
+		{Name: "Backup", Amount: 30.00, Currency: "USD", BillingCycle: "quarterly", NextRenewalDate: "2026-02-01"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+
+	if _, err := tdb.SpendingService.ForecastFrom(ctx, 2026, 2, 0); err == nil {
+		t.Error("ForecastFrom() should reject an empty forecast")
+	}
+
+	// Month 2 is the period of January 2026; 15 periods run through March 2027
+	forecast, err := tdb.SpendingService.ForecastFrom(ctx, 2026, 2, 15)
+	if err != nil {
+		t.Fatalf("ForecastFrom() error = %v", err)
+	}
+	if len(forecast.Periods) != 15 {
+		t.Fatalf("ForecastFrom() = %d periods, want 15", len(forecast.Periods))
+	}
+
+	// Netflix every month, Backup every third month from February, and Domain every March
+	want := []float64{10, 40, 130, 10, 40, 10, 10, 40, 10, 10, 40, 10, 10, 40, 130}
+	var cumulative float64
+	for i, period := range forecast.Periods {
+		cumulative += want[i]
+		if !almostEqual(period.Total, want[i]) || !almostEqual(period.Cumulative, cumulative) {
+			t.Errorf("period %d-%02d = %.2f (cumulative %.2f), want %.2f (cumulative %.2f)",
+				period.Year, period.Month, period.Total, period.Cumulative, want[i], cumulative)
+		}
+	}
+	if !almostEqual(forecast.Total, cumulative) || !almostEqual(forecast.Average, cumulative/15) {
+		t.Errorf("Total = %.2f, Average = %.2f, want %.2f and %.2f", forecast.Total, forecast.Average, cumulative, cumulative/15)
+	}
+
+	top := forecast.MostExpensive(3)
+	if len(top) != 3 {
+		t.Fatalf("MostExpensive() = %+v, want 3 periods", top)
+	}
+	if top[0].Year != 2026 || top[0].Month != 4 || top[1].Year != 2027 || top[1].Month != 4 || top[2].Total != 40 {
+		t.Errorf("MostExpensive() = %d-%02d, %d-%02d, %.2f, want both Marches and then a Backup month",
+			top[0].Year, top[0].Month, top[1].Year, top[1].Month, top[2].Total)
+	}
+	if n := len(forecast.MostExpensive(100)); n != 15 {
+		t.Errorf("MostExpensive(100) = %d periods, want all 15", n)
+	}
+	for _, n := range []int{0, -1} {
+		if top := forecast.MostExpensive(n); len(top) != 0 {
+			t.Errorf("MostExpensive(%d) = %+v, want none", n, top)
+		}
+	}
+}
+
+func TestSpendingService_ForecastRange(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	if err := tdb.ConfigService.SetMonthCutoffDay(ctx, 15); err != nil {
+		t.Fatalf("SetMonthCutoffDay() error = %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-20",
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	from := time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)
+	if _, err := tdb.SpendingService.ForecastRange(ctx, from, from.AddDate(0, 0, -1)); err == nil {
+		t.Error("ForecastRange() should reject a range that ends before it starts")
+	}
+
+	// With cutoff 15, Jan 20 is in the period Jan 15 - Feb 14 and Mar 5 in Feb 15 - Mar 14
+	forecast, err := tdb.SpendingService.ForecastRange(ctx, from, time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ForecastRange() error = %v", err)
+	}
+	if len(forecast.Periods) != 2 {
+		t.Fatalf("ForecastRange() = %d periods, want 2", len(forecast.Periods))
+	}
+	first, last := forecast.Periods[0], forecast.Periods[1]
+	if first.PeriodStart.Format("2006-01-02") != "2026-01-15" || last.PeriodEnd.Format("2006-01-02") != "2026-03-14" {
+		t.Errorf("ForecastRange() covers %s - %s, want 2026-01-15 - 2026-03-14",
+			first.PeriodStart.Format("2006-01-02"), last.PeriodEnd.Format("2006-01-02"))
+	}
+	if !almostEqual(forecast.Total, 20) {
+		t.Errorf("Total = %.2f, want 20.00", forecast.Total)
+	}
+}
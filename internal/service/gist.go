@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const gistAPIURL = "https://api.github.com/gists"
+
+// GistBackend keeps backups as the files of a private GitHub Gist
+type GistBackend struct {
+	Token  string // GitHub personal access token
+	GistID string // Gist ID; empty until Put creates the gist
+	APIURL string // Defaults to the GitHub API
+}
+
+// gistFile is a file of a gist in the API
+type gistFile struct {
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+	RawURL    string `json:"raw_url"`
+}
+
+// Location names the gist
+func (b *GistBackend) Location() string {
+	if b.GistID == "" {
+		return "GitHub Gist"
+	}
+	return "gist " + b.GistID
+}
+
+// Put uploads a backup, creating the gist if there is none yet
+func (b *GistBackend) Put(ctx context.Context, name string, data []byte) error {
+	payload := map[string]interface{}{
+		"files": map[string]interface{}{
+			name: map[string]string{"content": string(data)},
+		},
+	}
+	if b.GistID == "" {
+		payload["description"] = "Subscription Tracker Backup (encrypted)"
+		payload["public"] = false
+		var created struct {
+			ID string `json:"id"`
+		}
+		if err := b.do(ctx, http.MethodPost, b.url(), payload, &created); err != nil {
+			return err
+		}
+		b.GistID = created.ID
+		return nil
+	}
+	return b.do(ctx, http.MethodPatch, b.gistURL(), payload, nil)
+}
+
+// Get downloads a backup
+func (b *GistBackend) Get(ctx context.Context, name string) ([]byte, error) {
+	files, err := b.files(ctx)
+	if err != nil {
+		return nil, err
+	}
+	file, ok := files[name]
+	if !ok {
+		return nil, ErrBackupNotFound
+	}
+	if !file.Truncated {
+		return []byte(file.Content), nil
+	}
+
+	// The API only includes the first megabyte of a file
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file.RawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gist download error (status %d)", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// List returns the names of the files in the gist
+func (b *GistBackend) List(ctx context.Context) ([]string, error) {
+	files, err := b.files(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete removes a file from the gist
+func (b *GistBackend) Delete(ctx context.Context, name string) error {
+	files, err := b.files(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := files[name]; !ok {
+		return ErrBackupNotFound
+	}
+	payload := map[string]interface{}{
+		"files": map[string]interface{}{name: nil},
+	}
+	return b.do(ctx, http.MethodPatch, b.gistURL(), payload, nil)
+}
+
+// files fetches the files of the gist
+func (b *GistBackend) files(ctx context.Context) (map[string]gistFile, error) {
+	if b.GistID == "" {
+		return nil, nil // No gist, no files
+	}
+	var gist struct {
+		Files map[string]gistFile `json:"files"`
+	}
+	if err := b.do(ctx, http.MethodGet, b.gistURL(), nil, &gist); err != nil {
+		return nil, err
+	}
+	return gist.Files, nil
+}
+
+// do makes a request to the gist API, decoding the response into out
+func (b *GistBackend) do(ctx context.Context, method, url string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		jsonPayload, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal gist payload: %w", err)
+		}
+		body = bytes.NewReader(jsonPayload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+b.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("gist API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse gist response: %w", err)
+	}
+	return nil
+}
+
+func (b *GistBackend) url() string {
+	if b.APIURL != "" {
+		return b.APIURL
+	}
+	return gistAPIURL
+}
+
+func (b *GistBackend) gistURL() string {
+	return b.url() + "/" + b.GistID
+}
+
+func (b *GistBackend) client() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
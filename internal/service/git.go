@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultGitBranch is the branch backups are committed to when none is set
+const DefaultGitBranch = "main"
+
+// SyncPlaintextName is the name push and pull use for the backup of a
+// plaintext git backend
+const SyncPlaintextName = "subscription-tracker.json"
+
+// GitBackend keeps backups in a git repository and commits every push, so
+// that the repository holds the history of the backups. Repo is either a
+// local work tree, created if missing, or a remote: a URL, an scp-style
+// user@host:path or a bare repository on disk. Remotes are cloned to
+// WorkDir, and every push is pushed to them.
+type GitBackend struct {
+	Repo      string
+	Branch    string // Defaults to DefaultGitBranch
+	WorkDir   string // The work tree; a clone in the user cache directory for remotes
+	Remote    bool   // Whether Repo is a remote that WorkDir is a clone of
+	Plaintext bool   // Commit the data as canonical JSON instead of encrypted
+}
+
+// NewGitBackend works out whether repo is a remote and where its work tree is
+func NewGitBackend(repo, branch string, plaintext bool) (*GitBackend, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("the git backend needs git installed: %w", err)
+	}
+	// Either would be taken for an option of git
+	if strings.HasPrefix(repo, "-") {
+		return nil, fmt.Errorf("invalid git repository %q: must not start with -", repo)
+	}
+	if strings.HasPrefix(branch, "-") {
+		return nil, fmt.Errorf("invalid git branch %q: must not start with -", branch)
+	}
+	if branch == "" {
+		branch = DefaultGitBranch
+	}
+	b := &GitBackend{Repo: repo, Branch: branch, Plaintext: plaintext}
+	if !isGitURL(repo) {
+		path, err := filepath.Abs(repo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid git repository: %w", err)
+		}
+		b.Repo = path
+		if !isBareRepo(path) {
+			b.WorkDir = path
+			return b, nil
+		}
+	}
+
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find a folder to clone %s to: %w", repo, err)
+	}
+	sum := sha256.Sum256([]byte(b.Repo))
+	b.WorkDir = filepath.Join(cache, appName, "git", hex.EncodeToString(sum[:8]))
+	b.Remote = true
+	return b, nil
+}
+
+// isGitURL reports whether repo is a URL or scp-style user@host:path
+func isGitURL(repo string) bool {
+	if strings.Contains(repo, "://") {
+		return true
+	}
+	// A colon before the first slash, but not a Windows drive letter
+	host, _, found := strings.Cut(repo, ":")
+	return found && len(host) > 1 && !strings.Contains(host, "/")
+}
+
+// isBareRepo reports whether path is a bare repository
+func isBareRepo(path string) bool {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return false
+	}
+	_, head := os.Stat(filepath.Join(path, "HEAD"))
+	_, objects := os.Stat(filepath.Join(path, "objects"))
+	return head == nil && objects == nil
+}
+
+// Location names the repository and, for remotes, the branch
+func (b *GitBackend) Location() string {
+	if b.Remote {
+		return b.Repo + " (" + b.Branch + ")"
+	}
+	return b.Repo
+}
+
+// Put writes the backup and commits it, see PutWithMessage
+func (b *GitBackend) Put(ctx context.Context, name string, data []byte) error {
+	return b.PutWithMessage(ctx, name, "Update "+name, data)
+}
+
+// PutWithMessage writes the backup and commits it with message, then pushes
+// remotes. Nothing is committed if the backup didn't change.
+func (b *GitBackend) PutWithMessage(ctx context.Context, name, message string, data []byte) error {
+	if err := checkBackupName(name); err != nil {
+		return err
+	}
+	if err := b.prepare(ctx); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(b.WorkDir, name), data, 0o600); err != nil {
+		return err
+	}
+	if _, err := b.git(ctx, "add", "--", name); err != nil {
+		return err
+	}
+	return b.commit(ctx, name, message)
+}
+
+// BackupName is SyncPlaintextName when committing plaintext
+func (b *GitBackend) BackupName() string {
+	if b.Plaintext {
+		return SyncPlaintextName
+	}
+	return SyncBackupName
+}
+
+// Get reads a backup from the work tree, after fetching remotes
+func (b *GitBackend) Get(ctx context.Context, name string) ([]byte, error) {
+	if err := checkBackupName(name); err != nil {
+		return nil, err
+	}
+	if err := b.prepare(ctx); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(b.WorkDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBackupNotFound
+	}
+	return data, err
+}
+
+// List returns the names of the files committed at the top of the repository
+func (b *GitBackend) List(ctx context.Context) ([]string, error) {
+	if err := b.prepare(ctx); err != nil {
+		return nil, err
+	}
+	return b.files(ctx)
+}
+
+// Delete removes a backup in a commit of its own
+func (b *GitBackend) Delete(ctx context.Context, name string) error {
+	if err := checkBackupName(name); err != nil {
+		return err
+	}
+	if err := b.prepare(ctx); err != nil {
+		return err
+	}
+	files, err := b.files(ctx)
+	if err != nil {
+		return err
+	}
+	if i := sort.SearchStrings(files, name); i == len(files) || files[i] != name {
+		return ErrBackupNotFound
+	}
+	if _, err := b.git(ctx, "rm", "--quiet", "--", name); err != nil {
+		return err
+	}
+	return b.commit(ctx, name, "Delete "+name)
+}
+
+// files lists the files committed at the top of the repository
+func (b *GitBackend) files(ctx context.Context) ([]string, error) {
+	out, err := b.git(ctx, "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" && !strings.Contains(name, "/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// prepare creates a missing local repository, or clones a remote and brings
+// the clone up to date with the branch on the remote
+func (b *GitBackend) prepare(ctx context.Context) error {
+	if _, err := os.Stat(filepath.Join(b.WorkDir, ".git")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(b.WorkDir), 0o700); err != nil {
+			return err
+		}
+		if b.Remote {
+			if _, err := b.run(ctx, "", "clone", "--quiet", "--no-checkout", "--", b.Repo, b.WorkDir); err != nil {
+				return err
+			}
+		} else {
+			if _, err := b.run(ctx, "", "init", "--quiet", "--", b.WorkDir); err != nil {
+				return err
+			}
+		}
+		if _, err := b.git(ctx, "symbolic-ref", "HEAD", "refs/heads/"+b.Branch); err != nil {
+			return err
+		}
+	}
+	if !b.Remote {
+		return nil
+	}
+
+	if _, err := b.git(ctx, "fetch", "--quiet", "origin"); err != nil {
+		return err
+	}
+	if _, err := b.git(ctx, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+b.Branch); err != nil {
+		return nil // Nothing pushed to the branch yet
+	}
+	// The clone is only a cache of the remote, so anything else in it goes
+	_, err := b.git(ctx, "checkout", "--quiet", "--force", "-B", b.Branch, "refs/remotes/origin/"+b.Branch)
+	return err
+}
+
+// commit commits the staged change of name, if there is one, and pushes
+// remotes
+func (b *GitBackend) commit(ctx context.Context, name, message string) error {
+	if _, err := b.git(ctx, "diff", "--cached", "--quiet", "--", name); err != nil {
+		args := []string{"commit", "--quiet", "-m", message}
+		if !b.hasIdentity(ctx) {
+			// Commit on a machine without git set up, such as a server
+			host, _ := os.Hostname()
+			args = append([]string{"-c", "user.name=" + appName, "-c", "user.email=" + appName + "@" + host}, args...)
+		}
+		if _, err := b.git(ctx, args...); err != nil {
+			return err
+		}
+	}
+	if !b.Remote {
+		return nil
+	}
+	_, err := b.git(ctx, "push", "--quiet", "origin", "HEAD:refs/heads/"+b.Branch)
+	return err
+}
+
+// hasIdentity reports whether git knows who to commit as
+func (b *GitBackend) hasIdentity(ctx context.Context) bool {
+	for _, key := range []string{"user.name", "user.email"} {
+		if out, err := b.git(ctx, "config", key); err != nil || strings.TrimSpace(string(out)) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// git runs a git command in the work tree
+func (b *GitBackend) git(ctx context.Context, args ...string) ([]byte, error) {
+	return b.run(ctx, b.WorkDir, args...)
+}
+
+// run runs a git command in dir, or the current directory if empty
+func (b *GitBackend) run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never wait for a password prompt in the terminal the TUI runs in
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		command := args[0]
+		for i := 0; i+1 < len(args) && args[i] == "-c"; i += 2 {
+			command = args[i+2]
+		}
+		return out, fmt.Errorf("git %s failed: %w", command, commandError(err, out))
+	}
+	return out, nil
+}
+
+// canonicalJSON encodes data the same way every time it is the same, so
+// that commits of plaintext backups have meaningful diffs
+func canonicalJSON(data *SyncData) ([]byte, error) {
+	canonical := *data
+	canonical.ExportedAt = time.Time{} // Left out
+	out, err := json.MarshalIndent(canonical, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// describeSync writes a commit message for a push of data, listing what
+// changed since the previous backup, which is nil for the first push
+func describeSync(previous, data *SyncData) string {
+	host, _ := os.Hostname()
+	footer := "\n\nPushed from " + host
+	if host == "" {
+		footer = ""
+	}
+	if previous == nil {
+		return countSubscriptions("Back up", len(data.Subscriptions)) + footer
+	}
+
+	// Subscriptions are the same by ID, or by name for backups without IDs
+	before := make(map[string]SyncSubscription, len(previous.Subscriptions))
+	for _, sub := range previous.Subscriptions {
+		before[syncKey(sub)] = sub
+	}
+	var changes, details []string
+	for _, sub := range data.Subscriptions {
+		key := syncKey(sub)
+		old, ok := before[key]
+		if !ok {
+			for k, prev := range before {
+				if prev.Name == sub.Name {
+					key, old, ok = k, prev, true
+					break
+				}
+			}
+		}
+		delete(before, key)
+		if !ok {
+			changes = append(changes, "add "+sub.Name)
+			details = append(details, fmt.Sprintf("- Add %s: %.2f %s %s", sub.Name, sub.Amount, sub.Currency, sub.BillingCycle))
+		} else if fields := changedFields(old, sub); len(fields) > 0 {
+			changes = append(changes, "update "+sub.Name)
+			details = append(details, fmt.Sprintf("- Update %s: %s", sub.Name, strings.Join(fields, ", ")))
+		}
+	}
+	var removed []string
+	for _, sub := range before {
+		removed = append(removed, sub.Name)
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		changes = append(changes, "remove "+name)
+		details = append(details, "- Remove "+name)
+	}
+
+	var other []string
+	if !sameList(previous.Categories, data.Categories) {
+		other = append(other, "categories")
+	}
+	if !sameList(previous.Budgets, data.Budgets) {
+		other = append(other, "budgets")
+	}
+	if !sameList(previous.Payments, data.Payments) {
+		other = append(other, "payments")
+	}
+	if !maps.Equal(previous.Config, data.Config) {
+		other = append(other, "settings")
+	}
+	if !maps.Equal(previous.ExchangeRates, data.ExchangeRates) {
+		other = append(other, "exchange rates")
+	}
+	if len(other) > 0 {
+		details = append(details, "- Update "+strings.Join(other, ", "))
+	}
+
+	var subject string
+	switch {
+	case len(changes) == 0 && len(other) == 0:
+		return "No changes" + footer
+	case len(changes) == 0:
+		subject = "Update " + strings.Join(other, ", ")
+	case len(changes) <= 3:
+		subject = strings.Join(changes, ", ")
+		subject = strings.ToUpper(subject[:1]) + subject[1:]
+	default:
+		subject = countSubscriptions("Change", len(changes))
+	}
+	return subject + "\n\n" + strings.Join(details, "\n") + footer
+}
+
+// syncKey identifies a subscription of a backup by its ID, or by its name
+// if it has none
+func syncKey(sub SyncSubscription) string {
+	if sub.ID != "" {
+		return sub.ID
+	}
+	return "name:" + sub.Name
+}
+
+// changedFields names what differs between two versions of a subscription
+func changedFields(old, sub SyncSubscription) []string {
+	var fields []string
+	if old.Name != sub.Name {
+		fields = append(fields, "renamed from "+old.Name)
+	}
+	if old.Amount != sub.Amount || old.Currency != sub.Currency {
+		fields = append(fields, fmt.Sprintf("price %.2f %s to %.2f %s", old.Amount, old.Currency, sub.Amount, sub.Currency))
+	}
+	if old.BillingCycle != sub.BillingCycle {
+		fields = append(fields, "billing cycle "+old.BillingCycle+" to "+sub.BillingCycle)
+	}
+	if old.NextRenewalDate != sub.NextRenewalDate {
+		fields = append(fields, "next renewal "+sub.NextRenewalDate)
+	}
+	if old.Category != sub.Category {
+		fields = append(fields, "category")
+	}
+	if !sameList(old.Tags, sub.Tags) {
+		fields = append(fields, "tags")
+	}
+	if old.Status != sub.Status || old.PauseDate != sub.PauseDate || old.CancelDate != sub.CancelDate {
+		fields = append(fields, "status "+sub.Status)
+	}
+	if old.TrialEndDate != sub.TrialEndDate || old.TrialPrice != sub.TrialPrice {
+		fields = append(fields, "trial")
+	}
+	if !reflect.DeepEqual(old.ReminderDays, sub.ReminderDays) {
+		fields = append(fields, "reminders")
+	}
+	if !sameList(old.PriceHistory, sub.PriceHistory) && len(fields) == 0 {
+		fields = append(fields, "price history")
+	}
+	return fields
+}
+
+// sameList reports whether two lists are equal, taking nil as empty, as it
+// is in a backup
+func sameList[T any](a, b []T) bool {
+	return len(a) == 0 && len(b) == 0 || reflect.DeepEqual(a, b)
+}
+
+// countSubscriptions writes "verb N subscriptions", in the singular for one
+func countSubscriptions(verb string, n int) string {
+	if n == 1 {
+		return verb + " 1 subscription"
+	}
+	return fmt.Sprintf("%s %d subscriptions", verb, n)
+}
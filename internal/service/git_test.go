@@ -0,0 +1,158 @@
+package service_test
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"subscription-tracker/internal/service"
+)
+
+// bareRepo creates a bare repository standing in for a remote such as Gitea,
+// with clones going to a cache folder of the test
+func bareRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	repo := filepath.Join(t.TempDir(), "backups.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+	return repo
+}
+
+// gitLog returns the subjects of the commits on branch of repo, newest first
+func gitLog(t *testing.T, repo, branch string) []string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", repo, "log", "--format=%s", branch).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git log failed: %v: %s", err, out)
+	}
+	return strings.Split(strings.TrimSpace(string(out)), "\n")
+}
+
+func TestGitBackend(t *testing.T) {
+	repo := bareRepo(t)
+	backend, err := service.NewGitBackend(repo, "", false)
+	if err != nil {
+		t.Fatalf("NewGitBackend() error = %v", err)
+	}
+	if !backend.Remote {
+		t.Errorf("NewGitBackend(%s) should treat a bare repository as a remote", repo)
+	}
+	testBackend(t, backend)
+
+	// Every change is a commit on the remote
+	if commits := gitLog(t, repo, service.DefaultGitBranch); len(commits) != 4 || commits[0] != "Delete b.enc" {
+		t.Errorf("commits = %v, want three puts and a delete", commits)
+	}
+
+	local := filepath.Join(t.TempDir(), "backups")
+	backend, err = service.NewGitBackend(local, "", false)
+	if err != nil {
+		t.Fatalf("NewGitBackend() error = %v", err)
+	}
+	if backend.Remote || backend.WorkDir != local {
+		t.Errorf("NewGitBackend(%s) = %+v, want a local work tree", local, backend)
+	}
+	testBackend(t, backend)
+
+	// Nothing is passed to git that it could take for an option
+	if _, err := service.NewGitBackend("--upload-pack=touch /tmp/pwned", "", false); err == nil {
+		t.Error("NewGitBackend() should reject a repository starting with -")
+	}
+	if _, err := service.NewGitBackend(repo, "--orphan", false); err == nil {
+		t.Error("NewGitBackend() should reject a branch starting with -")
+	}
+}
+
+func TestGitBackend_PushPull(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+	repo := bareRepo(t)
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	config := &service.SyncConfig{Backend: service.SyncBackendGit, GitRepo: repo, GitBranch: "backups"}
+	location, err := tdb.SyncService.Push(ctx, "secret", config)
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if location != repo+" (backups)" {
+		t.Errorf("Push() went to %s", location)
+	}
+	if _, err := tdb.SyncService.Push(ctx, "secret", config); err != nil {
+		t.Fatalf("Push() without changes error = %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Update(ctx, service.UpdateSubscriptionInput{
+		ID: sub.ID, Name: "Netflix", Amount: 18.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15",
+	}); err != nil {
+		t.Fatalf("failed to update subscription: %v", err)
+	}
+	if _, err := tdb.SyncService.Push(ctx, "secret", config); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	// A push without changes commits nothing, and the others say what changed
+	if got := strings.Join(gitLog(t, repo, "backups"), "; "); got != "Update Netflix; Back up 1 subscription" {
+		t.Errorf("commits = %s", got)
+	}
+
+	// The other device has a clone of its own
+	tdb2 := setupTestDB(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	if err := tdb2.SyncService.Pull(ctx, "secret", config); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	subs, _ := tdb2.SubscriptionService.List(ctx, "")
+	if len(subs) != 1 || subs[0].Amount != 18.00 {
+		t.Errorf("Pull() imported %+v, want Netflix at 18.00", subs)
+	}
+}
+
+func TestGitBackend_Plaintext(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+	repo := bareRepo(t)
+
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Spotify", Amount: 9.99, Currency: "EUR", BillingCycle: "monthly", NextRenewalDate: "2026-03-01",
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	config := &service.SyncConfig{Backend: service.SyncBackendGit, GitRepo: repo, GitPlaintext: true}
+	if config.Encrypted() {
+		t.Error("Encrypted() = true for a plaintext git backend")
+	}
+	if _, err := tdb.SyncService.Push(ctx, "", config); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", repo, "show", "main:"+service.SyncPlaintextName).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git show failed: %v: %s", err, out)
+	}
+	if !strings.Contains(string(out), `"name": "Spotify"`) {
+		t.Errorf("committed backup isn't readable JSON:\n%s", out)
+	}
+	if strings.Contains(string(out), "exported_at") {
+		t.Error("committed backup has the export time, which would change every commit")
+	}
+
+	tdb2 := setupTestDB(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	if err := tdb2.SyncService.Pull(ctx, "", config); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if subs, _ := tdb2.SubscriptionService.List(ctx, ""); len(subs) != 1 || subs[0].Name != "Spotify" {
+		t.Errorf("Pull() imported %+v, want Spotify", subs)
+	}
+}
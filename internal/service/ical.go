@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"subscription-tracker/internal/db"
+)
+
+// DefaultICSMonths is how far ahead an ICS export lists single renewals
+const DefaultICSMonths = 12
+
+// ICSOptions configures an iCalendar export
+type ICSOptions struct {
+	Recurring  bool // One repeating event per price of each subscription instead of one event per renewal
+	Months     int  // How many months of single renewals to list; DefaultICSMonths when 0
+	RemindDays int  // Days before each renewal to remind; 0 for no reminder
+}
+
+// ExportICS writes the upcoming renewals of every subscription as an
+// iCalendar file and returns the number of events written
+func (s *ExportService) ExportICS(ctx context.Context, w io.Writer, opts ICSOptions) (int, error) {
+	return s.ExportICSFrom(ctx, w, opts, time.Now())
+}
+
+// ExportICSFrom writes the renewals from referenceTime on as an iCalendar file.
+// Events are all-day, billed at the price in effect on their date, and their
+// UIDs only depend on the subscription ID (and the date of the renewal or
+// price), so importing a newer export updates events instead of duplicating them.
+func (s *ExportService) ExportICSFrom(ctx context.Context, w io.Writer, opts ICSOptions, referenceTime time.Time) (int, error) {
+	if opts.Months < 0 || opts.Months > MaxForecastMonths {
+		return 0, fmt.Errorf("months must be between 1 and %d", MaxForecastMonths)
+	}
+	if opts.Months == 0 {
+		opts.Months = DefaultICSMonths
+	}
+	if opts.RemindDays < 0 {
+		return 0, fmt.Errorf("reminder must be 0 or more days before the renewal")
+	}
+
+	subs, err := s.queries.GetAllSubscriptionsForExport(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	names, err := categoryNames(ctx, s.queries)
+	if err != nil {
+		return 0, err
+	}
+	history, err := loadPriceHistory(ctx, s.queries)
+	if err != nil {
+		return 0, err
+	}
+
+	today := time.Date(referenceTime.Year(), referenceTime.Month(), referenceTime.Day(), 0, 0, 0, 0, time.UTC)
+	cal := icsWriter{stamp: referenceTime.UTC().Format("20060102T150405Z"), remindDays: opts.RemindDays}
+
+	for _, sub := range subs {
+		renewalDate, ok := parseNullDate(sub.NextRenewalDate)
+		if !ok {
+			continue
+		}
+		cycle, err := ParseBillingCycle(sub.BillingCycle)
+		if err != nil {
+			continue
+		}
+		category := CategoryName(sub, names)
+
+		if opts.Recurring {
+			for _, rule := range recurringRenewals(sub, cycle, renewalDate, today, history) {
+				cal.event(sub, category, rule)
+			}
+			continue
+		}
+
+		end := today.AddDate(0, opts.Months, -1)
+		for _, date := range cycle.DatesInPeriod(renewalDate, today, end) {
+			if !ChargesOn(sub, date) {
+				continue
+			}
+			amount, currency := history.priceOn(sub, date)
+			cal.event(sub, category, icsRenewal{
+				uid:      fmt.Sprintf("subscription-%d-%s@subscription-tracker", sub.ID, date.Format("20060102")),
+				start:    date,
+				amount:   amount,
+				currency: currency,
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, cal.String()); err != nil {
+		return 0, fmt.Errorf("failed to write calendar: %w", err)
+	}
+	return cal.events, nil
+}
+
+// icsRenewal is one calendar event: a single renewal, or a repeating one when rrule is set
+type icsRenewal struct {
+	uid      string
+	start    time.Time
+	rrule    string
+	end      time.Time // Last day a repeating event may occur on; zero when it doesn't end
+	amount   float64
+	currency string
+}
+
+// recurringRenewals returns a repeating event for each price sub is billed
+// at from today on. The first keeps a UID of just the subscription ID; each
+// later price starts a new event on the first renewal it applies to.
+func recurringRenewals(sub db.Subscription, cycle BillingCycle, anchor, today time.Time, history priceHistory) []icsRenewal {
+	// Billing stops on the pause or cancel date, and starts after a trial
+	stop, stops := time.Time{}, false
+	switch sub.Status {
+	case StatusPaused:
+		stop, stops = parseNullDate(sub.PauseDate)
+	case StatusCancelled:
+		stop, stops = parseNullDate(sub.CancelDate)
+	}
+	from := today
+	if end, ok := parseNullDate(sub.TrialEndDate); ok && sub.Status == StatusTrial && end.After(from) {
+		from = end
+	}
+
+	// Each price change after from ends one event and starts the next
+	bounds := []time.Time{from}
+	for _, change := range history[sub.ID] {
+		if date, err := time.Parse("2006-01-02", change.EffectiveDate); err == nil && date.After(from) {
+			bounds = append(bounds, date)
+		}
+	}
+
+	var renewals []icsRenewal
+	for i, start := range bounds {
+		until, bounded := stop, stops
+		if i+1 < len(bounds) && (!bounded || bounds[i+1].Before(until)) {
+			until, bounded = bounds[i+1], true
+		}
+
+		first, ok := firstChargeFrom(sub, cycle, anchor, start)
+		if !ok || bounded && !first.Before(until) {
+			continue
+		}
+
+		rrule, end := cycleRule(cycle, anchor), time.Time{}
+		if bounded {
+			end = until.AddDate(0, 0, -1)
+			rrule += ";UNTIL=" + end.Format("20060102")
+		}
+		uid := fmt.Sprintf("subscription-%d@subscription-tracker", sub.ID)
+		if len(renewals) > 0 {
+			uid = fmt.Sprintf("subscription-%d-%s@subscription-tracker", sub.ID, first.Format("20060102"))
+		}
+		amount, currency := history.priceOn(sub, first)
+		renewals = append(renewals, icsRenewal{uid: uid, start: first, rrule: rrule, end: end, amount: amount, currency: currency})
+	}
+	return renewals
+}
+
+// firstChargeFrom returns the first billed renewal on or after from
+func firstChargeFrom(sub db.Subscription, cycle BillingCycle, anchor, from time.Time) (time.Time, bool) {
+	for _, date := range cycle.DatesInPeriod(anchor, from, cycle.AddTo(from, 1)) {
+		if ChargesOn(sub, date) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// cycleRule returns the RRULE of a billing cycle. Renewals on the 29th to 31st
+// move to the last day of shorter months, like BillingCycle.AddTo does.
+func cycleRule(cycle BillingCycle, anchor time.Time) string {
+	freq := map[CycleUnit]string{UnitDay: "DAILY", UnitWeek: "WEEKLY", UnitMonth: "MONTHLY", UnitYear: "YEARLY"}[cycle.Unit]
+	rule := "FREQ=" + freq
+	if cycle.Count > 1 {
+		rule += fmt.Sprintf(";INTERVAL=%d", cycle.Count)
+	}
+
+	if cycle.Unit != UnitMonth && cycle.Unit != UnitYear {
+		return rule
+	}
+	if cycle.Unit == UnitYear {
+		rule += fmt.Sprintf(";BYMONTH=%d", int(anchor.Month()))
+	}
+	if anchor.Day() > 28 {
+		days := make([]string, 0, anchor.Day()-27)
+		for day := 28; day <= anchor.Day(); day++ {
+			days = append(days, fmt.Sprintf("%d", day))
+		}
+		rule += ";BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+	} else {
+		rule += fmt.Sprintf(";BYMONTHDAY=%d", anchor.Day())
+	}
+	return rule
+}
+
+// icsWriter builds an iCalendar file (RFC 5545)
+type icsWriter struct {
+	b          strings.Builder
+	stamp      string
+	remindDays int
+	events     int
+}
+
+func (c *icsWriter) event(sub db.Subscription, category string, r icsRenewal) {
+	c.events++
+	c.line("BEGIN:VEVENT")
+	c.line("UID:" + r.uid)
+	c.line("DTSTAMP:" + c.stamp)
+	c.line("DTSTART;VALUE=DATE:" + r.start.Format("20060102"))
+	c.line("DTEND;VALUE=DATE:" + r.start.AddDate(0, 0, 1).Format("20060102"))
+	if r.rrule != "" {
+		c.line("RRULE:" + r.rrule)
+	}
+	summary := fmt.Sprintf("%s: %.2f %s", sub.Name, r.amount, r.currency)
+	c.line("SUMMARY:" + icsEscape(summary))
+	description := fmt.Sprintf("%s renews (%s)", sub.Name, sub.BillingCycle)
+	if category != "" {
+		description += "\nCategory: " + category
+		c.line("CATEGORIES:" + icsEscape(category))
+	}
+	c.line("DESCRIPTION:" + icsEscape(description))
+	if updated, err := time.Parse("2006-01-02 15:04:05", sub.UpdatedAt); err == nil {
+		c.line("LAST-MODIFIED:" + updated.Format("20060102T150405Z"))
+	}
+	c.line("TRANSP:TRANSPARENT")
+	if c.remindDays > 0 {
+		c.line("BEGIN:VALARM")
+		c.line("ACTION:DISPLAY")
+		c.line("DESCRIPTION:" + icsEscape(summary))
+		c.line(fmt.Sprintf("TRIGGER:-P%dD", c.remindDays))
+		c.line("END:VALARM")
+	}
+	c.line("END:VEVENT")
+}
+
+// String returns the complete calendar
+func (c *icsWriter) String() string {
+	var out icsWriter
+	out.line("BEGIN:VCALENDAR")
+	out.line("VERSION:2.0")
+	out.line("PRODID:-//subscription-tracker//Renewals//EN")
+	out.line("CALSCALE:GREGORIAN")
+	out.line("METHOD:PUBLISH")
+	out.line("X-WR-CALNAME:Subscriptions")
+	// How often subscribed clients should refresh the feed, see FeedHandler
+	out.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	out.line("X-PUBLISHED-TTL:PT1H")
+	out.b.WriteString(c.b.String())
+	out.line("END:VCALENDAR")
+	return out.b.String()
+}
+
+// line writes a content line, folded at 75 octets without splitting characters
+func (c *icsWriter) line(s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		c.b.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // The leading space counts
+	}
+	c.b.WriteString(s + "\r\n")
+}
+
+// icsEscape escapes a TEXT property value
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
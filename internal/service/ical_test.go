@@ -0,0 +1,229 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+// icsEvents splits an iCalendar file into its unfolded events
+func icsEvents(t *testing.T, ics string) []string {
+	t.Helper()
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	if !strings.HasPrefix(unfolded, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(unfolded, "END:VCALENDAR\r\n") {
+		t.Errorf("not a calendar: %q", unfolded)
+	}
+
+	var events []string
+	for _, part := range strings.Split(unfolded, "BEGIN:VEVENT\r\n")[1:] {
+		events = append(events, part[:strings.Index(part, "END:VEVENT")])
+	}
+	return events
+}
+
+func TestExportService_ExportICSFrom(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	netflix, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-31", Category: "streaming",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	disney, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Disney+, Hulu; and a bundle with a name too long for a single line", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-20",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Music", Amount: 9.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", TrialEndDate: "2026-03-01",
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	// Netflix costs 18 from March and Disney+ is cancelled from March
+	if _, err := tdb.SubscriptionService.Update(ctx, service.UpdateSubscriptionInput{
+		ID: netflix.ID, Name: "Netflix", Amount: 18.00, Currency: "USD", BillingCycle: "monthly",
+		NextRenewalDate: "2026-01-31", Category: "streaming", PriceEffective: "2026-03-01",
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Cancel(ctx, disney.ID, "2026-03-01"); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	now := time.Date(2026, 1, 12, 9, 30, 0, 0, time.UTC)
+
+	t.Run("single renewals", func(t *testing.T) {
+		var buf bytes.Buffer
+		count, err := tdb.ExportService.ExportICSFrom(ctx, &buf, service.ICSOptions{Months: 3, RemindDays: 2}, now)
+		if err != nil {
+			t.Fatalf("ExportICSFrom() error = %v", err)
+		}
+
+		events := icsEvents(t, buf.String())
+		want := []string{
+			// Subscriptions are in name order
+			"DTSTART;VALUE=DATE:20260120\r\nDTEND;VALUE=DATE:20260121\r\nSUMMARY:Disney+\\, Hulu\\; and a bundle",
+			"DTSTART;VALUE=DATE:20260220\r\n",
+			// Music is billed after its trial ends, the first time on March 10
+			"DTSTART;VALUE=DATE:20260310\r\n",
+			"DTSTART;VALUE=DATE:20260410\r\n",
+			"UID:subscription-1-20260131@subscription-tracker\r\nDTSTAMP:20260112T093000Z\r\nDTSTART;VALUE=DATE:20260131\r\nDTEND;VALUE=DATE:20260201\r\nSUMMARY:Netflix: 15.00 USD\r\nCATEGORIES:streaming\r\n",
+			"DTSTART;VALUE=DATE:20260228\r\n",
+			"DTSTART;VALUE=DATE:20260331\r\nDTEND;VALUE=DATE:20260401\r\nSUMMARY:Netflix: 18.00 USD\r\n",
+		}
+		if count != len(want) || len(events) != len(want) {
+			t.Fatalf("ExportICSFrom() count = %d with %d events, want %d:\n%s", count, len(events), len(want), buf.String())
+		}
+		for i, w := range want {
+			if !strings.Contains(events[i], w) {
+				t.Errorf("event %d = %q, want it to contain %q", i, events[i], w)
+			}
+			if !strings.Contains(events[i], "BEGIN:VALARM\r\nACTION:DISPLAY\r\n") || !strings.Contains(events[i], "TRIGGER:-P2D\r\n") {
+				t.Errorf("event %d = %q, want a reminder 2 days before", i, events[i])
+			}
+		}
+	})
+
+	t.Run("recurring", func(t *testing.T) {
+		var buf bytes.Buffer
+		count, err := tdb.ExportService.ExportICSFrom(ctx, &buf, service.ICSOptions{Recurring: true}, now)
+		if err != nil {
+			t.Fatalf("ExportICSFrom() error = %v", err)
+		}
+
+		events := icsEvents(t, buf.String())
+		want := []string{
+			"UID:subscription-2@subscription-tracker\r\nDTSTAMP:20260112T093000Z\r\nDTSTART;VALUE=DATE:20260120\r\nDTEND;VALUE=DATE:20260121\r\nRRULE:FREQ=MONTHLY;BYMONTHDAY=20;UNTIL=20260228\r\n",
+			"UID:subscription-3@subscription-tracker\r\nDTSTAMP:20260112T093000Z\r\nDTSTART;VALUE=DATE:20260310\r\nDTEND;VALUE=DATE:20260311\r\nRRULE:FREQ=MONTHLY;BYMONTHDAY=10\r\nSUMMARY:Music: 9.00 USD\r\n",
+			// The 31st moves to the last day of shorter months, and each price gets its own event
+			"UID:subscription-1@subscription-tracker\r\nDTSTAMP:20260112T093000Z\r\nDTSTART;VALUE=DATE:20260131\r\nDTEND;VALUE=DATE:20260201\r\nRRULE:FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1;UNTIL=20260228\r\nSUMMARY:Netflix: 15.00 USD\r\n",
+			"UID:subscription-1-20260331@subscription-tracker\r\nDTSTAMP:20260112T093000Z\r\nDTSTART;VALUE=DATE:20260331\r\nDTEND;VALUE=DATE:20260401\r\nRRULE:FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1\r\nSUMMARY:Netflix: 18.00 USD\r\n",
+		}
+		if count != len(want) || len(events) != len(want) {
+			t.Fatalf("ExportICSFrom() count = %d with %d events, want %d:\n%s", count, len(events), len(want), buf.String())
+		}
+		for i, w := range want {
+			if !strings.HasPrefix(events[i], w) {
+				t.Errorf("event %d = %q, want it to start with %q", i, events[i], w)
+			}
+			if strings.Contains(events[i], "VALARM") {
+				t.Errorf("event %d = %q, want no reminder", i, events[i])
+			}
+		}
+	})
+
+	if _, err := tdb.ExportService.ExportICSFrom(ctx, &bytes.Buffer{}, service.ICSOptions{RemindDays: -1}, now); err == nil {
+		t.Error("ExportICSFrom() should reject a negative reminder")
+	}
+}
+
+func TestExportService_Export_ICS(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Amazon Prime", Amount: 139.00, Currency: "USD", BillingCycle: "yearly", NextRenewalDate: time.Now().AddDate(0, 1, 0).Format("2006-01-02"),
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	// The default export lists the renewals of the next 12 months
+	var buf bytes.Buffer
+	count, err := tdb.ExportService.Export(ctx, &buf, service.FormatICS)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if count != 1 || len(icsEvents(t, buf.String())) != 1 {
+		t.Errorf("Export() count = %d, want 1 event:\n%s", count, buf.String())
+	}
+}
+
+func TestExportService_FeedHandler(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	token, err := tdb.ExportService.FeedToken(ctx)
+	if err != nil {
+		t.Fatalf("FeedToken() error = %v", err)
+	}
+	if again, _ := tdb.ExportService.FeedToken(ctx); again != token || token == "" {
+		t.Errorf("FeedToken() = %q then %q, want the same saved token", token, again)
+	}
+
+	server := httptest.NewServer(tdb.ExportService.FeedHandler(token, service.ICSOptions{Recurring: true}))
+	defer server.Close()
+
+	get := func(url string) (int, string) {
+		t.Helper()
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", url, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, _ := get(server.URL + "/subscriptions.ics"); status != http.StatusUnauthorized {
+		t.Errorf("GET without token = %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status, _ := get(server.URL + "/subscriptions.ics?token=wrong"); status != http.StatusUnauthorized {
+		t.Errorf("GET with a wrong token = %d, want %d", status, http.StatusUnauthorized)
+	}
+	resp, err := http.Post(server.URL+"/subscriptions.ics?token="+token, "text/plain", nil)
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+
+	// The feed is generated on every request, keeping the UID of an edited subscription
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: time.Now().AddDate(0, 0, 3).Format("2006-01-02"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	uid := "UID:subscription-1@subscription-tracker"
+	status, body := get(server.URL + "/subscriptions.ics?token=" + token)
+	if events := icsEvents(t, body); status != http.StatusOK || len(events) != 1 || !strings.HasPrefix(events[0], uid) {
+		t.Fatalf("GET = %d %q, want the Netflix event", status, body)
+	}
+
+	if _, err := tdb.SubscriptionService.Update(ctx, service.UpdateSubscriptionInput{
+		ID: sub.ID, Name: "Netflix Premium", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: sub.NextRenewalDate.String,
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	status, body = get(server.URL + "/subscriptions.ics?token=" + token)
+	if events := icsEvents(t, body); status != http.StatusOK || len(events) != 1 || !strings.HasPrefix(events[0], uid) || !strings.Contains(events[0], "SUMMARY:Netflix Premium") {
+		t.Errorf("GET after Update() = %d %q, want the renamed event with the same UID", status, body)
+	}
+
+	// A new token locks out the old one
+	newToken, err := tdb.ExportService.NewFeedToken(ctx)
+	if err != nil || newToken == token {
+		t.Fatalf("NewFeedToken() = %q, %v, want a different token", newToken, err)
+	}
+	if saved, _ := tdb.ExportService.FeedToken(ctx); saved != newToken {
+		t.Errorf("FeedToken() = %q, want %q", saved, newToken)
+	}
+}
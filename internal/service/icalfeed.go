@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"subscription-tracker/internal/db"
+)
+
+// ConfigKeyFeedToken is the token calendar clients pass to read the ICS feed
+const ConfigKeyFeedToken = "ical_feed_token"
+
+// FeedToken returns the saved ICS feed token, creating one on first use
+func (s *ExportService) FeedToken(ctx context.Context) (string, error) {
+	token, err := s.queries.GetConfig(ctx, ConfigKeyFeedToken)
+	if err == nil && token != "" {
+		return token, nil
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to get feed token: %w", err)
+	}
+	return s.NewFeedToken(ctx)
+}
+
+// NewFeedToken replaces the ICS feed token, locking out clients that use the old one
+func (s *ExportService) NewFeedToken(ctx context.Context) (string, error) {
+	b := make([]byte, 24)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := s.queries.SetConfig(ctx, db.SetConfigParams{Key: ConfigKeyFeedToken, Value: token}); err != nil {
+		return "", fmt.Errorf("failed to save feed token: %w", err)
+	}
+	return token, nil
+}
+
+// FeedHandler serves the upcoming renewals as a read-only ICS feed. The calendar
+// is generated from the database on every request, so calendar clients that
+// subscribe to it pick up changes on their next refresh. Clients authenticate
+// with token as the token query parameter or as a bearer token.
+func (s *ExportService) FeedHandler(token string, opts ICSOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		given := r.URL.Query().Get("token")
+		if auth := r.Header.Get("Authorization"); given == "" && strings.HasPrefix(auth, "Bearer ") {
+			given = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		var buf bytes.Buffer
+		if _, err := s.ExportICS(r.Context(), &buf, opts); err != nil {
+			http.Error(w, "failed to generate calendar", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="subscriptions.ics"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Method == http.MethodHead {
+			return
+		}
+		w.Write(buf.Bytes())
+	})
+}
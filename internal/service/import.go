@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"subscription-tracker/internal/db"
+)
+
+// ImportMode tells what an import does with the existing subscriptions
+type ImportMode string
+
+const (
+	ImportMerge   ImportMode = "merge"   // Update subscriptions with the same name, create the others
+	ImportReplace ImportMode = "replace" // Delete every existing subscription once the import is saved
+)
+
+// ImportAction is what an import does with one row
+type ImportAction string
+
+const (
+	ImportCreate ImportAction = "create"
+	ImportUpdate ImportAction = "update"
+	ImportSkip   ImportAction = "skip" // The row has an error
+)
+
+// ImportService reads back the CSV and JSON files written by ExportService
+type ImportService struct {
+	queries       *db.Queries
+	subscriptions *SubscriptionService
+}
+
+// NewImportService creates a new import service
+func NewImportService(queries *db.Queries, subscriptions *SubscriptionService) *ImportService {
+	return &ImportService{queries: queries, subscriptions: subscriptions}
+}
+
+// ImportRow is one subscription read from an import file
+type ImportRow struct {
+	Line         int // Line of a CSV file, or position in a JSON array, from 1
+	Subscription ExportSubscription
+	Input        CreateSubscriptionInput // Validated input, see CreateSubscriptionInput.Validate
+	Action       ImportAction
+	ExistingID   int64 // Subscription updated by a merge
+	Err          error
+}
+
+// ImportPreview is what an import will do, to be shown before it is applied
+type ImportPreview struct {
+	Mode     ImportMode
+	Rows     []ImportRow
+	Existing int // Subscriptions deleted by a replace
+	Creates  int
+	Updates  int
+	Errors   int
+}
+
+// ImportResult counts what an applied import did
+type ImportResult struct {
+	Created int
+	Updated int
+	Deleted int
+	Skipped int
+}
+
+// Preview reads subscriptions in the CSV or JSON export format and validates
+// each of them. Rows with errors are kept with their error and skipped by Apply.
+func (s *ImportService) Preview(ctx context.Context, r io.Reader, format ExportFormat, mode ImportMode) (*ImportPreview, error) {
+	if mode != ImportMerge && mode != ImportReplace {
+		return nil, fmt.Errorf("import mode must be merge or replace: %s", mode)
+	}
+
+	var rows []ImportRow
+	var err error
+	switch format {
+	case FormatCSV:
+		rows, err = readImportCSV(r)
+	case FormatJSON:
+		rows, err = readImportJSON(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	byName := make(map[string]int64, len(existing))
+	for _, sub := range existing {
+		if _, ok := byName[importKey(sub.Name)]; !ok {
+			byName[importKey(sub.Name)] = sub.ID
+		}
+	}
+
+	preview := &ImportPreview{Mode: mode, Rows: rows}
+	if mode == ImportReplace {
+		preview.Existing = len(existing)
+	}
+
+	seen := make(map[string]int)
+	for i := range preview.Rows {
+		row := &preview.Rows[i]
+		if row.Err == nil {
+			row.Input, row.Err = importInput(row.Subscription)
+		}
+		// Merging matches by name, so each name may only appear once
+		if row.Err == nil && mode == ImportMerge {
+			if line, ok := seen[importKey(row.Input.Name)]; ok {
+				row.Err = fmt.Errorf("%s is already on line %d", row.Input.Name, line)
+			}
+			seen[importKey(row.Input.Name)] = row.Line
+		}
+
+		switch {
+		case row.Err != nil:
+			row.Action = ImportSkip
+			preview.Errors++
+		case mode == ImportMerge && byName[importKey(row.Input.Name)] != 0:
+			row.Action = ImportUpdate
+			row.ExistingID = byName[importKey(row.Input.Name)]
+			preview.Updates++
+		default:
+			row.Action = ImportCreate
+			preview.Creates++
+		}
+	}
+	return preview, nil
+}
+
+// Summary describes what the import will do in one line
+func (p *ImportPreview) Summary() string {
+	summary := fmt.Sprintf("%d to create, %d to update, %d with errors", p.Creates, p.Updates, p.Errors)
+	if p.Mode == ImportReplace {
+		summary += fmt.Sprintf("; replaces all %d existing subscriptions", p.Existing)
+	}
+	return summary
+}
+
+// ImportFormatOf guesses the format of an import file from its extension
+func ImportFormatOf(path string) ExportFormat {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+// Apply imports the valid rows of a preview. A replace creates the imported
+// subscriptions before it deletes the existing ones, and deletes what it
+// created when a row fails, so that a failed replace keeps the old subscriptions.
+func (s *ImportService) Apply(ctx context.Context, preview *ImportPreview) (ImportResult, error) {
+	var result ImportResult
+
+	var existing []db.Subscription
+	if preview.Mode == ImportReplace {
+		var err error
+		if existing, err = s.queries.ListSubscriptions(ctx); err != nil {
+			return result, fmt.Errorf("failed to list subscriptions: %w", err)
+		}
+	}
+
+	var created []int64
+	for _, row := range preview.Rows {
+		if err := s.applyRow(ctx, row, &result, &created); err != nil {
+			err = fmt.Errorf("failed to import %s (line %d): %w", row.Input.Name, row.Line, err)
+			if preview.Mode == ImportReplace {
+				for _, id := range created {
+					if undoErr := s.subscriptions.Delete(ctx, id); undoErr != nil {
+						err = errors.Join(err, fmt.Errorf("failed to remove imported subscription %d: %w", id, undoErr))
+					}
+				}
+				result.Created = 0
+			}
+			return result, err
+		}
+	}
+
+	for _, sub := range existing {
+		if err := s.subscriptions.Delete(ctx, sub.ID); err != nil {
+			return result, fmt.Errorf("failed to delete subscription %s: %w", sub.Name, err)
+		}
+		result.Deleted++
+	}
+	return result, nil
+}
+
+// applyRow creates or updates the subscription of one row, counting it in the
+// result once it is saved and adding the IDs of new subscriptions to created
+func (s *ImportService) applyRow(ctx context.Context, row ImportRow, result *ImportResult, created *[]int64) error {
+	var sub db.Subscription
+	var err error
+	switch row.Action {
+	case ImportCreate:
+		if sub, err = s.subscriptions.Create(ctx, row.Input); err != nil {
+			return err
+		}
+		*created = append(*created, sub.ID)
+		result.Created++
+	case ImportUpdate:
+		if sub, err = s.subscriptions.Update(ctx, UpdateSubscriptionInput{
+			ID:              row.ExistingID,
+			Name:            row.Input.Name,
+			Amount:          row.Input.Amount,
+			Currency:        row.Input.Currency,
+			BillingCycle:    row.Input.BillingCycle,
+			NextRenewalDate: row.Input.NextRenewalDate,
+			Category:        row.Input.Category,
+			Tags:            row.Input.Tags,
+		}); err != nil {
+			return err
+		}
+		result.Updated++
+	default:
+		result.Skipped++
+		return nil
+	}
+	return s.applyState(ctx, sub, row.Subscription)
+}
+
+// applyState sets the trial, pause and cancellation of an imported
+// subscription. Without a status a merged subscription keeps its own.
+func (s *ImportService) applyState(ctx context.Context, sub db.Subscription, row ExportSubscription) error {
+	if row.Status == "" && row.TrialEndDate == "" {
+		return nil
+	}
+
+	status, pause, cancel := row.Status, nullDate(row.PauseDate), nullDate(row.CancelDate)
+	if status == "" {
+		status, pause, cancel = sub.Status, sub.PauseDate, sub.CancelDate
+		if IsRecurring(sub) {
+			status = StatusTrial
+		}
+	}
+	trialEnd, trialPrice := sub.TrialEndDate, sub.TrialPrice
+	if row.TrialEndDate != "" {
+		trialEnd = nullDate(row.TrialEndDate)
+		trialPrice = sql.NullFloat64{Float64: row.TrialPrice, Valid: row.TrialPrice > 0}
+	}
+
+	if _, err := s.queries.UpdateSubscriptionTrial(ctx, db.UpdateSubscriptionTrialParams{
+		ID:           sub.ID,
+		Status:       status,
+		TrialEndDate: trialEnd,
+		TrialPrice:   trialPrice,
+	}); err != nil {
+		return err
+	}
+	_, err := s.queries.UpdateSubscriptionStatus(ctx, db.UpdateSubscriptionStatusParams{
+		ID:           sub.ID,
+		Status:       status,
+		TrialEndDate: trialEnd,
+		PauseDate:    pause,
+		CancelDate:   cancel,
+	})
+	return err
+}
+
+// importInput validates a subscription read from an import file
+func importInput(sub ExportSubscription) (CreateSubscriptionInput, error) {
+	input := CreateSubscriptionInput{
+		Name:            strings.TrimSpace(sub.Name),
+		Amount:          sub.Amount,
+		Currency:        strings.ToUpper(strings.TrimSpace(sub.Currency)),
+		BillingCycle:    sub.BillingCycle,
+		NextRenewalDate: strings.TrimSpace(sub.NextRenewalDate),
+		Category:        sub.Category,
+		Tags:            sub.Tags,
+		TrialEndDate:    sub.TrialEndDate,
+		TrialPrice:      sub.TrialPrice,
+	}
+	if strings.EqualFold(strings.TrimSpace(input.Category), Uncategorized) {
+		input.Category = ""
+	}
+	if err := input.Validate(); err != nil {
+		return input, err
+	}
+
+	switch sub.Status {
+	case "", StatusActive:
+	case StatusTrial:
+		if sub.TrialEndDate == "" {
+			return input, fmt.Errorf("trial end date is required for a trial")
+		}
+	case StatusPaused:
+		if _, ok := parseNullDate(nullDate(sub.PauseDate)); !ok {
+			return input, fmt.Errorf("pause date is required for a paused subscription, use YYYY-MM-DD")
+		}
+	case StatusCancelled:
+		if _, ok := parseNullDate(nullDate(sub.CancelDate)); !ok {
+			return input, fmt.Errorf("cancel date is required for a cancelled subscription, use YYYY-MM-DD")
+		}
+	default:
+		return input, fmt.Errorf("status must be one of %s: %s", strings.Join(Statuses, ", "), sub.Status)
+	}
+	return input, nil
+}
+
+// importKey is how a merge matches names
+func importKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// readImportCSV reads rows in the ExportCSVHeader layout. Columns are matched
+// by header name, so they may come in any order and all but Name may be left out.
+func readImportCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("the file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")) // Spreadsheets may write a BOM
+		if slices.ContainsFunc(ExportCSVHeader, func(h string) bool { return strings.EqualFold(h, name) }) {
+			columns[strings.ToLower(name)] = i
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("the header has no Name column, expected the columns %s", strings.Join(ExportCSVHeader, ","))
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[strings.ToLower(name)]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := ImportRow{Line: line, Subscription: ExportSubscription{
+			Name:            field("Name"),
+			Currency:        field("Currency"),
+			BillingCycle:    field("Billing Cycle"),
+			NextRenewalDate: field("Next Renewal Date"),
+			Category:        field("Category"),
+			Tags:            ParseTags(field("Tags")),
+			Status:          strings.ToLower(field("Status")),
+			TrialEndDate:    field("Trial End Date"),
+			PauseDate:       field("Pause Date"),
+			CancelDate:      field("Cancel Date"),
+		}}
+		row.Subscription.Amount, row.Err = parseImportAmount("amount", field("Amount"))
+		if row.Err == nil && field("Trial Price") != "" {
+			row.Subscription.TrialPrice, row.Err = parseImportAmount("trial price", field("Trial Price"))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseImportAmount(name, value string) (float64, error) {
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return amount, nil
+}
+
+// readImportJSON reads an array of ExportSubscription objects
+func readImportJSON(r io.Reader) ([]ImportRow, error) {
+	var subs []ExportSubscription
+	if err := json.NewDecoder(r).Decode(&subs); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON, expected an array of subscriptions: %w", err)
+	}
+
+	rows := make([]ImportRow, len(subs))
+	for i, sub := range subs {
+		sub.Status = strings.ToLower(sub.Status)
+		rows[i] = ImportRow{Line: i + 1, Subscription: sub}
+	}
+	return rows, nil
+}
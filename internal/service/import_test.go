@@ -0,0 +1,237 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestImportService_RoundTrip(t *testing.T) {
+	for _, format := range []service.ExportFormat{service.FormatCSV, service.FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			tdb := setupTestDB(t)
+			ctx := context.Background()
+
+			inputs := []service.CreateSubscriptionInput{
+				{Name: "Netflix", Amount: 15.99, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15", Category: "streaming", Tags: []string{"family", "tv"}},
+				{Name: "Music", Amount: 9.99, Currency: "EUR", BillingCycle: "every 4 weeks", NextRenewalDate: "2026-01-20", TrialEndDate: "2099-01-01", TrialPrice: 11.99},
+				{Name: "Gym", Amount: 30.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-01"},
+			}
+			for _, input := range inputs {
+				if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+					t.Fatalf("failed to create subscription: %v", err)
+				}
+			}
+			if _, err := tdb.SubscriptionService.Cancel(ctx, 3, "2026-02-01"); err != nil {
+				t.Fatalf("Cancel() error = %v", err)
+			}
+
+			var buf bytes.Buffer
+			if _, err := tdb.ExportService.Export(ctx, &buf, format); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			before, err := tdb.SubscriptionService.List(ctx, "")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			// Replacing with the export gives back the same subscriptions
+			preview, err := tdb.ImportService.Preview(ctx, bytes.NewReader(buf.Bytes()), format, service.ImportReplace)
+			if err != nil {
+				t.Fatalf("Preview() error = %v", err)
+			}
+			if preview.Existing != 3 || preview.Creates != 3 || preview.Errors != 0 {
+				t.Fatalf("Preview() = %+v, want 3 existing and 3 to create", preview)
+			}
+			result, err := tdb.ImportService.Apply(ctx, preview)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if result != (service.ImportResult{Created: 3, Deleted: 3}) {
+				t.Errorf("Apply() = %+v, want 3 created and 3 deleted", result)
+			}
+
+			after, err := tdb.SubscriptionService.List(ctx, "")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(after) != len(before) {
+				t.Fatalf("List() = %d subscriptions, want %d", len(after), len(before))
+			}
+			for i := range before {
+				b, a := before[i], after[i]
+				if a.Name != b.Name || a.Amount != b.Amount || a.Currency != b.Currency || a.BillingCycle != b.BillingCycle ||
+					a.NextRenewalDate != b.NextRenewalDate || a.CategoryID != b.CategoryID || a.Tags != b.Tags || a.Status != b.Status ||
+					a.TrialEndDate != b.TrialEndDate || a.TrialPrice != b.TrialPrice || a.CancelDate != b.CancelDate {
+					t.Errorf("imported %+v, want %+v", a, b)
+				}
+			}
+		})
+	}
+}
+
+func TestImportService_Merge(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	netflix, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	// Columns may come in any order and only Name is required, as in a spreadsheet
+	csv := strings.Join([]string{
+		"Name,Amount,Billing Cycle,Next Renewal Date,Category,Currency,Status,Pause Date",
+		"netflix ,18.00,monthly,2026-01-15,Streaming,usd,,",
+		"Spotify,9.99,monthly,2026-01-20,,EUR,,",
+		"Broken,abc,monthly,2026-01-20,,,,",
+		"Weird,5,fortnightly,2026-01-20,,,,",
+		"Gym,30,monthly,2026-01-01,,,paused,",
+		"",
+		"Spotify,10.99,monthly,2026-01-20,,,,",
+		"Paper,2,weekly,2026-01-05,news,,paused,2026-02-01",
+	}, "\n")
+
+	preview, err := tdb.ImportService.Preview(ctx, strings.NewReader(csv), service.FormatCSV, service.ImportMerge)
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+
+	want := []struct {
+		line   int
+		action service.ImportAction
+		err    string
+	}{
+		{2, service.ImportUpdate, ""},
+		{3, service.ImportCreate, ""},
+		{4, service.ImportSkip, "invalid amount"},
+		{5, service.ImportSkip, "billing cycle"},
+		{6, service.ImportSkip, "pause date is required"},
+		{8, service.ImportSkip, "already on line 3"},
+		{9, service.ImportCreate, ""},
+	}
+	if len(preview.Rows) != len(want) {
+		t.Fatalf("Preview() rows = %+v, want %d", preview.Rows, len(want))
+	}
+	for i, w := range want {
+		row := preview.Rows[i]
+		errText := ""
+		if row.Err != nil {
+			errText = row.Err.Error()
+		}
+		if row.Line != w.line || row.Action != w.action || (w.err == "") != (row.Err == nil) || !strings.Contains(errText, w.err) {
+			t.Errorf("row %d = line %d %s %v, want line %d %s %q", i, row.Line, row.Action, row.Err, w.line, w.action, w.err)
+		}
+	}
+	if preview.Rows[0].ExistingID != netflix.ID {
+		t.Errorf("Preview() merges netflix into %d, want %d", preview.Rows[0].ExistingID, netflix.ID)
+	}
+	if preview.Creates != 2 || preview.Updates != 1 || preview.Errors != 4 {
+		t.Errorf("Preview() = %d creates, %d updates, %d errors, want 2, 1, 4", preview.Creates, preview.Updates, preview.Errors)
+	}
+
+	result, err := tdb.ImportService.Apply(ctx, preview)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if result != (service.ImportResult{Created: 2, Updated: 1, Skipped: 4}) {
+		t.Errorf("Apply() = %+v, want 2 created, 1 updated and 4 skipped", result)
+	}
+
+	subs, err := tdb.SubscriptionService.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(subs) != 3 {
+		t.Fatalf("List() = %+v, want Netflix, Spotify and Paper", subs)
+	}
+	updated, err := tdb.SubscriptionService.Get(ctx, netflix.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if updated.Name != "netflix" || updated.Amount != 18 || !updated.CategoryID.Valid {
+		t.Errorf("merged Netflix = %+v, want netflix at 18 in streaming", updated)
+	}
+	// The merged price change is kept in the price history
+	if history, _ := tdb.SubscriptionService.PriceHistory(ctx, netflix.ID); len(history) != 1 {
+		t.Errorf("PriceHistory() = %+v, want the change to 18", history)
+	}
+	for _, sub := range subs {
+		if sub.Name == "Paper" && (sub.Status != service.StatusPaused || sub.PauseDate.String != "2026-02-01") {
+			t.Errorf("Paper = %s from %v, want paused from 2026-02-01", sub.Status, sub.PauseDate)
+		}
+	}
+}
+
+func TestImportService_ReplaceFailure(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Netflix", "Gym"} {
+		if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+			Name: name, Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+		}); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+	if _, err := tdb.DB.Exec(`CREATE TRIGGER fail_inserts BEFORE INSERT ON subscriptions WHEN NEW.name = 'Broken'
+		BEGIN SELECT RAISE(ABORT, 'disk is full'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	csv := "Name,Amount,Billing Cycle,Next Renewal Date\nSpotify,9.99,monthly,2026-01-20\nBroken,5,monthly,2026-01-20\n"
+	preview, err := tdb.ImportService.Preview(ctx, strings.NewReader(csv), service.FormatCSV, service.ImportReplace)
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	result, err := tdb.ImportService.Apply(ctx, preview)
+	if err == nil {
+		t.Fatal("Apply() should fail")
+	}
+	if result != (service.ImportResult{}) {
+		t.Errorf("Apply() = %+v after a failure, want nothing counted", result)
+	}
+
+	// The old subscriptions are kept and the rows imported before the failure removed
+	subs, err := tdb.SubscriptionService.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var names []string
+	for _, sub := range subs {
+		names = append(names, sub.Name)
+	}
+	slices.Sort(names)
+	if strings.Join(names, ",") != "Gym,Netflix" {
+		t.Errorf("List() = %v after a failed replace, want Netflix and Gym", names)
+	}
+}
+
+func TestImportService_Preview_Errors(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		format service.ExportFormat
+		mode   service.ImportMode
+		data   string
+	}{
+		{"empty CSV", service.FormatCSV, service.ImportMerge, ""},
+		{"CSV without names", service.FormatCSV, service.ImportMerge, "Amount,Currency\n5,USD\n"},
+		{"JSON object", service.FormatJSON, service.ImportMerge, `{"name": "Netflix"}`},
+		{"format", service.FormatICS, service.ImportMerge, ""},
+		{"mode", service.FormatCSV, "append", "Name\nNetflix\n"},
+	}
+	for _, tt := range tests {
+		if _, err := tdb.ImportService.Preview(ctx, strings.NewReader(tt.data), tt.format, tt.mode); err == nil {
+			t.Errorf("Preview() should reject the %s", tt.name)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"subscription-tracker/internal/db"
+)
+
+// Default accounts of the plain-text accounting exports
+const (
+	DefaultExpenseAccount = "Expenses:Subscriptions" // Parent of an account per category
+	DefaultFundingAccount = "Assets:Checking"
+)
+
+// LedgerOptions configures a ledger, hledger or beancount export
+type LedgerOptions struct {
+	Periodic       bool      // Periodic rules (ledger, hledger) or custom entries (beancount) instead of dated transactions
+	From, To       time.Time // Days of the dated transactions, inclusive; the current calendar month when zero
+	ExpenseAccount string    // DefaultExpenseAccount when empty
+	FundingAccount string    // Account the subscriptions are paid from; DefaultFundingAccount when empty
+}
+
+// IsLedgerFormat reports whether format is one of the plain-text accounting formats
+func IsLedgerFormat(format ExportFormat) bool {
+	return format == FormatLedger || format == FormatHledger || format == FormatBeancount
+}
+
+// ExportLedger writes the subscriptions as a plain-text accounting journal
+// and returns the number of entries written, see ExportLedgerFrom
+func (s *ExportService) ExportLedger(ctx context.Context, w io.Writer, format ExportFormat, opts LedgerOptions) (int, error) {
+	return s.ExportLedgerFrom(ctx, w, format, opts, time.Now())
+}
+
+// ExportLedgerFrom writes the subscriptions as a ledger, hledger or beancount
+// journal. Dated transactions are the charges of the range as the spending
+// summary counts them: renewals already recorded at the amount paid (cleared)
+// and later ones at the price in effect on their date (pending). Periodic
+// entries start at the first renewal from referenceTime on, and a future price
+// change, pause or cancellation ends them. Each charge is booked to an account
+// per category under ExpenseAccount, in the subscription's own currency.
+func (s *ExportService) ExportLedgerFrom(ctx context.Context, w io.Writer, format ExportFormat, opts LedgerOptions, referenceTime time.Time) (int, error) {
+	if !IsLedgerFormat(format) {
+		return 0, fmt.Errorf("unsupported format: %s", format)
+	}
+	if opts.ExpenseAccount == "" {
+		opts.ExpenseAccount = DefaultExpenseAccount
+	}
+	if opts.FundingAccount == "" {
+		opts.FundingAccount = DefaultFundingAccount
+	}
+	for _, account := range []string{opts.ExpenseAccount, opts.FundingAccount} {
+		if err := validateAccount(format, account); err != nil {
+			return 0, err
+		}
+	}
+
+	names, err := categoryNames(ctx, s.queries)
+	if err != nil {
+		return 0, err
+	}
+	today := time.Date(referenceTime.Year(), referenceTime.Month(), referenceTime.Day(), 0, 0, 0, 0, time.UTC)
+	journal := &journalWriter{format: format, opts: opts, categories: names}
+	journal.comment(fmt.Sprintf("Subscriptions exported by subscription-tracker on %s", today.Format("2006-01-02")))
+
+	if opts.Periodic {
+		subs, err := s.queries.GetAllSubscriptionsForExport(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get subscriptions: %w", err)
+		}
+		history, err := loadPriceHistory(ctx, s.queries)
+		if err != nil {
+			return 0, err
+		}
+		for _, sub := range subs {
+			renewalDate, ok := parseNullDate(sub.NextRenewalDate)
+			if !ok {
+				continue
+			}
+			cycle, err := ParseBillingCycle(sub.BillingCycle)
+			if err != nil {
+				continue
+			}
+			for _, r := range recurringRenewals(sub, cycle, renewalDate, today, history) {
+				journal.periodic(sub, cycle, r)
+			}
+		}
+	} else {
+		from, to := opts.From, opts.To
+		if from.IsZero() {
+			from = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+		if to.IsZero() {
+			to = time.Date(from.Year(), from.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+		}
+		if to.Before(from) {
+			return 0, fmt.Errorf("the range must end after it starts")
+		}
+		if to.After(from.AddDate(0, MaxForecastMonths, 0)) {
+			return 0, fmt.Errorf("the range can cover at most %d months", MaxForecastMonths)
+		}
+		charges, err := s.chargesInRange(ctx, from, to)
+		if err != nil {
+			return 0, err
+		}
+		for _, charge := range charges {
+			journal.transaction(charge)
+		}
+	}
+
+	if _, err := io.WriteString(w, journal.b.String()); err != nil {
+		return 0, fmt.Errorf("failed to write journal: %w", err)
+	}
+	return journal.entries, nil
+}
+
+// chargesInRange returns the charges from start through end by date. Charges
+// before the day the payment ledger is recorded through come only from the
+// ledger, like in a spending summary; later renewals are projected from each
+// subscription's renewal date on.
+func (s *ExportService) chargesInRange(ctx context.Context, start, end time.Time) ([]Charge, error) {
+	subs, err := s.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	recorded, _ := recordedThrough(ctx, s.queries)
+
+	var charges []Charge
+	for _, sub := range subs {
+		renewalDate, ok := parseNullDate(sub.NextRenewalDate)
+		if !ok {
+			continue
+		}
+		cycle, err := ParseBillingCycle(sub.BillingCycle)
+		if err != nil {
+			continue
+		}
+		from := start
+		for _, date := range []time.Time{recorded, renewalDate} {
+			if date.After(from) {
+				from = date
+			}
+		}
+		for _, date := range cycle.DatesInPeriod(renewalDate, from, end) {
+			charges = append(charges, Charge{Subscription: sub, Date: date, Amount: BilledAmount(sub)})
+		}
+	}
+	charges = billedCharges(charges)
+
+	history, err := loadPriceHistory(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+	history.apply(charges)
+
+	paid, err := paidChargesInPeriod(ctx, s.queries, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments: %w", err)
+	}
+	charges = append(projectedCharges(charges, paid, recorded), paid...)
+
+	sort.SliceStable(charges, func(i, j int) bool {
+		if !charges[i].Date.Equal(charges[j].Date) {
+			return charges[i].Date.Before(charges[j].Date)
+		}
+		return charges[i].Subscription.Name < charges[j].Subscription.Name
+	})
+	return charges, nil
+}
+
+// beancountAccount matches the account names beancount accepts
+var beancountAccount = regexp.MustCompile(`^[A-Z][A-Za-z0-9-]*(:[A-Z0-9][A-Za-z0-9-]*)+$`)
+
+// validateAccount rejects account names the journal format can't read
+func validateAccount(format ExportFormat, account string) error {
+	if format == FormatBeancount && !beancountAccount.MatchString(account) {
+		return fmt.Errorf("invalid beancount account: %q (use names such as Assets:Checking)", account)
+	}
+	if strings.Contains(account, "  ") || strings.ContainsAny(account, "\t\n;") {
+		return fmt.Errorf("invalid account: %q", account)
+	}
+	return nil
+}
+
+// CategoryAccount returns the account of a category under parent, such as
+// Expenses:Subscriptions:DevTools for "dev tools"
+func CategoryAccount(parent, category string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(category, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	if b.Len() == 0 {
+		return parent
+	}
+	return parent + ":" + b.String()
+}
+
+// journalWriter builds a ledger, hledger or beancount journal
+type journalWriter struct {
+	b          strings.Builder
+	format     ExportFormat
+	opts       LedgerOptions
+	categories map[int64]string
+	entries    int
+}
+
+func (j *journalWriter) comment(text string) {
+	fmt.Fprintf(&j.b, "; %s\n\n", text)
+}
+
+// date formats a date the way the journal format writes it
+func (j *journalWriter) date(t time.Time) string {
+	if j.format == FormatLedger {
+		return t.Format("2006/01/02")
+	}
+	return t.Format("2006-01-02")
+}
+
+// postings writes the expense and the payment of one charge
+func (j *journalWriter) postings(sub db.Subscription, amount float64, currency string) {
+	account := CategoryAccount(j.opts.ExpenseAccount, CategoryName(sub, j.categories))
+	indent := "    "
+	if j.format == FormatBeancount {
+		indent = "  "
+	}
+	fmt.Fprintf(&j.b, "%s%-44s %10.2f %s\n", indent, account, amount, currency)
+	fmt.Fprintf(&j.b, "%s%s\n\n", indent, j.opts.FundingAccount)
+}
+
+// transaction writes a dated charge, cleared when it was paid and pending when projected
+func (j *journalWriter) transaction(charge Charge) {
+	j.entries++
+	flag := "!"
+	if charge.Paid {
+		flag = "*"
+	}
+	sub := charge.Subscription
+	if j.format == FormatBeancount {
+		fmt.Fprintf(&j.b, "%s %s %s %s\n", j.date(charge.Date), flag, beancountString(sub.Name), beancountString(sub.BillingCycle))
+	} else {
+		fmt.Fprintf(&j.b, "%s %s %s\n", j.date(charge.Date), flag, journalText(sub.Name))
+		if sub.BillingCycle != "" {
+			fmt.Fprintf(&j.b, "    ; %s subscription\n", journalText(sub.BillingCycle))
+		}
+	}
+	j.postings(sub, charge.Amount, sub.Currency)
+}
+
+// periodic writes a periodic rule, or a beancount custom entry, for one price of a subscription
+func (j *journalWriter) periodic(sub db.Subscription, cycle BillingCycle, r icsRenewal) {
+	j.entries++
+	switch j.format {
+	case FormatBeancount:
+		account := CategoryAccount(j.opts.ExpenseAccount, CategoryName(sub, j.categories))
+		fmt.Fprintf(&j.b, "%s custom \"subscription\" %s %s %.2f %s %s\n", j.date(r.start),
+			beancountString(sub.Name), beancountString(sub.BillingCycle), r.amount, r.currency, account)
+		if !r.end.IsZero() {
+			fmt.Fprintf(&j.b, "  until: %s\n", j.date(r.end))
+		}
+		j.b.WriteString("\n")
+		return
+	case FormatHledger:
+		fmt.Fprintf(&j.b, "~ %s  %s\n", j.period(cycle, r), journalText(sub.Name))
+		if cycle.Count > 1 && (cycle.Unit == UnitMonth || cycle.Unit == UnitYear) {
+			fmt.Fprintf(&j.b, "    ; renews on %s\n", r.start.Format("January 2"))
+		}
+	default:
+		fmt.Fprintf(&j.b, "~ %s\n", j.period(cycle, r))
+		fmt.Fprintf(&j.b, "    ; %s\n", journalText(sub.Name))
+	}
+	j.postings(sub, r.amount, r.currency)
+}
+
+// period returns the period expression of a periodic rule. hledger only starts
+// rules of whole weeks, months and years on the first day of one, so its rules
+// name the day of the week, month or year instead, and rules of several months
+// or years start on the first of the renewal's month.
+func (j *journalWriter) period(cycle BillingCycle, r icsRenewal) string {
+	start := r.start
+	var expr string
+	if j.format == FormatHledger {
+		switch {
+		case cycle.Unit == UnitDay:
+			expr = fmt.Sprintf("every %d days", cycle.Count)
+		case cycle.Unit == UnitWeek && cycle.Count == 1:
+			expr = fmt.Sprintf("every %s day of week", ordinal((int(start.Weekday())+6)%7+1))
+		case cycle.Unit == UnitWeek:
+			expr = fmt.Sprintf("every %d days", cycle.Count*7)
+		case cycle.Unit == UnitMonth && cycle.Count == 1:
+			expr = fmt.Sprintf("every %s day of month", ordinal(start.Day()))
+		case cycle.Unit == UnitYear && cycle.Count == 1:
+			expr = fmt.Sprintf("every %d/%d", int(start.Month()), start.Day())
+		default:
+			months := cycle.Count
+			if cycle.Unit == UnitYear {
+				months *= 12
+			}
+			expr = fmt.Sprintf("every %d months", months)
+			start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+	} else {
+		names := map[CycleUnit][2]string{UnitDay: {"Daily", "days"}, UnitWeek: {"Weekly", "weeks"}, UnitMonth: {"Monthly", "months"}, UnitYear: {"Yearly", "years"}}[cycle.Unit]
+		switch {
+		case cycle.Count == 1:
+			expr = names[0]
+		case cycle.Unit == UnitMonth && cycle.Count == 3:
+			expr = "Quarterly"
+		default:
+			expr = fmt.Sprintf("Every %d %s", cycle.Count, names[1])
+		}
+	}
+
+	expr += " from " + j.date(start)
+	if !r.end.IsZero() {
+		// The end of a period expression is exclusive
+		expr += " to " + j.date(r.end.AddDate(0, 0, 1))
+	}
+	return expr
+}
+
+// ordinal formats n as 1st, 2nd, 3rd, 4th...
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// journalText makes a payee or comment safe for ledger and hledger, which end it at a line break
+func journalText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// beancountString quotes a beancount string
+func beancountString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(journalText(s)) + `"`
+}
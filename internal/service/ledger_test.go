@@ -0,0 +1,261 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+// journalText collapses the alignment of a journal so it can be compared
+func journalText(journal string) string {
+	var lines []string
+	for _, line := range strings.Split(journal, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && !strings.HasPrefix(line, "; ") {
+			lines = append(lines, strings.Join(fields, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestExportService_ExportLedgerFrom(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	netflix, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15", Category: "streaming",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	for _, input := range []service.CreateSubscriptionInput{
+		{Name: "Figma", Amount: 120.00, Currency: "EUR", BillingCycle: "yearly", NextRenewalDate: "2026-06-10", Category: "dev tools"},
+		{Name: "Paper", Amount: 2.00, Currency: "USD", BillingCycle: "weekly", NextRenewalDate: "2026-01-05"},
+	} {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+	// Netflix costs 18 from March
+	if _, err := tdb.SubscriptionService.Update(ctx, service.UpdateSubscriptionInput{
+		ID: netflix.ID, Name: "Netflix", Amount: 18.00, Currency: "USD", BillingCycle: "monthly",
+		NextRenewalDate: "2026-01-15", Category: "streaming", PriceEffective: "2026-03-01",
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// Renewals up to January 20 are in the payment ledger
+	for _, day := range []time.Time{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)} {
+		if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, day); err != nil {
+			t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+		}
+	}
+	now := time.Date(2026, 1, 20, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		format service.ExportFormat
+		opts   service.LedgerOptions
+		count  int
+		want   string
+	}{
+		{
+			name:   "ledger transactions of this month",
+			format: service.FormatLedger,
+			count:  5,
+			want: `2026/01/05 * Paper
+; weekly subscription
+Expenses:Subscriptions:Uncategorized 2.00 USD
+Assets:Checking
+2026/01/12 * Paper
+; weekly subscription
+Expenses:Subscriptions:Uncategorized 2.00 USD
+Assets:Checking
+2026/01/15 * Netflix
+; monthly subscription
+Expenses:Subscriptions:Streaming 15.00 USD
+Assets:Checking
+2026/01/19 * Paper
+; weekly subscription
+Expenses:Subscriptions:Uncategorized 2.00 USD
+Assets:Checking
+2026/01/26 ! Paper
+; weekly subscription
+Expenses:Subscriptions:Uncategorized 2.00 USD
+Assets:Checking`,
+		},
+		{
+			name:   "beancount transactions of a range",
+			format: service.FormatBeancount,
+			opts: service.LedgerOptions{
+				From: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC),
+				ExpenseAccount: "Expenses:Online", FundingAccount: "Liabilities:CreditCard",
+			},
+			count: 4,
+			want: `2026-03-02 ! "Paper" "weekly"
+Expenses:Online:Uncategorized 2.00 USD
+Liabilities:CreditCard
+2026-03-09 ! "Paper" "weekly"
+Expenses:Online:Uncategorized 2.00 USD
+Liabilities:CreditCard
+2026-03-15 ! "Netflix" "monthly"
+Expenses:Online:Streaming 18.00 USD
+Liabilities:CreditCard
+2026-03-16 ! "Paper" "weekly"
+Expenses:Online:Uncategorized 2.00 USD
+Liabilities:CreditCard`,
+		},
+		{
+			name:   "ledger periodic",
+			format: service.FormatLedger,
+			opts:   service.LedgerOptions{Periodic: true},
+			count:  4,
+			want: `~ Yearly from 2026/06/10
+; Figma
+Expenses:Subscriptions:DevTools 120.00 EUR
+Assets:Checking
+~ Monthly from 2026/02/15 to 2026/03/01
+; Netflix
+Expenses:Subscriptions:Streaming 15.00 USD
+Assets:Checking
+~ Monthly from 2026/03/15
+; Netflix
+Expenses:Subscriptions:Streaming 18.00 USD
+Assets:Checking
+~ Weekly from 2026/01/26
+; Paper
+Expenses:Subscriptions:Uncategorized 2.00 USD
+Assets:Checking`,
+		},
+		{
+			name:   "hledger periodic",
+			format: service.FormatHledger,
+			opts:   service.LedgerOptions{Periodic: true},
+			count:  4,
+			want: `~ every 6/10 from 2026-06-10 Figma
+Expenses:Subscriptions:DevTools 120.00 EUR
+Assets:Checking
+~ every 15th day of month from 2026-02-15 to 2026-03-01 Netflix
+Expenses:Subscriptions:Streaming 15.00 USD
+Assets:Checking
+~ every 15th day of month from 2026-03-15 Netflix
+Expenses:Subscriptions:Streaming 18.00 USD
+Assets:Checking
+~ every 1st day of week from 2026-01-26 Paper
+Expenses:Subscriptions:Uncategorized 2.00 USD
+Assets:Checking`,
+		},
+		{
+			name:   "beancount custom entries",
+			format: service.FormatBeancount,
+			opts:   service.LedgerOptions{Periodic: true},
+			count:  4,
+			want: `2026-06-10 custom "subscription" "Figma" "yearly" 120.00 EUR Expenses:Subscriptions:DevTools
+2026-02-15 custom "subscription" "Netflix" "monthly" 15.00 USD Expenses:Subscriptions:Streaming
+until: 2026-02-28
+2026-03-15 custom "subscription" "Netflix" "monthly" 18.00 USD Expenses:Subscriptions:Streaming
+2026-01-26 custom "subscription" "Paper" "weekly" 2.00 USD Expenses:Subscriptions:Uncategorized`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			count, err := tdb.ExportService.ExportLedgerFrom(ctx, &buf, tt.format, tt.opts, now)
+			if err != nil {
+				t.Fatalf("ExportLedgerFrom() error = %v", err)
+			}
+			if count != tt.count {
+				t.Errorf("ExportLedgerFrom() = %d entries, want %d", count, tt.count)
+			}
+			if got := journalText(buf.String()); got != tt.want {
+				t.Errorf("ExportLedgerFrom() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []struct {
+		name   string
+		format service.ExportFormat
+		opts   service.LedgerOptions
+	}{
+		{"lowercase beancount account", service.FormatBeancount, service.LedgerOptions{FundingAccount: "assets:checking"}},
+		{"account with a comment", service.FormatLedger, service.LedgerOptions{ExpenseAccount: "Expenses ; note"}},
+		{"reversed range", service.FormatLedger, service.LedgerOptions{From: now, To: now.AddDate(0, 0, -1)}},
+		{"format", service.FormatCSV, service.LedgerOptions{}},
+	} {
+		if _, err := tdb.ExportService.ExportLedgerFrom(ctx, &bytes.Buffer{}, bad.format, bad.opts, now); err == nil {
+			t.Errorf("ExportLedgerFrom() should reject the %s", bad.name)
+		}
+	}
+}
+
+func TestExportService_ExportLedgerPast(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, input := range []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-31"},
+		{Name: "Prime", Amount: 139.00, Currency: "USD", BillingCycle: "yearly", NextRenewalDate: "2026-02-14"},
+	} {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name     string
+		from, to time.Time
+		want     string
+	}{
+		{
+			name: "before the first renewals",
+			from: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "a recorded month",
+			from: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+			want: `2026/03/31 * Netflix
+; monthly subscription
+Expenses:Subscriptions:Uncategorized 10.00 USD
+Assets:Checking`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			count, err := tdb.ExportService.ExportLedgerFrom(ctx, &buf, service.FormatLedger, service.LedgerOptions{From: tt.from, To: tt.to}, now)
+			if err != nil {
+				t.Fatalf("ExportLedgerFrom() error = %v", err)
+			}
+			if got := journalText(buf.String()); got != tt.want {
+				t.Errorf("journal =\n%s\nwant\n%s", got, tt.want)
+			}
+			if want := strings.Count(tt.want, " * "); count != want {
+				t.Errorf("ExportLedgerFrom() = %d, want %d", count, want)
+			}
+		})
+	}
+}
+
+func TestCategoryAccount(t *testing.T) {
+	tests := []struct {
+		category string
+		want     string
+	}{
+		{"streaming", "Expenses:Subscriptions:Streaming"},
+		{"dev tools", "Expenses:Subscriptions:DevTools"},
+		{"news & magazines", "Expenses:Subscriptions:NewsMagazines"},
+		{"", "Expenses:Subscriptions"},
+	}
+	for _, tt := range tests {
+		if got := service.CategoryAccount(service.DefaultExpenseAccount, tt.category); got != tt.want {
+			t.Errorf("CategoryAccount(%q) = %q, want %q", tt.category, got, tt.want)
+		}
+	}
+}
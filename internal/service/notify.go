@@ -0,0 +1,152 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notifier delivers reminders
+type Notifier interface {
+	// Name identifies the notifier in errors
+	Name() string
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// appName is the sender of desktop notifications
+const appName = "subscription-tracker"
+
+// DesktopNotifier shows reminders as desktop notifications with notify-send,
+// or through D-Bus with gdbus where notify-send isn't installed
+type DesktopNotifier struct{}
+
+// Name identifies the notifier in errors
+func (DesktopNotifier) Name() string { return "desktop" }
+
+// Notify shows the reminder as a desktop notification
+func (DesktopNotifier) Notify(ctx context.Context, r Reminder) error {
+	var cmd *exec.Cmd
+	if path, err := exec.LookPath("notify-send"); err == nil {
+		cmd = exec.CommandContext(ctx, path, "--app-name="+appName, r.Title(), r.Message())
+	} else if path, err := exec.LookPath("gdbus"); err == nil {
+		cmd = exec.CommandContext(ctx, path, "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			gvariantString(appName), "0", gvariantString(""), gvariantString(r.Title()), gvariantString(r.Message()),
+			"[]", "{}", "-1")
+	} else {
+		return errors.New("neither notify-send nor gdbus is installed")
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return commandError(err, out)
+	}
+	return nil
+}
+
+// gvariantString quotes s as a GVariant string for gdbus
+func gvariantString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// EmailNotifier mails reminders through an SMTP server
+type EmailNotifier struct {
+	Addr     string // host:port of the server
+	Username string // Empty to send without authenticating
+	Password string
+	From     string
+	To       []string
+}
+
+// Name identifies the notifier in errors
+func (n EmailNotifier) Name() string { return "email" }
+
+// Notify mails the reminder to every recipient. Authentication needs TLS
+// unless the server is on localhost.
+func (n EmailNotifier) Notify(ctx context.Context, r Reminder) error {
+	if len(n.To) == 0 {
+		return errors.New("no recipients")
+	}
+	var auth smtp.Auth
+	if n.Username != "" {
+		host := n.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", n.Username, n.Password, host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", r.Title()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(r.Message())
+	msg.WriteString("\r\n")
+
+	if err := smtp.SendMail(n.Addr, auth, n.From, n.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// CommandNotifier runs a shell command for each reminder. The reminder is in
+// its environment, see Env, and its message is on standard input.
+type CommandNotifier struct {
+	Command string
+}
+
+// Name identifies the notifier in errors
+func (n CommandNotifier) Name() string { return "command" }
+
+// Notify runs the command for the reminder
+func (n CommandNotifier) Notify(ctx context.Context, r Reminder) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", n.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", n.Command)
+	}
+	cmd.Env = append(os.Environ(), r.Env()...)
+	cmd.Stdin = strings.NewReader(r.Message() + "\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return commandError(err, out)
+	}
+	return nil
+}
+
+// Env describes the reminder as environment variables for CommandNotifier
+func (r Reminder) Env() []string {
+	return []string{
+		"SUBSCRIPTION_ID=" + strconv.FormatInt(r.Subscription.ID, 10),
+		"SUBSCRIPTION_NAME=" + r.Subscription.Name,
+		"SUBSCRIPTION_AMOUNT=" + strconv.FormatFloat(r.Amount, 'f', 2, 64),
+		"SUBSCRIPTION_CURRENCY=" + r.Currency,
+		"SUBSCRIPTION_CYCLE=" + r.Subscription.BillingCycle,
+		"RENEWAL_DATE=" + r.RenewalDate.Format("2006-01-02"),
+		"DAYS_LEFT=" + strconv.Itoa(r.DaysLeft),
+		"REMINDER_TITLE=" + r.Title(),
+		"REMINDER_MESSAGE=" + r.Message(),
+	}
+}
+
+// commandError adds what a failed command printed to its error
+func commandError(err error, out []byte) error {
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// Payment sources
+const (
+	PaymentRenewal = "renewal" // Recorded when a renewal date passes
+	PaymentManual  = "manual"  // Entered by hand, for one-off or adjusted charges
+)
+
+// PaymentService handles the ledger of charges that actually happened
+type PaymentService struct {
+	queries *db.Queries
+}
+
+// NewPaymentService creates a new payment service
+func NewPaymentService(queries *db.Queries) *PaymentService {
+	return &PaymentService{queries: queries}
+}
+
+// PaymentInput represents input for recording a payment by hand
+type PaymentInput struct {
+	SubscriptionID int64   // 0 for a one-off charge
+	Name           string  // Defaults to the subscription's name
+	Amount         float64 // Negative for a refund
+	Currency       string  // Defaults to the subscription's currency
+	Date           string  // YYYY-MM-DD, default today
+	Note           string
+}
+
+// List returns the payments made from one date to another (YYYY-MM-DD,
+// inclusive), oldest first. Empty dates leave the range open.
+func (s *PaymentService) List(ctx context.Context, from, to string) ([]db.Payment, error) {
+	for _, date := range []string{from, to} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("invalid date format, use YYYY-MM-DD: %w", err)
+		}
+	}
+	if to == "" {
+		to = "9999-12-31"
+	}
+
+	return s.queries.ListPaymentsInPeriod(ctx, db.ListPaymentsInPeriodParams{StartDate: from, EndDate: to})
+}
+
+// ListForSubscription returns the payments of a subscription, newest first
+func (s *PaymentService) ListForSubscription(ctx context.Context, subscriptionID int64) ([]db.Payment, error) {
+	return s.queries.ListSubscriptionPayments(ctx, sql.NullInt64{Int64: subscriptionID, Valid: true})
+}
+
+// Add records a payment by hand
+func (s *PaymentService) Add(ctx context.Context, input PaymentInput) (db.Payment, error) {
+	if input.Amount == 0 {
+		return db.Payment{}, fmt.Errorf("amount is required")
+	}
+	date, err := effectiveDate(input.Date)
+	if err != nil {
+		return db.Payment{}, err
+	}
+
+	params := db.CreatePaymentParams{
+		Name:     strings.TrimSpace(input.Name),
+		Amount:   input.Amount,
+		Currency: strings.ToUpper(strings.TrimSpace(input.Currency)),
+		PaidOn:   date,
+		Source:   PaymentManual,
+		Note:     strings.TrimSpace(input.Note),
+	}
+
+	if input.SubscriptionID != 0 {
+		sub, err := s.queries.GetSubscription(ctx, input.SubscriptionID)
+		if err != nil {
+			return db.Payment{}, fmt.Errorf("subscription %d not found: %w", input.SubscriptionID, err)
+		}
+		params.SubscriptionID = sql.NullInt64{Int64: sub.ID, Valid: true}
+		if params.Name == "" {
+			params.Name = sub.Name
+		}
+		if params.Currency == "" {
+			params.Currency = sub.Currency
+		}
+	}
+
+	if params.Name == "" {
+		return db.Payment{}, fmt.Errorf("name is required")
+	}
+	if params.Currency == "" {
+		params.Currency = DefaultBaseCurrency
+	}
+
+	return s.queries.CreatePayment(ctx, params)
+}
+
+// Delete removes a payment from the ledger
+func (s *PaymentService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.queries.GetPayment(ctx, id); err != nil {
+		return fmt.Errorf("payment %d not found: %w", id, err)
+	}
+	return s.queries.DeletePayment(ctx, id)
+}
+
+// recordedThrough returns the day renewals have been recorded up to, not
+// including it. It returns false until renewals are first recorded.
+func recordedThrough(ctx context.Context, queries *db.Queries) (time.Time, bool) {
+	value, err := queries.GetConfig(ctx, ConfigKeyPaymentsRecordedThrough)
+	if err != nil {
+		return time.Time{}, false
+	}
+	date, err := time.Parse("2006-01-02", value)
+	return date, err == nil
+}
+
+// setRecordedThrough stores the day renewals have been recorded up to
+func setRecordedThrough(ctx context.Context, queries *db.Queries, date time.Time) error {
+	return queries.SetConfig(ctx, db.SetConfigParams{
+		Key:   ConfigKeyPaymentsRecordedThrough,
+		Value: date.Format("2006-01-02"),
+	})
+}
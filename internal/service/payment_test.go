@@ -0,0 +1,311 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestAdvanceRenewalDatesFrom_RecordsPayments(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15"},
+		{Name: "Newspaper", Amount: 2.00, Currency: "EUR", BillingCycle: "weekly", NextRenewalDate: "2026-01-05"},
+		{Name: "Music", Amount: 9.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", TrialEndDate: "2026-02-10"},
+	}
+	ids := make(map[string]int64)
+	for _, input := range inputs {
+		sub, err := tdb.SubscriptionService.Create(ctx, input)
+		if err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+		ids[sub.Name] = sub.ID
+	}
+
+	// Nothing has renewed yet on the first run
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Pause(ctx, ids["Newspaper"], "2026-01-20"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	// Running twice on the same day records nothing more
+	for range 2 {
+		if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+		}
+	}
+
+	payments, err := tdb.PaymentService.List(ctx, "", "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := []struct {
+		name     string
+		date     string
+		amount   float64
+		currency string
+	}{
+		{"Newspaper", "2026-01-05", 2, "EUR"},
+		{"Newspaper", "2026-01-12", 2, "EUR"},
+		{"Netflix", "2026-01-15", 15, "USD"},
+		{"Newspaper", "2026-01-19", 2, "EUR"},
+		{"Music", "2026-02-10", 9, "USD"},
+		{"Netflix", "2026-02-15", 15, "USD"},
+	}
+	if len(payments) != len(want) {
+		t.Fatalf("List() = %+v, want %d payments", payments, len(want))
+	}
+	for i, w := range want {
+		p := payments[i]
+		if p.Name != w.name || p.PaidOn != w.date || p.Amount != w.amount || p.Currency != w.currency || p.Source != service.PaymentRenewal {
+			t.Errorf("payment %d = %s %.2f %s on %s (%s), want %s %.2f %s on %s", i,
+				p.Name, p.Amount, p.Currency, p.PaidOn, p.Source, w.name, w.amount, w.currency, w.date)
+		}
+		if p.SubscriptionID.Int64 != ids[w.name] {
+			t.Errorf("payment %d subscription = %d, want %d", i, p.SubscriptionID.Int64, ids[w.name])
+		}
+	}
+}
+
+func TestAdvanceRenewalDatesFrom_FirstRun(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, input := range []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-10-15"},
+		{Name: "Spotify", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-10-05"},
+	} {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+
+	// A fresh database records the renewals that already passed this month
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+	payments, err := tdb.PaymentService.List(ctx, "", "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(payments) != 2 || payments[0].PaidOn != "2026-10-05" || payments[1].PaidOn != "2026-10-15" {
+		t.Errorf("List() = %+v, want Spotify on 10-05 and Netflix on 10-15", payments)
+	}
+
+	// A subscription added later with a renewal before the recorded-through
+	// day is neither projected nor backfilled there
+	hulu, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Hulu", Amount: 8.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-10-10",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	// Month 11 is the period of October 2026
+	for run := 0; run < 2; run++ {
+		summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, 11)
+		if err != nil {
+			t.Fatalf("CalculateForMonth() error = %v", err)
+		}
+		if !almostEqual(summary.GrandTotal, 25) || len(summary.Charges) != 2 {
+			t.Errorf("GrandTotal = %.2f in %d charges, want the 25.00 paid in 2", summary.GrandTotal, len(summary.Charges))
+		}
+		if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+		}
+	}
+	if payments, _ := tdb.PaymentService.List(ctx, "", ""); len(payments) != 2 {
+		t.Errorf("List() = %+v, want Hulu's backdated renewal not recorded", payments)
+	}
+	if sub, _ := tdb.SubscriptionService.Get(ctx, hulu.ID); sub.NextRenewalDate.String != "2026-11-10" {
+		t.Errorf("Hulu renews %s, want 2026-11-10", sub.NextRenewalDate.String)
+	}
+
+	// Nothing is made up before the subscriptions' first renewals
+	summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2025, 6)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+	if len(summary.Charges) != 0 {
+		t.Errorf("Charges = %+v in June 2025, want none", summary.Charges)
+	}
+}
+
+func TestSpendingService_PaidInBillingPeriod(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	netflix, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-31",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+	if sub, _ := tdb.SubscriptionService.Get(ctx, netflix.ID); sub.NextRenewalDate.String != "2026-10-31" {
+		t.Errorf("NextRenewalDate = %s, want the 31st kept", sub.NextRenewalDate.String)
+	}
+
+	// Months 4 and 8 are the periods of March and July, renewed on the 31st
+	for _, month := range []int{4, 8} {
+		summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, month)
+		if err != nil {
+			t.Fatalf("CalculateForMonth() error = %v", err)
+		}
+		if !almostEqual(summary.GrandTotal, 10) || len(summary.Charges) != 1 || !summary.Charges[0].Paid {
+			t.Errorf("month %d = %.2f in %+v, want the one renewal paid", month, summary.GrandTotal, summary.Charges)
+		}
+	}
+
+	// A payment entered on another day of the billing period covers the renewal
+	spotify, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Spotify", Amount: 12.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-11-28",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if _, err := tdb.PaymentService.Add(ctx, service.PaymentInput{SubscriptionID: spotify.ID, Amount: 11.00, Date: "2026-11-30"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, 12)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+	var spotifyCharges []service.Charge
+	for _, charge := range summary.Charges {
+		if charge.Subscription.ID == spotify.ID {
+			spotifyCharges = append(spotifyCharges, charge)
+		}
+	}
+	if len(spotifyCharges) != 1 || !spotifyCharges[0].Paid || spotifyCharges[0].Amount != 11.00 {
+		t.Errorf("Spotify charges = %+v, want only the payment of 11.00", spotifyCharges)
+	}
+}
+
+func TestPaymentService_AddDelete(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "EUR", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	if _, err := tdb.PaymentService.Add(ctx, service.PaymentInput{SubscriptionID: sub.ID}); err == nil {
+		t.Error("Add() should require an amount")
+	}
+	if _, err := tdb.PaymentService.Add(ctx, service.PaymentInput{Amount: 5}); err == nil {
+		t.Error("Add() should require a name for a one-off charge")
+	}
+	if _, err := tdb.PaymentService.Add(ctx, service.PaymentInput{Name: "Fee", Amount: 5, Date: "2026-02-30"}); err == nil {
+		t.Error("Add() should reject an invalid date")
+	}
+
+	// A charge of a subscription defaults to its name and currency
+	adjusted, err := tdb.PaymentService.Add(ctx, service.PaymentInput{SubscriptionID: sub.ID, Amount: 17.50, Date: "2026-01-15", Note: "tax"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if adjusted.Name != "Netflix" || adjusted.Currency != "EUR" || adjusted.Source != service.PaymentManual {
+		t.Errorf("Add() = %+v, want a manual Netflix payment in EUR", adjusted)
+	}
+	oneOff, err := tdb.PaymentService.Add(ctx, service.PaymentInput{Name: "Conference", Amount: 300, Currency: "usd", Date: "2026-01-20"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if oneOff.SubscriptionID.Valid || oneOff.Currency != "USD" {
+		t.Errorf("Add() = %+v, want a one-off payment in USD", oneOff)
+	}
+
+	payments, err := tdb.PaymentService.ListForSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("ListForSubscription() error = %v", err)
+	}
+	if len(payments) != 1 || payments[0].ID != adjusted.ID {
+		t.Errorf("ListForSubscription() = %+v, want the Netflix payment", payments)
+	}
+
+	// Deleting the subscription keeps its payments
+	if err := tdb.SubscriptionService.Delete(ctx, sub.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	payments, err = tdb.PaymentService.List(ctx, "2026-01-01", "2026-01-31")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(payments) != 2 || payments[0].SubscriptionID.Valid {
+		t.Errorf("List() = %+v, want both payments without a subscription", payments)
+	}
+
+	if err := tdb.PaymentService.Delete(ctx, oneOff.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := tdb.PaymentService.Delete(ctx, oneOff.ID); err == nil {
+		t.Error("Delete() should fail for a missing payment")
+	}
+}
+
+func TestSpendingService_PaymentLedger(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	netflix, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+
+	// The price goes up after the January renewal was paid
+	if _, err := tdb.SubscriptionService.Update(ctx, service.UpdateSubscriptionInput{
+		ID: netflix.ID, Name: "Netflix", Amount: 18.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-02-15", PriceEffective: "2026-02-01",
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := tdb.PaymentService.Add(ctx, service.PaymentInput{Name: "Conference", Amount: 300, Currency: "USD", Date: "2026-01-20"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	tests := []struct {
+		month int
+		want  float64
+		paid  []bool
+	}{
+		// January 2026 is taken from the ledger, at the old price
+		{month: 2, want: 15 + 300, paid: []bool{true, true}},
+		// February 2026 is projected at the current price
+		{month: 3, want: 18, paid: []bool{false}},
+	}
+	for _, tt := range tests {
+		summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, tt.month)
+		if err != nil {
+			t.Fatalf("CalculateForMonth() error = %v", err)
+		}
+		if !almostEqual(summary.GrandTotal, tt.want) {
+			t.Errorf("month %d GrandTotal = %.2f, want %.2f", tt.month, summary.GrandTotal, tt.want)
+		}
+		if len(summary.Charges) != len(tt.paid) {
+			t.Fatalf("month %d charges = %+v, want %d", tt.month, summary.Charges, len(tt.paid))
+		}
+		for i, charge := range summary.Charges {
+			if charge.Paid != tt.paid[i] {
+				t.Errorf("month %d charge %s paid = %v, want %v", tt.month, charge.Subscription.Name, charge.Paid, tt.paid[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// PriceChange is a change of a subscription's amount or currency
+type PriceChange struct {
+	db.PriceChange
+	Name string // Name of the subscription
+}
+
+// Difference returns how much the price went up, negative when it went down.
+// It returns false when the currency changed, since the prices can't be compared.
+func (c PriceChange) Difference() (float64, bool) {
+	if c.Currency != c.OldCurrency {
+		return 0, false
+	}
+	return c.Amount - c.OldAmount, true
+}
+
+// Percent returns the difference as a percentage of the old price, see Difference
+func (c PriceChange) Percent() (float64, bool) {
+	diff, ok := c.Difference()
+	if !ok || c.OldAmount == 0 {
+		return 0, false
+	}
+	return diff / c.OldAmount * 100, true
+}
+
+// PriceHistory returns the price changes of a subscription, oldest first
+func (s *SubscriptionService) PriceHistory(ctx context.Context, id int64) ([]db.PriceChange, error) {
+	return s.queries.ListSubscriptionPriceChanges(ctx, id)
+}
+
+// PriceChanges returns the price changes of every subscription that took
+// effect from one date to another (YYYY-MM-DD, inclusive), oldest first
+func (s *SubscriptionService) PriceChanges(ctx context.Context, from, to string) ([]PriceChange, error) {
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("invalid date format, use YYYY-MM-DD: %w", err)
+		}
+	}
+
+	rows, err := s.queries.ListPriceChangesInPeriod(ctx, db.ListPriceChangesInPeriodParams{StartDate: from, EndDate: to})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price changes: %w", err)
+	}
+
+	changes := make([]PriceChange, len(rows))
+	for i, row := range rows {
+		changes[i] = PriceChange{
+			PriceChange: db.PriceChange{
+				ID:             row.ID,
+				SubscriptionID: row.SubscriptionID,
+				OldAmount:      row.OldAmount,
+				OldCurrency:    row.OldCurrency,
+				Amount:         row.Amount,
+				Currency:       row.Currency,
+				EffectiveDate:  row.EffectiveDate,
+				CreatedAt:      row.CreatedAt,
+			},
+			Name: row.Name,
+		}
+	}
+	return changes, nil
+}
+
+// priceChange checks a change from the price of sub to amount in currency,
+// effective from date (YYYY-MM-DD, default today), and returns it for
+// recordPriceChange once the subscription is updated; nil if the price is the same
+func (s *SubscriptionService) priceChange(ctx context.Context, sub db.Subscription, amount float64, currency, date string) (*db.CreatePriceChangeParams, error) {
+	if amount == sub.Amount && currency == sub.Currency {
+		return nil, nil
+	}
+
+	date, err := effectiveDate(date)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.queries.ListSubscriptionPriceChanges(ctx, sub.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price changes: %w", err)
+	}
+	// The history only reads correctly in order, see priceHistory.priceOn
+	if n := len(changes); n > 0 && date < changes[n-1].EffectiveDate {
+		return nil, fmt.Errorf("price change must take effect on or after the last one, on %s", changes[n-1].EffectiveDate)
+	}
+
+	return &db.CreatePriceChangeParams{
+		SubscriptionID: sub.ID,
+		OldAmount:      sub.Amount,
+		OldCurrency:    sub.Currency,
+		Amount:         amount,
+		Currency:       currency,
+		EffectiveDate:  date,
+	}, nil
+}
+
+// recordPriceChange adds change, from priceChange, to the price history
+func (s *SubscriptionService) recordPriceChange(ctx context.Context, change *db.CreatePriceChangeParams) error {
+	if change == nil {
+		return nil
+	}
+	if _, err := s.queries.CreatePriceChange(ctx, *change); err != nil {
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+	return nil
+}
+
+// priceHistory holds the price changes of each subscription by ID, oldest first
+type priceHistory map[int64][]db.PriceChange
+
+// loadPriceHistory returns the price changes of every subscription
+func loadPriceHistory(ctx context.Context, queries *db.Queries) (priceHistory, error) {
+	changes, err := queries.ListPriceChanges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price changes: %w", err)
+	}
+	history := make(priceHistory)
+	for _, change := range changes {
+		history[change.SubscriptionID] = append(history[change.SubscriptionID], change)
+	}
+	return history, nil
+}
+
+// priceOn returns the amount and currency sub is billed on date: the old price
+// of the first change that took effect after date, or its billed amount now.
+// A trial with a price after trial is billed that price from its first charge.
+func (h priceHistory) priceOn(sub db.Subscription, date time.Time) (float64, string) {
+	if sub.Status == StatusTrial && sub.TrialPrice.Valid {
+		return BilledAmount(sub), sub.Currency
+	}
+	day := date.Format("2006-01-02")
+	for _, change := range h[sub.ID] {
+		if day < change.EffectiveDate {
+			return change.OldAmount, change.OldCurrency
+		}
+	}
+	return BilledAmount(sub), sub.Currency
+}
+
+// apply sets the amount and currency of each charge to the price in effect on its date
+func (h priceHistory) apply(charges []Charge) {
+	for i := range charges {
+		charges[i].Amount, charges[i].Subscription.Currency = h.priceOn(charges[i].Subscription, charges[i].Date)
+	}
+}
@@ -0,0 +1,177 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestSubscriptionService_PriceHistory(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	update := service.UpdateSubscriptionInput{
+		ID: sub.ID, Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+	}
+
+	// Changing anything but the price records nothing
+	update.Category = "streaming"
+	if _, err := tdb.SubscriptionService.Update(ctx, update); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	update.Amount, update.PriceEffective = 18.00, "2026-03-01"
+	if _, err := tdb.SubscriptionService.Update(ctx, update); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	update.Amount, update.Currency, update.PriceEffective = 17.00, "EUR", "2026-02-01"
+	if _, err := tdb.SubscriptionService.Update(ctx, update); err == nil {
+		t.Error("Update() should reject a price change before the last one")
+	}
+	update.PriceEffective = "2026-09-01"
+	if _, err := tdb.SubscriptionService.Update(ctx, update); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	history, err := tdb.SubscriptionService.PriceHistory(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("PriceHistory() error = %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("PriceHistory() = %+v, want 2 changes", history)
+	}
+	if history[0].OldAmount != 15 || history[0].Amount != 18 || history[0].EffectiveDate != "2026-03-01" {
+		t.Errorf("PriceHistory()[0] = %+v, want 15 -> 18 from 2026-03-01", history[0])
+	}
+	if history[1].OldCurrency != "USD" || history[1].Currency != "EUR" {
+		t.Errorf("PriceHistory()[1] = %+v, want USD -> EUR", history[1])
+	}
+
+	changes, err := tdb.SubscriptionService.PriceChanges(ctx, "2026-01-01", "2026-06-30")
+	if err != nil {
+		t.Fatalf("PriceChanges() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].Name != "Netflix" {
+		t.Fatalf("PriceChanges() = %+v, want the Netflix increase", changes)
+	}
+	if diff, ok := changes[0].Difference(); !ok || !almostEqual(diff, 3) {
+		t.Errorf("Difference() = %.2f, %v, want 3.00", diff, ok)
+	}
+	if percent, ok := changes[0].Percent(); !ok || !almostEqual(percent, 20) {
+		t.Errorf("Percent() = %.2f, %v, want 20.00", percent, ok)
+	}
+	if _, ok := (service.PriceChange{PriceChange: history[1]}).Difference(); ok {
+		t.Error("Difference() should not compare prices in different currencies")
+	}
+
+	// Deleting the subscription deletes its history
+	if err := tdb.SubscriptionService.Delete(ctx, sub.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if history, _ := tdb.SubscriptionService.PriceHistory(ctx, sub.ID); len(history) != 0 {
+		t.Errorf("PriceHistory() = %+v after Delete(), want none", history)
+	}
+}
+
+func TestSubscriptionService_PriceHistoryFailedUpdate(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if _, err := tdb.DB.Exec(`CREATE TRIGGER fail_updates BEFORE UPDATE ON subscriptions
+		BEGIN SELECT RAISE(ABORT, 'disk is full'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+
+	// A price that wasn't saved isn't in the history either
+	if _, err := tdb.SubscriptionService.Update(ctx, service.UpdateSubscriptionInput{
+		ID: sub.ID, Name: "Netflix", Amount: 18.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+	}); err == nil {
+		t.Fatal("Update() should fail")
+	}
+	if history, _ := tdb.SubscriptionService.PriceHistory(ctx, sub.ID); len(history) != 0 {
+		t.Errorf("PriceHistory() = %+v after a failed Update(), want none", history)
+	}
+}
+
+func TestSpendingService_PriceHistory(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if err := tdb.CurrencyService.SetRate(ctx, "EUR", 0.5); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+
+	// A price increase from March, and an announced switch to euros from June
+	update := service.UpdateSubscriptionInput{
+		ID: sub.ID, Name: "Netflix", Amount: 12.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15", PriceEffective: "2026-03-01",
+	}
+	if _, err := tdb.SubscriptionService.Update(ctx, update); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	update.Amount, update.Currency, update.PriceEffective = 7.00, "EUR", "2026-06-01"
+	if _, err := tdb.SubscriptionService.Update(ctx, update); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	tests := []struct {
+		month    int
+		amount   float64
+		currency string
+		total    float64
+	}{
+		// Month 3 is the period of February 2026, month 4 March, and so on
+		{month: 3, amount: 10, currency: "USD", total: 10},
+		{month: 4, amount: 12, currency: "USD", total: 12},
+		{month: 6, amount: 12, currency: "USD", total: 12},
+		{month: 7, amount: 7, currency: "EUR", total: 14},
+	}
+	for _, tt := range tests {
+		summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, tt.month)
+		if err != nil {
+			t.Fatalf("CalculateForMonth() error = %v", err)
+		}
+		if len(summary.Charges) != 1 {
+			t.Fatalf("month %d charges = %+v, want 1", tt.month, summary.Charges)
+		}
+		charge := summary.Charges[0]
+		if charge.Amount != tt.amount || charge.Subscription.Currency != tt.currency || !almostEqual(summary.GrandTotal, tt.total) {
+			t.Errorf("month %d = %.2f %s (total %.2f), want %.2f %s (total %.2f)", tt.month,
+				charge.Amount, charge.Subscription.Currency, summary.GrandTotal, tt.amount, tt.currency, tt.total)
+		}
+	}
+
+	// Renewals are recorded at the price of their day
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+	payments, err := tdb.PaymentService.List(ctx, "", "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(payments) != 3 || payments[0].Amount != 10 || payments[1].Amount != 10 || payments[2].Amount != 12 {
+		t.Errorf("List() = %+v, want 10.00 in January and February and 12.00 in March", payments)
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"subscription-tracker/internal/db"
+)
+
+// Defaults of recurring charge detection
+const (
+	DefaultMinOccurrences = 3    // Charges needed to call a payee recurring; yearly and semi-annual ones need 2
+	amountTolerance       = 0.15 // How far charges of one subscription may differ from the smallest, for price changes and exchange rates
+	regularShare          = 0.75 // Share of the gaps between charges that must match the cycle
+)
+
+// StatementService finds recurring charges in bank statements and proposes them as subscriptions
+type StatementService struct {
+	queries       *db.Queries
+	subscriptions *SubscriptionService
+	config        *ConfigService
+}
+
+// NewStatementService creates a new statement service
+func NewStatementService(queries *db.Queries, subscriptions *SubscriptionService, config *ConfigService) *StatementService {
+	return &StatementService{queries: queries, subscriptions: subscriptions, config: config}
+}
+
+// RecurringCandidate is a series of similar charges to one payee at a regular interval
+type RecurringCandidate struct {
+	Name            string // Payee, cleaned up for a subscription name
+	Amount          float64
+	Currency        string
+	BillingCycle    string
+	NextRenewalDate string // YYYY-MM-DD, the first expected charge after today
+	Occurrences     int
+	FirstCharge     time.Time
+	LastCharge      time.Time
+	Confidence      float64 // Share of the gaps between charges that match the cycle, 0-1
+	ExistingID      int64   // A subscription that seems to track it already, or 0
+	Transactions    []Transaction
+}
+
+// Input returns the subscription to create for the candidate
+func (c RecurringCandidate) Input() CreateSubscriptionInput {
+	return CreateSubscriptionInput{
+		Name:            c.Name,
+		Amount:          c.Amount,
+		Currency:        c.Currency,
+		BillingCycle:    c.BillingCycle,
+		NextRenewalDate: c.NextRenewalDate,
+	}
+}
+
+// Detect reads a bank statement and returns its recurring charges, see DetectFrom
+func (s *StatementService) Detect(ctx context.Context, r io.Reader, format StatementFormat, mapping StatementMapping, minOccurrences int) ([]RecurringCandidate, error) {
+	return s.DetectFrom(ctx, r, format, mapping, minOccurrences, time.Now())
+}
+
+// DetectFrom reads a bank statement and groups its outgoing transactions by
+// payee and amount. A group whose charges come at the interval of a billing
+// cycle becomes a candidate, unless it stopped before the end of the statement.
+// Charges without a currency are taken to be in the base currency.
+func (s *StatementService) DetectFrom(ctx context.Context, r io.Reader, format StatementFormat, mapping StatementMapping, minOccurrences int, referenceTime time.Time) ([]RecurringCandidate, error) {
+	txns, err := ParseStatement(r, format, mapping)
+	if err != nil {
+		return nil, err
+	}
+	base, err := s.config.GetBaseCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range txns {
+		if txns[i].Currency == "" {
+			txns[i].Currency = base
+		}
+	}
+
+	today := time.Date(referenceTime.Year(), referenceTime.Month(), referenceTime.Day(), 0, 0, 0, 0, time.UTC)
+	candidates := DetectRecurring(txns, minOccurrences, today)
+
+	// Flag the candidates that look like subscriptions already tracked
+	subs, err := s.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	for i := range candidates {
+		key := payeeKey(candidates[i].Name)
+		for _, sub := range subs {
+			if name := payeeKey(sub.Name); name != "" && (strings.Contains(key, name) || strings.Contains(name, key)) {
+				candidates[i].ExistingID = sub.ID
+				break
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// Accept adds a candidate as a subscription
+func (s *StatementService) Accept(ctx context.Context, candidate RecurringCandidate) (db.Subscription, error) {
+	return s.subscriptions.Create(ctx, candidate.Input())
+}
+
+// recurringCycles are the billing cycles detection recognizes, with their
+// length in days and how many days a gap may be off for bank processing times
+var recurringCycles = []struct {
+	cycle     string
+	days      float64
+	tolerance float64
+}{
+	{CycleWeekly, 7, 1},
+	{CycleBiweekly, 14, 2},
+	{CycleMonthly, 30.44, 4},
+	{CycleQuarterly, 91.31, 7},
+	{CycleSemiannual, 182.62, 10},
+	{CycleYearly, 365.25, 15},
+}
+
+// DetectRecurring returns the recurring outgoing charges among txns, most confident first
+func DetectRecurring(txns []Transaction, minOccurrences int, today time.Time) []RecurringCandidate {
+	if minOccurrences < 2 {
+		minOccurrences = DefaultMinOccurrences
+	}
+
+	// The statement ends with its last transaction, which may be long before today
+	var end time.Time
+	groups := make(map[string][]Transaction)
+	var keys []string
+	for _, txn := range txns {
+		if txn.Date.After(end) {
+			end = txn.Date
+		}
+		key := payeeKey(txn.Payee)
+		if txn.Amount >= 0 || key == "" {
+			continue
+		}
+		key += " " + txn.Currency
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], txn)
+	}
+
+	var candidates []RecurringCandidate
+	for _, key := range keys {
+		for _, series := range splitByAmount(groups[key]) {
+			if candidate, ok := detectSeries(series, minOccurrences, end, today); ok {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	return candidates
+}
+
+// splitByAmount splits the charges to one payee into series of similar
+// amounts, so that two subscriptions with one company are kept apart
+func splitByAmount(txns []Transaction) [][]Transaction {
+	sorted := append([]Transaction(nil), txns...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Amount > sorted[j].Amount })
+
+	var series [][]Transaction
+	for _, txn := range sorted {
+		n := len(series)
+		if n > 0 && -txn.Amount <= -series[n-1][0].Amount*(1+amountTolerance)+0.01 {
+			series[n-1] = append(series[n-1], txn)
+			continue
+		}
+		series = append(series, []Transaction{txn})
+	}
+	for _, s := range series {
+		sort.SliceStable(s, func(i, j int) bool { return s[i].Date.Before(s[j].Date) })
+	}
+	return series
+}
+
+// detectSeries matches the gaps between charges to a billing cycle
+func detectSeries(txns []Transaction, minOccurrences int, end, today time.Time) (RecurringCandidate, bool) {
+	// Charges on the same day count once
+	var charges []Transaction
+	for _, txn := range txns {
+		if n := len(charges); n == 0 || !txn.Date.Equal(charges[n-1].Date) {
+			charges = append(charges, txn)
+		}
+	}
+	if len(charges) < 2 {
+		return RecurringCandidate{}, false
+	}
+
+	gaps := make([]float64, len(charges)-1)
+	for i := range gaps {
+		gaps[i] = charges[i+1].Date.Sub(charges[i].Date).Hours() / 24
+	}
+	median := medianOf(gaps)
+
+	for _, c := range recurringCycles {
+		if math.Abs(median-c.days) > c.tolerance {
+			continue
+		}
+		needed := minOccurrences
+		if c.days > 180 {
+			needed = min(needed, 2)
+		}
+		if len(charges) < needed {
+			return RecurringCandidate{}, false
+		}
+
+		regular := 0
+		for _, gap := range gaps {
+			if math.Abs(gap-c.days) <= c.tolerance {
+				regular++
+			}
+		}
+		confidence := float64(regular) / float64(len(gaps))
+		if confidence < regularShare {
+			return RecurringCandidate{}, false
+		}
+
+		// A series that missed its last expected charge has probably ended
+		last := charges[len(charges)-1]
+		if end.Sub(last.Date).Hours()/24 > c.days+c.tolerance {
+			return RecurringCandidate{}, false
+		}
+
+		cycle, _ := ParseBillingCycle(c.cycle)
+		next := last.Date
+		for n := 1; !next.After(today); n++ {
+			next = cycle.AddTo(last.Date, n)
+		}
+
+		return RecurringCandidate{
+			Name:            payeeName(last.Payee),
+			Amount:          -last.Amount,
+			Currency:        last.Currency,
+			BillingCycle:    c.cycle,
+			NextRenewalDate: next.Format("2006-01-02"),
+			Occurrences:     len(charges),
+			FirstCharge:     charges[0].Date,
+			LastCharge:      last.Date,
+			Confidence:      confidence,
+			Transactions:    charges,
+		}, true
+	}
+	return RecurringCandidate{}, false
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// payeeNoise are words banks add to payees that say nothing about who was paid
+var payeeNoise = map[string]bool{
+	"pos": true, "purchase": true, "debit": true, "card": true, "visa": true, "mastercard": true, "ach": true,
+	"payment": true, "paypal": true, "recurring": true, "direct": true, "dd": true, "sepa": true, "www": true, "com": true,
+	"net": true, "inc": true, "ltd": true, "llc": true, "gmbh": true, "bv": true, "co": true,
+}
+
+// payeeWords returns the meaningful words of a payee: without punctuation,
+// reference numbers, card details and the like
+func payeeWords(payee string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(payee), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if payeeNoise[word] || strings.IndexFunc(word, unicode.IsDigit) >= 0 || len([]rune(word)) < 2 {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// payeeKey groups the transactions of one payee, whose statement lines often
+// differ by a reference number or location
+func payeeKey(payee string) string {
+	words := payeeWords(payee)
+	if len(words) > 3 {
+		words = words[:3]
+	}
+	return strings.Join(words, " ")
+}
+
+// payeeName turns a payee into a subscription name, such as "NETFLIX.COM 866-579" into "Netflix"
+func payeeName(payee string) string {
+	words := payeeWords(payee)
+	if len(words) > 3 {
+		words = words[:3]
+	}
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	if len(words) == 0 {
+		return strings.TrimSpace(payee)
+	}
+	return strings.Join(words, " ")
+}
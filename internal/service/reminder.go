@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// MaxReminderDays is the longest lead time a reminder can have
+const MaxReminderDays = 365
+
+// DefaultReminderInterval is how often the watcher checks for reminders to send
+const DefaultReminderInterval = time.Hour
+
+// ReminderService finds the renewals that are due a reminder and delivers the
+// reminders through notifiers, remembering which ones were sent
+type ReminderService struct {
+	queries       *db.Queries
+	subscriptions *SubscriptionService
+	config        *ConfigService
+}
+
+// NewReminderService creates a new reminder service
+func NewReminderService(queries *db.Queries, subscriptions *SubscriptionService, config *ConfigService) *ReminderService {
+	return &ReminderService{queries: queries, subscriptions: subscriptions, config: config}
+}
+
+// Reminder is a notice that a subscription renews soon
+type Reminder struct {
+	Subscription db.Subscription
+	RenewalDate  time.Time
+	LeadDays     int     // Lead time the reminder is sent for
+	DaysLeft     int     // Days from today to the renewal; less than LeadDays when a check was missed
+	Amount       float64 // At the price in effect on RenewalDate
+	Currency     string
+}
+
+// Title is a one-line summary of the reminder
+func (r Reminder) Title() string {
+	switch r.DaysLeft {
+	case 0:
+		return r.Subscription.Name + " renews today"
+	case 1:
+		return r.Subscription.Name + " renews tomorrow"
+	}
+	return fmt.Sprintf("%s renews in %d days", r.Subscription.Name, r.DaysLeft)
+}
+
+// Message says what will be charged and when
+func (r Reminder) Message() string {
+	return fmt.Sprintf("%.2f %s will be charged on %s (%s).",
+		r.Amount, r.Currency, r.RenewalDate.Format("Mon, Jan 2 2006"), r.Subscription.BillingCycle)
+}
+
+// ReminderSettings are the lead times of one subscription
+type ReminderSettings struct {
+	Days   []int // Days before each renewal to remind, largest first; empty when reminders are off
+	Custom bool  // Set for the subscription rather than taken from the default
+}
+
+// ParseReminderDays parses a comma separated list of lead times such as "7,1".
+// "off" and "none" are an empty list.
+func ParseReminderDays(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" || s == "none" {
+		return []int{}, nil
+	}
+	var days []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 0 || n > MaxReminderDays {
+			return nil, fmt.Errorf("invalid reminder days %q: use 0 to %d days, such as 7,1", part, MaxReminderDays)
+		}
+		if !slices.Contains(days, n) {
+			days = append(days, n)
+		}
+	}
+	slices.SortFunc(days, func(a, b int) int { return b - a })
+	return days, nil
+}
+
+// FormatReminderDays formats lead times as ParseReminderDays reads them
+func FormatReminderDays(days []int) string {
+	if len(days) == 0 {
+		return "off"
+	}
+	parts := make([]string, len(days))
+	for i, d := range days {
+		parts[i] = strconv.Itoa(d)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Settings returns the lead times of every subscription by ID. Subscriptions
+// without their own lead times use defaults.
+func (s *ReminderService) Settings(ctx context.Context) (map[int64]ReminderSettings, []int, error) {
+	defaults, err := s.config.GetReminderDays(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := s.queries.ListReminderSettings(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list reminder settings: %w", err)
+	}
+	settings := make(map[int64]ReminderSettings, len(rows))
+	for _, row := range rows {
+		days, err := ParseReminderDays(row.LeadDays)
+		if err != nil {
+			return nil, nil, err
+		}
+		settings[row.SubscriptionID] = ReminderSettings{Days: days, Custom: true}
+	}
+	return settings, defaults, nil
+}
+
+// SetDays sets the lead times of a subscription; no days turns its reminders off
+func (s *ReminderService) SetDays(ctx context.Context, id int64, days []int) error {
+	if _, err := s.queries.GetSubscription(ctx, id); err != nil {
+		return fmt.Errorf("subscription not found: %w", err)
+	}
+	for _, d := range days {
+		if d < 0 || d > MaxReminderDays {
+			return fmt.Errorf("reminder days must be between 0 and %d", MaxReminderDays)
+		}
+	}
+	return s.queries.SetReminderSetting(ctx, db.SetReminderSettingParams{SubscriptionID: id, LeadDays: storedReminderDays(days)})
+}
+
+// storedReminderDays formats the lead times of a subscription for the
+// database, where an empty list turns its reminders off
+func storedReminderDays(days []int) string {
+	if len(days) == 0 {
+		return ""
+	}
+	return FormatReminderDays(days)
+}
+
+// ResetDays makes a subscription use the default lead times again
+func (s *ReminderService) ResetDays(ctx context.Context, id int64) error {
+	return s.queries.DeleteReminderSetting(ctx, id)
+}
+
+// Due returns the reminders to send now, see DueFrom
+func (s *ReminderService) Due(ctx context.Context) ([]Reminder, error) {
+	return s.DueFrom(ctx, time.Now())
+}
+
+// DueFrom returns the reminders to send on the day of referenceTime, in date
+// order. A renewal is due a reminder once it is no more than a lead time away,
+// unless the reminder for that lead time, or a later one, was already sent. A
+// check that was missed sends only the reminder of the nearest lead time.
+func (s *ReminderService) DueFrom(ctx context.Context, referenceTime time.Time) ([]Reminder, error) {
+	settings, defaults, err := s.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := s.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	history, err := loadPriceHistory(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Date(referenceTime.Year(), referenceTime.Month(), referenceTime.Day(), 0, 0, 0, 0, time.UTC)
+	sent, err := s.queries.ListSentReminders(ctx, today.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sent reminders: %w", err)
+	}
+	// Nearest lead time already sent for each renewal
+	type renewal struct {
+		id   int64
+		date string
+	}
+	nearest := make(map[renewal]int)
+	for _, r := range sent {
+		key := renewal{r.SubscriptionID, r.RenewalDate}
+		if d, ok := nearest[key]; !ok || int(r.Days) < d {
+			nearest[key] = int(r.Days)
+		}
+	}
+
+	var reminders []Reminder
+	for _, sub := range subs {
+		days := defaults
+		if setting, ok := settings[sub.ID]; ok {
+			days = setting.Days
+		}
+		if len(days) == 0 {
+			continue
+		}
+		anchor, ok := parseNullDate(sub.NextRenewalDate)
+		if !ok {
+			continue
+		}
+		cycle, err := ParseBillingCycle(sub.BillingCycle)
+		if err != nil {
+			continue
+		}
+
+		// days is sorted largest first
+		for _, date := range cycle.DatesInPeriod(anchor, today, today.AddDate(0, 0, days[0])) {
+			if !ChargesOn(sub, date) {
+				continue
+			}
+			left := int(date.Sub(today).Hours() / 24)
+			lead := -1
+			for _, d := range days {
+				if d >= left {
+					lead = d
+				}
+			}
+			if lead < 0 {
+				continue
+			}
+			if d, ok := nearest[renewal{sub.ID, date.Format("2006-01-02")}]; ok && d <= lead {
+				continue
+			}
+			amount, currency := history.priceOn(sub, date)
+			reminders = append(reminders, Reminder{
+				Subscription: sub,
+				RenewalDate:  date,
+				LeadDays:     lead,
+				DaysLeft:     left,
+				Amount:       amount,
+				Currency:     currency,
+			})
+		}
+	}
+	slices.SortStableFunc(reminders, func(a, b Reminder) int {
+		return a.RenewalDate.Compare(b.RenewalDate)
+	})
+	return reminders, nil
+}
+
+// Deliver sends each reminder through every notifier and records the ones
+// that at least one notifier delivered, so they aren't sent again. It returns
+// the delivered reminders and the errors of the notifiers that failed.
+func (s *ReminderService) Deliver(ctx context.Context, reminders []Reminder, notifiers []Notifier) ([]Reminder, error) {
+	if len(notifiers) == 0 {
+		return nil, fmt.Errorf("no notifiers to deliver reminders with")
+	}
+	var errs []error
+	var delivered []Reminder
+	for _, r := range reminders {
+		ok := false
+		for _, n := range notifiers {
+			if err := n.Notify(ctx, r); err != nil {
+				errs = append(errs, fmt.Errorf("%s reminder for %s: %w", n.Name(), r.Subscription.Name, err))
+				continue
+			}
+			ok = true
+		}
+		if !ok {
+			continue
+		}
+		if err := s.queries.RecordSentReminder(ctx, db.RecordSentReminderParams{
+			SubscriptionID: r.Subscription.ID,
+			RenewalDate:    r.RenewalDate.Format("2006-01-02"),
+			Days:           int64(r.LeadDays),
+		}); err != nil {
+			return delivered, fmt.Errorf("failed to record reminder for %s: %w", r.Subscription.Name, err)
+		}
+		delivered = append(delivered, r)
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// Check advances past renewal dates, which emits renewal events, and delivers
+// the reminders due on the day of referenceTime, returning the ones that were
+// delivered. Without notifiers it only advances renewal dates.
+func (s *ReminderService) Check(ctx context.Context, notifiers []Notifier, referenceTime time.Time) ([]Reminder, error) {
+	if err := s.subscriptions.AdvanceRenewalDatesFrom(ctx, referenceTime); err != nil {
+		return nil, fmt.Errorf("failed to advance renewal dates: %w", err)
+	}
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+	due, err := s.DueFrom(ctx, referenceTime)
+	if err != nil {
+		return nil, err
+	}
+	return s.Deliver(ctx, due, notifiers)
+}
+
+// Watch runs Check now and then every interval until ctx is cancelled,
+// reporting the delivered reminders of each check, and its error, to report. A failed
+// check is retried on the next one.
+func (s *ReminderService) Watch(ctx context.Context, notifiers []Notifier, interval time.Duration, report func([]Reminder, error)) error {
+	if interval < time.Minute {
+		return fmt.Errorf("interval must be at least a minute")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report(s.Check(ctx, notifiers, time.Now()))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
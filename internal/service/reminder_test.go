@@ -0,0 +1,296 @@
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
+)
+
+// recorder is a notifier that remembers the reminders it was given
+type recorder struct {
+	sent []string
+	err  error
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) Notify(ctx context.Context, reminder service.Reminder) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.sent = append(r.sent, reminder.Subscription.Name+" "+reminder.RenewalDate.Format("2006-01-02"))
+	return nil
+}
+
+func TestReminderService_Check(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	for _, input := range []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15"},
+		{Name: "Figma", Amount: 120.00, Currency: "EUR", BillingCycle: "yearly", NextRenewalDate: "2026-03-30"},
+		{Name: "Paper", Amount: 2.00, Currency: "USD", BillingCycle: "weekly", NextRenewalDate: "2026-03-16"},
+	} {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+	subs, err := tdb.SubscriptionService.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	ids := make(map[string]int64)
+	for _, sub := range subs {
+		ids[sub.Name] = sub.ID
+	}
+	// Figma reminds two weeks and a day ahead; Paper never
+	if err := tdb.ReminderService.SetDays(ctx, ids["Figma"], []int{14, 1}); err != nil {
+		t.Fatalf("SetDays() error = %v", err)
+	}
+	if err := tdb.ReminderService.SetDays(ctx, ids["Paper"], nil); err != nil {
+		t.Fatalf("SetDays() error = %v", err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 9, 0, 0, 0, time.UTC) }
+	notifier := &recorder{}
+	notifiers := []service.Notifier{notifier}
+
+	check := func(d int, want string) {
+		t.Helper()
+		notifier.sent = nil
+		if _, err := tdb.ReminderService.Check(ctx, notifiers, day(d)); err != nil {
+			t.Fatalf("Check() error = %v", err)
+		}
+		if got := strings.Join(notifier.sent, ", "); got != want {
+			t.Errorf("Check() on March %d sent %q, want %q", d, got, want)
+		}
+	}
+	// Netflix is within the default 3 days
+	check(12, "Netflix 2026-03-15")
+	// Nothing is sent twice
+	check(14, "")
+	// Figma is within 14 days, and Netflix renews again on April 15
+	check(16, "Figma 2026-03-30")
+
+	// A failed delivery is retried on the next check
+	failing := &recorder{err: errors.New("unreachable")}
+	delivered, err := tdb.ReminderService.Check(ctx, []service.Notifier{failing}, day(29))
+	if err == nil || len(delivered) != 0 {
+		t.Errorf("Check() = %d, %v; want an error and nothing delivered", len(delivered), err)
+	}
+	// Figma gets its second reminder
+	check(29, "Figma 2026-03-30")
+	netflix, err := tdb.SubscriptionService.Get(ctx, ids["Netflix"])
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if netflix.NextRenewalDate.String != "2026-04-15" {
+		t.Errorf("Check() left the Netflix renewal at %s, want 2026-04-15", netflix.NextRenewalDate.String)
+	}
+}
+
+func TestReminderService_DueFrom(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Update(ctx, service.UpdateSubscriptionInput{
+		ID: sub.ID, Name: "Netflix", Amount: 18.00, Currency: "USD", BillingCycle: "monthly",
+		NextRenewalDate: "2026-03-15", PriceEffective: "2026-03-15",
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := tdb.ConfigService.SetReminderDays(ctx, []int{7, 1}); err != nil {
+		t.Fatalf("SetReminderDays() error = %v", err)
+	}
+
+	// A check that missed the 7 day reminder sends only one
+	due, err := tdb.ReminderService.DueFrom(ctx, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("DueFrom() error = %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("DueFrom() = %d reminders, want 1", len(due))
+	}
+	r := due[0]
+	if r.LeadDays != 7 || r.DaysLeft != 4 || r.Amount != 18.00 {
+		t.Errorf("DueFrom() = lead %d, %d days left, %.2f; want lead 7, 4 days left, 18.00", r.LeadDays, r.DaysLeft, r.Amount)
+	}
+	if want := "Netflix renews in 4 days"; r.Title() != want {
+		t.Errorf("Title() = %q, want %q", r.Title(), want)
+	}
+	if _, err := tdb.ReminderService.Deliver(ctx, due, []service.Notifier{&recorder{}}); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	// The day before, the 1 day reminder is still due
+	due, err = tdb.ReminderService.DueFrom(ctx, time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("DueFrom() error = %v", err)
+	}
+	if len(due) != 1 || due[0].LeadDays != 1 || due[0].Title() != "Netflix renews tomorrow" {
+		t.Errorf("DueFrom() = %+v, want the 1 day reminder", due)
+	}
+
+	// Paused subscriptions aren't charged, so aren't reminded of
+	if _, err := tdb.SubscriptionService.Pause(ctx, sub.ID, "2026-03-12"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	due, err = tdb.ReminderService.DueFrom(ctx, time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("DueFrom() error = %v", err)
+	}
+	if len(due) != 0 {
+		t.Errorf("DueFrom() = %d reminders for a paused subscription, want 0", len(due))
+	}
+}
+
+func TestParseReminderDays(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"3", "3"},
+		{"1, 7,7", "7,1"},
+		{"0", "0"},
+		{"off", "off"},
+		{"", "off"},
+	}
+	for _, tt := range tests {
+		days, err := service.ParseReminderDays(tt.input)
+		if err != nil {
+			t.Errorf("ParseReminderDays(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got := service.FormatReminderDays(days); got != tt.want {
+			t.Errorf("ParseReminderDays(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"-1", "366", "weekly", "7,"} {
+		if _, err := service.ParseReminderDays(bad); err == nil {
+			t.Errorf("ParseReminderDays(%q) should fail", bad)
+		}
+	}
+}
+
+// testReminder is a reminder of a subscription renewing on March 15
+var testReminder = service.Reminder{
+	Subscription: db.Subscription{ID: 4, Name: "Netflix", BillingCycle: "monthly"},
+	RenewalDate:  time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC),
+	LeadDays:     3,
+	DaysLeft:     3,
+	Amount:       15.00,
+	Currency:     "USD",
+}
+
+func TestEmailNotifier(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	// A minimal SMTP server that accepts one message
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 Go ahead")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	notifier := service.EmailNotifier{
+		Addr: listener.Addr().String(),
+		From: "tracker@localhost",
+		To:   []string{"me@example.com"},
+	}
+	if err := notifier.Notify(context.Background(), testReminder); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	select {
+	case msg := <-received:
+		for _, want := range []string{
+			"Subject: Netflix renews in 3 days",
+			"To: me@example.com",
+			"15.00 USD will be charged on Sun, Mar 15 2026 (monthly).",
+		} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("mail does not contain %q:\n%s", want, msg)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail was received")
+	}
+}
+
+func TestCommandNotifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	out := filepath.Join(t.TempDir(), "reminder.txt")
+	notifier := service.CommandNotifier{Command: `printf '%s %s %s\n' "$SUBSCRIPTION_NAME" "$RENEWAL_DATE" "$DAYS_LEFT" > "` + out + `"; cat >> "` + out + `"`}
+	if err := notifier.Notify(context.Background(), testReminder); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read command output: %v", err)
+	}
+	want := "Netflix 2026-03-15 3\n15.00 USD will be charged on Sun, Mar 15 2026 (monthly).\n"
+	if string(got) != want {
+		t.Errorf("command wrote %q, want %q", got, want)
+	}
+
+	if err := (service.CommandNotifier{Command: "echo broken >&2; exit 3"}).Notify(context.Background(), testReminder); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Notify() error = %v, want the command's output", err)
+	}
+}
@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// ReportFormat is the format of a spending report
+type ReportFormat string
+
+const (
+	ReportHTML     ReportFormat = "html"     // Standalone page with inline CSS and SVG charts
+	ReportMarkdown ReportFormat = "markdown" // Tables for a wiki or chat
+)
+
+// DefaultReportUpcomingDays is how far ahead a report lists renewals
+const DefaultReportUpcomingDays = 30
+
+// ReportOptions chooses the billing periods of a spending report. With neither
+// Year nor Month it covers the current billing period, with only Year every
+// billing period of that year, and with Month that month of Year (or of the
+// current year).
+type ReportOptions struct {
+	Year         int
+	Month        int
+	UpcomingDays int // Days of renewals listed from today; DefaultReportUpcomingDays when 0
+}
+
+// ReportService builds spending reports from the spending summaries
+type ReportService struct {
+	queries  *db.Queries
+	spending *SpendingService
+}
+
+// NewReportService creates a new report service
+func NewReportService(queries *db.Queries, spending *SpendingService) *ReportService {
+	return &ReportService{queries: queries, spending: spending}
+}
+
+// Report is the spending of one or more billing periods, ready to be written
+// as HTML or Markdown. All totals are in BaseCurrency.
+type Report struct {
+	Title        string
+	Generated    time.Time
+	BaseCurrency string
+	Periods      []*SpendingSummary // In date order
+	Groups       []ReportGroup      // Charges by billing cycle: monthly, yearly, then other cycles
+	Categories   []CategoryTotal    // Total broken down by category, largest first
+	MonthlyTotal float64
+	YearlyTotal  float64
+	OtherTotal   float64
+	Total        float64
+	Average      float64 // Total per period
+	Salary       float64 // Salary over all periods; 0 when none is set
+	Remaining    float64 // Salary - Total (0 if no salary set)
+	Upcoming     []Charge
+	UpcomingDays int
+	MissingRates []string // Currencies without an exchange rate; their amounts are counted unconverted
+}
+
+// ReportGroup is the charges of the subscriptions on one kind of billing cycle
+type ReportGroup struct {
+	Title string
+	Items []ReportItem // Largest first
+	Total float64
+}
+
+// ReportItem is what one subscription was charged over the periods of a report
+type ReportItem struct {
+	Name         string
+	Category     string
+	BillingCycle string
+	Dates        []time.Time
+	Amount       float64 // In Currency
+	Currency     string
+	Converted    float64 // In the base currency
+	Paid         int     // Charges taken from the payment ledger
+}
+
+// Build builds the report of the periods opts chooses, see BuildFrom
+func (s *ReportService) Build(ctx context.Context, opts ReportOptions) (*Report, error) {
+	return s.BuildFrom(ctx, opts, time.Now())
+}
+
+// BuildFrom builds the report of the periods opts chooses, with the renewals
+// of the UpcomingDays from referenceTime on
+func (s *ReportService) BuildFrom(ctx context.Context, opts ReportOptions, referenceTime time.Time) (*Report, error) {
+	if opts.Month < 0 || opts.Month > 12 {
+		return nil, fmt.Errorf("month must be between 1 and 12")
+	}
+	if opts.UpcomingDays < 0 || opts.UpcomingDays > 366 {
+		return nil, fmt.Errorf("upcoming renewals must cover 0 to 366 days")
+	}
+	if opts.UpcomingDays == 0 {
+		opts.UpcomingDays = DefaultReportUpcomingDays
+	}
+
+	cutoffDay, err := s.spending.configService.GetMonthCutoffDay(ctx)
+	if err != nil {
+		cutoffDay = 1
+	}
+	today := time.Date(referenceTime.Year(), referenceTime.Month(), referenceTime.Day(), 0, 0, 0, 0, time.UTC)
+
+	var months [][2]int
+	switch {
+	case opts.Year == 0 && opts.Month == 0:
+		year, month := billingMonth(today, cutoffDay)
+		months = append(months, [2]int{year, month})
+	case opts.Month == 0:
+		for month := 1; month <= 12; month++ {
+			months = append(months, [2]int{opts.Year, month})
+		}
+	default:
+		year := opts.Year
+		if year == 0 {
+			year = today.Year()
+		}
+		months = append(months, [2]int{year, opts.Month})
+	}
+
+	summaries := make([]*SpendingSummary, len(months))
+	for i, m := range months {
+		summary, err := s.spending.CalculateForMonth(ctx, m[0], m[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate spending for %d-%02d: %w", m[0], m[1], err)
+		}
+		summaries[i] = summary
+	}
+
+	upcoming, err := s.upcomingCharges(ctx, today, today.AddDate(0, 0, opts.UpcomingDays-1), cutoffDay)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := categoryNames(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+	report := NewReport(summaries, names, referenceTime)
+	report.Upcoming = upcoming
+	report.UpcomingDays = opts.UpcomingDays
+	return report, nil
+}
+
+// upcomingCharges returns the charges from start through end, counted like the spending summaries
+func (s *ReportService) upcomingCharges(ctx context.Context, start, end time.Time, cutoffDay int) ([]Charge, error) {
+	var charges []Charge
+	year, month := billingMonth(start, cutoffDay)
+	for {
+		summary, err := s.spending.CalculateForMonth(ctx, year, month)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate upcoming renewals: %w", err)
+		}
+		for _, charge := range summary.Charges {
+			if !charge.Date.Before(start) && !charge.Date.After(end) {
+				charges = append(charges, charge)
+			}
+		}
+		if !summary.PeriodEnd.Before(end) {
+			break
+		}
+		month++
+		if month > 12 {
+			month = 1
+			year++
+		}
+	}
+	return charges, nil
+}
+
+// NewReport combines the summaries of consecutive billing periods into a
+// report, naming categories from categories (see CategoryService.Names)
+func NewReport(summaries []*SpendingSummary, categories map[int64]string, generated time.Time) *Report {
+	report := &Report{Generated: generated, Periods: summaries}
+	if len(summaries) == 0 {
+		report.Title = "Spending Report"
+		return report
+	}
+	first, last := summaries[0], summaries[len(summaries)-1]
+	report.BaseCurrency = first.BaseCurrency
+	switch {
+	case len(summaries) == 1:
+		report.Title = fmt.Sprintf("Spending Report: %s %d", time.Month(first.Month), first.Year)
+	case len(summaries) == 12 && first.Month == 1 && last.Year == first.Year:
+		report.Title = fmt.Sprintf("Spending Report: %d", first.Year)
+	default:
+		report.Title = fmt.Sprintf("Spending Report: %s %d to %s %d", time.Month(first.Month), first.Year, time.Month(last.Month), last.Year)
+	}
+
+	var charges []Charge
+	missing := make(map[string]bool)
+	for _, summary := range summaries {
+		report.MonthlyTotal += summary.MonthlyTotal
+		report.YearlyTotal += summary.YearlyTotal
+		report.OtherTotal += summary.OtherTotal
+		report.Total += summary.GrandTotal
+		report.Salary += summary.MonthlySalary
+		charges = append(charges, summary.Charges...)
+		for _, currency := range summary.MissingRates {
+			if !missing[currency] {
+				missing[currency] = true
+				report.MissingRates = append(report.MissingRates, currency)
+			}
+		}
+	}
+	sort.Strings(report.MissingRates)
+	report.Average = report.Total / float64(len(summaries))
+	if report.Salary > 0 {
+		report.Remaining = report.Salary - report.Total
+	}
+	report.Categories = totalsByCategory(charges, categories)
+
+	groups := []ReportGroup{{Title: "Monthly Subscriptions"}, {Title: "Yearly Subscriptions"}, {Title: "Other Billing Cycles"}}
+	type itemKey struct {
+		sub      subscriptionKey
+		currency string
+	}
+	index := make(map[itemKey]int)
+	for _, charge := range charges {
+		g := 2
+		switch charge.Subscription.BillingCycle {
+		case CycleMonthly:
+			g = 0
+		case CycleYearly:
+			g = 1
+		}
+		group := &groups[g]
+		key := itemKey{keyOf(charge.Subscription), charge.Subscription.Currency}
+		i, ok := index[key]
+		if !ok {
+			i = len(group.Items)
+			index[key] = i
+			group.Items = append(group.Items, ReportItem{
+				Name:         charge.Subscription.Name,
+				Category:     CategoryName(charge.Subscription, categories),
+				BillingCycle: charge.Subscription.BillingCycle,
+				Currency:     charge.Subscription.Currency,
+			})
+		}
+		item := &group.Items[i]
+		item.Dates = append(item.Dates, charge.Date)
+		item.Amount += charge.Amount
+		item.Converted += charge.Converted
+		if charge.Paid {
+			item.Paid++
+		}
+		group.Total += charge.Converted
+	}
+	for _, group := range groups {
+		if len(group.Items) == 0 {
+			continue
+		}
+		sort.SliceStable(group.Items, func(i, j int) bool {
+			if group.Items[i].Converted != group.Items[j].Converted {
+				return group.Items[i].Converted > group.Items[j].Converted
+			}
+			return group.Items[i].Name < group.Items[j].Name
+		})
+		report.Groups = append(report.Groups, group)
+	}
+	return report
+}
+
+// Write writes the report in the given format
+func (r *Report) Write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportHTML:
+		return r.writeHTML(w)
+	case ReportMarkdown:
+		return r.writeMarkdown(w)
+	default:
+		return fmt.Errorf("unsupported report format: %s", format)
+	}
+}
+
+// ReportFormatOf guesses the report format from a file name; Markdown for
+// .md and .markdown files, HTML otherwise
+func ReportFormatOf(path string) ReportFormat {
+	lower := strings.ToLower(path)
+	if strings.HasSuffix(lower, ".md") || strings.HasSuffix(lower, ".markdown") {
+		return ReportMarkdown
+	}
+	return ReportHTML
+}
+
+// PeriodRange is the first and last day of a report's billing periods
+func (r *Report) PeriodRange() (time.Time, time.Time) {
+	if len(r.Periods) == 0 {
+		return time.Time{}, time.Time{}
+	}
+	return r.Periods[0].PeriodStart, r.Periods[len(r.Periods)-1].PeriodEnd
+}
+
+// ChargedOn describes when an item was charged: the dates when there are a
+// few, otherwise how many times
+func (item ReportItem) ChargedOn() string {
+	if len(item.Dates) > 3 {
+		return fmt.Sprintf("%d times", len(item.Dates))
+	}
+	dates := make([]string, len(item.Dates))
+	for i, date := range item.Dates {
+		dates[i] = date.Format("Jan 2")
+	}
+	return strings.Join(dates, ", ")
+}
+
+// periodLabel is the month a billing period is named after
+func periodLabel(summary *SpendingSummary) string {
+	return fmt.Sprintf("%s %d", time.Month(summary.Month).String()[:3], summary.Year)
+}
+
+// periodDates is the first and last day of a billing period
+func periodDates(start, end time.Time) string {
+	return fmt.Sprintf("%s - %s", start.Format("Jan 2, 2006"), end.Format("Jan 2, 2006"))
+}
+
+// writeMarkdown writes the report as GitHub-flavored Markdown
+func (r *Report) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	money := func(amount float64) string {
+		return fmt.Sprintf("%.2f %s", amount, r.BaseCurrency)
+	}
+
+	fmt.Fprintf(&b, "# %s\n\n", mdEscape(r.Title))
+	start, end := r.PeriodRange()
+	fmt.Fprintf(&b, "%s · amounts in %s · generated %s\n\n", periodDates(start, end), r.BaseCurrency, r.Generated.Format("2006-01-02"))
+
+	b.WriteString("## Summary\n\n| | Amount |\n|---|---:|\n")
+	fmt.Fprintf(&b, "| Monthly subscriptions | %s |\n", money(r.MonthlyTotal))
+	fmt.Fprintf(&b, "| Yearly subscriptions | %s |\n", money(r.YearlyTotal))
+	if r.OtherTotal != 0 {
+		fmt.Fprintf(&b, "| Other billing cycles | %s |\n", money(r.OtherTotal))
+	}
+	fmt.Fprintf(&b, "| **Total** | **%s** |\n", money(r.Total))
+	if len(r.Periods) > 1 {
+		fmt.Fprintf(&b, "| Average per month | %s |\n", money(r.Average))
+	} else if len(r.Periods) == 1 && r.Periods[0].AverageMonthly != r.Total {
+		fmt.Fprintf(&b, "| Average monthly (other cycles prorated) | %s |\n", money(r.Periods[0].AverageMonthly))
+	}
+	if r.Salary > 0 {
+		fmt.Fprintf(&b, "| Salary | %s |\n", money(r.Salary))
+		if r.Remaining >= 0 {
+			fmt.Fprintf(&b, "| Remaining | %s |\n", money(r.Remaining))
+		} else {
+			fmt.Fprintf(&b, "| **Over budget** | **%s** |\n", money(-r.Remaining))
+		}
+	}
+	b.WriteString("\n")
+
+	if len(r.Periods) > 1 {
+		b.WriteString("## By Month\n\n| Month | Period | Monthly | Yearly | Other | Total | Remaining |\n|---|---|---:|---:|---:|---:|---:|\n")
+		for _, p := range r.Periods {
+			remaining := ""
+			if p.MonthlySalary > 0 {
+				remaining = fmt.Sprintf("%.2f", p.Remaining)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %.2f | %.2f | %.2f | %.2f | %s |\n", periodLabel(p), periodDates(p.PeriodStart, p.PeriodEnd),
+				p.MonthlyTotal, p.YearlyTotal, p.OtherTotal, p.GrandTotal, remaining)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(r.Categories) > 0 {
+		b.WriteString("## By Category\n\n| Category | Subscriptions | Total | Share |\n|---|---:|---:|---:|\n")
+		for _, c := range r.Categories {
+			fmt.Fprintf(&b, "| %s | %d | %s | %.0f%% |\n", mdEscape(c.Category), c.Count, money(c.Total), share(c.Total, r.Total))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, group := range r.Groups {
+		fmt.Fprintf(&b, "## %s\n\n| Name | Category | Charged | Amount | %s |\n|---|---|---|---:|---:|\n", group.Title, r.BaseCurrency)
+		for _, item := range group.Items {
+			fmt.Fprintf(&b, "| %s | %s | %s | %.2f %s | %.2f |\n", mdEscape(item.Name), mdEscape(item.Category), item.ChargedOn(),
+				item.Amount, item.Currency, item.Converted)
+		}
+		fmt.Fprintf(&b, "| **Subtotal** | | | | **%.2f** |\n\n", group.Total)
+	}
+	if len(r.Groups) == 0 {
+		b.WriteString("No subscriptions were charged in this period.\n\n")
+	}
+
+	fmt.Fprintf(&b, "## Upcoming Renewals\n\nNext %d days from %s\n\n", r.UpcomingDays, r.Generated.Format("2006-01-02"))
+	if len(r.Upcoming) == 0 {
+		b.WriteString("No renewals.\n")
+	} else {
+		fmt.Fprintf(&b, "| Date | Name | Amount | %s |\n|---|---|---:|---:|\n", r.BaseCurrency)
+		for _, c := range r.Upcoming {
+			fmt.Fprintf(&b, "| %s | %s | %.2f %s | %.2f |\n", c.Date.Format("2006-01-02"), mdEscape(c.Subscription.Name),
+				c.Amount, c.Subscription.Currency, c.Converted)
+		}
+	}
+
+	if len(r.MissingRates) > 0 {
+		fmt.Fprintf(&b, "\n> No exchange rate for %s; those amounts are counted unconverted.\n", strings.Join(r.MissingRates, ", "))
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// share is part as a percentage of total
+func share(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total * 100
+}
+
+// mdEscaper escapes the characters that would break a Markdown table cell or format text
+var mdEscaper = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", "&lt;", "`", "\\`", "\n", " ")
+
+// mdEscape escapes s for Markdown
+func mdEscape(s string) string {
+	return mdEscaper.Replace(s)
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestReportService_BuildFrom(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15", Category: "streaming"},
+		{Name: "Figma", Amount: 120.00, Currency: "USD", BillingCycle: "yearly", NextRenewalDate: "2026-03-10", Category: "dev tools"},
+		{Name: "Tom & Jerry <Club>", Amount: 2.00, Currency: "USD", BillingCycle: "weekly", NextRenewalDate: "2026-03-02"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+	if err := tdb.ConfigService.SetMonthlySalary(ctx, 100); err != nil {
+		t.Fatalf("SetMonthlySalary() error = %v", err)
+	}
+	now := time.Date(2026, 3, 20, 9, 0, 0, 0, time.UTC)
+
+	// The billing month of March 20 runs from March 1 to March 31
+	report, err := tdb.ReportService.BuildFrom(ctx, service.ReportOptions{}, now)
+	if err != nil {
+		t.Fatalf("BuildFrom() error = %v", err)
+	}
+	if len(report.Periods) != 1 || report.Title != "Spending Report: April 2026" {
+		t.Fatalf("BuildFrom() = %q with %d periods, want the April 2026 billing month", report.Title, len(report.Periods))
+	}
+	// 15 + 120 + 5 weekly charges of 2
+	if !almostEqual(report.Total, 145) || !almostEqual(report.Remaining, -45) {
+		t.Errorf("Total = %.2f, Remaining = %.2f, want 145 and -45", report.Total, report.Remaining)
+	}
+	if len(report.Groups) != 3 || report.Groups[0].Title != "Monthly Subscriptions" || report.Groups[2].Items[0].ChargedOn() != "5 times" {
+		t.Errorf("Groups = %+v, want monthly, yearly and other cycles with 5 weekly charges", report.Groups)
+	}
+	// The weekly charges and Netflix on April 15, until April 18
+	var upcoming []string
+	for _, c := range report.Upcoming {
+		upcoming = append(upcoming, c.Date.Format("01-02")+" "+c.Subscription.Name)
+	}
+	if len(upcoming) != 5 || upcoming[0] != "03-23 Tom & Jerry <Club>" || upcoming[3] != "04-13 Tom & Jerry <Club>" || upcoming[4] != "04-15 Netflix" {
+		t.Errorf("Upcoming = %v, want the charges of the next 30 days", upcoming)
+	}
+
+	var md bytes.Buffer
+	if err := report.Write(&md, service.ReportMarkdown); err != nil {
+		t.Fatalf("Write(markdown) error = %v", err)
+	}
+	for _, want := range []string{
+		"# Spending Report: April 2026",
+		"Mar 1, 2026 - Mar 31, 2026",
+		"| **Total** | **145.00 USD** |",
+		"| **Over budget** | **45.00 USD** |",
+		"| Figma | dev tools | Mar 10 | 120.00 USD | 120.00 |",
+		"| 2026-04-15 | Netflix | 15.00 USD | 15.00 |",
+		`Tom & Jerry &lt;Club>`,
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown report does not contain %q:\n%s", want, md.String())
+		}
+	}
+
+	// A year has a chart and a row per billing month
+	report, err = tdb.ReportService.BuildFrom(ctx, service.ReportOptions{Year: 2026}, now)
+	if err != nil {
+		t.Fatalf("BuildFrom() error = %v", err)
+	}
+	if len(report.Periods) != 12 || report.Title != "Spending Report: 2026" || !almostEqual(report.Salary, 1200) {
+		t.Errorf("BuildFrom() = %q with %d periods and salary %.2f, want 2026 with 12 and 1200", report.Title, len(report.Periods), report.Salary)
+	}
+	var page bytes.Buffer
+	if err := report.Write(&page, service.ReportHTML); err != nil {
+		t.Fatalf("Write(html) error = %v", err)
+	}
+	html := page.String()
+	for _, want := range []string{"<title>Spending Report: 2026</title>", `aria-label="Total per month"`, `aria-label="Total per category"`, "Tom &amp; Jerry &lt;Club&gt;", "<td>Dec 2026</td>"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML report does not contain %q", want)
+		}
+	}
+	if strings.Contains(html, "<Club>") {
+		t.Error("HTML report does not escape subscription names")
+	}
+
+	if _, err := tdb.ReportService.BuildFrom(ctx, service.ReportOptions{Month: 13}, now); err == nil {
+		t.Error("BuildFrom() should reject month 13")
+	}
+	if err := report.Write(&bytes.Buffer{}, "pdf"); err == nil {
+		t.Error("Write() should reject the pdf format")
+	}
+}
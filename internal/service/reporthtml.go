@@ -0,0 +1,199 @@
+package service
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// reportTemplate is a standalone page: styles are inline and charts are SVG,
+// so the file can be mailed or opened offline
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"money":  func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	"date":   func(r *Report) string { start, end := r.PeriodRange(); return periodDates(start, end) },
+	"share":  share,
+	"neg":    func(amount float64) float64 { return -amount },
+	"month":  periodLabel,
+	"period": periodDates,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 860px; margin: 2rem auto; padding: 0 1rem; line-height: 1.45; }
+h1 { margin-bottom: 0.2rem; }
+h2 { margin-top: 2rem; border-bottom: 1px solid #d0d7de; padding-bottom: 0.3rem; font-size: 1.2rem; }
+.meta { color: #656d76; margin-top: 0; }
+.cards { display: flex; flex-wrap: wrap; gap: 0.75rem; margin: 1.5rem 0; }
+.card { flex: 1 1 150px; border: 1px solid #d0d7de; border-radius: 6px; padding: 0.75rem 1rem; }
+.card .label { color: #656d76; font-size: 0.85rem; }
+.card .value { font-size: 1.4rem; font-weight: 600; }
+.over { color: #cf222e; }
+.under { color: #1a7f37; }
+table { border-collapse: collapse; width: 100%; font-size: 0.95rem; }
+th, td { text-align: left; padding: 0.35rem 0.6rem; border-bottom: 1px solid #eaeef2; }
+th { color: #656d76; font-weight: 600; }
+td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; white-space: nowrap; }
+tr.total td { font-weight: 600; border-top: 1px solid #d0d7de; }
+.muted { color: #656d76; }
+.note { background: #fff8c5; border: 1px solid #d4a72c66; border-radius: 6px; padding: 0.5rem 0.75rem; }
+svg { display: block; max-width: 100%; height: auto; margin: 1rem 0; }
+svg text { font-family: inherit; font-size: 12px; fill: #1f2328; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{date .}} · amounts in {{.BaseCurrency}} · generated {{.Generated.Format "2006-01-02"}}</p>
+
+<div class="cards">
+<div class="card"><div class="label">Total</div><div class="value">{{money .Total}}</div></div>
+<div class="card"><div class="label">Monthly subscriptions</div><div class="value">{{money .MonthlyTotal}}</div></div>
+<div class="card"><div class="label">Yearly subscriptions</div><div class="value">{{money .YearlyTotal}}</div></div>
+{{- if .OtherTotal}}
+<div class="card"><div class="label">Other billing cycles</div><div class="value">{{money .OtherTotal}}</div></div>
+{{- end}}
+{{- if gt (len .Periods) 1}}
+<div class="card"><div class="label">Average per month</div><div class="value">{{money .Average}}</div></div>
+{{- end}}
+{{- if .Salary}}
+<div class="card"><div class="label">Salary</div><div class="value">{{money .Salary}}</div></div>
+{{- if ge .Remaining 0.0}}
+<div class="card"><div class="label">Remaining</div><div class="value under">{{money .Remaining}}</div></div>
+{{- else}}
+<div class="card"><div class="label">Over budget</div><div class="value over">{{money (neg .Remaining)}}</div></div>
+{{- end}}
+{{- end}}
+</div>
+{{- if .MissingRates}}
+<p class="note">No exchange rate for {{range $i, $c := .MissingRates}}{{if $i}}, {{end}}{{$c}}{{end}}; those amounts are counted unconverted.</p>
+{{- end}}
+
+{{- if gt (len .Periods) 1}}
+<h2>By Month</h2>
+{{.PeriodChart}}
+<table>
+<tr><th>Month</th><th>Period</th><th class="num">Monthly</th><th class="num">Yearly</th><th class="num">Other</th><th class="num">Total</th><th class="num">Remaining</th></tr>
+{{- range .Periods}}
+<tr><td>{{month .}}</td><td class="muted">{{period .PeriodStart .PeriodEnd}}</td><td class="num">{{money .MonthlyTotal}}</td><td class="num">{{money .YearlyTotal}}</td><td class="num">{{money .OtherTotal}}</td><td class="num">{{money .GrandTotal}}</td><td class="num{{if lt .Remaining 0.0}} over{{end}}">{{if .MonthlySalary}}{{money .Remaining}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- if .Categories}}
+<h2>By Category</h2>
+{{.CategoryChart}}
+<table>
+<tr><th>Category</th><th class="num">Subscriptions</th><th class="num">Total</th><th class="num">Share</th></tr>
+{{- range .Categories}}
+<tr><td>{{.Category}}</td><td class="num">{{.Count}}</td><td class="num">{{money .Total}}</td><td class="num">{{printf "%.0f" (share .Total $.Total)}}%</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- range .Groups}}
+<h2>{{.Title}}</h2>
+<table>
+<tr><th>Name</th><th>Category</th><th>Charged</th><th class="num">Amount</th><th class="num">{{$.BaseCurrency}}</th></tr>
+{{- range .Items}}
+<tr><td>{{.Name}}</td><td class="muted">{{.Category}}</td><td>{{.ChargedOn}}</td><td class="num">{{money .Amount}} {{.Currency}}</td><td class="num">{{money .Converted}}</td></tr>
+{{- end}}
+<tr class="total"><td colspan="4">Subtotal</td><td class="num">{{money .Total}}</td></tr>
+</table>
+{{- else}}
+<p class="muted">No subscriptions were charged in this period.</p>
+{{- end}}
+
+<h2>Upcoming Renewals</h2>
+<p class="muted">Next {{.UpcomingDays}} days from {{.Generated.Format "2006-01-02"}}</p>
+{{- if .Upcoming}}
+<table>
+<tr><th>Date</th><th>Name</th><th class="num">Amount</th><th class="num">{{.BaseCurrency}}</th></tr>
+{{- range .Upcoming}}
+<tr><td>{{.Date.Format "2006-01-02"}}</td><td>{{.Subscription.Name}}</td><td class="num">{{money .Amount}} {{.Subscription.Currency}}</td><td class="num">{{money .Converted}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No renewals.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// writeHTML writes the report as a standalone HTML page
+func (r *Report) writeHTML(w io.Writer) error {
+	if err := reportTemplate.Execute(w, r); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// Colors of the report charts
+const (
+	chartBar    = "#0969da"
+	chartSalary = "#cf222e"
+	chartGrid   = "#d0d7de"
+)
+
+// PeriodChart is an SVG bar chart of the total of each billing period, with
+// the salary as a dashed line when one is set
+func (r *Report) PeriodChart() template.HTML {
+	const width, height, top, bottom, left = 800, 240, 20, 28, 8
+	ceiling := 0.0
+	for _, p := range r.Periods {
+		ceiling = max(ceiling, p.GrandTotal, p.MonthlySalary)
+	}
+	if ceiling == 0 || len(r.Periods) == 0 {
+		return ""
+	}
+	plot := float64(height - top - bottom)
+	slot := float64(width-2*left) / float64(len(r.Periods))
+	barWidth := slot * 0.7
+	y := func(amount float64) float64 { return top + plot - amount/ceiling*plot }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="%d" height="%d" role="img" aria-label="Total per month">`, width, height, width, height)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="%s"/>`, left, y(0), width-left, y(0), chartGrid)
+	for i, p := range r.Periods {
+		x := left + float64(i)*slot + (slot-barWidth)/2
+		total := max(p.GrandTotal, 0)
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="2" fill="%s"><title>%s: %.2f %s</title></rect>`,
+			x, y(total), barWidth, y(0)-y(total), chartBar, periodLabel(p), p.GrandTotal, template.HTMLEscapeString(r.BaseCurrency))
+		if total > 0 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%.0f</text>`, x+barWidth/2, y(total)-4, total)
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, x+barWidth/2, height-10, periodLabel(p)[:3])
+		if p.MonthlySalary > 0 {
+			fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="2" stroke-dasharray="4 3"><title>Salary: %.2f</title></line>`,
+				left+float64(i)*slot, y(p.MonthlySalary), left+float64(i+1)*slot, y(p.MonthlySalary), chartSalary, p.MonthlySalary)
+		}
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// CategoryChart is an SVG bar chart of the total of each category
+func (r *Report) CategoryChart() template.HTML {
+	const width, row, label, right = 800, 26, 180, 90
+	if len(r.Categories) == 0 || r.Categories[0].Total <= 0 {
+		return ""
+	}
+	ceiling := r.Categories[0].Total
+	height := row*len(r.Categories) + 4
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="%d" height="%d" role="img" aria-label="Total per category">`, width, height, width, height)
+	for i, c := range r.Categories {
+		y := i*row + 4
+		barWidth := max(c.Total, 0) / ceiling * float64(width-label-right)
+		name := template.HTMLEscapeString(c.Category)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end">%s</text>`, label-8, y+15, name)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" rx="2" fill="%s"><title>%s: %.2f</title></rect>`,
+			label, y, barWidth, row-8, chartBar, name, c.Total)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d">%.2f (%.0f%%)</text>`, float64(label)+barWidth+6, y+15, c.Total, share(c.Total, r.Total))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultS3Region is the region requests are signed for when none is set.
+// MinIO and most other S3-compatible servers accept it.
+const DefaultS3Region = "us-east-1"
+
+// s3VersionLayout stamps versioned backups so that their names sort by time
+const s3VersionLayout = "20060102T150405.000Z"
+
+// S3Backend keeps backups as objects in a bucket of AWS S3 or an
+// S3-compatible server such as Backblaze B2 or MinIO
+type S3Backend struct {
+	Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	Region    string // Defaults to DefaultS3Region
+	Bucket    string
+	Prefix    string // Put in front of every object key, e.g. "subscription-tracker/"
+	PathStyle bool   // ENDPOINT/BUCKET/KEY instead of BUCKET.ENDPOINT/KEY
+	AccessKey string
+	SecretKey string
+	Versioned bool // Also keep every backup under a timestamped name
+}
+
+// NewS3Backend checks the endpoint and bucket and returns the backend
+func NewS3Backend(b S3Backend) (*S3Backend, error) {
+	u, err := url.Parse(b.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: want http:// or https://", b.Endpoint)
+	}
+	if b.Bucket == "" || strings.Contains(b.Bucket, "/") {
+		return nil, fmt.Errorf("invalid S3 bucket %q", b.Bucket)
+	}
+	if b.AccessKey == "" || b.SecretKey == "" {
+		return nil, fmt.Errorf("S3 access key and secret key are required")
+	}
+	b.Endpoint = strings.TrimSuffix(u.String(), "/")
+	if b.Region == "" {
+		b.Region = DefaultS3Region
+	}
+	if b.Prefix = strings.TrimPrefix(b.Prefix, "/"); b.Prefix != "" && !strings.HasSuffix(b.Prefix, "/") {
+		b.Prefix += "/"
+	}
+	return &b, nil
+}
+
+// Location names the bucket and prefix
+func (b *S3Backend) Location() string {
+	return "s3://" + b.Bucket + "/" + b.Prefix
+}
+
+// VersionName returns the name a versioned backup pushed at t is kept under:
+// name with a timestamp before its extension
+func VersionName(name string, t time.Time) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + t.UTC().Format(s3VersionLayout) + ext
+}
+
+// Put uploads a backup. A versioned backend uploads it under its version
+// name first, so the backup under name is never newer than the versions.
+func (b *S3Backend) Put(ctx context.Context, name string, data []byte) error {
+	if err := checkBackupName(name); err != nil {
+		return err
+	}
+	names := []string{name}
+	if b.Versioned {
+		names = []string{VersionName(name, time.Now()), name}
+	}
+	for _, n := range names {
+		resp, err := b.do(ctx, http.MethodPut, b.Prefix+n, nil, data)
+		if err != nil {
+			return err
+		}
+		if err := s3Error(resp, http.StatusOK); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get downloads a backup
+func (b *S3Backend) Get(ctx context.Context, name string) ([]byte, error) {
+	if err := checkBackupName(name); err != nil {
+		return nil, err
+	}
+	resp, err := b.do(ctx, http.MethodGet, b.Prefix+name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status == http.StatusNotFound {
+		return nil, ErrBackupNotFound
+	}
+	if err := s3Error(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp.body, nil
+}
+
+// List returns the names of the objects under the prefix, leaving out
+// those further down, in "folders" of the prefix
+func (b *S3Backend) List(ctx context.Context) ([]string, error) {
+	var names []string
+	query := url.Values{"list-type": {"2"}, "prefix": {b.Prefix}}
+	for {
+		resp, err := b.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := s3Error(resp, http.StatusOK); err != nil {
+			return nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(resp.body, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse S3 listing: %w", err)
+		}
+		for _, c := range result.Contents {
+			if name := strings.TrimPrefix(c.Key, b.Prefix); name != "" && !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete removes a backup. S3 reports success for missing objects, so it
+// checks that the backup is there first.
+func (b *S3Backend) Delete(ctx context.Context, name string) error {
+	if err := checkBackupName(name); err != nil {
+		return err
+	}
+	resp, err := b.do(ctx, http.MethodHead, b.Prefix+name, nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.status == http.StatusNotFound {
+		return ErrBackupNotFound
+	}
+	if err := s3Error(resp, http.StatusOK); err != nil {
+		return err
+	}
+	if resp, err = b.do(ctx, http.MethodDelete, b.Prefix+name, nil, nil); err != nil {
+		return err
+	}
+	return s3Error(resp, http.StatusOK, http.StatusNoContent)
+}
+
+// objectURL returns the URL of an object, or of the bucket for an empty key
+func (b *S3Backend) objectURL(key string, query url.Values) (*url.URL, error) {
+	u, err := url.Parse(b.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if b.PathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + b.Bucket + "/" + key
+	} else {
+		u.Host = b.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	u.RawQuery = s3CanonicalQuery(query)
+	return u, nil
+}
+
+// do makes a signed request for an object, or the bucket for an empty key
+func (b *S3Backend) do(ctx context.Context, method, key string, query url.Values, body []byte) (*backendResponse, error) {
+	u, err := b.objectURL(key, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body == nil {
+		req.Body, req.ContentLength = http.NoBody, 0
+	}
+	hash := sha256.Sum256(body)
+	b.Sign(req, hex.EncodeToString(hash[:]), time.Now())
+
+	return send(req)
+}
+
+// Sign adds AWS Signature Version 4 headers to req, whose body has the
+// SHA-256 payloadHash, as of t
+func (b *S3Backend) Sign(req *http.Request, payloadHash string, t time.Time) {
+	amzDate := t.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for key, values := range req.Header {
+		if key = strings.ToLower(key); strings.HasPrefix(key, "x-amz-") {
+			headers[key] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	region := b.Region
+	if region == "" {
+		region = DefaultS3Region
+	}
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + b.SecretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		b.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes everything but the unreserved characters, as
+// signing requires
+func s3Escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3EscapePath escapes each segment of a path
+func s3EscapePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = s3Escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3CanonicalQuery encodes a query with its parameters sorted
+func s3CanonicalQuery(query url.Values) string {
+	var params []string
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, s3Escape(key)+"="+s3Escape(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// s3Error returns an error unless the response has one of the statuses
+func s3Error(resp *backendResponse, ok ...int) error {
+	for _, status := range ok {
+		if resp.status == status {
+			return nil
+		}
+	}
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(resp.body, &e) == nil && e.Code != "" {
+		return fmt.Errorf("S3 error (status %d): %s: %s", resp.status, e.Code, e.Message)
+	}
+	return fmt.Errorf("S3 error (status %d)", resp.status)
+}
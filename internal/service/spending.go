@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
+	"sort"
 	"time"
 
 	"subscription-tracker/internal/db"
@@ -11,33 +13,52 @@ import (
 
 // SpendingService handles spending calculation logic
 type SpendingService struct {
-	queries       *db.Queries
-	configService *ConfigService
+	queries         *db.Queries
+	configService   *ConfigService
+	currencyService *CurrencyService
 }
 
 // NewSpendingService creates a new spending service
 func NewSpendingService(queries *db.Queries, configService *ConfigService) *SpendingService {
 	return &SpendingService{
-		queries:       queries,
-		configService: configService,
+		queries:         queries,
+		configService:   configService,
+		currencyService: NewCurrencyService(queries, configService),
 	}
 }
 
-// SpendingSummary represents spending for a given billing period
+// SpendingSummary represents spending for a given billing period.
+// All totals are in BaseCurrency.
 type SpendingSummary struct {
 	Year           int
 	Month          int
 	CutoffDay      int
 	PeriodStart    time.Time
 	PeriodEnd      time.Time
+	BaseCurrency   string
+	MissingRates   []string // Currencies without an exchange rate; their amounts are counted unconverted
 	MonthlyTotal   float64
 	YearlyTotal    float64
+	OtherTotal     float64 // Subscriptions on any other cycle (weekly, quarterly, ...)
 	GrandTotal     float64
 	MonthlyItems   []db.Subscription
 	YearlyItems    []db.Subscription
-	AverageMonthly float64 // Monthly + (Yearly / 12)
-	MonthlySalary  float64 // User's monthly salary from config
-	Remaining      float64 // Salary - GrandTotal (0 if no salary set)
+	OtherItems     []db.Subscription
+	Charges        []Charge        // Every charge in the period by date; a weekly subscription can appear several times
+	Categories     []CategoryTotal // GrandTotal broken down by category, largest first
+	Budgets        []BudgetStatus  // Overall budget first, then category budgets
+	AverageMonthly float64         // Monthly + (Yearly / 12) + other cycles prorated to a month
+	MonthlySalary  float64         // User's monthly salary from config
+	Remaining      float64         // Salary - GrandTotal (0 if no salary set)
+}
+
+// Charge is a single renewal of a subscription on a given date
+type Charge struct {
+	Subscription db.Subscription
+	Date         time.Time
+	Amount       float64 // In the subscription's currency
+	Converted    float64 // In the base currency
+	Paid         bool    // Taken from the payment ledger rather than projected
 }
 
 // CalculateForMonth calculates spending for a specific billing period
@@ -65,38 +86,116 @@ func (s *SpendingService) CalculateForMonth(ctx context.Context, year, month int
 	// Calculate period end: day before cutoffDay of the current month (end of that day)
 	periodEnd := time.Date(year, time.Month(month), cutoffDay, 0, 0, 0, 0, time.UTC).Add(-time.Second)
 
+	converter, err := s.currencyService.Converter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+
 	// Get monthly subscriptions that renew during this period
-	monthlySubs, err := s.getMonthlySubscriptionsInPeriod(ctx, periodStart, periodEnd)
+	monthlyCharges, err := s.getMonthlyChargesInPeriod(ctx, periodStart, periodEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get monthly subscriptions: %w", err)
 	}
 
 	// Get yearly subscriptions that renew during this period
-	yearlySubs, err := s.getYearlySubscriptionsInPeriod(ctx, periodStart, periodEnd)
+	yearlyCharges, err := s.getYearlyChargesInPeriod(ctx, periodStart, periodEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get yearly subscriptions: %w", err)
 	}
 
+	// Get charges of subscriptions on other cycles during this period
+	otherCharges, otherMonthly, err := s.getOtherChargesInPeriod(ctx, converter, periodStart, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get other subscriptions: %w", err)
+	}
+
+	// Drop renewals during a free trial or after a pause or cancellation
+	monthlyCharges = billedCharges(monthlyCharges)
+	yearlyCharges = billedCharges(yearlyCharges)
+	otherCharges = billedCharges(otherCharges)
+
+	// Each charge is at the price in effect on its date
+	history, err := loadPriceHistory(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+	history.apply(monthlyCharges)
+	history.apply(yearlyCharges)
+	history.apply(otherCharges)
+
+	// Renewals that already happened come from the payment ledger, at the amount that was paid,
+	// and are never made up
+	paidCharges, err := paidChargesInPeriod(ctx, s.queries, periodStart, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments: %w", err)
+	}
+	recorded, _ := recordedThrough(ctx, s.queries)
+	monthlyCharges = projectedCharges(monthlyCharges, paidCharges, recorded)
+	yearlyCharges = projectedCharges(yearlyCharges, paidCharges, recorded)
+	otherCharges = projectedCharges(otherCharges, paidCharges, recorded)
+	for _, charge := range paidCharges {
+		switch charge.Subscription.BillingCycle {
+		case CycleMonthly:
+			monthlyCharges = append(monthlyCharges, charge)
+		case CycleYearly:
+			yearlyCharges = append(yearlyCharges, charge)
+		default:
+			otherCharges = append(otherCharges, charge)
+		}
+	}
+
 	summary := &SpendingSummary{
 		Year:         year,
 		Month:        month,
 		CutoffDay:    cutoffDay,
 		PeriodStart:  periodStart,
 		PeriodEnd:    periodEnd,
-		MonthlyItems: monthlySubs,
-		YearlyItems:  yearlySubs,
+		BaseCurrency: converter.Base,
+		MonthlyItems: subscriptionsOf(monthlyCharges),
+		YearlyItems:  subscriptionsOf(yearlyCharges),
+		OtherItems:   subscriptionsOf(otherCharges),
 	}
 
-	// Calculate totals
-	for _, sub := range monthlySubs {
-		summary.MonthlyTotal += sub.Amount
+	// Calculate totals in the base currency
+	for _, charges := range [][]Charge{monthlyCharges, yearlyCharges, otherCharges} {
+		for i := range charges {
+			charges[i].Converted, _ = converter.Convert(charges[i].Amount, charges[i].Subscription.Currency)
+		}
 	}
-	for _, sub := range yearlySubs {
-		summary.YearlyTotal += sub.Amount
+	for _, charge := range monthlyCharges {
+		summary.MonthlyTotal += charge.Converted
+	}
+	for _, charge := range yearlyCharges {
+		summary.YearlyTotal += charge.Converted
 	}
+	for _, charge := range otherCharges {
+		summary.OtherTotal += charge.Converted
+	}
+
+	summary.GrandTotal = summary.MonthlyTotal + summary.YearlyTotal + summary.OtherTotal
+	summary.AverageMonthly = summary.MonthlyTotal + (summary.YearlyTotal / 12) + otherMonthly
+
+	summary.Charges = append(append(append([]Charge{}, monthlyCharges...), yearlyCharges...), otherCharges...)
+	sort.SliceStable(summary.Charges, func(i, j int) bool {
+		return summary.Charges[i].Date.Before(summary.Charges[j].Date)
+	})
+	charged := make([]db.Subscription, len(summary.Charges))
+	for i, charge := range summary.Charges {
+		charged[i] = charge.Subscription
+	}
+	summary.MissingRates = converter.MissingRates(charged)
 
-	summary.GrandTotal = summary.MonthlyTotal + summary.YearlyTotal
-	summary.AverageMonthly = summary.MonthlyTotal + (summary.YearlyTotal / 12)
+	names, err := categoryNames(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+	summary.Categories = totalsByCategory(summary.Charges, names)
+
+	budgets, err := listBudgets(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+	summary.Budgets = budgetStatuses(budgets, summary.GrandTotal, summary.Categories)
 
 	// Get salary and calculate remaining
 	salary, err := s.configService.GetMonthlySalary(ctx)
@@ -108,14 +207,19 @@ func (s *SpendingService) CalculateForMonth(ctx context.Context, year, month int
 	return summary, nil
 }
 
-// getYearlySubscriptionsInPeriod returns yearly subscriptions that renew within the given period
-func (s *SpendingService) getYearlySubscriptionsInPeriod(ctx context.Context, start, end time.Time) ([]db.Subscription, error) {
+// getYearlyChargesInPeriod returns the charges of yearly subscriptions that renew within the given period.
+// A yearly subscription renews on its renewal date and on the same date every year after it.
+func (s *SpendingService) getYearlyChargesInPeriod(ctx context.Context, start, end time.Time) ([]Charge, error) {
 	yearlySubs, err := s.queries.ListYearlySubscriptions(ctx)
 	if err != nil {
 		return nil, err
 	}
+	yearly, err := ParseBillingCycle(CycleYearly)
+	if err != nil {
+		return nil, err
+	}
 
-	var result []db.Subscription
+	var result []Charge
 	for _, sub := range yearlySubs {
 		if !sub.NextRenewalDate.Valid {
 			continue
@@ -126,25 +230,28 @@ func (s *SpendingService) getYearlySubscriptionsInPeriod(ctx context.Context, st
 			continue
 		}
 
-		// Check if renewal falls within the period
-		if isDateInPeriod(renewalDate, start, end) {
-			result = append(result, sub)
+		from := start
+		if renewalDate.After(from) {
+			from = renewalDate
+		}
+		for _, date := range yearly.DatesInPeriod(renewalDate, from, end) {
+			result = append(result, Charge{Subscription: sub, Date: date, Amount: BilledAmount(sub)})
 		}
 	}
 
 	return result, nil
 }
 
-// getMonthlySubscriptionsInPeriod returns monthly subscriptions that renew within the given period.
+// getMonthlyChargesInPeriod returns the charges of monthly subscriptions that renew within the given period.
 // A monthly subscription renews on the same day each month. We check if the stored renewal date
 // falls within the period, OR if a future occurrence of that day falls within the period.
-func (s *SpendingService) getMonthlySubscriptionsInPeriod(ctx context.Context, start, end time.Time) ([]db.Subscription, error) {
+func (s *SpendingService) getMonthlyChargesInPeriod(ctx context.Context, start, end time.Time) ([]Charge, error) {
 	monthlySubs, err := s.queries.ListMonthlySubscriptions(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var result []db.Subscription
+	var result []Charge
 	for _, sub := range monthlySubs {
 		if !sub.NextRenewalDate.Valid {
 			continue
@@ -157,7 +264,7 @@ func (s *SpendingService) getMonthlySubscriptionsInPeriod(ctx context.Context, s
 
 		// Check if the stored renewal date itself falls in the period
 		if isDateInPeriod(renewalDate, start, end) {
-			result = append(result, sub)
+			result = append(result, Charge{Subscription: sub, Date: renewalDate, Amount: BilledAmount(sub)})
 			continue
 		}
 
@@ -165,13 +272,213 @@ func (s *SpendingService) getMonthlySubscriptionsInPeriod(ctx context.Context, s
 		// This handles cases where the stored date is in a different month but the day recurs
 		renewalInPeriod := calculateMonthlyRenewalInPeriod(renewalDate.Day(), start, end)
 		if renewalInPeriod != nil {
-			result = append(result, sub)
+			result = append(result, Charge{Subscription: sub, Date: *renewalInPeriod, Amount: BilledAmount(sub)})
 		}
 	}
 
 	return result, nil
 }
 
+// getOtherChargesInPeriod returns every charge within the given period of subscriptions
+// that are billed on a cycle other than monthly or yearly, along with what all of those
+// subscriptions cost per month on average in the base currency
+func (s *SpendingService) getOtherChargesInPeriod(ctx context.Context, converter *Converter, start, end time.Time) ([]Charge, float64, error) {
+	subs, err := s.queries.ListOtherCycleSubscriptions(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var charges []Charge
+	var monthlyEquivalent float64
+	for _, sub := range subs {
+		if !sub.NextRenewalDate.Valid {
+			continue
+		}
+
+		renewalDate, err := time.Parse("2006-01-02", sub.NextRenewalDate.String)
+		if err != nil {
+			continue
+		}
+
+		cycle, err := ParseBillingCycle(sub.BillingCycle)
+		if err != nil {
+			continue
+		}
+
+		if IsRecurring(sub) {
+			amount, _ := converter.Convert(BilledAmount(sub), sub.Currency)
+			monthlyEquivalent += amount * cycle.ChargesPerYear() / 12
+		}
+		for _, date := range cycle.DatesInPeriod(renewalDate, start, end) {
+			charges = append(charges, Charge{Subscription: sub, Date: date, Amount: BilledAmount(sub)})
+		}
+	}
+
+	return charges, monthlyEquivalent, nil
+}
+
+// paidChargesInPeriod returns the payments within the given period as charges.
+// A payment of a deleted subscription or a one-off charge gets a subscription of its own.
+func paidChargesInPeriod(ctx context.Context, queries *db.Queries, start, end time.Time) ([]Charge, error) {
+	payments, err := queries.ListPaymentsInPeriod(ctx, db.ListPaymentsInPeriodParams{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+	})
+	if err != nil || len(payments) == 0 {
+		return nil, err
+	}
+
+	subs, err := queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]db.Subscription, len(subs))
+	for _, sub := range subs {
+		byID[sub.ID] = sub
+	}
+
+	charges := make([]Charge, 0, len(payments))
+	for _, payment := range payments {
+		date, err := time.Parse("2006-01-02", payment.PaidOn)
+		if err != nil {
+			continue
+		}
+		sub, ok := byID[payment.SubscriptionID.Int64]
+		if !ok || !payment.SubscriptionID.Valid {
+			sub = db.Subscription{Name: payment.Name, Status: StatusActive}
+		}
+		// The amount is in the currency it was paid in
+		sub.Currency = payment.Currency
+		charges = append(charges, Charge{Subscription: sub, Date: date, Amount: payment.Amount, Paid: true})
+	}
+	return charges, nil
+}
+
+// projectedCharges returns the charges that aren't in the payment ledger, i.e.
+// that no payment of the same subscription in the same billing period covers.
+// A payment covers the charge nearest to it, so a renewal recorded on Mar 31
+// covers one projected on Mar 28, but a payment never covers two charges.
+// Nothing is projected before recorded, the day renewals are recorded
+// through (zero if they never were), as the ledger has what was paid until
+// then, nor before the renewal date of a subscription, which renewals either
+// moved past or that is its first.
+func projectedCharges(charges, paid []Charge, recorded time.Time) []Charge {
+	payments := make(map[int64][]time.Time)
+	for _, charge := range paid {
+		if charge.Subscription.ID != 0 {
+			payments[charge.Subscription.ID] = append(payments[charge.Subscription.ID], charge.Date)
+		}
+	}
+
+	var projected []Charge
+	for _, charge := range charges {
+		if charge.Date.Before(recorded) {
+			continue
+		}
+		if renewal, ok := parseNullDate(charge.Subscription.NextRenewalDate); ok && charge.Date.Before(renewal) {
+			continue
+		}
+		dates := payments[charge.Subscription.ID]
+		if i := coveringPayment(charge, dates); i >= 0 {
+			payments[charge.Subscription.ID] = slices.Delete(dates, i, i+1)
+			continue
+		}
+		projected = append(projected, charge)
+	}
+	return projected
+}
+
+// coveringPayment returns the index of the payment date nearest to charge
+// that is within its billing period, i.e. after the renewal before it and
+// before the one after it, or -1 if there is none
+func coveringPayment(charge Charge, dates []time.Time) int {
+	cycle, err := ParseBillingCycle(charge.Subscription.BillingCycle)
+	if err != nil {
+		return slices.IndexFunc(dates, charge.Date.Equal)
+	}
+	prev, next := cycle.AddTo(charge.Date, -1), cycle.AddTo(charge.Date, 1)
+	best := -1
+	for i, date := range dates {
+		if !date.After(prev) || !date.Before(next) {
+			continue
+		}
+		if best < 0 || absDuration(date.Sub(charge.Date)) < absDuration(dates[best].Sub(charge.Date)) {
+			best = i
+		}
+	}
+	return best
+}
+
+// absDuration returns the absolute value of d
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// billedCharges returns the charges that are actually billed, see ChargesOn
+func billedCharges(charges []Charge) []Charge {
+	var billed []Charge
+	for _, charge := range charges {
+		if ChargesOn(charge.Subscription, charge.Date) {
+			billed = append(billed, charge)
+		}
+	}
+	return billed
+}
+
+// totalsByCategory sums the converted amount of charges per category
+func totalsByCategory(charges []Charge, names map[int64]string) []CategoryTotal {
+	index := make(map[string]int)
+	counted := make(map[subscriptionKey]bool)
+	var totals []CategoryTotal
+	for _, charge := range charges {
+		name := CategoryName(charge.Subscription, names)
+		i, ok := index[name]
+		if !ok {
+			i = len(totals)
+			index[name] = i
+			totals = append(totals, CategoryTotal{Category: name})
+		}
+		totals[i].Total += charge.Converted
+		if key := keyOf(charge.Subscription); !counted[key] {
+			counted[key] = true
+			totals[i].Count++
+		}
+	}
+	sortCategoryTotals(totals)
+	return totals
+}
+
+// subscriptionKey tells subscriptions apart, including one-off payments that have no ID
+type subscriptionKey struct {
+	id   int64
+	name string
+}
+
+func keyOf(sub db.Subscription) subscriptionKey {
+	if sub.ID != 0 {
+		return subscriptionKey{id: sub.ID}
+	}
+	return subscriptionKey{name: sub.Name}
+}
+
+// subscriptionsOf returns each subscription that has a charge, once, in charge order
+func subscriptionsOf(charges []Charge) []db.Subscription {
+	seen := make(map[subscriptionKey]bool)
+	var subs []db.Subscription
+	for _, charge := range charges {
+		key := keyOf(charge.Subscription)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		subs = append(subs, charge.Subscription)
+	}
+	return subs
+}
+
 // calculateMonthlyRenewalInPeriod determines if a monthly subscription with a given renewal day
 // would renew within the specified period. Returns the renewal date if it falls in the period, nil otherwise.
 func calculateMonthlyRenewalInPeriod(renewalDay int, periodStart, periodEnd time.Time) *time.Time {
@@ -209,44 +516,152 @@ func isDateInPeriod(date, start, end time.Time) bool {
 
 // CalculateForCurrentMonth calculates spending for the current billing period
 func (s *SpendingService) CalculateForCurrentMonth(ctx context.Context) (*SpendingSummary, error) {
-	now := time.Now()
 	cutoffDay, _ := s.configService.GetMonthCutoffDay(ctx)
+	year, month := billingMonth(time.Now(), cutoffDay)
+	return s.CalculateForMonth(ctx, year, month)
+}
 
-	// Determine which period we're in
-	// Period for month M runs from cutoffDay of M-1 to cutoffDay-1 of M
-	// If today >= cutoffDay, we're in next month's period
-	year, month := now.Year(), int(now.Month())
-	if now.Day() >= cutoffDay {
-		// We're past the cutoff, so we're in the next month's period
+// billingMonth returns the year and month of the billing period date falls in.
+// Period for month M runs from cutoffDay of M-1 to cutoffDay-1 of M,
+// so from cutoffDay on, date is in next month's period.
+func billingMonth(date time.Time, cutoffDay int) (int, int) {
+	year, month := date.Year(), int(date.Month())
+	if date.Day() >= cutoffDay {
 		month++
 		if month > 12 {
 			month = 1
 			year++
 		}
 	}
-
-	return s.CalculateForMonth(ctx, year, month)
+	return year, month
 }
 
-// CalculateAnnualTotal calculates total annual spending
+// CalculateAnnualTotal calculates total annual spending in the base currency.
+// Paused and cancelled subscriptions are not counted.
 func (s *SpendingService) CalculateAnnualTotal(ctx context.Context) (float64, error) {
 	subs, err := s.queries.ListSubscriptions(ctx)
 	if err != nil {
 		return 0, err
 	}
 
+	converter, err := s.currencyService.Converter(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	var total float64
 	for _, sub := range subs {
-		if sub.BillingCycle == "monthly" {
-			total += sub.Amount * 12
-		} else {
-			total += sub.Amount
+		if !IsRecurring(sub) {
+			continue
 		}
+		total += annualAmount(sub, converter)
 	}
 
 	return total, nil
 }
 
+// CalculateAnnualByCategory calculates annual spending per category in the base currency
+func (s *SpendingService) CalculateAnnualByCategory(ctx context.Context) ([]CategoryTotal, error) {
+	subs, err := s.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	converter, err := s.currencyService.Converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := categoryNames(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	var totals []CategoryTotal
+	for _, sub := range subs {
+		if !IsRecurring(sub) {
+			continue
+		}
+		name := CategoryName(sub, names)
+		i, ok := index[name]
+		if !ok {
+			i = len(totals)
+			index[name] = i
+			totals = append(totals, CategoryTotal{Category: name})
+		}
+		totals[i].Total += annualAmount(sub, converter)
+		totals[i].Count++
+	}
+	sortCategoryTotals(totals)
+
+	return totals, nil
+}
+
+// CalculateAnnualByCurrency calculates annual spending per currency, in that
+// currency and converted to the base currency
+func (s *SpendingService) CalculateAnnualByCurrency(ctx context.Context) ([]CurrencyTotal, error) {
+	subs, err := s.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	converter, err := s.currencyService.Converter(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	var totals []CurrencyTotal
+	for _, sub := range subs {
+		if !IsRecurring(sub) {
+			continue
+		}
+		i, ok := index[sub.Currency]
+		if !ok {
+			i = len(totals)
+			index[sub.Currency] = i
+			_, hasRate := converter.Convert(0, sub.Currency)
+			totals = append(totals, CurrencyTotal{Currency: sub.Currency, HasRate: hasRate})
+		}
+		totals[i].Total += annualCost(sub)
+		totals[i].Converted += annualAmount(sub, converter)
+		totals[i].Count++
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Converted != totals[j].Converted {
+			return totals[i].Converted > totals[j].Converted
+		}
+		return totals[i].Currency < totals[j].Currency
+	})
+
+	return totals, nil
+}
+
+// annualAmount returns what a subscription costs per year in the base currency,
+// at the post-trial price for a subscription on a trial
+func annualAmount(sub db.Subscription, converter *Converter) float64 {
+	amount, _ := converter.Convert(annualCost(sub), sub.Currency)
+	return amount
+}
+
+// annualCost returns what a subscription costs per year in its own currency
+func annualCost(sub db.Subscription) float64 {
+	amount := BilledAmount(sub)
+	switch sub.BillingCycle {
+	case CycleMonthly:
+		return amount * 12
+	case CycleYearly:
+		return amount
+	default:
+		cycle, err := ParseBillingCycle(sub.BillingCycle)
+		if err != nil {
+			return amount
+		}
+		return amount * cycle.ChargesPerYear()
+	}
+}
+
 // ParseMonth parses a month string (number or name) to an int
 func ParseMonth(monthStr string) (int, error) {
 	if monthStr == "" {
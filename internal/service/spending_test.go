@@ -442,3 +442,192 @@ func TestParseMonth(t *testing.T) {
 		})
 	}
 }
+
+func TestSpendingService_OtherBillingCycles(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Newspaper", Amount: 5.00, Currency: "USD", BillingCycle: "weekly", NextRenewalDate: "2026-01-05"},
+		{Name: "Insurance", Amount: 300.00, Currency: "USD", BillingCycle: "quarterly", NextRenewalDate: "2025-10-20"},
+		{Name: "Netflix", Amount: 15.99, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+
+	// February 2026 period = Jan 1 to Jan 31
+	// - Newspaper renews Jan 5, 12, 19, 26
+	// - Insurance renews Jan 20 (three months after Oct 20)
+	summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, 2)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+
+	if len(summary.OtherItems) != 2 {
+		t.Errorf("other item count = %d, want 2", len(summary.OtherItems))
+	}
+	if len(summary.Charges) != 6 {
+		t.Errorf("charge count = %d, want 6", len(summary.Charges))
+	}
+	if !almostEqual(summary.OtherTotal, 320.00) {
+		t.Errorf("OtherTotal = %.2f, want 320.00", summary.OtherTotal)
+	}
+	if !almostEqual(summary.GrandTotal, 335.99) {
+		t.Errorf("GrandTotal = %.2f, want 335.99", summary.GrandTotal)
+	}
+
+	// March 2026 period = Feb 1 to Feb 28, no insurance renewal
+	summary, err = tdb.SpendingService.CalculateForMonth(ctx, 2026, 3)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+	if !almostEqual(summary.OtherTotal, 20.00) {
+		t.Errorf("OtherTotal = %.2f, want 20.00", summary.OtherTotal)
+	}
+}
+
+func TestSpendingService_CalculateAnnualTotal(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15"},
+		{Name: "Prime", Amount: 100.00, Currency: "USD", BillingCycle: "yearly", NextRenewalDate: "2026-06-01"},
+		{Name: "Insurance", Amount: 50.00, Currency: "USD", BillingCycle: "quarterly", NextRenewalDate: "2026-03-01"},
+		{Name: "Newspaper", Amount: 2.00, Currency: "USD", BillingCycle: "weekly", NextRenewalDate: "2026-01-05"},
+		{Name: "Domain", Amount: 40.00, Currency: "USD", BillingCycle: "every 2 years", NextRenewalDate: "2027-01-01"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+
+	total, err := tdb.SpendingService.CalculateAnnualTotal(ctx)
+	if err != nil {
+		t.Fatalf("CalculateAnnualTotal() error = %v", err)
+	}
+
+	// 120 + 100 + 200 + 104 + 20
+	if !almostEqual(total, 544.00) {
+		t.Errorf("CalculateAnnualTotal() = %.2f, want 544.00", total)
+	}
+}
+
+func TestSpendingService_CalculateAnnualByCurrency(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15"},
+		{Name: "Spotify", Amount: 9.00, Currency: "EUR", BillingCycle: "monthly", NextRenewalDate: "2026-01-20"},
+		{Name: "Figma", Amount: 90.00, Currency: "EUR", BillingCycle: "yearly", NextRenewalDate: "2026-03-01"},
+		{Name: "Kinopoisk", Amount: 300.00, Currency: "RUB", BillingCycle: "monthly", NextRenewalDate: "2026-01-05"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+	if err := tdb.CurrencyService.SetRate(ctx, "EUR", 0.90); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+
+	totals, err := tdb.SpendingService.CalculateAnnualByCurrency(ctx)
+	if err != nil {
+		t.Fatalf("CalculateAnnualByCurrency() error = %v", err)
+	}
+
+	// RUB has no rate and is counted unconverted, so it sorts first
+	want := []service.CurrencyTotal{
+		{Currency: "RUB", Total: 3600, Converted: 3600, HasRate: false, Count: 1},
+		{Currency: "EUR", Total: 198, Converted: 220, HasRate: true, Count: 2},
+		{Currency: "USD", Total: 120, Converted: 120, HasRate: true, Count: 1},
+	}
+	if len(totals) != len(want) {
+		t.Fatalf("CalculateAnnualByCurrency() = %+v, want %+v", totals, want)
+	}
+	for i, w := range want {
+		got := totals[i]
+		if got.Currency != w.Currency || !almostEqual(got.Total, w.Total) || !almostEqual(got.Converted, w.Converted) ||
+			got.HasRate != w.HasRate || got.Count != w.Count {
+			t.Errorf("CalculateAnnualByCurrency()[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestSpendingService_MixedCurrencies(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15"},
+		{Name: "Spotify", Amount: 9.00, Currency: "EUR", BillingCycle: "monthly", NextRenewalDate: "2026-01-20"},
+		{Name: "Guardian", Amount: 100.00, Currency: "GBP", BillingCycle: "yearly", NextRenewalDate: "2026-01-10"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+
+	// 1 USD = 0.90 EUR = 0.80 GBP
+	if err := tdb.CurrencyService.SetRate(ctx, "EUR", 0.90); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+	if err := tdb.CurrencyService.SetRate(ctx, "GBP", 0.80); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+
+	summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, 2)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+
+	if summary.BaseCurrency != "USD" {
+		t.Errorf("BaseCurrency = %q, want USD", summary.BaseCurrency)
+	}
+	// 10 + 9 / 0.9
+	if !almostEqual(summary.MonthlyTotal, 20.00) {
+		t.Errorf("MonthlyTotal = %.2f, want 20.00", summary.MonthlyTotal)
+	}
+	// 100 / 0.8
+	if !almostEqual(summary.YearlyTotal, 125.00) {
+		t.Errorf("YearlyTotal = %.2f, want 125.00", summary.YearlyTotal)
+	}
+	if len(summary.MissingRates) != 0 {
+		t.Errorf("MissingRates = %v, want none", summary.MissingRates)
+	}
+	for _, charge := range summary.Charges {
+		if charge.Subscription.Name == "Spotify" && (charge.Amount != 9.00 || !almostEqual(charge.Converted, 10.00)) {
+			t.Errorf("Spotify charge = %.2f (%.2f converted), want 9.00 (10.00 converted)", charge.Amount, charge.Converted)
+		}
+	}
+
+	total, err := tdb.SpendingService.CalculateAnnualTotal(ctx)
+	if err != nil {
+		t.Fatalf("CalculateAnnualTotal() error = %v", err)
+	}
+	// 120 + 120 + 125
+	if !almostEqual(total, 365.00) {
+		t.Errorf("CalculateAnnualTotal() = %.2f, want 365.00", total)
+	}
+
+	// Without a rate the amount is counted as is and the currency is reported
+	if err := tdb.CurrencyService.DeleteRate(ctx, "GBP"); err != nil {
+		t.Fatalf("DeleteRate() error = %v", err)
+	}
+	summary, err = tdb.SpendingService.CalculateForMonth(ctx, 2026, 2)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+	if !almostEqual(summary.YearlyTotal, 100.00) {
+		t.Errorf("YearlyTotal = %.2f, want 100.00", summary.YearlyTotal)
+	}
+	if len(summary.MissingRates) != 1 || summary.MissingRates[0] != "GBP" {
+		t.Errorf("MissingRates = %v, want [GBP]", summary.MissingRates)
+	}
+}
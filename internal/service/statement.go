@@ -0,0 +1,541 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatementFormat is the file format of a bank statement
+type StatementFormat string
+
+const (
+	StatementOFX StatementFormat = "ofx" // OFX 1.x (SGML) and 2.x (XML), including Quicken's QFX
+	StatementQIF StatementFormat = "qif"
+	StatementCSV StatementFormat = "csv" // Columns as described by a StatementMapping
+)
+
+// StatementFormatOf guesses the format of a bank statement from its file extension
+func StatementFormatOf(path string) StatementFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ofx", ".qfx":
+		return StatementOFX
+	case ".qif":
+		return StatementQIF
+	}
+	return StatementCSV
+}
+
+// Transaction is one entry of a bank statement
+type Transaction struct {
+	Date     time.Time
+	Payee    string
+	Amount   float64 // Negative for money going out
+	Currency string  // Empty when the statement doesn't say
+}
+
+// StatementMapping tells which columns of a CSV statement hold what. Columns
+// are named by their header, or numbered from 1 when the file has no header.
+// Empty columns are guessed from common header names.
+type StatementMapping struct {
+	Date           string
+	Payee          string
+	Amount         string // Signed amount; or use Debit and Credit
+	Debit          string // Money going out, as a positive number
+	Credit         string // Money coming in
+	DateFormat     string // Go time layout; by default the first known layout that fits every date is used
+	Delimiter      rune   // Guessed from the first line when 0
+	DecimalComma   bool   // Amounts are written as 1.234,56
+	DebitsPositive bool   // The Amount column is positive for money going out
+	NoHeader       bool
+}
+
+// Header names guessed for each column, in order of preference
+var (
+	dateHeaders   = []string{"date", "transaction date", "posted date", "posting date", "booking date", "value date"}
+	payeeHeaders  = []string{"payee", "description", "name", "merchant", "counterparty", "details", "memo"}
+	amountHeaders = []string{"amount", "transaction amount", "value"}
+	debitHeaders  = []string{"debit", "withdrawal", "withdrawals", "money out", "paid out"}
+	creditHeaders = []string{"credit", "deposit", "deposits", "money in", "paid in"}
+)
+
+// ParseStatement reads the transactions of a bank statement. The mapping is only used for CSV files.
+func ParseStatement(r io.Reader, format StatementFormat, mapping StatementMapping) ([]Transaction, error) {
+	switch format {
+	case StatementOFX:
+		return parseOFX(r)
+	case StatementQIF:
+		return parseQIF(r)
+	case StatementCSV:
+		return parseStatementCSV(r, mapping)
+	}
+	return nil, fmt.Errorf("unsupported statement format: %s (use ofx, qif or csv)", format)
+}
+
+// ofxTag matches an OFX element and its value; SGML files leave out the closing tags
+var ofxTag = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+func parseOFX(r io.Reader) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+
+	var txns []Transaction
+	var current *Transaction
+	var currency, name, memo string
+	for _, m := range ofxTag.FindAllStringSubmatch(string(data), -1) {
+		closing, tag, value := m[1] == "/", strings.ToUpper(m[2]), strings.TrimSpace(m[3])
+		switch {
+		case tag == "CURDEF" && !closing:
+			currency = strings.ToUpper(value)
+		case tag == "STMTTRN" && !closing:
+			current, name, memo = &Transaction{}, "", ""
+		case tag == "STMTTRN" && closing && current != nil:
+			current.Payee = name
+			if current.Payee == "" {
+				current.Payee = memo
+			}
+			current.Currency = currency
+			if current.Date.IsZero() {
+				return nil, fmt.Errorf("transaction %q has no date", current.Payee)
+			}
+			txns = append(txns, *current)
+			current = nil
+		case current == nil || closing:
+		case tag == "DTPOSTED":
+			if len(value) < 8 {
+				return nil, fmt.Errorf("invalid OFX date: %q", value)
+			}
+			if current.Date, err = time.Parse("20060102", value[:8]); err != nil {
+				return nil, fmt.Errorf("invalid OFX date: %q", value)
+			}
+		case tag == "TRNAMT":
+			if current.Amount, err = parseStatementAmount(value, false); err != nil {
+				return nil, err
+			}
+		case tag == "NAME" || tag == "PAYEE":
+			name = ofxUnescape(value)
+		case tag == "MEMO":
+			memo = ofxUnescape(value)
+		}
+	}
+	if len(txns) == 0 {
+		return nil, fmt.Errorf("no transactions found in the OFX file")
+	}
+	return txns, nil
+}
+
+func ofxUnescape(s string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&apos;", "'", "&quot;", `"`).Replace(s)
+}
+
+func parseQIF(r io.Reader) ([]Transaction, error) {
+	var txns []Transaction
+	var current Transaction
+	var memo string
+	var date qifDate
+	var dates []qifDate
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "!") {
+			continue
+		}
+		field, value := text[0], strings.TrimSpace(text[1:])
+		switch field {
+		case 'D':
+			date = qifDate{value, line}
+		case 'T', 'U':
+			amount, err := parseStatementAmount(value, false)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			current.Amount = amount
+		case 'P':
+			current.Payee = value
+		case 'M':
+			memo = value
+		case '^':
+			if current.Payee == "" {
+				current.Payee = memo
+			}
+			if date.value != "" {
+				txns = append(txns, current)
+				dates = append(dates, date)
+			}
+			current, memo, date = Transaction{}, "", qifDate{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+	if len(txns) == 0 {
+		return nil, fmt.Errorf("no transactions found in the QIF file")
+	}
+
+	dayFirst, err := qifDayFirst(dates)
+	if err != nil {
+		return nil, err
+	}
+	for i, d := range dates {
+		if txns[i].Date, err = parseQIFDate(d.value, dayFirst); err != nil {
+			return nil, fmt.Errorf("line %d: %w", d.line, err)
+		}
+	}
+	return txns, nil
+}
+
+// qifDate is the raw date of a QIF transaction and its line in the file
+type qifDate struct {
+	value string
+	line  int
+}
+
+// qifDayFirst tells whether the dates of a QIF file are day-first. QIF dates
+// are month-first unless one of them can only be read day-first; a file that
+// has dates of both kinds is rejected rather than read row by row.
+func qifDayFirst(dates []qifDate) (bool, error) {
+	var dayFirst, monthFirst string
+	for _, d := range dates {
+		n, iso, err := qifDateParts(d.value)
+		if err != nil {
+			return false, fmt.Errorf("line %d: %w", d.line, err)
+		}
+		switch {
+		case iso:
+		case n[0] > 12 && dayFirst == "":
+			dayFirst = d.value
+		case n[1] > 12 && monthFirst == "":
+			monthFirst = d.value
+		}
+	}
+	if dayFirst != "" && monthFirst != "" {
+		return false, fmt.Errorf("the QIF file mixes day-first and month-first dates, such as %q and %q", dayFirst, monthFirst)
+	}
+	return dayFirst != "", nil
+}
+
+// qifDateParts splits QIF dates such as 1/15/2026, 01/15'26 or 1-15-26 into
+// their three numbers; iso is set for year-first dates
+func qifDateParts(s string) (n []int, iso bool, err error) {
+	parts := strings.FieldsFunc(strings.ReplaceAll(s, " ", ""), func(r rune) bool {
+		return r == '/' || r == '-' || r == '\'' || r == '.'
+	})
+	if len(parts) != 3 {
+		return nil, false, fmt.Errorf("invalid QIF date: %q", s)
+	}
+	n = make([]int, 3)
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid QIF date: %q", s)
+		}
+		n[i] = v
+	}
+	return n, len(parts[0]) == 4, nil
+}
+
+// parseQIFDate parses a month-first or, with dayFirst, a day-first QIF date;
+// ISO dates are taken either way
+func parseQIFDate(s string, dayFirst bool) (time.Time, error) {
+	n, iso, err := qifDateParts(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	year, month, day := n[2], n[0], n[1]
+	switch {
+	case iso:
+		year, month, day = n[0], n[1], n[2]
+	case dayFirst:
+		month, day = day, month
+	}
+	if year < 100 {
+		year += 2000
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if month < 1 || month > 12 || date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid QIF date: %q", s)
+	}
+	return date, nil
+}
+
+func parseStatementCSV(r io.Reader, mapping StatementMapping) ([]Transaction, error) {
+	buffered := bufio.NewReader(r)
+	if mapping.Delimiter == 0 {
+		first, _ := buffered.ReadString('\n')
+		mapping.Delimiter = guessDelimiter(first)
+		r = io.MultiReader(strings.NewReader(first), buffered)
+	} else {
+		r = buffered
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comma = mapping.Delimiter
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("the file is empty")
+	}
+
+	var header []string
+	first := 0
+	if !mapping.NoHeader {
+		header, first = records[0], 1
+		for i := range header {
+			header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+		}
+	}
+
+	column := func(name string, guesses []string) (int, error) {
+		if name == "" {
+			for _, guess := range guesses {
+				for i, h := range header {
+					if h == guess {
+						return i, nil
+					}
+				}
+			}
+			return -1, nil
+		}
+		if n, err := strconv.Atoi(name); err == nil && n >= 1 {
+			return n - 1, nil
+		}
+		for i, h := range header {
+			if h == strings.ToLower(strings.TrimSpace(name)) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("column %q not found in the header", name)
+	}
+
+	cols := make(map[string]int)
+	for _, c := range []struct {
+		key     string
+		name    string
+		guesses []string
+	}{
+		{"date", mapping.Date, dateHeaders},
+		{"payee", mapping.Payee, payeeHeaders},
+		{"amount", mapping.Amount, amountHeaders},
+		{"debit", mapping.Debit, debitHeaders},
+		{"credit", mapping.Credit, creditHeaders},
+	} {
+		i, err := column(c.name, c.guesses)
+		if err != nil {
+			return nil, err
+		}
+		cols[c.key] = i
+	}
+	if cols["date"] < 0 || cols["payee"] < 0 {
+		return nil, fmt.Errorf("date and payee columns are required; name them with the column mapping")
+	}
+	if cols["amount"] < 0 && cols["debit"] < 0 {
+		return nil, fmt.Errorf("an amount or debit column is required; name it with the column mapping")
+	}
+
+	cell := func(record []string, key string) string {
+		if c := cols[key]; c >= 0 && c < len(record) {
+			return strings.TrimSpace(record[c])
+		}
+		return ""
+	}
+	blank := func(record []string) bool {
+		return strings.TrimSpace(strings.Join(record, "")) == ""
+	}
+
+	layout := mapping.DateFormat
+	if layout == "" {
+		var dates []string
+		for _, record := range records[first:] {
+			if !blank(record) {
+				dates = append(dates, trimTimestamp(cell(record, "date")))
+			}
+		}
+		if layout, err = statementDateLayout(dates); err != nil {
+			return nil, err
+		}
+	}
+
+	var txns []Transaction
+	for i, record := range records[first:] {
+		line := i + first + 1
+		field := func(key string) string { return cell(record, key) }
+		if blank(record) {
+			continue
+		}
+
+		value := field("date")
+		if mapping.DateFormat == "" {
+			value = trimTimestamp(value)
+		}
+		date, err := parseStatementDate(value, layout)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		txn := Transaction{Date: date, Payee: field("payee")}
+
+		if cols["amount"] >= 0 && field("amount") != "" {
+			if txn.Amount, err = parseStatementAmount(field("amount"), mapping.DecimalComma); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if mapping.DebitsPositive {
+				txn.Amount = -txn.Amount
+			}
+		} else {
+			for key, sign := range map[string]float64{"debit": -1, "credit": 1} {
+				if field(key) == "" {
+					continue
+				}
+				amount, err := parseStatementAmount(field(key), mapping.DecimalComma)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				// Some banks write debits as negative numbers already
+				if amount < 0 {
+					amount = -amount
+				}
+				txn.Amount += sign * amount
+			}
+		}
+		txns = append(txns, txn)
+	}
+	if len(txns) == 0 {
+		return nil, fmt.Errorf("no transactions found in the CSV file")
+	}
+	return txns, nil
+}
+
+// guessDelimiter picks the most common of the usual CSV delimiters in a line;
+// banks in countries with decimal commas often separate fields with semicolons
+func guessDelimiter(line string) rune {
+	delimiter, most := ',', strings.Count(line, ",")
+	for _, d := range []rune{';', '\t', '|'} {
+		if n := strings.Count(line, string(d)); n > most {
+			delimiter, most = d, n
+		}
+	}
+	return delimiter
+}
+
+// statementDateLayouts are tried in order when a CSV mapping has no date format
+var statementDateLayouts = []string{"2006-01-02", "2006/01/02", "02.01.2006", "01/02/2006", "02/01/2006", "1/2/2006", "2 Jan 2006", "Jan 2, 2006", "20060102"}
+
+// statementDateLayout picks the first layout that parses every date of a CSV
+// column, so that 03/04 and 13/04 in one file are both read day-first. When
+// another layout also parses every date but reads some of them differently,
+// the column is ambiguous and the format has to be given in the mapping.
+func statementDateLayout(dates []string) (string, error) {
+	var layout string
+	var parsed []time.Time
+	for _, l := range statementDateLayouts {
+		column, ok := parseStatementDates(dates, l)
+		if !ok {
+			continue
+		}
+		if layout == "" {
+			layout, parsed = l, column
+			continue
+		}
+		for i := range column {
+			if !column[i].Equal(parsed[i]) {
+				return "", fmt.Errorf("dates such as %q can be read as %s or %s, give their format in the column mapping", dates[i], layout, l)
+			}
+		}
+	}
+	if layout != "" {
+		return layout, nil
+	}
+
+	for _, s := range dates {
+		if _, ok := parseStatementDates([]string{s}, ""); !ok {
+			return "", fmt.Errorf("invalid date %q, give its format in the column mapping", s)
+		}
+	}
+	return "", fmt.Errorf("the dates don't share one format, give it in the column mapping")
+}
+
+// parseStatementDates parses every date with a layout, or with any of the
+// statementDateLayouts when the layout is empty
+func parseStatementDates(dates []string, layout string) ([]time.Time, bool) {
+	layouts := statementDateLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	parsed := make([]time.Time, len(dates))
+	for i, s := range dates {
+		ok := false
+		for _, l := range layouts {
+			if date, err := time.Parse(l, s); err == nil {
+				parsed[i], ok = date, true
+				break
+			}
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return parsed, true
+}
+
+// trimTimestamp drops the time of timestamps such as 2026-01-15T10:00:00 or 2026-01-15 10:00
+func trimTimestamp(s string) string {
+	if len(s) > 10 && (s[10] == 'T' || s[10] == ' ') {
+		return s[:10]
+	}
+	return s
+}
+
+func parseStatementDate(s, layout string) (time.Time, error) {
+	date, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected the format %s", s, layout)
+	}
+	return date, nil
+}
+
+// parseStatementAmount parses amounts such as -1,234.56, (15.99), 15.99- or $15.99
+func parseStatementAmount(s string, decimalComma bool) (float64, error) {
+	value := strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value, negative = value[1:len(value)-1], true
+	}
+	if strings.HasSuffix(value, "-") {
+		value, negative = strings.TrimSuffix(value, "-"), true
+	}
+	value = strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' || r == '-' || r == '+' {
+			return r
+		}
+		return -1
+	}, value)
+	if decimalComma {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %q", s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
@@ -0,0 +1,230 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestParseStatement(t *testing.T) {
+	ofx := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>EUR
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20260115120000[0:GMT]<TRNAMT>-15.99<NAME>NETFLIX.COM<MEMO>Card 1234
+</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20260116<TRNAMT>2500.00<MEMO>Salary &amp; bonus
+</STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+
+	qif := `!Type:Bank
+D1/15/2026
+T-15.99
+PNETFLIX.COM
+^
+D01/16'26
+T2,500.00
+MSalary
+^`
+
+	tests := []struct {
+		name    string
+		format  service.StatementFormat
+		mapping service.StatementMapping
+		data    string
+	}{
+		{"ofx", service.StatementOFX, service.StatementMapping{}, ofx},
+		{"qif", service.StatementQIF, service.StatementMapping{}, qif},
+		{"csv guessed columns", service.StatementCSV, service.StatementMapping{},
+			"Transaction Date,Description,Amount\n2026-01-15,NETFLIX.COM,-15.99\n2026-01-16,Salary,\"2,500.00\"\n"},
+		{"csv debit and credit", service.StatementCSV, service.StatementMapping{DateFormat: "02.01.2006", DecimalComma: true},
+			"Booking Date;Counterparty;Debit;Credit\n15.01.2026;NETFLIX.COM;15,99;\n16.01.2026;Salary;;2.500,00\n"},
+		{"csv numbered columns", service.StatementCSV, service.StatementMapping{Date: "3", Payee: "1", Amount: "2", NoHeader: true, DebitsPositive: true},
+			"NETFLIX.COM,15.99,01/15/2026\nSalary,(2500.00),01/16/2026\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txns, err := service.ParseStatement(strings.NewReader(tt.data), tt.format, tt.mapping)
+			if err != nil {
+				t.Fatalf("ParseStatement() error = %v", err)
+			}
+			if len(txns) != 2 {
+				t.Fatalf("ParseStatement() = %+v, want 2 transactions", txns)
+			}
+			netflix, salary := txns[0], txns[1]
+			if netflix.Payee != "NETFLIX.COM" || netflix.Amount != -15.99 || netflix.Date.Format("2006-01-02") != "2026-01-15" {
+				t.Errorf("first transaction = %+v, want NETFLIX.COM -15.99 on 2026-01-15", netflix)
+			}
+			if !strings.HasPrefix(salary.Payee, "Salary") || salary.Amount != 2500 || salary.Date.Format("2006-01-02") != "2026-01-16" {
+				t.Errorf("second transaction = %+v, want Salary 2500 on 2026-01-16", salary)
+			}
+		})
+	}
+
+	if txns, _ := service.ParseStatement(strings.NewReader(ofx), service.StatementOFX, service.StatementMapping{}); txns[0].Currency != "EUR" {
+		t.Errorf("OFX currency = %q, want EUR", txns[0].Currency)
+	}
+
+	for _, bad := range []struct {
+		name    string
+		format  service.StatementFormat
+		mapping service.StatementMapping
+		data    string
+	}{
+		{"empty OFX", service.StatementOFX, service.StatementMapping{}, "<OFX></OFX>"},
+		{"unknown columns", service.StatementCSV, service.StatementMapping{}, "When,Who,How much\n2026-01-15,Netflix,-15.99\n"},
+		{"missing column", service.StatementCSV, service.StatementMapping{Payee: "Merchant"}, "Date,Payee,Amount\n2026-01-15,Netflix,-15.99\n"},
+		{"bad date", service.StatementCSV, service.StatementMapping{}, "Date,Payee,Amount\nyesterday,Netflix,-15.99\n"},
+		{"ambiguous dates", service.StatementCSV, service.StatementMapping{}, "Date,Payee,Amount\n03/04/2026,Netflix,-15.99\n04/03/2026,Netflix,-15.99\n"},
+		{"mixed dates", service.StatementCSV, service.StatementMapping{}, "Date,Payee,Amount\n2026-01-15,Netflix,-15.99\n01/16/2026,Salary,2500\n"},
+		{"mixed QIF dates", service.StatementQIF, service.StatementMapping{}, "D13/01/2026\nT-15.99\n^\nD01/14/2026\nT-15.99\n^\n"},
+		{"format", "mt940", service.StatementMapping{}, ""},
+	} {
+		if _, err := service.ParseStatement(strings.NewReader(bad.data), bad.format, bad.mapping); err == nil {
+			t.Errorf("ParseStatement() should reject the %s", bad.name)
+		}
+	}
+}
+
+func TestParseStatement_OneDateLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		format service.StatementFormat
+		data   string
+	}{
+		{"csv", service.StatementCSV, "Date,Payee,Amount\n03/04/2026,Netflix,-15.99\n13/04/2026 09:30,Spotify,-9.99\n"},
+		{"qif", service.StatementQIF, "!Type:Bank\nD03/04'26\nT-15.99\nPNetflix\n^\nD13/04'26\nT-9.99\nPSpotify\n^\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txns, err := service.ParseStatement(strings.NewReader(tt.data), tt.format, service.StatementMapping{})
+			if err != nil {
+				t.Fatalf("ParseStatement() error = %v", err)
+			}
+			// 13/04 can only be day-first, so 03/04 is read day-first too
+			for i, want := range []string{"2026-04-03", "2026-04-13"} {
+				if got := txns[i].Date.Format("2006-01-02"); got != want {
+					t.Errorf("transaction %d date = %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// statementCSV builds a CSV statement with a charge every interval days
+func statementCSV(lines *[]string, payee string, amount float64, start string, interval, count int) {
+	date, _ := time.Parse("2006-01-02", start)
+	for i := 0; i < count; i++ {
+		*lines = append(*lines, fmt.Sprintf("%s,%s,%.2f", date.AddDate(0, 0, i*interval).Format("2006-01-02"), payee, -amount))
+	}
+}
+
+func TestStatementService_DetectFrom(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Spotify", Amount: 10.99, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-07-03",
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	lines := []string{"Date,Description,Amount"}
+	// Monthly, with card numbers that change and a price rise from 15.49 to 15.99
+	for i, day := range []string{"2026-01-15", "2026-02-16", "2026-03-15", "2026-04-14", "2026-05-15", "2026-06-15"} {
+		amount := 15.49
+		if i >= 3 {
+			amount = 15.99
+		}
+		lines = append(lines, fmt.Sprintf("%s,NETFLIX.COM %d,%.2f", day, 8660+i, -amount))
+	}
+	statementCSV(&lines, "SPOTIFY P0123", 10.99, "2026-01-03", 31, 6)
+	statementCSV(&lines, "POS PURCHASE GYM CLUB", 4, "2026-05-01", 7, 8)
+	statementCSV(&lines, "Domain Registrar Inc", 12, "2025-03-10", 365, 2)
+	// Irregular shopping, a subscription that ended and a refund
+	lines = append(lines, "2026-01-03,Grocery Store,-54.20", "2026-01-20,Grocery Store,-12.00", "2026-02-28,Grocery Store,-80.10", "2026-03-02,Grocery Store,-23.00")
+	statementCSV(&lines, "Old Magazine", 5, "2025-10-01", 30, 4)
+	statementCSV(&lines, "Insurance Refund", -20, "2026-01-01", 30, 6)
+	lines = append(lines, "2026-06-25,Coffee,-3.50")
+
+	today := time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)
+	candidates, err := tdb.StatementService.DetectFrom(ctx, strings.NewReader(strings.Join(lines, "\n")), service.StatementCSV, service.StatementMapping{}, 0, today)
+	if err != nil {
+		t.Fatalf("DetectFrom() error = %v", err)
+	}
+
+	want := map[string]struct {
+		amount  float64
+		cycle   string
+		renewal string
+		count   int
+	}{
+		"Netflix":          {15.99, "monthly", "2026-07-15", 6},
+		"Spotify":          {10.99, "monthly", "2026-07-07", 6},
+		"Gym Club":         {4, "weekly", "2026-06-26", 8},
+		"Domain Registrar": {12, "yearly", "2027-03-10", 2},
+	}
+	if len(candidates) != len(want) {
+		t.Fatalf("DetectFrom() = %+v, want %d candidates", candidates, len(want))
+	}
+	for _, c := range candidates {
+		w, ok := want[c.Name]
+		if !ok {
+			t.Errorf("DetectFrom() found %q, want only %v", c.Name, want)
+			continue
+		}
+		if !almostEqual(c.Amount, w.amount) || c.BillingCycle != w.cycle || c.NextRenewalDate != w.renewal || c.Occurrences != w.count || c.Currency != "USD" {
+			t.Errorf("%s = %.2f %s %s every %s after %d charges, want %.2f USD %s every %s after %d",
+				c.Name, c.Amount, c.Currency, c.NextRenewalDate, c.BillingCycle, c.Occurrences, w.amount, w.renewal, w.cycle, w.count)
+		}
+		if (c.Name == "Spotify") != (c.ExistingID != 0) {
+			t.Errorf("%s ExistingID = %d, want only Spotify flagged as tracked", c.Name, c.ExistingID)
+		}
+	}
+
+	// Accepting a candidate tracks it as a subscription
+	var netflix service.RecurringCandidate
+	for _, c := range candidates {
+		if c.Name == "Netflix" {
+			netflix = c
+		}
+	}
+	sub, err := tdb.StatementService.Accept(ctx, netflix)
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	if sub.Name != "Netflix" || sub.Amount != 15.99 || sub.BillingCycle != "monthly" || sub.NextRenewalDate.String != "2026-07-15" {
+		t.Errorf("Accept() = %+v, want Netflix at 15.99 monthly from 2026-07-15", sub)
+	}
+}
+
+func TestDetectRecurring_SeparatesAmounts(t *testing.T) {
+	var txns []service.Transaction
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		date := start.AddDate(0, i, 0)
+		txns = append(txns,
+			service.Transaction{Date: date, Payee: "Google Storage", Amount: -1.99, Currency: "USD"},
+			service.Transaction{Date: date.AddDate(0, 0, 3), Payee: "Google Storage", Amount: -9.99, Currency: "USD"},
+		)
+	}
+
+	candidates := service.DetectRecurring(txns, 3, start.AddDate(0, 3, 4))
+	if len(candidates) != 2 {
+		t.Fatalf("DetectRecurring() = %+v, want the 1.99 and 9.99 plans apart", candidates)
+	}
+	amounts := map[float64]bool{candidates[0].Amount: true, candidates[1].Amount: true}
+	if !amounts[1.99] || !amounts[9.99] {
+		t.Errorf("DetectRecurring() amounts = %v, want 1.99 and 9.99", amounts)
+	}
+
+	// Two charges are not enough for a monthly subscription
+	if candidates := service.DetectRecurring(txns[:4], 3, start); len(candidates) != 0 {
+		t.Errorf("DetectRecurring() = %+v, want nothing from two charges each", candidates)
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// Subscription lifecycle states
+const (
+	StatusActive    = "active"
+	StatusTrial     = "trial"     // Free until TrialEndDate, then billed
+	StatusPaused    = "paused"    // Not billed from PauseDate until resumed
+	StatusCancelled = "cancelled" // Not billed from CancelDate; kept for reference
+)
+
+// Statuses lists the lifecycle states in display order
+var Statuses = []string{StatusActive, StatusTrial, StatusPaused, StatusCancelled}
+
+// ChargesOn reports whether a renewal of sub on date is billed: not during a
+// free trial, and not once the subscription is paused or cancelled
+func ChargesOn(sub db.Subscription, date time.Time) bool {
+	switch sub.Status {
+	case StatusTrial:
+		end, ok := parseNullDate(sub.TrialEndDate)
+		return ok && !date.Before(end)
+	case StatusPaused:
+		pause, ok := parseNullDate(sub.PauseDate)
+		return ok && date.Before(pause)
+	case StatusCancelled:
+		cancel, ok := parseNullDate(sub.CancelDate)
+		return ok && date.Before(cancel)
+	}
+	return true
+}
+
+// IsRecurring reports whether sub keeps renewing, i.e. it is active or on a trial
+func IsRecurring(sub db.Subscription) bool {
+	return sub.Status != StatusPaused && sub.Status != StatusCancelled
+}
+
+// parseNullDate parses an optional YYYY-MM-DD date
+func parseNullDate(s sql.NullString) (time.Time, bool) {
+	if !s.Valid {
+		return time.Time{}, false
+	}
+	date, err := time.Parse("2006-01-02", s.String)
+	return date, err == nil
+}
+
+// nullDate returns date as an optional column value, NULL when empty
+func nullDate(date string) sql.NullString {
+	return sql.NullString{String: date, Valid: date != ""}
+}
+
+// effectiveDate parses a YYYY-MM-DD date, defaulting to today when empty
+func effectiveDate(date string) (string, error) {
+	if date == "" {
+		return time.Now().Format("2006-01-02"), nil
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return "", fmt.Errorf("invalid date format, use YYYY-MM-DD: %w", err)
+	}
+	return date, nil
+}
+
+// Pause stops billing a subscription from date (YYYY-MM-DD, default today) until it is resumed
+func (s *SubscriptionService) Pause(ctx context.Context, id int64, date string) (db.Subscription, error) {
+	sub, err := s.queries.GetSubscription(ctx, id)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+	if !IsRecurring(sub) {
+		return db.Subscription{}, fmt.Errorf("%s is %s", sub.Name, sub.Status)
+	}
+
+	date, err = effectiveDate(date)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+
+	sub, err = s.queries.UpdateSubscriptionStatus(ctx, db.UpdateSubscriptionStatusParams{
+		ID:           id,
+		Status:       StatusPaused,
+		TrialEndDate: sub.TrialEndDate,
+		PauseDate:    sql.NullString{String: date, Valid: true},
+	})
+	return s.updated(ctx, sub, err)
+}
+
+// Cancel stops billing a subscription from date (YYYY-MM-DD, default today).
+// The subscription is kept for reference and can be resumed.
+func (s *SubscriptionService) Cancel(ctx context.Context, id int64, date string) (db.Subscription, error) {
+	sub, err := s.queries.GetSubscription(ctx, id)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+	if sub.Status == StatusCancelled {
+		return db.Subscription{}, fmt.Errorf("%s is already cancelled", sub.Name)
+	}
+
+	date, err = effectiveDate(date)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+
+	sub, err = s.queries.UpdateSubscriptionStatus(ctx, db.UpdateSubscriptionStatusParams{
+		ID:           id,
+		Status:       StatusCancelled,
+		TrialEndDate: sub.TrialEndDate,
+		CancelDate:   sql.NullString{String: date, Valid: true},
+	})
+	return s.updated(ctx, sub, err)
+}
+
+// Resume reactivates a paused or cancelled subscription and moves its renewal
+// date past today. A subscription whose trial hasn't ended returns to its trial.
+func (s *SubscriptionService) Resume(ctx context.Context, id int64) (db.Subscription, error) {
+	return s.resumeFrom(ctx, id, time.Now())
+}
+
+func (s *SubscriptionService) resumeFrom(ctx context.Context, id int64, referenceTime time.Time) (db.Subscription, error) {
+	sub, err := s.queries.GetSubscription(ctx, id)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+	if IsRecurring(sub) {
+		return db.Subscription{}, fmt.Errorf("%s is not paused or cancelled", sub.Name)
+	}
+
+	today := time.Date(referenceTime.Year(), referenceTime.Month(), referenceTime.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Record what was billed before the pause or cancellation took effect
+	from, _ := recordedThrough(ctx, s.queries)
+	if err := s.recordRenewals(ctx, sub, from, today); err != nil {
+		return db.Subscription{}, err
+	}
+
+	status := StatusActive
+	if end, ok := parseNullDate(sub.TrialEndDate); ok && !end.Before(today) {
+		status = StatusTrial
+	}
+
+	sub, err = s.queries.UpdateSubscriptionStatus(ctx, db.UpdateSubscriptionStatusParams{
+		ID:           id,
+		Status:       status,
+		TrialEndDate: sub.TrialEndDate,
+	})
+	if err != nil {
+		return db.Subscription{}, err
+	}
+
+	sub, err = s.advanceRenewalDate(ctx, sub, today)
+	return s.updated(ctx, sub, err)
+}
@@ -0,0 +1,190 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestSubscriptionService_PauseCancelResume(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2020-01-15",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if sub.Status != service.StatusActive {
+		t.Errorf("Create() status = %q, want %q", sub.Status, service.StatusActive)
+	}
+
+	if _, err := tdb.SubscriptionService.Resume(ctx, sub.ID); err == nil {
+		t.Error("Resume() should fail for an active subscription")
+	}
+	if _, err := tdb.SubscriptionService.Pause(ctx, sub.ID, "2020-13-01"); err == nil {
+		t.Error("Pause() should reject an invalid date")
+	}
+
+	paused, err := tdb.SubscriptionService.Pause(ctx, sub.ID, "2020-03-01")
+	if err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if paused.Status != service.StatusPaused || paused.PauseDate.String != "2020-03-01" {
+		t.Errorf("Pause() = %s from %v, want paused from 2020-03-01", paused.Status, paused.PauseDate)
+	}
+	if _, err := tdb.SubscriptionService.Pause(ctx, sub.ID, ""); err == nil {
+		t.Error("Pause() should fail for a paused subscription")
+	}
+
+	cancelled, err := tdb.SubscriptionService.Cancel(ctx, sub.ID, "")
+	if err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if cancelled.Status != service.StatusCancelled || cancelled.CancelDate.String != time.Now().Format("2006-01-02") {
+		t.Errorf("Cancel() = %s from %v, want cancelled from today", cancelled.Status, cancelled.CancelDate)
+	}
+	if _, err := tdb.SubscriptionService.Cancel(ctx, sub.ID, ""); err == nil {
+		t.Error("Cancel() should fail for a cancelled subscription")
+	}
+
+	// Resuming clears the dates and moves the stale renewal date past today
+	resumed, err := tdb.SubscriptionService.Resume(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if resumed.Status != service.StatusActive || resumed.PauseDate.Valid || resumed.CancelDate.Valid {
+		t.Errorf("Resume() = %+v, want active without pause or cancel dates", resumed)
+	}
+	renewal, _ := time.Parse("2006-01-02", resumed.NextRenewalDate.String)
+	if renewal.Before(time.Now().AddDate(0, 0, -1)) || renewal.Day() != 15 {
+		t.Errorf("Resume() renewal date = %s, want the 15th after today", resumed.NextRenewalDate.String)
+	}
+}
+
+func TestSpendingService_Statuses(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15"},
+		{Name: "Disney+", Amount: 10.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-20"},
+		{Name: "Gym", Amount: 30.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-01"},
+		{Name: "Newspaper", Amount: 2.00, Currency: "USD", BillingCycle: "weekly", NextRenewalDate: "2026-01-05"},
+		{Name: "Music", Amount: 9.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", TrialEndDate: "2026-02-10"},
+	}
+	ids := make(map[string]int64)
+	for _, input := range inputs {
+		sub, err := tdb.SubscriptionService.Create(ctx, input)
+		if err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+		ids[sub.Name] = sub.ID
+	}
+
+	// Disney+ is cancelled before its January renewal, Gym after it,
+	// and the newspaper is paused after its first January charge
+	if _, err := tdb.SubscriptionService.Cancel(ctx, ids["Disney+"], "2026-01-18"); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Cancel(ctx, ids["Gym"], "2026-01-02"); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Pause(ctx, ids["Newspaper"], "2026-01-10"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	tests := []struct {
+		month int
+		want  float64
+		names []string
+	}{
+		// The period of month 2 is January 2026: Gym on the 1st, the newspaper
+		// on the 5th and Netflix; Music is on its trial
+		{month: 2, want: 15 + 30 + 2, names: []string{"Gym", "Newspaper", "Netflix"}},
+		// February 2026: the first charge of Music after its trial, and Netflix
+		{month: 3, want: 15 + 9, names: []string{"Music", "Netflix"}},
+	}
+
+	for _, tt := range tests {
+		summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, tt.month)
+		if err != nil {
+			t.Fatalf("CalculateForMonth() error = %v", err)
+		}
+		if !almostEqual(summary.GrandTotal, tt.want) {
+			t.Errorf("month %d GrandTotal = %.2f, want %.2f", tt.month, summary.GrandTotal, tt.want)
+		}
+		var names []string
+		for _, charge := range summary.Charges {
+			names = append(names, charge.Subscription.Name)
+		}
+		if len(names) != len(tt.names) {
+			t.Fatalf("month %d charges = %v, want %v", tt.month, names, tt.names)
+		}
+		for i := range names {
+			if names[i] != tt.names[i] {
+				t.Errorf("month %d charges = %v, want %v", tt.month, names, tt.names)
+				break
+			}
+		}
+	}
+
+	// Only active and trial subscriptions count towards the annual total
+	annual, err := tdb.SpendingService.CalculateAnnualTotal(ctx)
+	if err != nil {
+		t.Fatalf("CalculateAnnualTotal() error = %v", err)
+	}
+	if !almostEqual(annual, 15*12+9*12) {
+		t.Errorf("CalculateAnnualTotal() = %.2f, want %.2f", annual, float64(15*12+9*12))
+	}
+}
+
+func TestAdvanceRenewalDatesFrom_Statuses(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15"},
+		{Name: "Gym", Amount: 30.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-01"},
+		{Name: "Music", Amount: 9.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", TrialEndDate: "2026-02-10"},
+		{Name: "Video", Amount: 5.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10", TrialEndDate: "2026-04-10"},
+	}
+	ids := make(map[string]int64)
+	for _, input := range inputs {
+		sub, err := tdb.SubscriptionService.Create(ctx, input)
+		if err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+		ids[sub.Name] = sub.ID
+	}
+	if _, err := tdb.SubscriptionService.Pause(ctx, ids["Gym"], "2026-01-01"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		status  string
+		renewal string
+	}{
+		{name: "Netflix", status: service.StatusActive, renewal: "2026-03-15"},
+		{name: "Gym", status: service.StatusPaused, renewal: "2026-01-01"},
+		{name: "Music", status: service.StatusActive, renewal: "2026-03-10"},
+		{name: "Video", status: service.StatusTrial, renewal: "2026-03-10"},
+	}
+	for _, tt := range tests {
+		sub, err := tdb.SubscriptionService.Get(ctx, ids[tt.name])
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if sub.Status != tt.status || sub.NextRenewalDate.String != tt.renewal {
+			t.Errorf("%s = %s renewing %s, want %s renewing %s", tt.name, sub.Status, sub.NextRenewalDate.String, tt.status, tt.renewal)
+		}
+	}
+}
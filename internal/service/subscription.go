@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"subscription-tracker/internal/db"
@@ -11,7 +12,8 @@ import (
 
 // SubscriptionService handles subscription business logic
 type SubscriptionService struct {
-	queries *db.Queries
+	queries  *db.Queries
+	handlers []EventHandler
 }
 
 // NewSubscriptionService creates a new subscription service
@@ -24,8 +26,12 @@ type CreateSubscriptionInput struct {
 	Name            string
 	Amount          float64
 	Currency        string
-	BillingCycle    string // "monthly" or "yearly"
-	NextRenewalDate string // YYYY-MM-DD format, required for yearly, optional for monthly (defaults to 1st)
+	BillingCycle    string   // a named cycle such as "monthly", or "every N days|weeks|months|years"
+	NextRenewalDate string   // YYYY-MM-DD format, required for yearly, optional for monthly (defaults to 1st)
+	Category        string   // Category name, created if it doesn't exist; empty for none
+	Tags            []string // Free-form tags
+	TrialEndDate    string   // YYYY-MM-DD; starts the subscription on a free trial until then
+	TrialPrice      float64  // Billed once the trial ends; 0 for Amount
 }
 
 // Validate validates the input
@@ -39,9 +45,11 @@ func (i *CreateSubscriptionInput) Validate() error {
 	if i.Currency == "" {
 		i.Currency = "USD"
 	}
-	if i.BillingCycle != "monthly" && i.BillingCycle != "yearly" {
-		return fmt.Errorf("billing cycle must be 'monthly' or 'yearly'")
+	cycle, err := NormalizeBillingCycle(i.BillingCycle)
+	if err != nil {
+		return err
 	}
+	i.BillingCycle = cycle
 	// Renewal date is required for all subscriptions
 	if i.NextRenewalDate == "" {
 		return fmt.Errorf("renewal date is required")
@@ -49,6 +57,17 @@ func (i *CreateSubscriptionInput) Validate() error {
 	if _, err := time.Parse("2006-01-02", i.NextRenewalDate); err != nil {
 		return fmt.Errorf("invalid date format, use YYYY-MM-DD: %w", err)
 	}
+	if i.TrialEndDate != "" {
+		if _, err := time.Parse("2006-01-02", i.TrialEndDate); err != nil {
+			return fmt.Errorf("invalid trial end date, use YYYY-MM-DD: %w", err)
+		}
+	}
+	if i.TrialPrice < 0 {
+		return fmt.Errorf("trial price cannot be negative")
+	}
+	if i.TrialPrice > 0 && i.TrialEndDate == "" {
+		return fmt.Errorf("trial price requires a trial end date")
+	}
 	return nil
 }
 
@@ -58,15 +77,34 @@ func (s *SubscriptionService) Create(ctx context.Context, input CreateSubscripti
 		return db.Subscription{}, err
 	}
 
+	categoryID, err := resolveCategory(ctx, s.queries, input.Category)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+
 	params := db.CreateSubscriptionParams{
 		Name:            input.Name,
 		Amount:          input.Amount,
 		Currency:        input.Currency,
 		BillingCycle:    input.BillingCycle,
 		NextRenewalDate: sql.NullString{String: input.NextRenewalDate, Valid: true},
+		CategoryID:      categoryID,
+		Tags:            NormalizeTags(input.Tags),
+		Status:          StatusActive,
+		Uuid:            newUUID(),
+	}
+	if input.TrialEndDate != "" {
+		params.Status = StatusTrial
+		params.TrialEndDate = sql.NullString{String: input.TrialEndDate, Valid: true}
+		params.TrialPrice = sql.NullFloat64{Float64: input.TrialPrice, Valid: input.TrialPrice > 0}
 	}
 
-	return s.queries.CreateSubscription(ctx, params)
+	sub, err := s.queries.CreateSubscription(ctx, params)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+	s.emit(ctx, Event{Type: EventSubscriptionCreated, Subscription: sub})
+	return sub, nil
 }
 
 // Get retrieves a subscription by ID
@@ -82,6 +120,44 @@ func (s *SubscriptionService) List(ctx context.Context, billingCycle string) ([]
 	return s.queries.ListSubscriptions(ctx)
 }
 
+// SubscriptionFilter narrows down a subscription listing. Empty fields match everything.
+type SubscriptionFilter struct {
+	BillingCycle string
+	Category     string // Category name, or Uncategorized
+	Tag          string
+	Status       string
+}
+
+// Filter retrieves the subscriptions matching every field of the filter
+func (s *SubscriptionService) Filter(ctx context.Context, filter SubscriptionFilter) ([]db.Subscription, error) {
+	subs, err := s.List(ctx, filter.BillingCycle)
+	if err != nil {
+		return nil, err
+	}
+
+	var names map[int64]string
+	if filter.Category != "" {
+		if names, err = categoryNames(ctx, s.queries); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []db.Subscription
+	for _, sub := range subs {
+		if filter.Category != "" && !strings.EqualFold(CategoryName(sub, names), strings.TrimSpace(filter.Category)) {
+			continue
+		}
+		if filter.Tag != "" && !HasTag(sub, filter.Tag) {
+			continue
+		}
+		if filter.Status != "" && !strings.EqualFold(sub.Status, strings.TrimSpace(filter.Status)) {
+			continue
+		}
+		result = append(result, sub)
+	}
+	return result, nil
+}
+
 // ListMonthly retrieves all monthly subscriptions
 func (s *SubscriptionService) ListMonthly(ctx context.Context) ([]db.Subscription, error) {
 	return s.queries.ListMonthlySubscriptions(ctx)
@@ -99,7 +175,10 @@ type UpdateSubscriptionInput struct {
 	Amount          float64
 	Currency        string
 	BillingCycle    string
-	NextRenewalDate string // Required for yearly, optional for monthly
+	NextRenewalDate string   // Required for yearly, optional for monthly
+	Category        string   // Category name, created if it doesn't exist; empty for none
+	Tags            []string // Free-form tags, replacing the current ones
+	PriceEffective  string   // YYYY-MM-DD a changed amount or currency applies from, default today
 }
 
 // Validate validates the update input
@@ -113,9 +192,11 @@ func (i *UpdateSubscriptionInput) Validate() error {
 	if i.Amount <= 0 {
 		return fmt.Errorf("amount must be positive")
 	}
-	if i.BillingCycle != "monthly" && i.BillingCycle != "yearly" {
-		return fmt.Errorf("billing cycle must be 'monthly' or 'yearly'")
+	cycle, err := NormalizeBillingCycle(i.BillingCycle)
+	if err != nil {
+		return err
 	}
+	i.BillingCycle = cycle
 	// Renewal date is required for all subscriptions
 	if i.NextRenewalDate == "" {
 		return fmt.Errorf("renewal date is required")
@@ -126,12 +207,27 @@ func (i *UpdateSubscriptionInput) Validate() error {
 	return nil
 }
 
-// Update updates an existing subscription
+// Update updates an existing subscription. A changed amount or currency is
+// recorded in its price history, taking effect from input.PriceEffective.
 func (s *SubscriptionService) Update(ctx context.Context, input UpdateSubscriptionInput) (db.Subscription, error) {
 	if err := input.Validate(); err != nil {
 		return db.Subscription{}, err
 	}
 
+	existing, err := s.queries.GetSubscription(ctx, input.ID)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+	change, err := s.priceChange(ctx, existing, input.Amount, input.Currency, input.PriceEffective)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+
+	categoryID, err := resolveCategory(ctx, s.queries, input.Category)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+
 	params := db.UpdateSubscriptionParams{
 		ID:              input.ID,
 		Name:            input.Name,
@@ -139,9 +235,16 @@ func (s *SubscriptionService) Update(ctx context.Context, input UpdateSubscripti
 		Currency:        input.Currency,
 		BillingCycle:    input.BillingCycle,
 		NextRenewalDate: sql.NullString{String: input.NextRenewalDate, Valid: true},
+		CategoryID:      categoryID,
+		Tags:            NormalizeTags(input.Tags),
 	}
 
-	return s.queries.UpdateSubscription(ctx, params)
+	sub, err := s.queries.UpdateSubscription(ctx, params)
+	if err == nil {
+		// Only a price that was saved has changed
+		err = s.recordPriceChange(ctx, change)
+	}
+	return s.updated(ctx, sub, err)
 }
 
 // UpdateRenewalDate updates only the renewal date (for yearly subscriptions)
@@ -150,24 +253,50 @@ func (s *SubscriptionService) UpdateRenewalDate(ctx context.Context, id int64, n
 		return db.Subscription{}, fmt.Errorf("invalid date format, use YYYY-MM-DD: %w", err)
 	}
 
-	return s.queries.UpdateRenewalDate(ctx, db.UpdateRenewalDateParams{
+	sub, err := s.queries.UpdateRenewalDate(ctx, db.UpdateRenewalDateParams{
 		ID:              id,
 		NextRenewalDate: sql.NullString{String: newDate, Valid: true},
 	})
+	return s.updated(ctx, sub, err)
 }
 
 // Delete removes a subscription
 func (s *SubscriptionService) Delete(ctx context.Context, id int64) error {
-	return s.queries.DeleteSubscription(ctx, id)
+	sub, err := s.queries.GetSubscription(ctx, id)
+	if err != nil {
+		return fmt.Errorf("subscription not found: %w", err)
+	}
+
+	// Its payments stay in the ledger
+	if err := s.queries.ClearPaymentSubscription(ctx, sql.NullInt64{Int64: id, Valid: true}); err != nil {
+		return fmt.Errorf("failed to keep payments: %w", err)
+	}
+	if err := s.queries.DeleteSubscriptionPriceChanges(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete price history: %w", err)
+	}
+	if err := s.queries.DeleteReminderSetting(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete reminder settings: %w", err)
+	}
+	if err := s.queries.DeleteSubscriptionSentReminders(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete sent reminders: %w", err)
+	}
+	if err := s.queries.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+	s.emit(ctx, Event{Type: EventSubscriptionDeleted, Subscription: sub})
+	return nil
 }
 
 // AdvanceRenewalDates checks all subscriptions and advances their renewal dates
-// if they are in the past. Monthly subscriptions advance by 1 month, yearly by 1 year.
+// if they are in the past, by as many billing cycles as needed.
 func (s *SubscriptionService) AdvanceRenewalDates(ctx context.Context) error {
 	return s.AdvanceRenewalDatesFrom(ctx, time.Now())
 }
 
-// AdvanceRenewalDatesFrom advances renewal dates that are before the given reference time.
+// AdvanceRenewalDatesFrom advances renewal dates that are before the given reference time,
+// recording each billed renewal that passed since the last run in the payment ledger.
+// Paused and cancelled subscriptions are left alone, and trials that have ended become active.
+// Afterwards renewal.upcoming is emitted for the next billed renewal of every subscription.
 // This is useful for testing with a specific date.
 func (s *SubscriptionService) AdvanceRenewalDatesFrom(ctx context.Context, referenceTime time.Time) error {
 	subs, err := s.queries.ListSubscriptions(ctx)
@@ -177,70 +306,137 @@ func (s *SubscriptionService) AdvanceRenewalDatesFrom(ctx context.Context, refer
 
 	today := time.Date(referenceTime.Year(), referenceTime.Month(), referenceTime.Day(), 0, 0, 0, 0, time.UTC)
 
+	// Paused and cancelled subscriptions are recorded from where the last run left off
+	from, ok := recordedThrough(ctx, s.queries)
+
+	var upcoming []db.Subscription
 	for _, sub := range subs {
-		if !sub.NextRenewalDate.Valid {
-			continue
+		if err := s.recordRenewals(ctx, sub, from, today); err != nil {
+			return err
 		}
-
-		renewalDate, err := time.Parse("2006-01-02", sub.NextRenewalDate.String)
-		if err != nil {
+		if !IsRecurring(sub) {
 			continue
 		}
 
-		// If renewal date is in the past, advance it
-		if renewalDate.Before(today) {
-			newDate := CalculateNextRenewalDate(renewalDate, sub.BillingCycle, today)
-			_, err := s.queries.UpdateRenewalDate(ctx, db.UpdateRenewalDateParams{
-				ID:              sub.ID,
-				NextRenewalDate: sql.NullString{String: newDate.Format("2006-01-02"), Valid: true},
-			})
-			if err != nil {
-				return fmt.Errorf("failed to update renewal date for %s: %w", sub.Name, err)
+		// The trial converts to paid, at the post-trial price, once it has ended
+		if end, ok := parseNullDate(sub.TrialEndDate); sub.Status == StatusTrial && ok && end.Before(today) {
+			sub, err = s.queries.EndTrial(ctx, sub.ID)
+			if sub, err = s.updated(ctx, sub, err); err != nil {
+				return fmt.Errorf("failed to end trial of %s: %w", sub.Name, err)
 			}
 		}
+
+		if sub, err = s.advanceRenewalDate(ctx, sub, today); err != nil {
+			return err
+		}
+		upcoming = append(upcoming, sub)
+	}
+
+	if !ok || today.After(from) {
+		if err := setRecordedThrough(ctx, s.queries, today); err != nil {
+			return fmt.Errorf("failed to record payments: %w", err)
+		}
 	}
 
+	for _, sub := range upcoming {
+		if date, ok := parseNullDate(sub.NextRenewalDate); ok && ChargesOn(sub, date) {
+			s.emit(ctx, Event{
+				Type:         EventRenewalUpcoming,
+				Subscription: sub,
+				RenewalDate:  date,
+				DaysLeft:     int(date.Sub(today).Hours() / 24),
+			})
+		}
+	}
 	return nil
 }
 
-// CalculateNextRenewalDate calculates the next renewal date after the reference time.
-// For monthly subscriptions, it advances by months keeping the same day.
-// For yearly subscriptions, it advances by years keeping the same month and day.
-func CalculateNextRenewalDate(currentRenewal time.Time, billingCycle string, referenceTime time.Time) time.Time {
-	newDate := currentRenewal
+// recordRenewals adds the billed renewals of sub from its renewal date until
+// before today to the payment ledger at the price in effect on each. Renewals
+// before from, the day the last run recorded through, are never recorded: a
+// subscription added or edited with a past renewal date isn't backfilled, and
+// paused and cancelled subscriptions, which keep their renewal date, aren't
+// recorded twice. On the first run from is zero.
+func (s *SubscriptionService) recordRenewals(ctx context.Context, sub db.Subscription, from, today time.Time) error {
+	renewalDate, ok := parseNullDate(sub.NextRenewalDate)
+	if !ok || !renewalDate.Before(today) {
+		return nil
+	}
+	cycle, err := ParseBillingCycle(sub.BillingCycle)
+	if err != nil {
+		return nil
+	}
+
+	start := renewalDate
+	if from.After(start) {
+		start = from
+	}
+	dates := cycle.DatesInPeriod(renewalDate, start, today.AddDate(0, 0, -1))
+	if len(dates) == 0 {
+		return nil
+	}
+	changes, err := s.queries.ListSubscriptionPriceChanges(ctx, sub.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list price changes: %w", err)
+	}
+	history := priceHistory{sub.ID: changes}
 
-	if billingCycle == "monthly" {
-		// Advance by months until we're at or after the reference time
-		for newDate.Before(referenceTime) {
-			newDate = addMonth(newDate)
+	for _, date := range dates {
+		if !ChargesOn(sub, date) {
+			continue
 		}
-	} else {
-		// Yearly: advance by years
-		for newDate.Before(referenceTime) {
-			newDate = newDate.AddDate(1, 0, 0)
+		amount, currency := history.priceOn(sub, date)
+		err := s.queries.RecordRenewalPayment(ctx, db.RecordRenewalPaymentParams{
+			SubscriptionID: sql.NullInt64{Int64: sub.ID, Valid: true},
+			Name:           sub.Name,
+			Amount:         amount,
+			Currency:       currency,
+			PaidOn:         date.Format("2006-01-02"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record payment of %s: %w", sub.Name, err)
 		}
 	}
-
-	return newDate
+	return nil
 }
 
-// addMonth adds one month to the date, handling edge cases like Jan 31 -> Feb 28
-func addMonth(t time.Time) time.Time {
-	year, month, day := t.Year(), t.Month(), t.Day()
+// advanceRenewalDate moves the renewal date of sub forward by as many billing
+// cycles as needed if it is before today
+func (s *SubscriptionService) advanceRenewalDate(ctx context.Context, sub db.Subscription, today time.Time) (db.Subscription, error) {
+	if !sub.NextRenewalDate.Valid {
+		return sub, nil
+	}
 
-	// Move to next month
-	month++
-	if month > 12 {
-		month = 1
-		year++
+	renewalDate, err := time.Parse("2006-01-02", sub.NextRenewalDate.String)
+	if err != nil || !renewalDate.Before(today) {
+		return sub, nil
 	}
 
-	// Handle edge cases where day doesn't exist in next month (e.g., Jan 31 -> Feb)
-	// Find the last day of the new month
-	lastDayOfMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
-	if day > lastDayOfMonth {
-		day = lastDayOfMonth
+	newDate := CalculateNextRenewalDate(renewalDate, sub.BillingCycle, today)
+	sub, err = s.queries.UpdateRenewalDate(ctx, db.UpdateRenewalDateParams{
+		ID:              sub.ID,
+		NextRenewalDate: sql.NullString{String: newDate.Format("2006-01-02"), Valid: true},
+	})
+	if err != nil {
+		return db.Subscription{}, fmt.Errorf("failed to update renewal date for %s: %w", sub.Name, err)
 	}
+	s.emit(ctx, Event{Type: EventRenewalAdvanced, Subscription: sub, PreviousDate: renewalDate, RenewalDate: newDate})
+	return sub, nil
+}
 
-	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+// CalculateNextRenewalDate calculates the next renewal date after the reference time.
+// Every cycle advances in steps of its interval from the current renewal date,
+// keeping its day, so a Jan 31 monthly renewal moves to Apr 30 and then May 31
+// like the renewals recorded in the payment ledger.
+func CalculateNextRenewalDate(currentRenewal time.Time, billingCycle string, referenceTime time.Time) time.Time {
+	cycle, err := ParseBillingCycle(billingCycle)
+	if err != nil {
+		// Unknown cycles are treated as yearly
+		cycle = namedCycles[CycleYearly]
+	}
+	newDate := currentRenewal
+	for n := 1; newDate.Before(referenceTime); n++ {
+		newDate = cycle.AddTo(currentRenewal, n)
+	}
+	return newDate
 }
@@ -1,12 +1,10 @@
 package service
 
 import (
-	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"time"
 
 	"subscription-tracker/internal/db"
@@ -29,18 +27,59 @@ func NewSyncService(queries *db.Queries, configService *ConfigService) *SyncServ
 // SyncData represents all data to be synced
 type SyncData struct {
 	Version       int                `json:"version"`
-	ExportedAt    time.Time          `json:"exported_at"`
+	ExportedAt    time.Time          `json:"exported_at,omitzero"`
 	Subscriptions []SyncSubscription `json:"subscriptions"`
+	Categories    []string           `json:"categories,omitempty"`
+	Budgets       []SyncBudget       `json:"budgets,omitempty"`
+	Payments      []SyncPayment      `json:"payments,omitempty"`
 	Config        map[string]string  `json:"config"`
+	ExchangeRates map[string]float64 `json:"exchange_rates"` // Per one unit of the base currency in Config; nil in older backups
 }
 
 // SyncSubscription represents a subscription for sync
 type SyncSubscription struct {
-	Name            string  `json:"name"`
-	Amount          float64 `json:"amount"`
-	Currency        string  `json:"currency"`
-	BillingCycle    string  `json:"billing_cycle"`
-	NextRenewalDate string  `json:"next_renewal_date,omitempty"`
+	ID              string   `json:"id,omitempty"` // UUID, the same on every device; missing from older backups
+	Name            string   `json:"name"`
+	Amount          float64  `json:"amount"`
+	Currency        string   `json:"currency"`
+	BillingCycle    string   `json:"billing_cycle"`
+	NextRenewalDate string   `json:"next_renewal_date,omitempty"`
+	Category        string   `json:"category,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Status          string   `json:"status,omitempty"`
+	TrialEndDate    string   `json:"trial_end_date,omitempty"`
+	TrialPrice      float64  `json:"trial_price,omitempty"`
+	PauseDate       string   `json:"pause_date,omitempty"`
+	CancelDate      string   `json:"cancel_date,omitempty"`
+	ReminderDays    *string  `json:"reminder_days,omitempty"` // Own reminder lead times, "" when off; nil uses the default
+
+	PriceHistory []SyncPriceChange `json:"price_history,omitempty"`
+}
+
+// SyncPriceChange represents a price change of a subscription for sync
+type SyncPriceChange struct {
+	OldAmount     float64 `json:"old_amount"`
+	OldCurrency   string  `json:"old_currency"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	EffectiveDate string  `json:"effective_date"`
+}
+
+// SyncBudget represents a budget for sync
+type SyncBudget struct {
+	Category string  `json:"category,omitempty"` // Empty for the overall budget
+	Amount   float64 `json:"amount"`
+}
+
+// SyncPayment represents a payment for sync
+type SyncPayment struct {
+	Subscription string  `json:"subscription,omitempty"` // Name of the subscription; empty for a one-off charge
+	Name         string  `json:"name"`
+	Amount       float64 `json:"amount"`
+	Currency     string  `json:"currency"`
+	PaidOn       string  `json:"paid_on"`
+	Source       string  `json:"source"`
+	Note         string  `json:"note,omitempty"`
 }
 
 // ExportEncrypted exports all data as an encrypted string
@@ -50,41 +89,46 @@ func (s *SyncService) ExportEncrypted(ctx context.Context, password string) (str
 	if err != nil {
 		return "", fmt.Errorf("failed to gather data: %w", err)
 	}
+	return encryptData(data, password)
+}
+
+// ImportEncrypted decrypts and imports data
+func (s *SyncService) ImportEncrypted(ctx context.Context, encrypted string, password string) error {
+	data, err := decryptData(encrypted, password)
+	if err != nil {
+		return err
+	}
+
+	// Import data
+	return s.importData(ctx, data)
+}
 
-	// Convert to JSON
+// encryptData converts data to JSON and encrypts it
+func encryptData(data *SyncData, password string) (string, error) {
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal data: %w", err)
 	}
-
-	// Encrypt
 	encrypted, err := Encrypt(jsonData, password)
 	if err != nil {
 		return "", fmt.Errorf("failed to encrypt data: %w", err)
 	}
-
 	return encrypted, nil
 }
 
-// ImportEncrypted imports data from an encrypted string
-func (s *SyncService) ImportEncrypted(ctx context.Context, encrypted string, password string) error {
-	// Decrypt
+// decryptData decrypts data and parses the JSON
+func decryptData(encrypted string, password string) (*SyncData, error) {
 	jsonData, err := Decrypt(encrypted, password)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt data: %w", err)
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
 	}
-
-	// Parse JSON
 	var data SyncData
 	if err := json.Unmarshal(jsonData, &data); err != nil {
-		return fmt.Errorf("failed to parse data: %w", err)
+		return nil, fmt.Errorf("failed to parse data: %w", err)
 	}
-
-	// Import data
-	return s.importData(ctx, &data)
+	return &data, nil
 }
 
-// gatherData collects all data for export
 func (s *SyncService) gatherData(ctx context.Context) (*SyncData, error) {
 	// Get subscriptions
 	subs, err := s.queries.ListSubscriptions(ctx)
@@ -92,17 +136,94 @@ func (s *SyncService) gatherData(ctx context.Context) (*SyncData, error) {
 		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
 	}
 
+	categories, err := s.queries.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	names := make(map[int64]string, len(categories))
+	categoryList := make([]string, len(categories))
+	for i, c := range categories {
+		names[c.ID] = c.Name
+		categoryList[i] = c.Name
+	}
+
+	history, err := loadPriceHistory(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+
+	reminders, err := s.queries.ListReminderSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminder settings: %w", err)
+	}
+	leadDays := make(map[int64]string, len(reminders))
+	for _, r := range reminders {
+		leadDays[r.SubscriptionID] = r.LeadDays
+	}
+
+	subNames := make(map[int64]string, len(subs))
 	syncSubs := make([]SyncSubscription, len(subs))
 	for i, sub := range subs {
+		subNames[sub.ID] = sub.Name
 		syncSubs[i] = SyncSubscription{
+			ID:           sub.Uuid,
 			Name:         sub.Name,
 			Amount:       sub.Amount,
 			Currency:     sub.Currency,
 			BillingCycle: sub.BillingCycle,
+			Tags:         ParseTags(sub.Tags),
+			Status:       sub.Status,
+			TrialEndDate: sub.TrialEndDate.String,
+			TrialPrice:   sub.TrialPrice.Float64,
+			PauseDate:    sub.PauseDate.String,
+			CancelDate:   sub.CancelDate.String,
 		}
 		if sub.NextRenewalDate.Valid {
 			syncSubs[i].NextRenewalDate = sub.NextRenewalDate.String
 		}
+		if sub.CategoryID.Valid {
+			syncSubs[i].Category = names[sub.CategoryID.Int64]
+		}
+		if days, ok := leadDays[sub.ID]; ok {
+			syncSubs[i].ReminderDays = &days
+		}
+		for _, change := range history[sub.ID] {
+			syncSubs[i].PriceHistory = append(syncSubs[i].PriceHistory, SyncPriceChange{
+				OldAmount:     change.OldAmount,
+				OldCurrency:   change.OldCurrency,
+				Amount:        change.Amount,
+				Currency:      change.Currency,
+				EffectiveDate: change.EffectiveDate,
+			})
+		}
+	}
+
+	budgets, err := listBudgets(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+	syncBudgets := make([]SyncBudget, len(budgets))
+	for i, b := range budgets {
+		syncBudgets[i] = SyncBudget{Category: b.Category, Amount: b.Amount}
+	}
+
+	payments, err := s.queries.ListPaymentsInPeriod(ctx, db.ListPaymentsInPeriodParams{EndDate: "9999-12-31"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	syncPayments := make([]SyncPayment, len(payments))
+	for i, p := range payments {
+		syncPayments[i] = SyncPayment{
+			Name:     p.Name,
+			Amount:   p.Amount,
+			Currency: p.Currency,
+			PaidOn:   p.PaidOn,
+			Source:   p.Source,
+			Note:     p.Note,
+		}
+		if p.SubscriptionID.Valid {
+			syncPayments[i].Subscription = subNames[p.SubscriptionID.Int64]
+		}
 	}
 
 	// Get config
@@ -113,225 +234,367 @@ func (s *SyncService) gatherData(ctx context.Context) (*SyncData, error) {
 
 	configMap := make(map[string]string)
 	for _, c := range configs {
+		if isSyncSetting(c.Key) {
+			continue
+		}
 		configMap[c.Key] = c.Value
 	}
 
+	rates, err := s.queries.ListExchangeRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates: %w", err)
+	}
+	rateMap := make(map[string]float64, len(rates))
+	for _, r := range rates {
+		rateMap[r.Currency] = r.Rate
+	}
+
 	return &SyncData{
 		Version:       1,
 		ExportedAt:    time.Now().UTC(),
 		Subscriptions: syncSubs,
+		Categories:    categoryList,
+		Budgets:       syncBudgets,
+		Payments:      syncPayments,
 		Config:        configMap,
+		ExchangeRates: rateMap,
 	}, nil
 }
 
-// importData imports sync data into the database
+// importData replaces all data with data. Subscriptions are matched to the
+// existing ones by ID, or else by name, and updated in place, so that what
+// only this device keeps about them, like sent reminders, is kept.
 func (s *SyncService) importData(ctx context.Context, data *SyncData) error {
-	// Delete existing subscriptions
 	subs, err := s.queries.ListSubscriptions(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list existing subscriptions: %w", err)
 	}
-	for _, sub := range subs {
-		if err := s.queries.DeleteSubscription(ctx, sub.ID); err != nil {
-			return fmt.Errorf("failed to delete subscription %s: %w", sub.Name, err)
-		}
+	current, err := s.gatherData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to gather data: %w", err)
+	}
+	currentSubs := make(map[string]SyncSubscription, len(current.Subscriptions))
+	for _, sub := range current.Subscriptions {
+		currentSubs[sub.ID] = sub
 	}
 
-	// Import subscriptions
-	for _, sub := range data.Subscriptions {
-		params := db.CreateSubscriptionParams{
-			Name:         sub.Name,
-			Amount:       sub.Amount,
-			Currency:     sub.Currency,
-			BillingCycle: sub.BillingCycle,
-		}
-		if sub.NextRenewalDate != "" {
-			params.NextRenewalDate.String = sub.NextRenewalDate
-			params.NextRenewalDate.Valid = true
+	// Match by ID first, so a name match never takes a subscription that
+	// is there under its ID
+	rows := make([]*db.Subscription, len(data.Subscriptions))
+	claimed := make(map[int64]bool, len(subs))
+	byUUID := make(map[string]*db.Subscription, len(subs))
+	for i := range subs {
+		byUUID[subs[i].Uuid] = &subs[i]
+	}
+	for i, sub := range data.Subscriptions {
+		if row, ok := byUUID[sub.ID]; ok && sub.ID != "" && !claimed[row.ID] {
+			rows[i] = row
+			claimed[row.ID] = true
 		}
-		if _, err := s.queries.CreateSubscription(ctx, params); err != nil {
-			return fmt.Errorf("failed to create subscription %s: %w", sub.Name, err)
+	}
+	for i, sub := range data.Subscriptions {
+		for j := range subs {
+			if rows[i] != nil {
+				break
+			}
+			if !claimed[subs[j].ID] && subs[j].Name == sub.Name {
+				rows[i] = &subs[j]
+				claimed[subs[j].ID] = true
+			}
 		}
 	}
 
-	// Import config
-	for key, value := range data.Config {
-		if err := s.queries.SetConfig(ctx, db.SetConfigParams{Key: key, Value: value}); err != nil {
-			return fmt.Errorf("failed to set config %s: %w", key, err)
+	// Delete the rest
+	for _, sub := range subs {
+		if claimed[sub.ID] {
+			continue
+		}
+		if err := s.queries.DeleteSubscriptionPriceChanges(ctx, sub.ID); err != nil {
+			return fmt.Errorf("failed to delete price history of %s: %w", sub.Name, err)
+		}
+		if err := s.queries.DeleteReminderSetting(ctx, sub.ID); err != nil {
+			return fmt.Errorf("failed to delete reminder settings of %s: %w", sub.Name, err)
+		}
+		if err := s.queries.DeleteSubscriptionSentReminders(ctx, sub.ID); err != nil {
+			return fmt.Errorf("failed to delete sent reminders of %s: %w", sub.Name, err)
+		}
+		if err := s.queries.DeleteSubscription(ctx, sub.ID); err != nil {
+			return fmt.Errorf("failed to delete subscription %s: %w", sub.Name, err)
 		}
 	}
 
-	return nil
-}
-
-// GitHub Gist API integration
-
-const (
-	gistAPIURL   = "https://api.github.com/gists"
-	gistFileName = "subscription-tracker-backup.enc"
-)
-
-// GistConfig holds GitHub Gist configuration
-type GistConfig struct {
-	Token  string // GitHub personal access token
-	GistID string // Gist ID (empty for new gist)
-}
-
-// PushToGist uploads encrypted data to a GitHub Gist
-func (s *SyncService) PushToGist(ctx context.Context, password string, gistConfig GistConfig) (string, error) {
-	// Export encrypted data
-	encrypted, err := s.ExportEncrypted(ctx, password)
-	if err != nil {
-		return "", err
+	// Import categories, keeping the local ones
+	for _, name := range data.Categories {
+		if _, err := resolveCategory(ctx, s.queries, name); err != nil {
+			return err
+		}
 	}
 
-	// Prepare gist payload
-	payload := map[string]interface{}{
-		"description": "Subscription Tracker Backup (encrypted)",
-		"public":      false,
-		"files": map[string]interface{}{
-			gistFileName: map[string]string{
-				"content": encrypted,
-			},
-		},
+	// Import subscriptions
+	ids := make(map[string]int64, len(data.Subscriptions))
+	seen := make(map[string]bool, len(data.Subscriptions))
+	for i, sub := range data.Subscriptions {
+		row := rows[i]
+		switch {
+		case sub.ID == "" && row != nil:
+			sub.ID = row.Uuid // Older backups have no IDs
+		case sub.ID == "" || seen[sub.ID]:
+			sub.ID = newUUID()
+		}
+		seen[sub.ID] = true
+
+		var id int64
+		if row == nil {
+			if id, err = s.createSubscription(ctx, sub); err != nil {
+				return err
+			}
+		} else {
+			id = row.ID
+			if err := s.updateSubscription(ctx, *row, currentSubs[row.Uuid], sub); err != nil {
+				return err
+			}
+		}
+		if _, ok := ids[sub.Name]; !ok {
+			ids[sub.Name] = id
+		}
 	}
 
-	jsonPayload, err := json.Marshal(payload)
+	// Replace budgets
+	budgets, err := s.queries.ListBudgets(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal gist payload: %w", err)
+		return fmt.Errorf("failed to list existing budgets: %w", err)
 	}
-
-	// Determine URL and method
-	var url string
-	var method string
-	if gistConfig.GistID != "" {
-		url = fmt.Sprintf("%s/%s", gistAPIURL, gistConfig.GistID)
-		method = "PATCH"
-	} else {
-		url = gistAPIURL
-		method = "POST"
+	for _, b := range budgets {
+		if err := s.queries.DeleteBudget(ctx, b.ID); err != nil {
+			return fmt.Errorf("failed to delete budget: %w", err)
+		}
 	}
-
-	// Make request
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	for _, b := range data.Budgets {
+		categoryID, err := resolveCategory(ctx, s.queries, b.Category)
+		if err != nil {
+			return err
+		}
+		if _, err := s.queries.CreateBudget(ctx, db.CreateBudgetParams{CategoryID: categoryID, Amount: b.Amount}); err != nil {
+			return fmt.Errorf("failed to create budget: %w", err)
+		}
 	}
 
-	req.Header.Set("Authorization", "Bearer "+gistConfig.Token)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+	// Replace payments, linking them to the imported subscriptions by name
+	if err := s.queries.DeleteAllPayments(ctx); err != nil {
+		return fmt.Errorf("failed to delete payments: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("gist API error (status %d): %s", resp.StatusCode, string(body))
+	for _, p := range data.Payments {
+		params := db.CreatePaymentParams{
+			Name:     p.Name,
+			Amount:   p.Amount,
+			Currency: p.Currency,
+			PaidOn:   p.PaidOn,
+			Source:   p.Source,
+			Note:     p.Note,
+		}
+		if id, ok := ids[p.Subscription]; ok && p.Subscription != "" {
+			params.SubscriptionID = sql.NullInt64{Int64: id, Valid: true}
+		}
+		if params.Source == "" {
+			params.Source = PaymentManual
+		}
+		if _, err := s.queries.CreatePayment(ctx, params); err != nil {
+			return fmt.Errorf("failed to create payment of %s: %w", p.Name, err)
+		}
 	}
 
-	// Parse response to get gist ID
-	var gistResp struct {
-		ID      string `json:"id"`
-		HTMLURL string `json:"html_url"`
+	// Import config, with the base currency going together with the rates
+	if err := s.importRates(ctx, data); err != nil {
+		return err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&gistResp); err != nil {
-		return "", fmt.Errorf("failed to parse gist response: %w", err)
+	for key, value := range data.Config {
+		if isSyncSetting(key) || key == ConfigKeyBaseCurrency {
+			continue // Older backups carry the gist settings of the pushing device
+		}
+		if err := s.queries.SetConfig(ctx, db.SetConfigParams{Key: key, Value: value}); err != nil {
+			return fmt.Errorf("failed to set config %s: %w", key, err)
+		}
 	}
 
-	return gistResp.ID, nil
+	return nil
 }
 
-// PullFromGist downloads and decrypts data from a GitHub Gist
-func (s *SyncService) PullFromGist(ctx context.Context, password string, gistConfig GistConfig) error {
-	if gistConfig.GistID == "" {
-		return fmt.Errorf("gist ID is required for pull")
+// importRates imports the base currency and the exchange rates, which are
+// relative to it. Older backups have no rates, so the rates here are rebased
+// to their base currency, which is only taken if there is a rate for it.
+func (s *SyncService) importRates(ctx context.Context, data *SyncData) error {
+	currency, ok := data.Config[ConfigKeyBaseCurrency]
+	if !ok {
+		return nil
 	}
-
-	// Fetch gist
-	url := fmt.Sprintf("%s/%s", gistAPIURL, gistConfig.GistID)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	rates := data.ExchangeRates
+	if rates == nil {
+		current, err := s.configService.GetBaseCurrency(ctx)
+		if err != nil || current == currency {
+			return err
+		}
+		stored, err := s.queries.ListExchangeRates(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list exchange rates: %w", err)
+		}
+		table := map[string]float64{current: 1}
+		for _, r := range stored {
+			table[r.Currency] = r.Rate
+		}
+		if _, ok := table[currency]; !ok && len(stored) > 0 {
+			return nil
+		}
+		rates = map[string]float64{}
+		if len(stored) > 0 {
+			rates = rebaseRates(table, currency)
+		}
 	}
-
-	req.Header.Set("Authorization", "Bearer "+gistConfig.Token)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to make request: %w", err)
+	if err := replaceRates(ctx, s.queries, rates); err != nil {
+		return err
 	}
-	defer resp.Body.Close()
+	return s.configService.setBaseCurrency(ctx, currency)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("gist API error (status %d): %s", resp.StatusCode, string(body))
+// createSubscription creates an imported subscription
+func (s *SyncService) createSubscription(ctx context.Context, sub SyncSubscription) (int64, error) {
+	categoryID, err := resolveCategory(ctx, s.queries, sub.Category)
+	if err != nil {
+		return 0, err
+	}
+	params := db.CreateSubscriptionParams{
+		Name:         sub.Name,
+		Amount:       sub.Amount,
+		Currency:     sub.Currency,
+		BillingCycle: sub.BillingCycle,
+		CategoryID:   categoryID,
+		Tags:         NormalizeTags(sub.Tags),
+		Status:       sub.Status,
+		TrialEndDate: nullDate(sub.TrialEndDate),
+		TrialPrice:   sql.NullFloat64{Float64: sub.TrialPrice, Valid: sub.TrialPrice > 0},
+		Uuid:         sub.ID,
+	}
+	if params.Status == "" {
+		params.Status = StatusActive
+	}
+	if sub.NextRenewalDate != "" {
+		params.NextRenewalDate.String = sub.NextRenewalDate
+		params.NextRenewalDate.Valid = true
+	}
+	created, err := s.queries.CreateSubscription(ctx, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create subscription %s: %w", sub.Name, err)
+	}
+	if err := s.importReminderDays(ctx, created.ID, sub); err != nil {
+		return 0, err
+	}
+	if err := s.importPriceHistory(ctx, created.ID, sub); err != nil {
+		return 0, err
+	}
+	if sub.PauseDate != "" || sub.CancelDate != "" {
+		_, err := s.queries.UpdateSubscriptionStatus(ctx, db.UpdateSubscriptionStatusParams{
+			ID:           created.ID,
+			Status:       params.Status,
+			TrialEndDate: params.TrialEndDate,
+			PauseDate:    nullDate(sub.PauseDate),
+			CancelDate:   nullDate(sub.CancelDate),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to set status of %s: %w", sub.Name, err)
+		}
 	}
+	return created.ID, nil
+}
 
-	// Parse response
-	var gistResp struct {
-		Files map[string]struct {
-			Content string `json:"content"`
-		} `json:"files"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&gistResp); err != nil {
-		return fmt.Errorf("failed to parse gist response: %w", err)
+// updateSubscription updates the subscription in row, which is current in
+// sync form, to the imported sub, leaving it alone if they are the same
+func (s *SyncService) updateSubscription(ctx context.Context, row db.Subscription, current, sub SyncSubscription) error {
+	if row.Uuid != sub.ID {
+		if err := s.queries.SetSubscriptionUUID(ctx, db.SetSubscriptionUUIDParams{Uuid: sub.ID, ID: row.ID}); err != nil {
+			return fmt.Errorf("failed to set ID of %s: %w", sub.Name, err)
+		}
 	}
-
-	// Get encrypted content
-	file, ok := gistResp.Files[gistFileName]
-	if !ok {
-		return fmt.Errorf("backup file not found in gist")
+	current.ID = sub.ID
+	if sameSubscription(current, sub) {
+		return nil
 	}
 
-	// Import encrypted data
-	return s.ImportEncrypted(ctx, file.Content, password)
+	categoryID, err := resolveCategory(ctx, s.queries, sub.Category)
+	if err != nil {
+		return err
+	}
+	if _, err := s.queries.UpdateSubscription(ctx, db.UpdateSubscriptionParams{
+		ID:              row.ID,
+		Name:            sub.Name,
+		Amount:          sub.Amount,
+		Currency:        sub.Currency,
+		BillingCycle:    sub.BillingCycle,
+		NextRenewalDate: nullDate(sub.NextRenewalDate),
+		CategoryID:      categoryID,
+		Tags:            NormalizeTags(sub.Tags),
+	}); err != nil {
+		return fmt.Errorf("failed to update subscription %s: %w", sub.Name, err)
+	}
+	status := sub.Status
+	if status == "" {
+		status = StatusActive
+	}
+	if _, err := s.queries.UpdateSubscriptionTrial(ctx, db.UpdateSubscriptionTrialParams{
+		ID:           row.ID,
+		Status:       status,
+		TrialEndDate: nullDate(sub.TrialEndDate),
+		TrialPrice:   sql.NullFloat64{Float64: sub.TrialPrice, Valid: sub.TrialPrice > 0},
+	}); err != nil {
+		return fmt.Errorf("failed to set trial of %s: %w", sub.Name, err)
+	}
+	if _, err := s.queries.UpdateSubscriptionStatus(ctx, db.UpdateSubscriptionStatusParams{
+		ID:           row.ID,
+		Status:       status,
+		TrialEndDate: nullDate(sub.TrialEndDate),
+		PauseDate:    nullDate(sub.PauseDate),
+		CancelDate:   nullDate(sub.CancelDate),
+	}); err != nil {
+		return fmt.Errorf("failed to set status of %s: %w", sub.Name, err)
+	}
+	if err := s.queries.DeleteReminderSetting(ctx, row.ID); err != nil {
+		return fmt.Errorf("failed to delete reminder settings of %s: %w", sub.Name, err)
+	}
+	if err := s.importReminderDays(ctx, row.ID, sub); err != nil {
+		return err
+	}
+	if err := s.queries.DeleteSubscriptionPriceChanges(ctx, row.ID); err != nil {
+		return fmt.Errorf("failed to delete price history of %s: %w", sub.Name, err)
+	}
+	return s.importPriceHistory(ctx, row.ID, sub)
 }
 
-// Config keys for storing gist settings
-const (
-	ConfigKeyGistID    = "sync_gist_id"
-	ConfigKeyGistToken = "sync_gist_token" // Note: storing tokens in DB isn't ideal, but encrypted
-)
-
-// GetGistConfig retrieves stored gist configuration
-func (s *SyncService) GetGistConfig(ctx context.Context) (*GistConfig, error) {
-	config := &GistConfig{}
-
-	if token, err := s.queries.GetConfig(ctx, ConfigKeyGistToken); err == nil {
-		config.Token = token
+// importReminderDays sets the reminder lead times of an imported subscription
+func (s *SyncService) importReminderDays(ctx context.Context, id int64, sub SyncSubscription) error {
+	if sub.ReminderDays == nil {
+		return nil
 	}
-	if gistID, err := s.queries.GetConfig(ctx, ConfigKeyGistID); err == nil {
-		config.GistID = gistID
+	days, err := ParseReminderDays(*sub.ReminderDays)
+	if err != nil {
+		return fmt.Errorf("invalid reminder days of %s: %w", sub.Name, err)
 	}
-
-	return config, nil
+	if err := s.queries.SetReminderSetting(ctx, db.SetReminderSettingParams{SubscriptionID: id, LeadDays: storedReminderDays(days)}); err != nil {
+		return fmt.Errorf("failed to set reminder days of %s: %w", sub.Name, err)
+	}
+	return nil
 }
 
-// SaveGistConfig saves gist configuration
-func (s *SyncService) SaveGistConfig(ctx context.Context, config *GistConfig) error {
-	if config.Token != "" {
-		if err := s.queries.SetConfig(ctx, db.SetConfigParams{
-			Key:   ConfigKeyGistToken,
-			Value: config.Token,
-		}); err != nil {
-			return err
-		}
-	}
-	if config.GistID != "" {
-		if err := s.queries.SetConfig(ctx, db.SetConfigParams{
-			Key:   ConfigKeyGistID,
-			Value: config.GistID,
-		}); err != nil {
-			return err
+// importPriceHistory creates the price history of an imported subscription
+func (s *SyncService) importPriceHistory(ctx context.Context, id int64, sub SyncSubscription) error {
+	for _, change := range sub.PriceHistory {
+		_, err := s.queries.CreatePriceChange(ctx, db.CreatePriceChangeParams{
+			SubscriptionID: id,
+			OldAmount:      change.OldAmount,
+			OldCurrency:    change.OldCurrency,
+			Amount:         change.Amount,
+			Currency:       change.Currency,
+			EffectiveDate:  change.EffectiveDate,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create price change of %s: %w", sub.Name, err)
 		}
 	}
 	return nil
@@ -2,6 +2,7 @@ package service_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"subscription-tracker/internal/service"
@@ -13,27 +14,34 @@ func TestSyncService_ExportImportEncrypted(t *testing.T) {
 	password := "test_password_123"
 
 	// Create some test data
-	_, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+	original, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
 		Name:            "Netflix",
 		Amount:          15.99,
 		Currency:        "USD",
 		BillingCycle:    "monthly",
 		NextRenewalDate: "2026-01-15",
+		Category:        "movies",
+		Tags:            []string{"family"},
 	})
 	if err != nil {
 		t.Fatalf("failed to create subscription: %v", err)
 	}
 
-	_, err = tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+	prime, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
 		Name:            "Amazon Prime",
 		Amount:          139.00,
 		Currency:        "USD",
 		BillingCycle:    "yearly",
 		NextRenewalDate: "2026-06-15",
+		TrialEndDate:    "2026-01-01",
+		TrialPrice:      149.00,
 	})
 	if err != nil {
 		t.Fatalf("failed to create subscription: %v", err)
 	}
+	if _, err := tdb.SubscriptionService.Cancel(ctx, prime.ID, "2026-03-01"); err != nil {
+		t.Fatalf("failed to cancel subscription: %v", err)
+	}
 
 	// Set some config
 	if err := tdb.ConfigService.SetMonthCutoffDay(ctx, 22); err != nil {
@@ -42,6 +50,18 @@ func TestSyncService_ExportImportEncrypted(t *testing.T) {
 	if err := tdb.ConfigService.SetMonthlySalary(ctx, 5000.00); err != nil {
 		t.Fatalf("failed to set salary: %v", err)
 	}
+	if _, err := tdb.BudgetService.Set(ctx, "movies", 20.00); err != nil {
+		t.Fatalf("failed to set budget: %v", err)
+	}
+	if _, err := tdb.PaymentService.Add(ctx, service.PaymentInput{SubscriptionID: prime.ID, Amount: 149.00, Date: "2026-01-01"}); err != nil {
+		t.Fatalf("failed to add payment: %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Update(ctx, service.UpdateSubscriptionInput{
+		ID: original.ID, Name: "Netflix", Amount: 17.99, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15",
+		Category: "movies", Tags: []string{"family"}, PriceEffective: "2026-02-01",
+	}); err != nil {
+		t.Fatalf("failed to change price: %v", err)
+	}
 
 	// Export encrypted
 	encrypted, err := tdb.SyncService.ExportEncrypted(ctx, password)
@@ -71,6 +91,51 @@ func TestSyncService_ExportImportEncrypted(t *testing.T) {
 		t.Errorf("expected 2 subscriptions, got %d", len(subs))
 	}
 
+	// Verify categories and tags were imported
+	netflix, err := tdb2.SubscriptionService.Filter(ctx, service.SubscriptionFilter{Category: "movies", Tag: "family"})
+	if err != nil {
+		t.Fatalf("failed to filter subscriptions: %v", err)
+	}
+	if len(netflix) != 1 || netflix[0].Name != "Netflix" {
+		t.Fatalf("expected Netflix in movies tagged family, got %v", netflix)
+	}
+
+	// Verify the price history was imported
+	history, err := tdb2.SubscriptionService.PriceHistory(ctx, netflix[0].ID)
+	if err != nil {
+		t.Fatalf("failed to get price history: %v", err)
+	}
+	if len(history) != 1 || history[0].OldAmount != 15.99 || history[0].Amount != 17.99 || history[0].EffectiveDate != "2026-02-01" {
+		t.Errorf("price history = %+v, want 15.99 -> 17.99 from 2026-02-01", history)
+	}
+
+	// Verify statuses were imported
+	cancelled, err := tdb2.SubscriptionService.Filter(ctx, service.SubscriptionFilter{Status: service.StatusCancelled})
+	if err != nil {
+		t.Fatalf("failed to filter subscriptions: %v", err)
+	}
+	if len(cancelled) != 1 || cancelled[0].CancelDate.String != "2026-03-01" || cancelled[0].TrialEndDate.String != "2026-01-01" || cancelled[0].TrialPrice.Float64 != 149.00 {
+		t.Errorf("expected Amazon Prime cancelled from 2026-03-01 after a trial, got %v", cancelled)
+	}
+
+	// Verify budgets were imported
+	budgets, err := tdb2.BudgetService.List(ctx)
+	if err != nil {
+		t.Fatalf("failed to list budgets: %v", err)
+	}
+	if len(budgets) != 1 || budgets[0].Category != "movies" || budgets[0].Amount != 20.00 {
+		t.Errorf("budgets = %+v, want movies budget of 20.00", budgets)
+	}
+
+	// Verify payments were imported and linked to their subscription
+	payments, err := tdb2.PaymentService.ListForSubscription(ctx, cancelled[0].ID)
+	if err != nil {
+		t.Fatalf("failed to list payments: %v", err)
+	}
+	if len(payments) != 1 || payments[0].Amount != 149.00 || payments[0].PaidOn != "2026-01-01" {
+		t.Errorf("payments = %+v, want Amazon Prime paid 149.00 on 2026-01-01", payments)
+	}
+
 	// Verify config was imported
 	cutoff, err := tdb2.ConfigService.GetMonthCutoffDay(ctx)
 	if err != nil {
@@ -186,3 +251,76 @@ func TestSyncService_ImportReplacesExistingData(t *testing.T) {
 		t.Errorf("expected Netflix subscription, got %s", subs[0].Name)
 	}
 }
+
+func TestSyncService_PullAfterRebase(t *testing.T) {
+	ctx := context.Background()
+	config := &service.SyncConfig{Backend: service.SyncBackendDir, Dir: t.TempDir()}
+
+	tdb, tdb2 := setupTestDB(t), setupTestDB(t)
+	for _, device := range []*testDB{tdb, tdb2} {
+		for currency, rate := range map[string]float64{"EUR": 0.9, "GBP": 0.8} {
+			if err := device.CurrencyService.SetRate(ctx, currency, rate); err != nil {
+				t.Fatalf("SetRate() error = %v", err)
+			}
+		}
+	}
+	if err := tdb.CurrencyService.SetBaseCurrency(ctx, "EUR"); err != nil {
+		t.Fatalf("SetBaseCurrency() error = %v", err)
+	}
+	if _, err := tdb.SyncService.Push(ctx, "secret", config); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if err := tdb2.SyncService.Pull(ctx, "secret", config); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	// The base currency comes with the rates relative to it
+	if base, _ := tdb2.ConfigService.GetBaseCurrency(ctx); base != "EUR" {
+		t.Errorf("base currency = %s after the pull, want EUR", base)
+	}
+	converter, err := tdb2.CurrencyService.Converter(ctx)
+	if err != nil {
+		t.Fatalf("Converter() error = %v", err)
+	}
+	for _, tt := range []struct {
+		amount   float64
+		currency string
+		want     float64
+	}{
+		{300, "EUR", 300},
+		{300, "USD", 270},
+		{80, "GBP", 90},
+	} {
+		if got, ok := converter.Convert(tt.amount, tt.currency); !ok || !almostEqual(got, tt.want) {
+			t.Errorf("Convert(%.2f, %s) = %.2f, %v, want %.2f EUR", tt.amount, tt.currency, got, ok, tt.want)
+		}
+	}
+
+	// A backup without rates rebases the rates here instead
+	encrypted, err := tdb.SyncService.ExportEncrypted(ctx, "secret")
+	if err != nil {
+		t.Fatalf("ExportEncrypted() error = %v", err)
+	}
+	tdb3 := setupTestDB(t)
+	if err := tdb3.CurrencyService.SetRate(ctx, "EUR", 0.9); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+	data, err := service.Decrypt(encrypted, "secret")
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	older := strings.Replace(string(data), `"exchange_rates"`, `"unknown"`, 1)
+	reencrypted, err := service.Encrypt([]byte(older), "secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if err := tdb3.SyncService.ImportEncrypted(ctx, reencrypted, "secret"); err != nil {
+		t.Fatalf("ImportEncrypted() error = %v", err)
+	}
+	if base, _ := tdb3.ConfigService.GetBaseCurrency(ctx); base != "EUR" {
+		t.Errorf("base currency = %s after importing an older backup, want EUR", base)
+	}
+	if rates, _ := tdb3.CurrencyService.ListRates(ctx); len(rates) != 1 || rates[0].Currency != "USD" || !almostEqual(rates[0].Rate, 1.11) {
+		t.Errorf("rates = %+v after importing an older backup, want USD rebased to 1.11", rates)
+	}
+}
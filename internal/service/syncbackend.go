@@ -0,0 +1,589 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// SyncBackend stores encrypted backups by name somewhere other devices can
+// reach. Backends never see the data unencrypted, unless a git backend is
+// set up to commit plaintext.
+type SyncBackend interface {
+	// Location describes where the backups go, for messages
+	Location() string
+	Put(ctx context.Context, name string, data []byte) error
+	// Get returns ErrBackupNotFound if there is no backup called name
+	Get(ctx context.Context, name string) ([]byte, error)
+	List(ctx context.Context) ([]string, error)
+	// Delete returns ErrBackupNotFound if there is no backup called name
+	Delete(ctx context.Context, name string) error
+}
+
+// MessagePutter is implemented by backends that keep the history of their
+// backups, like GitBackend, for a push to describe what it changed
+type MessagePutter interface {
+	// PutWithMessage is Put, recording message with the change
+	PutWithMessage(ctx context.Context, name, message string, data []byte) error
+}
+
+// BackupNamer is implemented by backends that keep the latest backup under
+// a name other than SyncBackupName, like a plaintext GitBackend. Backups
+// named *.json are plaintext.
+type BackupNamer interface {
+	BackupName() string
+}
+
+// backupName is the name push and pull use for the backup in backend
+func backupName(backend SyncBackend) string {
+	if namer, ok := backend.(BackupNamer); ok {
+		return namer.BackupName()
+	}
+	return SyncBackupName
+}
+
+// ErrBackupNotFound is returned by a SyncBackend for a backup it doesn't have
+var ErrBackupNotFound = errors.New("backup not found")
+
+// SyncBackupName is the name push and pull use for the backup
+const SyncBackupName = "subscription-tracker-backup.enc"
+
+// Sync backends
+const (
+	SyncBackendGist   = "gist"
+	SyncBackendDir    = "dir"
+	SyncBackendWebDAV = "webdav"
+	SyncBackendS3     = "s3"
+	SyncBackendGit    = "git"
+)
+
+// SyncBackends lists the sync backends
+var SyncBackends = []string{SyncBackendGist, SyncBackendDir, SyncBackendWebDAV, SyncBackendS3, SyncBackendGit}
+
+// Config keys for storing sync settings. Settings under syncSettingPrefix
+// belong to this device and are left out of backups.
+const (
+	syncSettingPrefix       = "sync_"
+	ConfigKeySyncBackend    = "sync_backend"
+	ConfigKeyGistID         = "sync_gist_id"
+	ConfigKeyGistToken      = "sync_gist_token" // Note: storing tokens in DB isn't ideal, but encrypted
+	ConfigKeySyncDir        = "sync_dir"
+	ConfigKeyWebDAVURL      = "sync_webdav_url"
+	ConfigKeyWebDAVUser     = "sync_webdav_user"
+	ConfigKeyWebDAVPassword = "sync_webdav_password" // No longer saved, see unsavedSyncConfigKeys
+	ConfigKeyS3Endpoint     = "sync_s3_endpoint"
+	ConfigKeyS3Region       = "sync_s3_region"
+	ConfigKeyS3Bucket       = "sync_s3_bucket"
+	ConfigKeyS3Prefix       = "sync_s3_prefix"
+	ConfigKeyS3PathStyle    = "sync_s3_path_style"
+	ConfigKeyS3AccessKey    = "sync_s3_access_key"
+	ConfigKeyS3SecretKey    = "sync_s3_secret_key" // No longer saved, see unsavedSyncConfigKeys
+	ConfigKeyS3Versioned    = "sync_s3_versioned"
+	ConfigKeyGitRepo        = "sync_git_repo"
+	ConfigKeyGitBranch      = "sync_git_branch"
+	ConfigKeyGitPlaintext   = "sync_git_plaintext"
+	ConfigKeySyncBase       = "sync_base" // The data last pushed or pulled
+)
+
+// unsavedSyncConfigKeys are secrets older versions saved in plaintext. They
+// are left out of SyncConfig, and cleared when it is saved.
+var unsavedSyncConfigKeys = []string{ConfigKeyWebDAVPassword, ConfigKeyS3SecretKey}
+
+// isSyncSetting reports whether the config key is a sync setting
+func isSyncSetting(key string) bool {
+	return strings.HasPrefix(key, syncSettingPrefix)
+}
+
+// SyncConfig holds the sync backend and the settings of each backend
+type SyncConfig struct {
+	Backend string // One of SyncBackends; gist when empty
+
+	GistToken string // GitHub personal access token
+	GistID    string // Gist ID (empty for new gist)
+
+	Dir string // Local or mounted folder
+
+	WebDAVURL      string // Collection the backups go in
+	WebDAVUser     string
+	WebDAVPassword string // Not saved; from the environment or typed in
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3Prefix    string
+	S3PathStyle bool
+	S3AccessKey string
+	S3SecretKey string // Not saved; from the environment or typed in
+	S3Versioned bool
+
+	GitRepo      string // Local path, URL or user@host:path
+	GitBranch    string
+	GitPlaintext bool // Commit canonical JSON instead of encrypted data
+
+	Force bool // Push over changes another device pushed since the last sync; not saved
+}
+
+// Encrypted reports whether backups are encrypted, and so need a password.
+// Only a git backend can be set up to commit plaintext.
+func (c *SyncConfig) Encrypted() bool {
+	return c.Backend != SyncBackendGit || !c.GitPlaintext
+}
+
+// Open returns the backend config selects
+func (c *SyncConfig) Open() (SyncBackend, error) {
+	switch c.Backend {
+	case SyncBackendGist, "":
+		if c.GistToken == "" {
+			return nil, fmt.Errorf("GitHub token is required")
+		}
+		return &GistBackend{Token: c.GistToken, GistID: c.GistID}, nil
+	case SyncBackendDir:
+		if c.Dir == "" {
+			return nil, fmt.Errorf("sync folder is required")
+		}
+		dir, err := filepath.Abs(c.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sync folder: %w", err)
+		}
+		return &DirBackend{Dir: dir}, nil
+	case SyncBackendWebDAV:
+		if c.WebDAVURL == "" {
+			return nil, fmt.Errorf("WebDAV URL is required")
+		}
+		return NewWebDAVBackend(c.WebDAVURL, c.WebDAVUser, c.WebDAVPassword)
+	case SyncBackendS3:
+		if c.S3Endpoint == "" || c.S3Bucket == "" {
+			return nil, fmt.Errorf("S3 endpoint and bucket are required")
+		}
+		return NewS3Backend(S3Backend{
+			Endpoint:  c.S3Endpoint,
+			Region:    c.S3Region,
+			Bucket:    c.S3Bucket,
+			Prefix:    c.S3Prefix,
+			PathStyle: c.S3PathStyle,
+			AccessKey: c.S3AccessKey,
+			SecretKey: c.S3SecretKey,
+			Versioned: c.S3Versioned,
+		})
+	case SyncBackendGit:
+		if c.GitRepo == "" {
+			return nil, fmt.Errorf("git repository is required")
+		}
+		return NewGitBackend(c.GitRepo, c.GitBranch, c.GitPlaintext)
+	}
+	return nil, fmt.Errorf("unknown sync backend %q (want %s)", c.Backend, strings.Join(SyncBackends, ", "))
+}
+
+// remember copies what the backend worked out, like the ID of a new gist
+// or the absolute folder or repository, back into config
+func (c *SyncConfig) remember(backend SyncBackend) {
+	switch b := backend.(type) {
+	case *GistBackend:
+		c.GistID = b.GistID
+	case *DirBackend:
+		c.Dir = b.Dir
+	case *GitBackend:
+		c.GitRepo = b.Repo
+	}
+}
+
+// syncConfigKeys maps config keys to the SyncConfig fields they store
+func (c *SyncConfig) syncConfigKeys() map[string]*string {
+	return map[string]*string{
+		ConfigKeySyncBackend: &c.Backend,
+		ConfigKeyGistToken:   &c.GistToken,
+		ConfigKeyGistID:      &c.GistID,
+		ConfigKeySyncDir:     &c.Dir,
+		ConfigKeyWebDAVURL:   &c.WebDAVURL,
+		ConfigKeyWebDAVUser:  &c.WebDAVUser,
+		ConfigKeyS3Endpoint:  &c.S3Endpoint,
+		ConfigKeyS3Region:    &c.S3Region,
+		ConfigKeyS3Bucket:    &c.S3Bucket,
+		ConfigKeyS3Prefix:    &c.S3Prefix,
+		ConfigKeyS3AccessKey: &c.S3AccessKey,
+		ConfigKeyGitRepo:     &c.GitRepo,
+		ConfigKeyGitBranch:   &c.GitBranch,
+	}
+}
+
+// syncConfigFlags maps config keys to the SyncConfig switches they store
+func (c *SyncConfig) syncConfigFlags() map[string]*bool {
+	return map[string]*bool{
+		ConfigKeyS3PathStyle:  &c.S3PathStyle,
+		ConfigKeyS3Versioned:  &c.S3Versioned,
+		ConfigKeyGitPlaintext: &c.GitPlaintext,
+	}
+}
+
+// GetSyncConfig retrieves the stored sync settings
+func (s *SyncService) GetSyncConfig(ctx context.Context) (*SyncConfig, error) {
+	config := &SyncConfig{}
+	for key, field := range config.syncConfigKeys() {
+		if value, err := s.queries.GetConfig(ctx, key); err == nil {
+			*field = value
+		}
+	}
+	for key, field := range config.syncConfigFlags() {
+		if value, err := s.queries.GetConfig(ctx, key); err == nil {
+			*field = value == "true"
+		}
+	}
+	if config.Backend == "" {
+		config.Backend = SyncBackendGist
+	}
+	return config, nil
+}
+
+// SaveSyncConfig saves the sync settings that are set
+func (s *SyncService) SaveSyncConfig(ctx context.Context, config *SyncConfig) error {
+	for key, field := range config.syncConfigKeys() {
+		if *field == "" {
+			continue
+		}
+		if err := s.queries.SetConfig(ctx, db.SetConfigParams{Key: key, Value: *field}); err != nil {
+			return fmt.Errorf("failed to save sync settings: %w", err)
+		}
+	}
+	for key, field := range config.syncConfigFlags() {
+		if err := s.queries.SetConfig(ctx, db.SetConfigParams{Key: key, Value: strconv.FormatBool(*field)}); err != nil {
+			return fmt.Errorf("failed to save sync settings: %w", err)
+		}
+	}
+	for _, key := range unsavedSyncConfigKeys {
+		if err := s.queries.SetConfig(ctx, db.SetConfigParams{Key: key, Value: ""}); err != nil {
+			return fmt.Errorf("failed to clear saved secrets: %w", err)
+		}
+	}
+	return nil
+}
+
+// PushTo encrypts all data and uploads it to backend, overwriting the
+// backup there, and records it as the base of the next merge
+func (s *SyncService) PushTo(ctx context.Context, password string, backend SyncBackend) error {
+	data, err := s.gatherData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to gather data: %w", err)
+	}
+	if putter, ok := backend.(MessagePutter); ok {
+		err = s.pushWithMessage(ctx, password, backend, putter, data)
+	} else {
+		err = s.upload(ctx, password, backend, data)
+	}
+	if err != nil {
+		return err
+	}
+	return s.saveSyncBase(ctx, data)
+}
+
+// upload encrypts data and uploads it as the backup
+func (s *SyncService) upload(ctx context.Context, password string, backend SyncBackend, data *SyncData) error {
+	encrypted, err := encryptData(data, password)
+	if err != nil {
+		return err
+	}
+	if err := backend.Put(ctx, SyncBackupName, []byte(encrypted)); err != nil {
+		return fmt.Errorf("failed to upload to %s: %w", backend.Location(), err)
+	}
+	return nil
+}
+
+// PullFrom downloads the named backup from backend and decrypts it. The
+// latest backup, SyncBackupName, is merged with the data here, returning a
+// *MergeConflictError if changes conflict; an older one replaces all data.
+func (s *SyncService) PullFrom(ctx context.Context, password string, backend SyncBackend, name string) error {
+	data, err := s.download(ctx, password, backend, name)
+	if errors.Is(err, ErrBackupNotFound) && name == SyncBackupName {
+		return fmt.Errorf("no backup in %s; push from the other device first", backend.Location())
+	}
+	if errors.Is(err, ErrBackupNotFound) {
+		return fmt.Errorf("no backup called %s in %s", name, backend.Location())
+	}
+	if err != nil {
+		return err
+	}
+	if name != SyncBackupName {
+		return s.importData(ctx, data)
+	}
+	return s.mergePulled(ctx, data)
+}
+
+// download gets the named backup from backend and decrypts it. Backups
+// named *.json are plaintext and need no password. It returns
+// ErrBackupNotFound if there is no such backup.
+func (s *SyncService) download(ctx context.Context, password string, backend SyncBackend, name string) (*SyncData, error) {
+	if name == SyncBackupName {
+		name = backupName(backend)
+	}
+	backup, err := backend.Get(ctx, name)
+	if errors.Is(err, ErrBackupNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from %s: %w", backend.Location(), err)
+	}
+	return decodeBackup(name, backup, password)
+}
+
+// decodeBackup decrypts the named backup, or parses it if it is plaintext
+func decodeBackup(name string, backup []byte, password string) (*SyncData, error) {
+	if strings.HasSuffix(name, ".json") {
+		var data SyncData
+		if err := json.Unmarshal(backup, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse data: %w", err)
+		}
+		return &data, nil
+	}
+	return decryptData(string(backup), password)
+}
+
+// checkPushed returns ErrBackupChanged if the backup in backend isn't the
+// one this device last pushed or pulled
+func (s *SyncService) checkPushed(ctx context.Context, password string, backend SyncBackend) error {
+	remote, err := s.download(ctx, password, backend, SyncBackupName)
+	if errors.Is(err, ErrBackupNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	base, err := s.syncBase(ctx)
+	if err != nil {
+		return err
+	}
+	if base == nil || !sameSnapshot(base, remote) {
+		return fmt.Errorf("%s: %w", backend.Location(), ErrBackupChanged)
+	}
+	return nil
+}
+
+// pushWithMessage puts data to a backend that keeps history, encrypted or as
+// canonical JSON, with a message describing what changed since the last push
+func (s *SyncService) pushWithMessage(ctx context.Context, password string, backend SyncBackend, putter MessagePutter, data *SyncData) error {
+	name := backupName(backend)
+	plaintext := strings.HasSuffix(name, ".json")
+
+	// The last push, to describe the change; a backup that can't be read is
+	// treated as missing
+	var previous *SyncData
+	old, err := backend.Get(ctx, name)
+	switch {
+	case err == nil:
+		previous, _ = decodeBackup(name, old, password)
+	case !errors.Is(err, ErrBackupNotFound):
+		return fmt.Errorf("failed to read %s: %w", backend.Location(), err)
+	}
+
+	canonical, err := canonicalJSON(data)
+	if err != nil {
+		return err
+	}
+	content := canonical
+	if previous != nil {
+		if last, err := canonicalJSON(previous); err == nil && bytes.Equal(last, canonical) {
+			return nil // Nothing to commit; encrypting again would change the file anyway
+		}
+	}
+	if !plaintext {
+		encrypted, err := encryptData(data, password)
+		if err != nil {
+			return err
+		}
+		content = []byte(encrypted)
+	}
+
+	if err := putter.PutWithMessage(ctx, name, describeSync(previous, data), content); err != nil {
+		return fmt.Errorf("failed to commit to %s: %w", backend.Location(), err)
+	}
+	return nil
+}
+
+// Push uploads to the backend config selects and saves config, so the
+// next push goes to the same place. It returns where the backup went. Unless
+// config.Force is set, it returns ErrBackupChanged instead of overwriting a
+// backup pushed from another device since this one last synced.
+func (s *SyncService) Push(ctx context.Context, password string, config *SyncConfig) (string, error) {
+	backend, err := config.Open()
+	if err != nil {
+		return "", err
+	}
+	if !config.Force {
+		if err := s.checkPushed(ctx, password, backend); err != nil {
+			return "", err
+		}
+	}
+	if err := s.PushTo(ctx, password, backend); err != nil {
+		return "", err
+	}
+	config.remember(backend)
+	if err := s.SaveSyncConfig(ctx, config); err != nil {
+		return "", fmt.Errorf("pushed but %w", err)
+	}
+	return backend.Location(), nil
+}
+
+// Pull merges the backup in the backend config selects into the data here
+// and saves config. It returns a *MergeConflictError if changes conflict.
+func (s *SyncService) Pull(ctx context.Context, password string, config *SyncConfig) error {
+	return s.PullBackup(ctx, password, config, SyncBackupName)
+}
+
+// PullBackup imports the named backup, such as an older version of the
+// backup, from the backend config selects and saves config, also when there
+// are conflicts to resolve
+func (s *SyncService) PullBackup(ctx context.Context, password string, config *SyncConfig, name string) error {
+	if (config.Backend == SyncBackendGist || config.Backend == "") && config.GistID == "" {
+		return fmt.Errorf("gist ID is required for pull")
+	}
+	backend, err := config.Open()
+	if err != nil {
+		return err
+	}
+	err = s.PullFrom(ctx, password, backend, name)
+	var conflicts *MergeConflictError
+	if err != nil && !errors.As(err, &conflicts) {
+		return err
+	}
+	config.remember(backend)
+	if err := s.SaveSyncConfig(ctx, config); err != nil {
+		return fmt.Errorf("pulled but %w", err)
+	}
+	return err
+}
+
+// Backups lists the backups in the backend config selects, such as the
+// older versions a versioned S3 backend keeps
+func (s *SyncService) Backups(ctx context.Context, config *SyncConfig) ([]string, error) {
+	backend, err := config.Open()
+	if err != nil {
+		return nil, err
+	}
+	names, err := backend.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", backend.Location(), err)
+	}
+	return names, nil
+}
+
+// DirBackend keeps backups in a local or mounted folder, such as one synced
+// by Syncthing or Dropbox or shared from a NAS
+type DirBackend struct {
+	Dir string
+}
+
+// Location returns the folder
+func (b *DirBackend) Location() string {
+	return b.Dir
+}
+
+// Put writes the backup to a temporary file and renames it into place, so
+// that a folder sync never picks up half a backup
+func (b *DirBackend) Put(ctx context.Context, name string, data []byte) error {
+	if err := checkBackupName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(b.Dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(b.Dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(b.Dir, name))
+}
+
+// Get reads a backup
+func (b *DirBackend) Get(ctx context.Context, name string) ([]byte, error) {
+	if err := checkBackupName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(b.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBackupNotFound
+	}
+	return data, err
+}
+
+// List returns the names of the files in the folder, leaving out hidden
+// files such as the temporary files of Put
+func (b *DirBackend) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(b.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete removes a backup
+func (b *DirBackend) Delete(ctx context.Context, name string) error {
+	if err := checkBackupName(name); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(b.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrBackupNotFound
+	}
+	return err
+}
+
+// checkBackupName rejects backup names that aren't a plain file name
+func checkBackupName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid backup name %q", name)
+	}
+	return nil
+}
+
+// backendResponse is a response of an HTTP backend with its body read
+type backendResponse struct {
+	status int
+	body   []byte
+}
+
+// send makes a request for an HTTP backend
+func send(req *http.Request) (*backendResponse, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return &backendResponse{status: resp.StatusCode, body: body}, nil
+}
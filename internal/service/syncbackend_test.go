@@ -0,0 +1,305 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
+)
+
+// testBackend puts, lists, gets and deletes backups through backend
+func testBackend(t *testing.T, backend service.SyncBackend) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := backend.Get(ctx, "missing.enc"); !errors.Is(err, service.ErrBackupNotFound) {
+		t.Errorf("Get() of a missing backup error = %v, want ErrBackupNotFound", err)
+	}
+	for name, data := range map[string]string{"b.enc": "second", "a.enc": "first"} {
+		if err := backend.Put(ctx, name, []byte(data)); err != nil {
+			t.Fatalf("Put(%s) error = %v", name, err)
+		}
+	}
+	if err := backend.Put(ctx, "a.enc", []byte("replaced")); err != nil {
+		t.Fatalf("Put() over a backup error = %v", err)
+	}
+
+	names, err := backend.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if got := strings.Join(names, ","); got != "a.enc,b.enc" {
+		t.Errorf("List() = %s, want a.enc,b.enc", got)
+	}
+	data, err := backend.Get(ctx, "a.enc")
+	if err != nil || string(data) != "replaced" {
+		t.Errorf("Get() = %q, %v; want the replaced backup", data, err)
+	}
+
+	if err := backend.Delete(ctx, "b.enc"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := backend.Delete(ctx, "b.enc"); !errors.Is(err, service.ErrBackupNotFound) {
+		t.Errorf("Delete() twice error = %v, want ErrBackupNotFound", err)
+	}
+	if names, _ := backend.List(ctx); len(names) != 1 {
+		t.Errorf("List() after Delete() = %v, want a.enc", names)
+	}
+}
+
+func TestDirBackend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sync")
+	testBackend(t, &service.DirBackend{Dir: dir})
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read sync folder: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("sync folder has %d files, want just the backup and no temporary files", len(entries))
+	}
+	if err := (&service.DirBackend{Dir: dir}).Put(context.Background(), "../escape.enc", nil); err == nil {
+		t.Error("Put() should reject a name outside the folder")
+	}
+}
+
+// davServer is a WebDAV server keeping files of one collection in memory
+type davServer struct {
+	mu         sync.Mutex
+	collection string
+	exists     bool
+	files      map[string][]byte
+}
+
+func (s *davServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, pass, ok := r.BasicAuth(); !ok || user != "me" || pass != "app-password" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, s.collection)
+	switch {
+	case r.Method == "MKCOL" && r.URL.Path == s.collection:
+		s.exists = true
+		w.WriteHeader(http.StatusCreated)
+	case !s.exists && r.Method == http.MethodPut:
+		w.WriteHeader(http.StatusConflict)
+	case !s.exists:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == "PROPFIND" && r.URL.Path == s.collection:
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:">`)
+		fmt.Fprintf(w, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop></d:propstat></d:response>`, s.collection)
+		for file := range s.files {
+			fmt.Fprintf(w, `<d:response><d:href>%s%s</d:href><d:propstat><d:prop><d:resourcetype/></d:prop></d:propstat></d:response>`, s.collection, file)
+		}
+		fmt.Fprintf(w, `</d:multistatus>`)
+	case r.Method == http.MethodPut:
+		s.files[name], _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet || r.Method == http.MethodDelete:
+		data, ok := s.files[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(s.files, name)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestWebDAVBackend(t *testing.T) {
+	dav := &davServer{collection: "/remote.php/dav/files/me/subscriptions/", files: map[string][]byte{}}
+	server := httptest.NewServer(dav)
+	defer server.Close()
+
+	// The folder doesn't exist yet and is created by the first Put
+	backend, err := service.NewWebDAVBackend(server.URL+"/remote.php/dav/files/me/subscriptions", "me", "app-password")
+	if err != nil {
+		t.Fatalf("NewWebDAVBackend() error = %v", err)
+	}
+	testBackend(t, backend)
+	if !dav.exists {
+		t.Error("Put() did not create the folder")
+	}
+
+	wrong, _ := service.NewWebDAVBackend(server.URL+dav.collection, "me", "guess")
+	if _, err := wrong.List(context.Background()); err == nil || !strings.Contains(err.Error(), "password") {
+		t.Errorf("List() with a wrong password error = %v, want a rejected password", err)
+	}
+	if _, err := service.NewWebDAVBackend("cloud.example.com/dav", "", ""); err == nil {
+		t.Error("NewWebDAVBackend() should reject a URL without a scheme")
+	}
+}
+
+// gistServer is a GitHub Gist API keeping one gist in memory
+type gistServer struct {
+	mu    sync.Mutex
+	id    string
+	files map[string]string
+}
+
+func (s *gistServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Method == http.MethodPost {
+		s.id = "abc123"
+		s.files = map[string]string{}
+	} else if s.id == "" || r.URL.Path != "/gists/"+s.id {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method == http.MethodPost || r.Method == http.MethodPatch {
+		var payload struct {
+			Files map[string]*struct {
+				Content string `json:"content"`
+			} `json:"files"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		for name, file := range payload.Files {
+			if file == nil {
+				delete(s.files, name)
+			} else {
+				s.files[name] = file.Content
+			}
+		}
+	}
+	files := map[string]map[string]string{}
+	for name, content := range s.files {
+		files[name] = map[string]string{"content": content}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": s.id, "files": files})
+}
+
+func TestGistBackend(t *testing.T) {
+	server := httptest.NewServer(&gistServer{})
+	defer server.Close()
+
+	backend := &service.GistBackend{Token: "token", APIURL: server.URL + "/gists"}
+	testBackend(t, backend)
+	if backend.GistID != "abc123" {
+		t.Errorf("GistID = %q after the first Put(), want the new gist", backend.GistID)
+	}
+}
+
+func TestSyncService_PushPull(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15",
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	dir := t.TempDir()
+	location, err := tdb.SyncService.Push(ctx, "secret", &service.SyncConfig{Backend: service.SyncBackendDir, Dir: dir})
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if location != dir {
+		t.Errorf("Push() went to %s, want %s", location, dir)
+	}
+	saved, err := tdb.SyncService.GetSyncConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetSyncConfig() error = %v", err)
+	}
+	if saved.Backend != service.SyncBackendDir || saved.Dir != dir {
+		t.Errorf("GetSyncConfig() = %+v, want the folder that was pushed to", saved)
+	}
+
+	// The other device pulls from its own copy of the folder
+	tdb2 := setupTestDB(t)
+	if err := tdb2.SyncService.Pull(ctx, "secret", &service.SyncConfig{Backend: service.SyncBackendDir, Dir: t.TempDir()}); err == nil || !strings.Contains(err.Error(), "no backup") {
+		t.Errorf("Pull() from an empty folder error = %v, want no backup", err)
+	}
+	if err := tdb2.ConfigService.SetMonthCutoffDay(ctx, 5); err != nil {
+		t.Fatalf("failed to set cutoff day: %v", err)
+	}
+	if err := tdb2.SyncService.SaveSyncConfig(ctx, &service.SyncConfig{Backend: service.SyncBackendDir, Dir: dir}); err != nil {
+		t.Fatalf("SaveSyncConfig() error = %v", err)
+	}
+	config, _ := tdb2.SyncService.GetSyncConfig(ctx)
+	if err := tdb2.SyncService.Pull(ctx, "secret", config); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	subs, _ := tdb2.SubscriptionService.List(ctx, "")
+	if len(subs) != 1 || subs[0].Name != "Netflix" {
+		t.Errorf("Pull() imported %d subscriptions, want Netflix", len(subs))
+	}
+
+	// Sync settings stay with the device
+	if err := tdb.SyncService.SaveSyncConfig(ctx, &service.SyncConfig{Backend: service.SyncBackendDir, Dir: "/mnt/nas/other"}); err != nil {
+		t.Fatalf("SaveSyncConfig() error = %v", err)
+	}
+	if _, err := tdb.SyncService.Push(ctx, "secret", &service.SyncConfig{Backend: service.SyncBackendDir, Dir: dir}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if err := tdb2.SyncService.Pull(ctx, "secret", config); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if after, _ := tdb2.SyncService.GetSyncConfig(ctx); after.Dir != dir {
+		t.Errorf("Pull() changed the sync folder to %s", after.Dir)
+	}
+
+	if _, err := (&service.SyncConfig{Backend: "ftp"}).Open(); err == nil {
+		t.Error("Open() should reject an unknown backend")
+	}
+}
+
+func TestSyncService_SaveSyncConfigSecrets(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	// Secrets saved in plaintext by an older version
+	for _, key := range []string{service.ConfigKeyWebDAVPassword, service.ConfigKeyS3SecretKey} {
+		if err := tdb.Queries.SetConfig(ctx, db.SetConfigParams{Key: key, Value: "hunter2"}); err != nil {
+			t.Fatalf("SetConfig() error = %v", err)
+		}
+	}
+	if config, _ := tdb.SyncService.GetSyncConfig(ctx); config.WebDAVPassword != "" || config.S3SecretKey != "" {
+		t.Errorf("GetSyncConfig() = %+v, read the saved secrets", config)
+	}
+
+	if err := tdb.SyncService.SaveSyncConfig(ctx, &service.SyncConfig{
+		Backend: service.SyncBackendWebDAV, WebDAVURL: "https://cloud.example.com/dav/", WebDAVUser: "alice", WebDAVPassword: "correct horse",
+		S3AccessKey: "AKIAEXAMPLE", S3SecretKey: "correct horse",
+	}); err != nil {
+		t.Fatalf("SaveSyncConfig() error = %v", err)
+	}
+	config, _ := tdb.SyncService.GetSyncConfig(ctx)
+	if config.WebDAVUser != "alice" || config.WebDAVPassword != "" || config.S3AccessKey != "AKIAEXAMPLE" || config.S3SecretKey != "" {
+		t.Errorf("GetSyncConfig() = %+v, want the user and access key without the secrets", config)
+	}
+	all, err := tdb.Queries.GetAllConfig(ctx)
+	if err != nil {
+		t.Fatalf("GetAllConfig() error = %v", err)
+	}
+	for _, c := range all {
+		if c.Value == "hunter2" || c.Value == "correct horse" {
+			t.Errorf("config %s keeps a secret", c.Key)
+		}
+	}
+}
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// Ways to resolve a sync conflict
+const (
+	ResolveLocal  = "local"  // Keep the change made on this device
+	ResolveRemote = "remote" // Take the change pulled from the other device
+	ResolveBoth   = "both"   // Keep this device's version and add the pulled one as a copy
+)
+
+// SyncResolutions lists the ways to resolve a sync conflict
+var SyncResolutions = []string{ResolveLocal, ResolveRemote, ResolveBoth}
+
+// ErrBackupChanged is returned by a push when another device pushed since
+// this one last synced, so that the push doesn't overwrite its changes
+var ErrBackupChanged = errors.New("another device pushed since the last sync; pull to merge its changes first")
+
+// syncFieldGroups ties together the subscription fields that only make sense
+// together, so that a merge takes them all from the same device. Other
+// fields are merged on their own.
+var syncFieldGroups = map[string]string{
+	"Currency":     "amount",
+	"PriceHistory": "amount",
+	"TrialEndDate": "status",
+	"TrialPrice":   "status",
+	"PauseDate":    "status",
+	"CancelDate":   "status",
+}
+
+// SyncConflict is a subscription changed in different ways on this device
+// and on the device that pushed since they last synced
+type SyncConflict struct {
+	ID         string
+	Base       *SyncSubscription // As it was at the last sync; nil if added on both devices
+	Local      *SyncSubscription // Nil if deleted on this device
+	Remote     *SyncSubscription // Nil if deleted on the other device
+	Fields     []string          // What both devices changed, e.g. "amount"; empty for a deletion
+	Resolution string            // One of SyncResolutions; empty until resolved
+}
+
+// Name returns the name of the subscription on this device, or on the other
+// if it was deleted here
+func (c *SyncConflict) Name() string {
+	if c.Local != nil {
+		return c.Local.Name
+	}
+	return c.Remote.Name
+}
+
+// Describe says what the devices did to the subscription
+func (c *SyncConflict) Describe() string {
+	switch {
+	case c.Local == nil:
+		return "deleted here, changed on the other device"
+	case c.Remote == nil:
+		return "changed here, deleted on the other device"
+	}
+	return "both changed " + strings.ReplaceAll(strings.Join(c.Fields, ", "), "_", " ")
+}
+
+// resolved returns the subscriptions the conflict resolves to
+func (c *SyncConflict) resolved() ([]SyncSubscription, error) {
+	if !slices.Contains(SyncResolutions, c.Resolution) {
+		return nil, fmt.Errorf("conflicting changes to %s are not resolved", c.Name())
+	}
+	if c.Local == nil || c.Remote == nil {
+		// Both keeps the changed version, as there is only one
+		changed, deletion := c.Remote, ResolveLocal
+		if c.Remote == nil {
+			changed, deletion = c.Local, ResolveRemote
+		}
+		if c.Resolution == deletion {
+			return nil, nil
+		}
+		return []SyncSubscription{*changed}, nil
+	}
+
+	var base SyncSubscription
+	if c.Base != nil {
+		base = *c.Base
+	}
+	// The changes that don't conflict are merged either way
+	merged, _ := mergeSubscription(base, *c.Local, *c.Remote, c.Resolution == ResolveRemote)
+	subs := []SyncSubscription{merged}
+	if c.Resolution == ResolveBoth {
+		remote := *c.Remote
+		remote.ID = newUUID()
+		subs = append(subs, remote)
+	}
+	return subs, nil
+}
+
+// SyncMerge is pulled data merged with the data on this device
+type SyncMerge struct {
+	Remote    *SyncData // The pulled data, the base of the next merge
+	Merged    *SyncData // Everything merged but the conflicting subscriptions
+	Conflicts []SyncConflict
+}
+
+// ResolveAll resolves every conflict the same way
+func (m *SyncMerge) ResolveAll(resolution string) error {
+	if !slices.Contains(SyncResolutions, resolution) {
+		return fmt.Errorf("invalid resolution %q (want %s)", resolution, strings.Join(SyncResolutions, ", "))
+	}
+	for i := range m.Conflicts {
+		m.Conflicts[i].Resolution = resolution
+	}
+	return nil
+}
+
+// Result returns the merged data with the conflicts resolved
+func (m *SyncMerge) Result() (*SyncData, error) {
+	data := *m.Merged
+	data.Subscriptions = slices.Clone(m.Merged.Subscriptions)
+	for i := range m.Conflicts {
+		subs, err := m.Conflicts[i].resolved()
+		if err != nil {
+			return nil, err
+		}
+		data.Subscriptions = append(data.Subscriptions, subs...)
+	}
+	slices.SortStableFunc(data.Subscriptions, func(a, b SyncSubscription) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return &data, nil
+}
+
+// MergeConflictError is returned by a pull that found subscriptions changed
+// in different ways on both devices. Nothing is imported until the conflicts
+// of Merge are resolved and it is passed to ApplyMerge.
+type MergeConflictError struct {
+	Merge *SyncMerge
+}
+
+func (e *MergeConflictError) Error() string {
+	conflicts := make([]string, len(e.Merge.Conflicts))
+	for i, c := range e.Merge.Conflicts {
+		conflicts[i] = c.Name() + " (" + c.Describe() + ")"
+	}
+	return "conflicting changes to " + strings.Join(conflicts, "; ")
+}
+
+// ApplyMerge imports a merge with its conflicts resolved and keeps the pulled
+// data as the base of the next merge
+func (s *SyncService) ApplyMerge(ctx context.Context, merge *SyncMerge) error {
+	data, err := merge.Result()
+	if err != nil {
+		return err
+	}
+	if err := s.importData(ctx, data); err != nil {
+		return err
+	}
+	return s.saveSyncBase(ctx, merge.Remote)
+}
+
+// mergePulled merges pulled data into the data on this device, returning a
+// *MergeConflictError if there are conflicts to resolve first
+func (s *SyncService) mergePulled(ctx context.Context, remote *SyncData) error {
+	local, err := s.gatherData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to gather data: %w", err)
+	}
+	base, err := s.syncBase(ctx)
+	if err != nil {
+		return err
+	}
+	merge := mergeSyncData(base, local, remote)
+	if len(merge.Conflicts) > 0 {
+		return &MergeConflictError{Merge: merge}
+	}
+	return s.ApplyMerge(ctx, merge)
+}
+
+// syncBase returns the data last pushed or pulled, which is what this device
+// and the backup had in common, or nil before the first sync
+func (s *SyncService) syncBase(ctx context.Context) (*SyncData, error) {
+	value, err := s.queries.GetConfig(ctx, ConfigKeySyncBase)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the last synced data: %w", err)
+	}
+	var base SyncData
+	if err := json.Unmarshal([]byte(value), &base); err != nil {
+		return nil, fmt.Errorf("failed to parse the last synced data: %w", err)
+	}
+	return &base, nil
+}
+
+// saveSyncBase records data as pushed or pulled
+func (s *SyncService) saveSyncBase(ctx context.Context, data *SyncData) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+	if err := s.queries.SetConfig(ctx, db.SetConfigParams{Key: ConfigKeySyncBase, Value: string(value)}); err != nil {
+		return fmt.Errorf("failed to save the synced data: %w", err)
+	}
+	return nil
+}
+
+// mergeSyncData merges the changes made on this device and on the other
+// since the last sync, at base, which is nil before the first sync. A
+// subscription changed in different ways on both is a conflict. Budgets and
+// settings changed on both take the pulled value.
+func mergeSyncData(base, local, remote *SyncData) *SyncMerge {
+	if base == nil {
+		base = &SyncData{}
+	}
+	base, local, remote = matchSubscriptions(base, local, remote)
+
+	index := func(subs []SyncSubscription) map[string]SyncSubscription {
+		m := make(map[string]SyncSubscription, len(subs))
+		for _, sub := range subs {
+			m[sub.ID] = sub
+		}
+		return m
+	}
+	bases, locals, remotes := index(base.Subscriptions), index(local.Subscriptions), index(remote.Subscriptions)
+	var ids []string
+	for _, subs := range [][]SyncSubscription{local.Subscriptions, remote.Subscriptions} {
+		for _, sub := range subs {
+			if !slices.Contains(ids, sub.ID) {
+				ids = append(ids, sub.ID)
+			}
+		}
+	}
+
+	merged := &SyncData{Version: remote.Version, ExportedAt: time.Now().UTC()}
+	var conflicts []SyncConflict
+	for _, id := range ids {
+		b, inBase := bases[id]
+		l, inLocal := locals[id]
+		r, inRemote := remotes[id]
+		conflict := SyncConflict{ID: id}
+		if inBase {
+			conflict.Base = &b
+		}
+		switch {
+		case inLocal && inRemote:
+			sub, fields := mergeSubscription(b, l, r, false)
+			if len(fields) == 0 {
+				merged.Subscriptions = append(merged.Subscriptions, sub)
+				continue
+			}
+			conflict.Local, conflict.Remote, conflict.Fields = &l, &r, fields
+		case inLocal:
+			// Added here, or deleted on the other device
+			if !inBase {
+				merged.Subscriptions = append(merged.Subscriptions, l)
+			}
+			if !inBase || sameSubscription(b, l) {
+				continue
+			}
+			conflict.Local = &l
+		default:
+			// Added on the other device, or deleted here
+			if !inBase {
+				merged.Subscriptions = append(merged.Subscriptions, r)
+			}
+			if !inBase || sameSubscription(b, r) {
+				continue
+			}
+			conflict.Remote = &r
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	identity := func(s string) string { return s }
+	merged.Categories = mergeList(base.Categories, local.Categories, remote.Categories, identity)
+	merged.Payments = mergeList(base.Payments, local.Payments, remote.Payments, func(p SyncPayment) string {
+		key, _ := json.Marshal(p)
+		return string(key)
+	})
+	budgets := mergeMap(budgetMap(base.Budgets), budgetMap(local.Budgets), budgetMap(remote.Budgets))
+	for category, amount := range budgets {
+		merged.Budgets = append(merged.Budgets, SyncBudget{Category: category, Amount: amount})
+	}
+	slices.SortFunc(merged.Budgets, func(a, b SyncBudget) int { return cmp.Compare(a.Category, b.Category) })
+	merged.Config = mergeMap(base.Config, local.Config, remote.Config)
+	merged.ExchangeRates = mergeRates(base, local, remote, merged.Config[ConfigKeyBaseCurrency])
+
+	return &SyncMerge{Remote: remote, Merged: merged, Conflicts: conflicts}
+}
+
+// matchSubscriptions gives every subscription an ID, returning copies of the
+// data. Subscriptions only known by name on one device, such as those of a
+// backup from before subscriptions had IDs, are matched by name to the
+// subscriptions of the other and take their ID from the pulled data.
+func matchSubscriptions(base, local, remote *SyncData) (*SyncData, *SyncData, *SyncData) {
+	clone := func(data *SyncData) *SyncData {
+		c := *data
+		c.Subscriptions = slices.Clone(data.Subscriptions)
+		return &c
+	}
+	base, local, remote = clone(base), clone(local), clone(remote)
+	ids := func(subs []SyncSubscription) map[string]bool {
+		m := make(map[string]bool, len(subs))
+		for _, sub := range subs {
+			if sub.ID != "" {
+				m[sub.ID] = true
+			}
+		}
+		return m
+	}
+	baseIDs, localIDs, remoteIDs := ids(base.Subscriptions), ids(local.Subscriptions), ids(remote.Subscriptions)
+
+	// byName finds a subscription called name that the pulled data doesn't know
+	byName := func(subs []SyncSubscription, name string) *SyncSubscription {
+		for i := range subs {
+			if subs[i].Name == name && !remoteIDs[subs[i].ID] {
+				return &subs[i]
+			}
+		}
+		return nil
+	}
+	for i := range remote.Subscriptions {
+		r := &remote.Subscriptions[i]
+		if r.ID != "" && (localIDs[r.ID] || baseIDs[r.ID]) {
+			continue
+		}
+		match := byName(local.Subscriptions, r.Name)
+		if match == nil {
+			match = byName(base.Subscriptions, r.Name)
+		}
+		switch {
+		case match == nil && r.ID == "":
+			r.ID = newUUID()
+		case match == nil:
+		case r.ID == "":
+			r.ID = match.ID
+		default:
+			// The same subscription under another ID; the pulled one wins
+			old := match.ID
+			for _, subs := range [][]SyncSubscription{base.Subscriptions, local.Subscriptions} {
+				for j := range subs {
+					if subs[j].ID == old {
+						subs[j].ID = r.ID
+					}
+				}
+			}
+		}
+		remoteIDs[r.ID] = true
+	}
+	return base, local, remote
+}
+
+// mergeSubscription merges two versions of a subscription changed from base,
+// field by field. It returns the fields both changed in different ways,
+// which are taken from remote if preferRemote, or else from local.
+func mergeSubscription(base, local, remote SyncSubscription, preferRemote bool) (SyncSubscription, []string) {
+	base, local, remote = normalizeSubscription(base), normalizeSubscription(local), normalizeSubscription(remote)
+	merged := local
+	mv, bv, lv, rv := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(base), reflect.ValueOf(local), reflect.ValueOf(remote)
+	t := mv.Type()
+
+	// Fields by group, in the order of the struct
+	var groups []string
+	fields := make(map[string][]int)
+	for i := range t.NumField() {
+		name := t.Field(i).Name
+		if name == "ID" {
+			continue
+		}
+		group, ok := syncFieldGroups[name]
+		if !ok {
+			group, _, _ = strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		}
+		if _, ok := fields[group]; !ok {
+			groups = append(groups, group)
+		}
+		fields[group] = append(fields[group], i)
+	}
+
+	var conflicts []string
+	for _, group := range groups {
+		values := func(v reflect.Value) []any {
+			var out []any
+			for _, i := range fields[group] {
+				out = append(out, v.Field(i).Interface())
+			}
+			return out
+		}
+		b, l, r := values(bv), values(lv), values(rv)
+		take := false
+		switch {
+		case reflect.DeepEqual(l, r) || reflect.DeepEqual(b, r):
+			// The same on both, or only changed here
+		case reflect.DeepEqual(b, l):
+			take = true
+		default:
+			conflicts = append(conflicts, group)
+			take = preferRemote
+		}
+		if take {
+			for _, i := range fields[group] {
+				mv.Field(i).Set(rv.Field(i))
+			}
+		}
+	}
+	return merged, conflicts
+}
+
+// normalizeSubscription makes empty lists nil, as they are in a backup
+func normalizeSubscription(sub SyncSubscription) SyncSubscription {
+	if len(sub.Tags) == 0 {
+		sub.Tags = nil
+	}
+	if len(sub.PriceHistory) == 0 {
+		sub.PriceHistory = nil
+	}
+	return sub
+}
+
+// sameSubscription reports whether two versions of a subscription are the same
+func sameSubscription(a, b SyncSubscription) bool {
+	return reflect.DeepEqual(normalizeSubscription(a), normalizeSubscription(b))
+}
+
+// sameSnapshot reports whether a and b hold the same data, whether or not
+// their subscriptions have IDs
+func sameSnapshot(a, b *SyncData) bool {
+	strip := func(data *SyncData) []byte {
+		c := *data
+		c.Subscriptions = slices.Clone(data.Subscriptions)
+		for i := range c.Subscriptions {
+			c.Subscriptions[i].ID = ""
+		}
+		out, _ := canonicalJSON(&c)
+		return out
+	}
+	return bytes.Equal(strip(a), strip(b))
+}
+
+// mergeList merges two versions of a list changed from base: items both kept,
+// plus those either added, in the order of local then remote. Items are told
+// apart by key, and may repeat.
+func mergeList[T any](base, local, remote []T, key func(T) string) []T {
+	count := func(items []T) map[string]int {
+		m := make(map[string]int, len(items))
+		for _, item := range items {
+			m[key(item)]++
+		}
+		return m
+	}
+	b, l, r := count(base), count(local), count(remote)
+	want := func(k string) int {
+		switch {
+		case l[k] == r[k] || r[k] == b[k]:
+			return l[k]
+		case l[k] == b[k]:
+			return r[k]
+		}
+		return max(l[k], r[k])
+	}
+
+	var merged []T
+	taken := make(map[string]int)
+	for _, items := range [][]T{local, remote} {
+		for _, item := range items {
+			if k := key(item); taken[k] < want(k) {
+				merged = append(merged, item)
+				taken[k]++
+			}
+		}
+	}
+	return merged
+}
+
+// mergeMap merges two versions of a map changed from base, key by key. Keys
+// changed on both take the remote value.
+func mergeMap[V comparable](base, local, remote map[string]V) map[string]V {
+	merged := make(map[string]V)
+	for _, m := range []map[string]V{base, local, remote} {
+		for k := range m {
+			b, inBase := base[k]
+			v, ok := remote[k]
+			if ok == inBase && v == b {
+				// Unchanged on the other device
+				v, ok = local[k]
+			}
+			if ok {
+				merged[k] = v
+			}
+		}
+	}
+	return merged
+}
+
+// mergeRates merges the exchange rates, which are relative to the base
+// currency: those of the device whose base currency the merge took, or rate by
+// rate if both have the same one. It returns nil for older backups without
+// rates, which importData rebases the rates here for.
+func mergeRates(base, local, remote *SyncData, currency string) map[string]float64 {
+	localBase, remoteBase := local.Config[ConfigKeyBaseCurrency], remote.Config[ConfigKeyBaseCurrency]
+	switch {
+	case remote.ExchangeRates == nil:
+		return nil
+	case localBase != remoteBase && currency == remoteBase:
+		return remote.ExchangeRates
+	case localBase != remoteBase:
+		return local.ExchangeRates
+	}
+	baseRates := base.ExchangeRates
+	if base.Config[ConfigKeyBaseCurrency] != localBase {
+		baseRates = nil
+	}
+	return mergeMap(baseRates, local.ExchangeRates, remote.ExchangeRates)
+}
+
+// budgetMap maps categories to their budget, with "" for the overall budget
+func budgetMap(budgets []SyncBudget) map[string]float64 {
+	m := make(map[string]float64, len(budgets))
+	for _, b := range budgets {
+		m[b.Category] = b.Amount
+	}
+	return m
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:]) // Never fails
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
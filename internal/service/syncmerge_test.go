@@ -0,0 +1,282 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
+)
+
+// syncDevice is the database of one device syncing through a shared folder
+type syncDevice struct {
+	*testDB
+	config *service.SyncConfig
+}
+
+func newSyncDevice(t *testing.T, dir string) *syncDevice {
+	t.Helper()
+	return &syncDevice{setupTestDB(t), &service.SyncConfig{Backend: service.SyncBackendDir, Dir: dir}}
+}
+
+func (d *syncDevice) push(t *testing.T) {
+	t.Helper()
+	if _, err := d.SyncService.Push(context.Background(), "secret", d.config); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+}
+
+func (d *syncDevice) pull(t *testing.T) {
+	t.Helper()
+	if err := d.SyncService.Pull(context.Background(), "secret", d.config); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+}
+
+func (d *syncDevice) create(t *testing.T, name string, amount float64) {
+	t.Helper()
+	if _, err := d.SubscriptionService.Create(context.Background(), service.CreateSubscriptionInput{
+		Name: name, Amount: amount, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15",
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+}
+
+// subscription returns the subscription with the given name
+func (d *syncDevice) subscription(t *testing.T, name string) db.Subscription {
+	t.Helper()
+	subs, err := d.SubscriptionService.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	for _, sub := range subs {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	t.Fatalf("no subscription named %s in %+v", name, subs)
+	return db.Subscription{}
+}
+
+// edit changes the subscription with the given name
+func (d *syncDevice) edit(t *testing.T, name string, change func(*service.UpdateSubscriptionInput)) {
+	t.Helper()
+	sub := d.subscription(t, name)
+	input := service.UpdateSubscriptionInput{
+		ID: sub.ID, Name: sub.Name, Amount: sub.Amount, Currency: sub.Currency,
+		BillingCycle: sub.BillingCycle, NextRenewalDate: sub.NextRenewalDate.String,
+	}
+	change(&input)
+	if _, err := d.SubscriptionService.Update(context.Background(), input); err != nil {
+		t.Fatalf("failed to update subscription: %v", err)
+	}
+}
+
+func (d *syncDevice) delete(t *testing.T, name string) {
+	t.Helper()
+	if err := d.SubscriptionService.Delete(context.Background(), d.subscription(t, name).ID); err != nil {
+		t.Fatalf("failed to delete subscription: %v", err)
+	}
+}
+
+// count returns how many subscriptions have the given name
+func (d *syncDevice) count(t *testing.T, name string) int {
+	t.Helper()
+	subs, _ := d.SubscriptionService.List(context.Background(), "")
+	n := 0
+	for _, sub := range subs {
+		if sub.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSyncService_Merge(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	laptop, desktop := newSyncDevice(t, dir), newSyncDevice(t, dir)
+
+	laptop.create(t, "Netflix", 15.00)
+	laptop.create(t, "Spotify", 10.00)
+	laptop.create(t, "Hulu", 8.00)
+	laptop.push(t)
+	desktop.pull(t)
+	if laptop.subscription(t, "Netflix").Uuid != desktop.subscription(t, "Netflix").Uuid {
+		t.Error("a pulled subscription should keep its UUID")
+	}
+
+	laptop.edit(t, "Netflix", func(in *service.UpdateSubscriptionInput) { in.Amount = 18.00 })
+	laptop.delete(t, "Hulu")
+	laptop.create(t, "Disney+", 9.00)
+	laptop.push(t)
+
+	netflixID := desktop.subscription(t, "Netflix").ID
+	desktop.edit(t, "Netflix", func(in *service.UpdateSubscriptionInput) { in.NextRenewalDate = "2026-03-20" })
+	desktop.edit(t, "Spotify", func(in *service.UpdateSubscriptionInput) { in.Amount = 12.00 })
+
+	// The desktop can't push over the laptop's changes
+	if _, err := desktop.SyncService.Push(ctx, "secret", desktop.config); !errors.Is(err, service.ErrBackupChanged) {
+		t.Fatalf("Push() error = %v, want ErrBackupChanged", err)
+	}
+
+	// Edits of different fields and subscriptions merge
+	desktop.pull(t)
+	netflix := desktop.subscription(t, "Netflix")
+	if netflix.ID != netflixID {
+		t.Errorf("Netflix was recreated as %d, want it updated in place as %d", netflix.ID, netflixID)
+	}
+	if netflix.Amount != 18.00 || netflix.NextRenewalDate.String != "2026-03-20" {
+		t.Errorf("Netflix = %.2f renewing %s, want both edits", netflix.Amount, netflix.NextRenewalDate.String)
+	}
+	if spotify := desktop.subscription(t, "Spotify"); spotify.Amount != 12.00 {
+		t.Errorf("Spotify = %.2f, want the local edit kept", spotify.Amount)
+	}
+	if desktop.count(t, "Hulu") != 0 || desktop.count(t, "Disney+") != 1 {
+		t.Error("the pull should delete Hulu and add Disney+")
+	}
+
+	desktop.push(t)
+	laptop.pull(t)
+	if spotify := laptop.subscription(t, "Spotify"); spotify.Amount != 12.00 {
+		t.Errorf("Spotify = %.2f on the laptop, want the desktop's edit", spotify.Amount)
+	}
+	if netflix := laptop.subscription(t, "Netflix"); netflix.NextRenewalDate.String != "2026-03-20" {
+		t.Errorf("Netflix renews %s on the laptop, want the desktop's edit", netflix.NextRenewalDate.String)
+	}
+}
+
+func TestSyncService_MergeConflicts(t *testing.T) {
+	ctx := context.Background()
+	for _, tt := range []struct {
+		resolution string
+		netflix    []float64 // Amounts of the Netflix subscriptions after the merge
+		spotify    int       // Spotify subscriptions after the merge
+	}{
+		{service.ResolveLocal, []float64{20.00}, 1},
+		{service.ResolveRemote, []float64{18.00}, 0},
+		{service.ResolveBoth, []float64{18.00, 20.00}, 1},
+	} {
+		t.Run(tt.resolution, func(t *testing.T) {
+			dir := t.TempDir()
+			laptop, desktop := newSyncDevice(t, dir), newSyncDevice(t, dir)
+			laptop.create(t, "Netflix", 15.00)
+			laptop.create(t, "Spotify", 10.00)
+			laptop.push(t)
+			desktop.pull(t)
+
+			laptop.edit(t, "Netflix", func(in *service.UpdateSubscriptionInput) { in.Amount = 18.00 })
+			laptop.delete(t, "Spotify")
+			laptop.push(t)
+			desktop.edit(t, "Netflix", func(in *service.UpdateSubscriptionInput) {
+				in.Amount = 20.00
+				in.Category = "Streaming"
+			})
+			desktop.edit(t, "Spotify", func(in *service.UpdateSubscriptionInput) { in.Amount = 11.00 })
+
+			err := desktop.SyncService.Pull(ctx, "secret", desktop.config)
+			var conflicts *service.MergeConflictError
+			if !errors.As(err, &conflicts) {
+				t.Fatalf("Pull() error = %v, want conflicts", err)
+			}
+			merge := conflicts.Merge
+			if len(merge.Conflicts) != 2 {
+				t.Fatalf("Conflicts = %+v, want Netflix and Spotify", merge.Conflicts)
+			}
+			for _, c := range merge.Conflicts {
+				switch c.Name() {
+				case "Netflix":
+					if c.Describe() != "both changed amount" {
+						t.Errorf("Netflix conflict = %s, want the amount", c.Describe())
+					}
+				case "Spotify":
+					if c.Remote != nil || c.Local == nil {
+						t.Errorf("Spotify conflict = %s, want deleted on the other device", c.Describe())
+					}
+				default:
+					t.Errorf("unexpected conflict %s", c.Name())
+				}
+			}
+			if desktop.subscription(t, "Netflix").Amount != 20.00 {
+				t.Error("Pull() should import nothing while there are conflicts")
+			}
+			if err := desktop.SyncService.ApplyMerge(ctx, merge); err == nil {
+				t.Error("ApplyMerge() should fail while conflicts aren't resolved")
+			}
+
+			if err := merge.ResolveAll(tt.resolution); err != nil {
+				t.Fatalf("ResolveAll() error = %v", err)
+			}
+			if err := desktop.SyncService.ApplyMerge(ctx, merge); err != nil {
+				t.Fatalf("ApplyMerge() error = %v", err)
+			}
+			subs, _ := desktop.SubscriptionService.List(ctx, "")
+			var netflix []float64
+			for _, sub := range subs {
+				if sub.Name == "Netflix" {
+					netflix = append(netflix, sub.Amount)
+					// The category didn't conflict, so it is kept either way
+					if !sub.CategoryID.Valid && sub.Amount == 20.00 {
+						t.Error("the merge lost the category set here")
+					}
+				}
+			}
+			slices.Sort(netflix)
+			if !slices.Equal(netflix, tt.netflix) {
+				t.Errorf("Netflix = %v, want %v", netflix, tt.netflix)
+			}
+			if n := desktop.count(t, "Spotify"); n != tt.spotify {
+				t.Errorf("%d Spotify subscriptions, want %d", n, tt.spotify)
+			}
+
+			// The merge is the base of the next push
+			desktop.push(t)
+			laptop.pull(t)
+			if n := laptop.count(t, "Netflix"); n != len(tt.netflix) {
+				t.Errorf("%d Netflix subscriptions on the laptop, want %d", n, len(tt.netflix))
+			}
+		})
+	}
+}
+
+func TestSyncService_MergeForce(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	laptop, desktop := newSyncDevice(t, dir), newSyncDevice(t, dir)
+	laptop.create(t, "Netflix", 15.00)
+	laptop.push(t)
+
+	// A device that never synced has to pull first, unless it forces the push
+	desktop.create(t, "Spotify", 10.00)
+	if _, err := desktop.SyncService.Push(ctx, "secret", desktop.config); !errors.Is(err, service.ErrBackupChanged) {
+		t.Fatalf("Push() error = %v, want ErrBackupChanged", err)
+	}
+	desktop.config.Force = true
+	desktop.push(t)
+	laptop.pull(t)
+	if laptop.count(t, "Netflix") != 0 || laptop.count(t, "Spotify") != 1 {
+		t.Error("a forced push should overwrite what the laptop pushed")
+	}
+}
+
+func TestSyncService_MergeWithoutIDs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	laptop, desktop := newSyncDevice(t, dir), newSyncDevice(t, dir)
+
+	// Both devices have the same subscription from before there were IDs
+	laptop.create(t, "Netflix", 15.00)
+	desktop.create(t, "Netflix", 15.00)
+	laptop.push(t)
+	if err := desktop.SyncService.Pull(ctx, "secret", desktop.config); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if desktop.count(t, "Netflix") != 1 {
+		t.Fatal("the same subscription on both devices should be matched by name")
+	}
+	if laptop.subscription(t, "Netflix").Uuid != desktop.subscription(t, "Netflix").Uuid {
+		t.Error("a subscription matched by name should take the pulled UUID")
+	}
+}
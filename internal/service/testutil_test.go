@@ -16,8 +16,17 @@ type testDB struct {
 	SubscriptionService *service.SubscriptionService
 	SpendingService     *service.SpendingService
 	ExportService       *service.ExportService
+	ReportService       *service.ReportService
+	ReminderService     *service.ReminderService
+	ImportService       *service.ImportService
+	StatementService    *service.StatementService
 	ConfigService       *service.ConfigService
 	SyncService         *service.SyncService
+	CurrencyService     *service.CurrencyService
+	CategoryService     *service.CategoryService
+	BudgetService       *service.BudgetService
+	PaymentService      *service.PaymentService
+	WebhookService      *service.WebhookService
 }
 
 // setupTestDB creates an in-memory SQLite database for testing
@@ -36,19 +45,126 @@ func setupTestDB(t *testing.T) *testDB {
 		name TEXT NOT NULL,
 		amount REAL NOT NULL,
 		currency TEXT NOT NULL DEFAULT 'USD',
-		billing_cycle TEXT NOT NULL CHECK (billing_cycle IN ('monthly', 'yearly')),
+		billing_cycle TEXT NOT NULL CHECK (
+			billing_cycle IN ('weekly', 'biweekly', 'monthly', 'quarterly', 'semiannual', 'yearly')
+			OR billing_cycle GLOB 'every [1-9]* *'
+		),
 		next_renewal_date TEXT,
 		created_at TEXT NOT NULL DEFAULT (datetime('now')),
-		updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+		updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+		category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+		tags TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'trial', 'paused', 'cancelled')),
+		trial_end_date TEXT,
+		pause_date TEXT,
+		cancel_date TEXT,
+		trial_price REAL CHECK (trial_price IS NULL OR trial_price >= 0),
+		uuid TEXT NOT NULL DEFAULT ''
 	);
 	CREATE INDEX IF NOT EXISTS idx_subscriptions_billing_cycle ON subscriptions(billing_cycle);
 	CREATE INDEX IF NOT EXISTS idx_subscriptions_next_renewal ON subscriptions(next_renewal_date);
+	CREATE INDEX IF NOT EXISTS idx_subscriptions_category ON subscriptions(category_id);
+	CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_uuid ON subscriptions(uuid);
 	
 	CREATE TABLE IF NOT EXISTS config (
 		key TEXT PRIMARY KEY,
 		value TEXT NOT NULL
 	);
 	INSERT OR IGNORE INTO config (key, value) VALUES ('month_cutoff_day', '1');
+
+	CREATE TABLE IF NOT EXISTS exchange_rates (
+		currency TEXT PRIMARY KEY,
+		rate REAL NOT NULL CHECK (rate > 0),
+		updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+	INSERT OR IGNORE INTO config (key, value) VALUES ('base_currency', 'USD');
+
+	CREATE TABLE IF NOT EXISTS categories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		created_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+	INSERT OR IGNORE INTO categories (name) VALUES ('streaming'), ('software'), ('utilities'), ('news'), ('gaming'), ('cloud');
+
+	CREATE TABLE IF NOT EXISTS budgets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+		amount REAL NOT NULL CHECK (amount > 0),
+		created_at TEXT NOT NULL DEFAULT (datetime('now')),
+		updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_category ON budgets(category_id);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_overall ON budgets((category_id IS NULL)) WHERE category_id IS NULL;
+
+	CREATE TABLE IF NOT EXISTS payments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		subscription_id INTEGER REFERENCES subscriptions(id) ON DELETE SET NULL,
+		name TEXT NOT NULL,
+		amount REAL NOT NULL,
+		currency TEXT NOT NULL,
+		paid_on TEXT NOT NULL,
+		source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('renewal', 'manual')),
+		note TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+	CREATE INDEX IF NOT EXISTS idx_payments_paid_on ON payments(paid_on);
+	CREATE INDEX IF NOT EXISTS idx_payments_subscription ON payments(subscription_id);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_renewal ON payments(subscription_id, paid_on) WHERE source = 'renewal';
+
+	CREATE TABLE IF NOT EXISTS price_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+		old_amount REAL NOT NULL,
+		old_currency TEXT NOT NULL,
+		amount REAL NOT NULL,
+		currency TEXT NOT NULL,
+		effective_date TEXT NOT NULL,
+		created_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+	CREATE INDEX IF NOT EXISTS idx_price_changes_subscription ON price_changes(subscription_id, effective_date);
+	CREATE INDEX IF NOT EXISTS idx_price_changes_effective ON price_changes(effective_date);
+
+	CREATE TABLE IF NOT EXISTS reminder_settings (
+		subscription_id INTEGER PRIMARY KEY REFERENCES subscriptions(id) ON DELETE CASCADE,
+		lead_days TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS sent_reminders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+		renewal_date TEXT NOT NULL,
+		days INTEGER NOT NULL,
+		sent_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_sent_reminders_renewal ON sent_reminders(subscription_id, renewal_date, days);
+	CREATE INDEX IF NOT EXISTS idx_sent_reminders_date ON sent_reminders(renewal_date);
+
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT NOT NULL DEFAULT '',
+		days INTEGER NOT NULL DEFAULT 3,
+		created_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+		event TEXT NOT NULL,
+		event_key TEXT,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		response_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		next_attempt_at TEXT NOT NULL,
+		created_at TEXT NOT NULL DEFAULT (datetime('now')),
+		delivered_at TEXT
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_key ON webhook_deliveries(webhook_id, event_key);
+	CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(status, next_attempt_at);
 	`
 	if _, err := database.Exec(schema); err != nil {
 		database.Close()
@@ -57,15 +173,28 @@ func setupTestDB(t *testing.T) *testDB {
 
 	queries := db.New(database)
 	configService := service.NewConfigService(queries)
+	subscriptionService := service.NewSubscriptionService(queries)
+	spendingService := service.NewSpendingService(queries, configService)
+	webhookService := service.NewWebhookService(queries)
+	subscriptionService.AddEventHandler(webhookService)
 
 	tdb := &testDB{
 		DB:                  database,
 		Queries:             queries,
-		SubscriptionService: service.NewSubscriptionService(queries),
-		SpendingService:     service.NewSpendingService(queries, configService),
-		ExportService:       service.NewExportService(queries),
+		SubscriptionService: subscriptionService,
+		SpendingService:     spendingService,
+		ExportService:       service.NewExportService(queries, spendingService),
+		ReportService:       service.NewReportService(queries, spendingService),
+		ReminderService:     service.NewReminderService(queries, subscriptionService, configService),
+		ImportService:       service.NewImportService(queries, subscriptionService),
+		StatementService:    service.NewStatementService(queries, subscriptionService, configService),
 		ConfigService:       configService,
 		SyncService:         service.NewSyncService(queries, configService),
+		CurrencyService:     service.NewCurrencyService(queries, configService),
+		CategoryService:     service.NewCategoryService(queries),
+		BudgetService:       service.NewBudgetService(queries),
+		PaymentService:      service.NewPaymentService(queries),
+		WebhookService:      webhookService,
 	}
 
 	t.Cleanup(func() {
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// BilledAmount returns what a renewal of sub costs: the post-trial price of a
+// subscription on a free trial, if one is set, otherwise its amount
+func BilledAmount(sub db.Subscription) float64 {
+	if sub.Status == StatusTrial && sub.TrialPrice.Valid {
+		return sub.TrialPrice.Float64
+	}
+	return sub.Amount
+}
+
+// TrialDaysLeft returns the number of days from today until the trial of sub
+// ends, or false if sub is not on a trial
+func TrialDaysLeft(sub db.Subscription, today time.Time) (int, bool) {
+	end, ok := parseNullDate(sub.TrialEndDate)
+	if sub.Status != StatusTrial || !ok {
+		return 0, false
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(today).Hours() / 24), true
+}
+
+// SetTrial puts a subscription on a free trial until endDate (YYYY-MM-DD), after
+// which it is billed price, or its current amount when price is 0. A paused or
+// cancelled subscription keeps its state and returns to the trial when resumed.
+func (s *SubscriptionService) SetTrial(ctx context.Context, id int64, endDate string, price float64) (db.Subscription, error) {
+	if _, err := time.Parse("2006-01-02", endDate); err != nil {
+		return db.Subscription{}, fmt.Errorf("invalid trial end date, use YYYY-MM-DD: %w", err)
+	}
+	if price < 0 {
+		return db.Subscription{}, fmt.Errorf("trial price cannot be negative")
+	}
+
+	sub, err := s.queries.GetSubscription(ctx, id)
+	if err != nil {
+		return db.Subscription{}, err
+	}
+
+	status := sub.Status
+	if IsRecurring(sub) {
+		status = StatusTrial
+	}
+
+	sub, err = s.queries.UpdateSubscriptionTrial(ctx, db.UpdateSubscriptionTrialParams{
+		ID:           id,
+		Status:       status,
+		TrialEndDate: sql.NullString{String: endDate, Valid: true},
+		TrialPrice:   sql.NullFloat64{Float64: price, Valid: price > 0},
+	})
+	return s.updated(ctx, sub, err)
+}
+
+// EndingTrials returns the subscriptions whose free trial ends within the
+// next days days, soonest first
+func (s *SubscriptionService) EndingTrials(ctx context.Context, days int) ([]db.Subscription, error) {
+	return s.EndingTrialsFrom(ctx, days, time.Now())
+}
+
+// EndingTrialsFrom returns the subscriptions whose free trial ends within days
+// days of the given reference time, soonest first
+func (s *SubscriptionService) EndingTrialsFrom(ctx context.Context, days int, referenceTime time.Time) ([]db.Subscription, error) {
+	subs, err := s.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	var ending []db.Subscription
+	for _, sub := range subs {
+		left, ok := TrialDaysLeft(sub, referenceTime)
+		if ok && left >= 0 && left <= days {
+			ending = append(ending, sub)
+		}
+	}
+	sort.SliceStable(ending, func(i, j int) bool {
+		return ending[i].TrialEndDate.String < ending[j].TrialEndDate.String
+	})
+	return ending, nil
+}
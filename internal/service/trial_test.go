@@ -0,0 +1,108 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestSubscriptionService_EndingTrials(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-01"},
+		{Name: "Music", Amount: 4.99, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-05", TrialEndDate: "2026-03-05", TrialPrice: 9.99},
+		{Name: "Video", Amount: 5.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-01", TrialEndDate: "2026-03-01"},
+		{Name: "News", Amount: 4.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-04-01", TrialEndDate: "2026-04-01"},
+		{Name: "Old", Amount: 4.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-02-01", TrialEndDate: "2026-02-01"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Bad", Amount: 4.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-04-01", TrialPrice: 5,
+	}); err == nil {
+		t.Error("Create() should reject a trial price without a trial end date")
+	}
+
+	today := time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC)
+	subs, err := tdb.SubscriptionService.EndingTrialsFrom(ctx, 7, today)
+	if err != nil {
+		t.Fatalf("EndingTrialsFrom() error = %v", err)
+	}
+	if len(subs) != 2 || subs[0].Name != "Video" || subs[1].Name != "Music" {
+		t.Fatalf("EndingTrialsFrom() = %v, want Video and Music", subs)
+	}
+
+	if left, ok := service.TrialDaysLeft(subs[1], today); !ok || left != 4 {
+		t.Errorf("TrialDaysLeft() = %d, %v, want 4 days", left, ok)
+	}
+	if got := service.BilledAmount(subs[1]); got != 9.99 {
+		t.Errorf("BilledAmount() = %.2f, want the post-trial price 9.99", got)
+	}
+	if got := service.BilledAmount(subs[0]); got != 5.00 {
+		t.Errorf("BilledAmount() = %.2f, want the amount 5.00", got)
+	}
+}
+
+func TestSubscriptionService_SetTrial(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Music", Amount: 4.99, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-10",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	if _, err := tdb.SubscriptionService.SetTrial(ctx, sub.ID, "2026-02-30", 9.00); err == nil {
+		t.Error("SetTrial() should reject an invalid date")
+	}
+	if _, err := tdb.SubscriptionService.SetTrial(ctx, sub.ID, "2026-02-10", -1); err == nil {
+		t.Error("SetTrial() should reject a negative price")
+	}
+
+	sub, err = tdb.SubscriptionService.SetTrial(ctx, sub.ID, "2026-02-10", 9.00)
+	if err != nil {
+		t.Fatalf("SetTrial() error = %v", err)
+	}
+	if sub.Status != service.StatusTrial || sub.TrialEndDate.String != "2026-02-10" || sub.TrialPrice.Float64 != 9.00 {
+		t.Errorf("SetTrial() = %+v, want a trial until 2026-02-10 at 9.00", sub)
+	}
+
+	// The post-trial price is projected from the end of the trial
+	summary, err := tdb.SpendingService.CalculateForMonth(ctx, 2026, 3)
+	if err != nil {
+		t.Fatalf("CalculateForMonth() error = %v", err)
+	}
+	if !almostEqual(summary.GrandTotal, 9.00) {
+		t.Errorf("GrandTotal = %.2f, want 9.00", summary.GrandTotal)
+	}
+	annual, err := tdb.SpendingService.CalculateAnnualTotal(ctx)
+	if err != nil {
+		t.Fatalf("CalculateAnnualTotal() error = %v", err)
+	}
+	if !almostEqual(annual, 108.00) {
+		t.Errorf("CalculateAnnualTotal() = %.2f, want 108.00", annual)
+	}
+
+	// Once the trial has ended the subscription is billed the post-trial price
+	if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+	}
+	sub, err = tdb.SubscriptionService.Get(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if sub.Status != service.StatusActive || sub.Amount != 9.00 || sub.NextRenewalDate.String != "2026-03-10" {
+		t.Errorf("after the trial = %s at %.2f renewing %s, want active at 9.00 renewing 2026-03-10",
+			sub.Status, sub.Amount, sub.NextRenewalDate.String)
+	}
+}
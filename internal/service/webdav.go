@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// WebDAVBackend keeps backups in a WebDAV collection, such as a Nextcloud
+// folder at https://HOST/remote.php/dav/files/USER/FOLDER
+type WebDAVBackend struct {
+	URL      string // The collection, ending in a slash
+	Username string
+	Password string
+}
+
+// NewWebDAVBackend returns a backend for the collection at rawURL
+func NewWebDAVBackend(rawURL, username, password string) (*WebDAVBackend, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid WebDAV URL %q: want http:// or https://", rawURL)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &WebDAVBackend{
+		URL:      u.String(),
+		Username: username,
+		Password: password,
+	}, nil
+}
+
+// Location returns the collection URL
+func (b *WebDAVBackend) Location() string {
+	return b.URL
+}
+
+// Put uploads a backup, creating the collection if the server says it is
+// missing
+func (b *WebDAVBackend) Put(ctx context.Context, name string, data []byte) error {
+	if err := checkBackupName(name); err != nil {
+		return err
+	}
+	resp, err := b.do(ctx, http.MethodPut, b.fileURL(name), data, nil)
+	if err != nil {
+		return err
+	}
+	if resp.status == http.StatusNotFound || resp.status == http.StatusConflict {
+		if err := b.mkcol(ctx); err != nil {
+			return err
+		}
+		if resp, err = b.do(ctx, http.MethodPut, b.fileURL(name), data, nil); err != nil {
+			return err
+		}
+	}
+	return webDAVError(resp, http.StatusOK, http.StatusCreated, http.StatusNoContent)
+}
+
+// Get downloads a backup
+func (b *WebDAVBackend) Get(ctx context.Context, name string) ([]byte, error) {
+	if err := checkBackupName(name); err != nil {
+		return nil, err
+	}
+	resp, err := b.do(ctx, http.MethodGet, b.fileURL(name), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status == http.StatusNotFound {
+		return nil, ErrBackupNotFound
+	}
+	if err := webDAVError(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return resp.body, nil
+}
+
+// propfindBody asks for just the resource type of each member
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/></d:prop></d:propfind>`
+
+// List returns the names of the files in the collection
+func (b *WebDAVBackend) List(ctx context.Context) ([]string, error) {
+	resp, err := b.do(ctx, "PROPFIND", b.URL, []byte(propfindBody), map[string]string{
+		"Depth":        "1",
+		"Content-Type": "application/xml; charset=utf-8",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := webDAVError(resp, http.StatusMultiStatus); err != nil {
+		return nil, err
+	}
+
+	var multistatus struct {
+		Responses []struct {
+			Href     string `xml:"href"`
+			Propstat []struct {
+				Collection *struct{} `xml:"prop>resourcetype>collection"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.Unmarshal(resp.body, &multistatus); err != nil {
+		return nil, fmt.Errorf("failed to parse WebDAV listing: %w", err)
+	}
+	var names []string
+	for _, r := range multistatus.Responses {
+		collection := strings.HasSuffix(r.Href, "/")
+		for _, p := range r.Propstat {
+			collection = collection || p.Collection != nil
+		}
+		if collection {
+			continue // The collection itself, or a folder in it
+		}
+		href, err := url.PathUnescape(r.Href)
+		if err != nil {
+			href = r.Href
+		}
+		names = append(names, path.Base(href))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete removes a backup
+func (b *WebDAVBackend) Delete(ctx context.Context, name string) error {
+	if err := checkBackupName(name); err != nil {
+		return err
+	}
+	resp, err := b.do(ctx, http.MethodDelete, b.fileURL(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.status == http.StatusNotFound {
+		return ErrBackupNotFound
+	}
+	return webDAVError(resp, http.StatusOK, http.StatusNoContent)
+}
+
+// mkcol creates the collection. Its parent has to exist already.
+func (b *WebDAVBackend) mkcol(ctx context.Context) error {
+	resp, err := b.do(ctx, "MKCOL", b.URL, nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.status == http.StatusMethodNotAllowed {
+		return nil // It exists
+	}
+	if err := webDAVError(resp, http.StatusCreated); err != nil {
+		return fmt.Errorf("failed to create the WebDAV folder: %w", err)
+	}
+	return nil
+}
+
+func (b *WebDAVBackend) fileURL(name string) string {
+	return b.URL + url.PathEscape(name)
+}
+
+// do makes a request to the server
+func (b *WebDAVBackend) do(ctx context.Context, method, target string, body []byte, headers map[string]string) (*backendResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if b.Username != "" || b.Password != "" {
+		req.SetBasicAuth(b.Username, b.Password)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return send(req)
+}
+
+// webDAVError returns an error unless the response has one of the statuses
+func webDAVError(resp *backendResponse, ok ...int) error {
+	for _, status := range ok {
+		if resp.status == status {
+			return nil
+		}
+	}
+	if resp.status == http.StatusUnauthorized {
+		return fmt.Errorf("WebDAV server rejected the user name or password")
+	}
+	return fmt.Errorf("WebDAV error (status %d): %s", resp.status, strings.TrimSpace(string(resp.body)))
+}
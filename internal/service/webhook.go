@@ -0,0 +1,401 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"subscription-tracker/internal/db"
+)
+
+// Delivery states in the webhook delivery log
+const (
+	DeliveryPending   = "pending"   // Not delivered yet; retried from its next attempt
+	DeliveryDelivered = "delivered" // The endpoint answered with a 2xx status
+	DeliveryFailed    = "failed"    // Gave up after MaxWebhookAttempts
+)
+
+const (
+	// DefaultWebhookDays is how long before a renewal renewal.upcoming is sent unless set
+	DefaultWebhookDays = 3
+
+	// MaxWebhookAttempts is how often a delivery is tried before it fails
+	MaxWebhookAttempts = 6
+
+	// WebhookRetryDelay is the wait before the first retry; it doubles with every attempt
+	WebhookRetryDelay = time.Minute
+
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body, keyed with the webhook secret
+	SignatureHeader = "X-Subscription-Tracker-Signature"
+	// EventHeader carries the event type
+	EventHeader = "X-Subscription-Tracker-Event"
+	// DeliveryHeader carries the delivery ID, which is the same for every attempt
+	DeliveryHeader = "X-Subscription-Tracker-Delivery"
+)
+
+// logTime is how times are stored in the webhook delivery log, as SQLite's datetime('now')
+const logTime = "2006-01-02 15:04:05"
+
+// WebhookService posts subscription events to webhook endpoints, keeping a
+// log of the deliveries and retrying failed ones with exponential backoff
+type WebhookService struct {
+	queries *db.Queries
+	client  *http.Client
+	queued  atomic.Int64 // Deliveries queued by HandleEvent
+
+	mu       sync.Mutex
+	queueErr error // Why HandleEvent couldn't queue events, until QueueErr
+}
+
+// NewWebhookService creates a new webhook service. Add it as an event handler
+// of the subscription service for events to be queued, and call DeliverDue or
+// DeliverEvery for them to be posted.
+func NewWebhookService(queries *db.Queries) *WebhookService {
+	return &WebhookService{queries: queries, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// WebhookInput represents input for registering a webhook
+type WebhookInput struct {
+	URL    string
+	Secret string   // Signing key; generated when empty
+	Events []string // Event types to post; empty for all
+	Days   int      // Days before a renewal renewal.upcoming is posted
+}
+
+// Add registers a webhook
+func (s *WebhookService) Add(ctx context.Context, input WebhookInput) (db.Webhook, error) {
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return db.Webhook{}, fmt.Errorf("invalid webhook URL %q: use an http or https URL", input.URL)
+	}
+	for _, event := range input.Events {
+		if !slices.Contains(EventTypes, event) {
+			return db.Webhook{}, fmt.Errorf("unknown event %q: use %s", event, strings.Join(EventTypes, ", "))
+		}
+	}
+	if input.Days < 0 || input.Days > MaxReminderDays {
+		return db.Webhook{}, fmt.Errorf("days must be between 0 and %d", MaxReminderDays)
+	}
+	if input.Secret == "" {
+		b := make([]byte, 24)
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return db.Webhook{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		input.Secret = hex.EncodeToString(b)
+	}
+
+	return s.queries.CreateWebhook(ctx, db.CreateWebhookParams{
+		Url:    input.URL,
+		Secret: input.Secret,
+		Events: strings.Join(input.Events, ","),
+		Days:   int64(input.Days),
+	})
+}
+
+// List returns the registered webhooks
+func (s *WebhookService) List(ctx context.Context) ([]db.Webhook, error) {
+	return s.queries.ListWebhooks(ctx)
+}
+
+// Delete removes a webhook and its delivery log
+func (s *WebhookService) Delete(ctx context.Context, id int64) error {
+	if _, err := s.queries.GetWebhook(ctx, id); err != nil {
+		return fmt.Errorf("webhook not found: %w", err)
+	}
+	if err := s.queries.DeleteWebhookDeliveries(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return s.queries.DeleteWebhook(ctx, id)
+}
+
+// Deliveries returns the latest limit deliveries, newest first
+func (s *WebhookService) Deliveries(ctx context.Context, limit int) ([]db.WebhookDelivery, error) {
+	return s.queries.ListWebhookDeliveries(ctx, int64(limit))
+}
+
+// WebhookEvents returns the event types a webhook is posted
+func WebhookEvents(hook db.Webhook) []string {
+	if hook.Events == "" {
+		return EventTypes
+	}
+	return strings.Split(hook.Events, ",")
+}
+
+// WebhookPayload is the JSON body posted for an event
+type WebhookPayload struct {
+	Event        string              `json:"event"`
+	OccurredAt   time.Time           `json:"occurred_at"`
+	Text         string              `json:"text"` // A one-line summary, which chat tools show as the message
+	Subscription WebhookSubscription `json:"subscription"`
+	Renewal      *WebhookRenewal     `json:"renewal,omitempty"` // Set for renewal events
+}
+
+// WebhookSubscription is the subscription of an event
+type WebhookSubscription struct {
+	ID              int64    `json:"id"`
+	Name            string   `json:"name"`
+	Amount          float64  `json:"amount"`
+	Currency        string   `json:"currency"`
+	BillingCycle    string   `json:"billing_cycle"`
+	NextRenewalDate string   `json:"next_renewal_date,omitempty"`
+	Category        string   `json:"category"`
+	Tags            []string `json:"tags"`
+	Status          string   `json:"status"`
+}
+
+// WebhookRenewal is the renewal of a renewal event
+type WebhookRenewal struct {
+	Date         string  `json:"date"`
+	PreviousDate string  `json:"previous_date,omitempty"` // renewal.advanced: the date that passed
+	DaysLeft     int     `json:"days_left"`
+	Amount       float64 `json:"amount"` // At the price in effect on Date
+	Currency     string  `json:"currency"`
+}
+
+// HandleEvent queues the event for every webhook that wants it. A
+// renewal.upcoming event is queued once per renewal, once the renewal is no
+// more than the webhook's days away. Nothing is posted here, so that emitting
+// events never waits on the network; DeliverDue sends the queue.
+func (s *WebhookService) HandleEvent(ctx context.Context, event Event) {
+	if err := s.queue(ctx, event); err != nil {
+		s.mu.Lock()
+		s.queueErr = errors.Join(s.queueErr, fmt.Errorf("failed to queue %s webhooks: %w", event.Type, err))
+		s.mu.Unlock()
+	}
+}
+
+// queue adds a delivery of event for every webhook that wants it
+func (s *WebhookService) queue(ctx context.Context, event Event) error {
+	hooks, err := s.queries.ListWebhooks(ctx)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+	var body []byte
+	for _, hook := range hooks {
+		if !slices.Contains(WebhookEvents(hook), event.Type) {
+			continue
+		}
+		var key sql.NullString
+		if event.Type == EventRenewalUpcoming {
+			if event.DaysLeft > int(hook.Days) {
+				continue
+			}
+			key = sql.NullString{String: fmt.Sprintf("%s:%d:%s", event.Type, event.Subscription.ID, event.RenewalDate.Format("2006-01-02")), Valid: true}
+		}
+		if body == nil {
+			if body, err = s.payload(ctx, event); err != nil {
+				return err
+			}
+		}
+		_, err := s.queries.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID:     hook.ID,
+			Event:         event.Type,
+			EventKey:      key,
+			Payload:       string(body),
+			NextAttemptAt: event.Time.UTC().Format(logTime),
+		})
+		// A renewal that was already queued conflicts on its event key, so
+		// the insert returns no row, and it isn't queued again
+		if errors.Is(err, sql.ErrNoRows) && key.Valid {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.queued.Add(1)
+	}
+	return nil
+}
+
+// QueueErr returns why events couldn't be queued since it was last called,
+// if any. Emitting an event can't fail, so this is how the errors surface.
+func (s *WebhookService) QueueErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.queueErr
+	s.queueErr = nil
+	return err
+}
+
+// Queued returns how many deliveries HandleEvent queued so far, so that a
+// caller can tell whether an action queued any
+func (s *WebhookService) Queued() int64 {
+	return s.queued.Load()
+}
+
+// payload builds the JSON body of event
+func (s *WebhookService) payload(ctx context.Context, event Event) ([]byte, error) {
+	sub := event.Subscription
+	names, err := categoryNames(ctx, s.queries)
+	if err != nil {
+		return nil, err
+	}
+	p := WebhookPayload{
+		Event:      event.Type,
+		OccurredAt: event.Time.UTC().Truncate(time.Second),
+		Subscription: WebhookSubscription{
+			ID:              sub.ID,
+			Name:            sub.Name,
+			Amount:          sub.Amount,
+			Currency:        sub.Currency,
+			BillingCycle:    sub.BillingCycle,
+			NextRenewalDate: sub.NextRenewalDate.String,
+			Category:        CategoryName(sub, names),
+			Tags:            ParseTags(sub.Tags),
+			Status:          sub.Status,
+		},
+	}
+	if p.Subscription.Tags == nil {
+		p.Subscription.Tags = []string{}
+	}
+
+	switch event.Type {
+	case EventSubscriptionCreated:
+		p.Text = fmt.Sprintf("%s was added: %.2f %s, %s", sub.Name, BilledAmount(sub), sub.Currency, sub.BillingCycle)
+	case EventSubscriptionUpdated:
+		p.Text = fmt.Sprintf("%s was updated: %.2f %s, %s, %s", sub.Name, BilledAmount(sub), sub.Currency, sub.BillingCycle, sub.Status)
+	case EventSubscriptionDeleted:
+		p.Text = sub.Name + " was deleted"
+	case EventRenewalAdvanced, EventRenewalUpcoming:
+		changes, err := s.queries.ListSubscriptionPriceChanges(ctx, sub.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list price changes: %w", err)
+		}
+		amount, currency := priceHistory{sub.ID: changes}.priceOn(sub, event.RenewalDate)
+		p.Renewal = &WebhookRenewal{
+			Date:     event.RenewalDate.Format("2006-01-02"),
+			DaysLeft: event.DaysLeft,
+			Amount:   amount,
+			Currency: currency,
+		}
+		if event.Type == EventRenewalAdvanced {
+			p.Renewal.PreviousDate = event.PreviousDate.Format("2006-01-02")
+			p.Text = fmt.Sprintf("%s renewed on %s; it next renews on %s", sub.Name, p.Renewal.PreviousDate, p.Renewal.Date)
+		} else {
+			r := Reminder{Subscription: sub, RenewalDate: event.RenewalDate, DaysLeft: event.DaysLeft, Amount: amount, Currency: currency}
+			p.Text = r.Title() + ": " + r.Message()
+		}
+	}
+	return json.Marshal(p)
+}
+
+// DeliverEvery tries the pending deliveries that are due every interval until
+// ctx is cancelled, for long-running processes like the TUI
+func (s *WebhookService) DeliverEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.DeliverDue(ctx) // Failures are kept in the delivery log
+		}
+	}
+}
+
+// DeliverDue tries the pending deliveries that are due, see DeliverDueFrom
+func (s *WebhookService) DeliverDue(ctx context.Context) (int, error) {
+	return s.DeliverDueFrom(ctx, time.Now())
+}
+
+// DeliverDueFrom tries the pending deliveries whose next attempt is not after
+// now, returning how many were delivered and why the others failed. A failed
+// attempt is retried after WebhookRetryDelay, doubling with every attempt,
+// until MaxWebhookAttempts.
+func (s *WebhookService) DeliverDueFrom(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := s.queries.ListDueWebhookDeliveries(ctx, now.UTC().Format(logTime))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	hooks := make(map[int64]db.Webhook)
+	var errs []error
+	delivered := 0
+	for _, d := range deliveries {
+		hook, ok := hooks[d.WebhookID]
+		if !ok {
+			if hook, err = s.queries.GetWebhook(ctx, d.WebhookID); err != nil {
+				return delivered, fmt.Errorf("failed to get webhook: %w", err)
+			}
+			hooks[d.WebhookID] = hook
+		}
+
+		code, err := s.post(ctx, hook, d)
+		params := db.UpdateWebhookDeliveryParams{
+			ID:            d.ID,
+			Status:        DeliveryDelivered,
+			Attempts:      d.Attempts + 1,
+			ResponseCode:  int64(code),
+			NextAttemptAt: d.NextAttemptAt,
+		}
+		if err == nil {
+			params.DeliveredAt = sql.NullString{String: now.UTC().Format(logTime), Valid: true}
+			delivered++
+		} else {
+			errs = append(errs, fmt.Errorf("delivery %d to %s: %w", d.ID, hook.Url, err))
+			params.Error = err.Error()
+			params.Status = DeliveryPending
+			if params.Attempts >= MaxWebhookAttempts {
+				params.Status = DeliveryFailed
+			}
+			params.NextAttemptAt = now.Add(WebhookRetryDelay << (params.Attempts - 1)).UTC().Format(logTime)
+		}
+		if err := s.queries.UpdateWebhookDelivery(ctx, params); err != nil {
+			return delivered, fmt.Errorf("failed to update webhook delivery: %w", err)
+		}
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// post sends a delivery to its webhook, returning the response status
+func (s *WebhookService) post(ctx context.Context, hook db.Webhook, d db.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", appName)
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, []byte(d.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if msg = bytes.TrimSpace(msg); len(msg) > 0 {
+			return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, msg)
+		}
+		return resp.StatusCode, errors.New(resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value of body: "sha256=" and the hex
+// HMAC-SHA256 of body keyed with secret. Receivers recompute it to check that
+// a request came from the tracker.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
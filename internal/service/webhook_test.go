@@ -0,0 +1,284 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"subscription-tracker/internal/service"
+)
+
+// receiver is a webhook endpoint that records the payloads it is posted
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	payloads []service.WebhookPayload
+	bad      int // Requests with an invalid signature
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(req.Body)
+	if req.Header.Get(service.SignatureHeader) != service.Sign("secret", body) {
+		r.bad++
+	}
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+		return
+	}
+	var p service.WebhookPayload
+	if err := json.Unmarshal(body, &p); err != nil || req.Header.Get(service.EventHeader) != p.Event {
+		r.bad++
+	}
+	r.payloads = append(r.payloads, p)
+}
+
+func (r *receiver) events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []string
+	for _, p := range r.payloads {
+		events = append(events, p.Event+" "+p.Subscription.Name)
+	}
+	return events
+}
+
+func TestWebhookService_HandleEvent(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+	if _, err := tdb.WebhookService.Add(ctx, service.WebhookInput{URL: server.URL, Secret: "secret", Days: 3}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	// Only told about new subscriptions
+	if _, err := tdb.WebhookService.Add(ctx, service.WebhookInput{
+		URL: server.URL + "/new", Secret: "secret", Events: []string{service.EventSubscriptionCreated},
+	}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// Events are only queued, and posted by DeliverDue
+	deliver := func() {
+		t.Helper()
+		if _, err := tdb.WebhookService.DeliverDue(ctx); err != nil {
+			t.Fatalf("DeliverDue() error = %v", err)
+		}
+	}
+
+	sub, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15", Category: "streaming",
+	})
+	if err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+	if got := recv.events(); len(got) != 0 {
+		t.Fatalf("received %v before DeliverDue", got)
+	}
+	deliver()
+	if _, err := tdb.SubscriptionService.UpdateRenewalDate(ctx, sub.ID, "2026-03-15"); err != nil {
+		t.Fatalf("UpdateRenewalDate() error = %v", err)
+	}
+	deliver()
+	// Too far off, then due and posted once, then advanced past
+	for _, day := range []int{1, 13, 14, 16} {
+		if err := tdb.SubscriptionService.AdvanceRenewalDatesFrom(ctx, time.Date(2026, 3, day, 8, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("AdvanceRenewalDatesFrom() error = %v", err)
+		}
+		deliver()
+	}
+	if err := tdb.SubscriptionService.Delete(ctx, sub.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	deliver()
+
+	want := []string{
+		"subscription.created Netflix",
+		"subscription.created Netflix",
+		"subscription.updated Netflix",
+		"renewal.upcoming Netflix",
+		"renewal.advanced Netflix",
+		"subscription.deleted Netflix",
+	}
+	got := recv.events()
+	if len(got) != len(want) {
+		t.Fatalf("received %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if recv.bad != 0 {
+		t.Errorf("%d requests had a bad signature or event header", recv.bad)
+	}
+	// The renewal already posted on the 14th conflicted without an error
+	if err := tdb.WebhookService.QueueErr(); err != nil {
+		t.Errorf("QueueErr() = %v", err)
+	}
+
+	created := recv.payloads[0]
+	if created.Subscription.Category != "streaming" || created.Subscription.NextRenewalDate != "2026-03-15" {
+		t.Errorf("created payload = %+v", created.Subscription)
+	}
+	upcoming := recv.payloads[3].Renewal
+	if upcoming == nil || upcoming.Date != "2026-03-15" || upcoming.DaysLeft != 2 || upcoming.Amount != 15.00 {
+		t.Errorf("renewal.upcoming renewal = %+v, want March 15 in 2 days at 15.00", upcoming)
+	}
+	if text := recv.payloads[3].Text; text != "Netflix renews in 2 days: 15.00 USD will be charged on Sun, Mar 15 2026 (monthly)." {
+		t.Errorf("renewal.upcoming text = %q", text)
+	}
+	advanced := recv.payloads[4].Renewal
+	if advanced == nil || advanced.PreviousDate != "2026-03-15" || advanced.Date != "2026-04-15" {
+		t.Errorf("renewal.advanced renewal = %+v, want from March 15 to April 15", advanced)
+	}
+
+	deliveries, err := tdb.WebhookService.Deliveries(ctx, 100)
+	if err != nil {
+		t.Fatalf("Deliveries() error = %v", err)
+	}
+	for _, d := range deliveries {
+		if d.Status != service.DeliveryDelivered || d.ResponseCode != http.StatusOK {
+			t.Errorf("delivery %d of %s = %s with %d, want delivered with 200", d.ID, d.Event, d.Status, d.ResponseCode)
+		}
+	}
+}
+
+func TestWebhookService_QueueErr(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := tdb.WebhookService.Add(ctx, service.WebhookInput{URL: "http://127.0.0.1:1/hook"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := tdb.DB.Exec(`CREATE TRIGGER fail_deliveries BEFORE INSERT ON webhook_deliveries
+		BEGIN SELECT RAISE(ABORT, 'disk is full'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15",
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	// Only a conflict of a renewal already queued is expected; anything else is reported
+	if n := tdb.WebhookService.Queued(); n != 0 {
+		t.Errorf("Queued() = %d, want 0", n)
+	}
+	if err := tdb.WebhookService.QueueErr(); err == nil || !strings.Contains(err.Error(), "disk is full") {
+		t.Errorf("QueueErr() = %v, want the failed insert", err)
+	}
+	if err := tdb.WebhookService.QueueErr(); err != nil {
+		t.Errorf("QueueErr() = %v after it was reported", err)
+	}
+}
+
+func TestWebhookService_DeliverDueFrom(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	recv := &receiver{status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+	if _, err := tdb.WebhookService.Add(ctx, service.WebhookInput{URL: server.URL, Secret: "secret"}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := tdb.SubscriptionService.Create(ctx, service.CreateSubscriptionInput{
+		Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-03-15",
+	}); err != nil {
+		t.Fatalf("failed to create subscription: %v", err)
+	}
+
+	latest := func() (status string, attempts int64, next time.Time) {
+		t.Helper()
+		deliveries, err := tdb.WebhookService.Deliveries(ctx, 1)
+		if err != nil || len(deliveries) != 1 {
+			t.Fatalf("Deliveries() = %v, %v", deliveries, err)
+		}
+		d := deliveries[0]
+		next, _ = time.Parse("2006-01-02 15:04:05", d.NextAttemptAt)
+		return d.Status, d.Attempts, next
+	}
+
+	// The first attempt failed, and each retry waits twice as long
+	if _, err := tdb.WebhookService.DeliverDueFrom(ctx, time.Now()); err == nil {
+		t.Fatal("DeliverDueFrom() should report the failed attempt")
+	}
+	status, attempts, next := latest()
+	if status != service.DeliveryPending || attempts != 1 {
+		t.Fatalf("delivery is %s after %d attempts, want pending after 1", status, attempts)
+	}
+	wait := service.WebhookRetryDelay
+	for attempts < service.MaxWebhookAttempts-1 {
+		// Not retried before it is due
+		if n, _ := tdb.WebhookService.DeliverDueFrom(ctx, next.Add(-time.Second)); n != 0 {
+			t.Fatalf("DeliverDueFrom() delivered %d early", n)
+		}
+		if _, err := tdb.WebhookService.DeliverDueFrom(ctx, next); err == nil {
+			t.Fatal("DeliverDueFrom() should report the failed attempt")
+		}
+		prev := next
+		wait *= 2
+		status, attempts, next = latest()
+		if got := next.Sub(prev); got != wait {
+			t.Errorf("retry %d waits %s, want %s", attempts, got, wait)
+		}
+	}
+
+	// The endpoint recovers in time for the last attempt
+	recv.mu.Lock()
+	recv.status = 0
+	recv.mu.Unlock()
+	if n, err := tdb.WebhookService.DeliverDueFrom(ctx, next); n != 1 || err != nil {
+		t.Fatalf("DeliverDueFrom() = %d, %v; want 1 delivered", n, err)
+	}
+	if status, attempts, _ = latest(); status != service.DeliveryDelivered || attempts != service.MaxWebhookAttempts {
+		t.Errorf("delivery is %s after %d attempts, want delivered after %d", status, attempts, service.MaxWebhookAttempts)
+	}
+	if got := recv.events(); len(got) != 1 || got[0] != "subscription.created Netflix" {
+		t.Errorf("received %v, want the created event once", got)
+	}
+}
+
+func TestWebhookService_Add(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	hook, err := tdb.WebhookService.Add(ctx, service.WebhookInput{URL: "https://hooks.example.com/renewals", Days: 7})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if len(hook.Secret) != 48 {
+		t.Errorf("Add() generated secret %q, want 48 hex characters", hook.Secret)
+	}
+	if got := service.WebhookEvents(hook); len(got) != len(service.EventTypes) {
+		t.Errorf("WebhookEvents() = %v, want all events", got)
+	}
+
+	for _, bad := range []service.WebhookInput{
+		{URL: "hooks.example.com"},
+		{URL: "ftp://hooks.example.com"},
+		{URL: "https://hooks.example.com", Events: []string{"renewal.today"}},
+		{URL: "https://hooks.example.com", Days: -1},
+	} {
+		if _, err := tdb.WebhookService.Add(ctx, bad); err == nil {
+			t.Errorf("Add(%+v) should fail", bad)
+		}
+	}
+
+	if err := tdb.WebhookService.Delete(ctx, hook.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if hooks, _ := tdb.WebhookService.List(ctx); len(hooks) != 0 {
+		t.Errorf("List() = %d webhooks after Delete(), want 0", len(hooks))
+	}
+}
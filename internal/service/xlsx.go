@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Sheets of the XLSX export
+const (
+	SheetSubscriptions   = "Subscriptions"
+	SheetMonthlySpending = "Monthly Spending"
+	SheetAnnualTotals    = "Annual Totals"
+	SheetByCurrency      = "By Currency"
+)
+
+// XLSXOptions configures an XLSX export
+type XLSXOptions struct {
+	Year int // Year of the monthly spending sheet; the current year when zero
+}
+
+// ExportXLSX writes an Excel workbook with the subscriptions and sheets
+// computed from them: spending per billing month of opts.Year as
+// SpendingService.CalculateForMonth counts it, annual totals overall and per
+// category, and annual totals per currency. Amounts and dates are stored as
+// numbers so they can be summed and pivoted. It returns the number of
+// subscriptions written.
+func (s *ExportService) ExportXLSX(ctx context.Context, w io.Writer, opts XLSXOptions) (int, error) {
+	if opts.Year == 0 {
+		opts.Year = time.Now().Year()
+	}
+	if opts.Year < 1900 || opts.Year > 9999 {
+		return 0, fmt.Errorf("year must be between 1900 and 9999: %d", opts.Year)
+	}
+
+	subs, err := s.queries.GetAllSubscriptionsForExport(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	names, err := categoryNames(ctx, s.queries)
+	if err != nil {
+		return 0, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	book, err := newWorkbook(f)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := book.subscriptions(ConvertToExportFormat(subs, names)); err != nil {
+		return 0, err
+	}
+	if err := s.monthlySpending(ctx, book, opts.Year); err != nil {
+		return 0, err
+	}
+	if err := s.annualTotals(ctx, book); err != nil {
+		return 0, err
+	}
+	if err := s.currencyTotals(ctx, book); err != nil {
+		return 0, err
+	}
+
+	if err := f.Write(w); err != nil {
+		return 0, fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return len(subs), nil
+}
+
+// monthlySpending adds a row per billing month of year, with a column per category
+func (s *ExportService) monthlySpending(ctx context.Context, book *workbook, year int) error {
+	summaries := make([]*SpendingSummary, 12)
+	var categories []string
+	seen := make(map[string]bool)
+	for month := 1; month <= 12; month++ {
+		summary, err := s.spending.CalculateForMonth(ctx, year, month)
+		if err != nil {
+			return fmt.Errorf("failed to calculate spending for %d-%02d: %w", year, month, err)
+		}
+		summaries[month-1] = summary
+		for _, c := range summary.Categories {
+			if !seen[c.Category] {
+				seen[c.Category] = true
+				categories = append(categories, c.Category)
+			}
+		}
+	}
+
+	sheet, err := book.sheet(SheetMonthlySpending)
+	if err != nil {
+		return err
+	}
+	header := []any{"Month", "Period Start", "Period End", "Currency", "Monthly", "Yearly", "Other", "Total"}
+	for _, category := range categories {
+		header = append(header, category)
+	}
+	header = append(header, "Salary", "Remaining")
+	if err := sheet.header(header...); err != nil {
+		return err
+	}
+
+	totals := make([]float64, len(header))
+	for _, summary := range summaries {
+		byCategory := make(map[string]float64)
+		for _, c := range summary.Categories {
+			byCategory[c.Category] = c.Total
+		}
+		values := []float64{summary.MonthlyTotal, summary.YearlyTotal, summary.OtherTotal, summary.GrandTotal}
+		for _, category := range categories {
+			values = append(values, byCategory[category])
+		}
+
+		row := []any{
+			monthCell(time.Date(summary.Year, time.Month(summary.Month), 1, 0, 0, 0, 0, time.UTC)),
+			summary.PeriodStart,
+			summary.PeriodEnd.Truncate(24 * time.Hour),
+			summary.BaseCurrency,
+		}
+		for i, v := range values {
+			row = append(row, v)
+			totals[4+i] += v
+		}
+		if summary.MonthlySalary > 0 {
+			row = append(row, summary.MonthlySalary, summary.Remaining)
+			totals[len(totals)-2] += summary.MonthlySalary
+			totals[len(totals)-1] += summary.Remaining
+		}
+		if err := sheet.row(row...); err != nil {
+			return err
+		}
+	}
+
+	total := []any{"Total", nil, nil, summaries[0].BaseCurrency}
+	for _, v := range totals[4:] {
+		total = append(total, v)
+	}
+	if err := sheet.footer(total...); err != nil {
+		return err
+	}
+	return sheet.finish(14)
+}
+
+// annualTotals adds the annual total and its breakdown by category
+func (s *ExportService) annualTotals(ctx context.Context, book *workbook) error {
+	total, err := s.spending.CalculateAnnualTotal(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to calculate annual total: %w", err)
+	}
+	categories, err := s.spending.CalculateAnnualByCategory(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to calculate category totals: %w", err)
+	}
+	converter, err := s.spending.currencyService.Converter(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+
+	sheet, err := book.sheet(SheetAnnualTotals)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, c := range categories {
+		count += c.Count
+	}
+	for _, row := range [][]any{
+		{"Annual Total", total, converter.Base},
+		{"Monthly Average", total / 12, converter.Base},
+		{"Subscriptions", count},
+	} {
+		if err := sheet.label(row...); err != nil {
+			return err
+		}
+	}
+
+	sheet.skip()
+	if err := sheet.header("Category", "Subscriptions", "Annual Total", "Currency", "Share"); err != nil {
+		return err
+	}
+	for _, c := range categories {
+		share := 0.0
+		if total > 0 {
+			share = c.Total / total
+		}
+		if err := sheet.row(c.Category, c.Count, c.Total, converter.Base, percentCell(share)); err != nil {
+			return err
+		}
+	}
+	return sheet.finish(16)
+}
+
+// currencyTotals adds the annual total per currency, in that currency and in the base currency
+func (s *ExportService) currencyTotals(ctx context.Context, book *workbook) error {
+	totals, err := s.spending.CalculateAnnualByCurrency(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to calculate currency totals: %w", err)
+	}
+	converter, err := s.spending.currencyService.Converter(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+
+	sheet, err := book.sheet(SheetByCurrency)
+	if err != nil {
+		return err
+	}
+	if err := sheet.header("Currency", "Subscriptions", "Annual Total", "Annual Total ("+converter.Base+")"); err != nil {
+		return err
+	}
+	var sum float64
+	for _, t := range totals {
+		// A currency without an exchange rate is left out of the base currency column
+		var converted any
+		if t.HasRate {
+			converted = t.Converted
+			sum += t.Converted
+		}
+		if err := sheet.row(t.Currency, t.Count, t.Total, converted); err != nil {
+			return err
+		}
+	}
+	if err := sheet.footer("Total", nil, nil, sum); err != nil {
+		return err
+	}
+	return sheet.finish(18)
+}
+
+// subscriptions adds the subscriptions with the columns of the CSV export
+func (b *workbook) subscriptions(subs []ExportSubscription) error {
+	sheet, err := b.sheet(SheetSubscriptions)
+	if err != nil {
+		return err
+	}
+	header := make([]any, len(ExportCSVHeader))
+	for i, h := range ExportCSVHeader {
+		header[i] = h
+	}
+	if err := sheet.header(header...); err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		var trialPrice any
+		if sub.TrialPrice > 0 {
+			trialPrice = sub.TrialPrice
+		}
+		if err := sheet.row(
+			sub.ID,
+			sub.Name,
+			sub.Amount,
+			sub.Currency,
+			sub.BillingCycle,
+			dateCell(sub.NextRenewalDate),
+			dateCell(sub.CreatedAt),
+			dateCell(sub.UpdatedAt),
+			sub.Category,
+			strings.Join(sub.Tags, ","),
+			sub.Status,
+			dateCell(sub.TrialEndDate),
+			dateCell(sub.PauseDate),
+			dateCell(sub.CancelDate),
+			trialPrice,
+		); err != nil {
+			return err
+		}
+	}
+	return sheet.finish(14)
+}
+
+// monthCell is a date shown as its month
+type monthCell time.Time
+
+// percentCell is a fraction shown as a percentage
+type percentCell float64
+
+// dateCell parses a stored date or timestamp into a time for a date cell,
+// or nil for an empty cell
+func dateCell(value string) any {
+	if value == "" {
+		return nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return value
+}
+
+// workbook writes the sheets of an XLSX export with shared cell styles
+type workbook struct {
+	file   *excelize.File
+	styles map[string]int
+	first  bool // The default sheet has not been renamed yet
+}
+
+// Number formats of the XLSX export, by the cell style they are used for
+var xlsxNumberFormats = map[string]string{
+	"date":     "yyyy-mm-dd",
+	"datetime": "yyyy-mm-dd hh:mm",
+	"month":    "mmm yyyy",
+	"amount":   "#,##0.00",
+	"percent":  "0.0%",
+}
+
+func newWorkbook(f *excelize.File) (*workbook, error) {
+	b := &workbook{file: f, styles: make(map[string]int), first: true}
+	for name, format := range xlsxNumberFormats {
+		for _, bold := range []bool{false, true} {
+			style := &excelize.Style{CustomNumFmt: &format}
+			key := name
+			if bold {
+				style.Font = &excelize.Font{Bold: true}
+				key += ",bold"
+			}
+			id, err := f.NewStyle(style)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create cell style: %w", err)
+			}
+			b.styles[key] = id
+		}
+	}
+	id, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cell style: %w", err)
+	}
+	b.styles["bold"] = id
+	return b, nil
+}
+
+// sheet adds a sheet, reusing the empty default sheet for the first one
+func (b *workbook) sheet(name string) (*worksheet, error) {
+	if b.first {
+		b.first = false
+		if err := b.file.SetSheetName(b.file.GetSheetName(0), name); err != nil {
+			return nil, fmt.Errorf("failed to add sheet %s: %w", name, err)
+		}
+	} else if _, err := b.file.NewSheet(name); err != nil {
+		return nil, fmt.Errorf("failed to add sheet %s: %w", name, err)
+	}
+	return &worksheet{book: b, name: name}, nil
+}
+
+// worksheet writes the rows of one sheet from the top
+type worksheet struct {
+	book    *workbook
+	name    string
+	rows    int
+	columns int
+	frozen  int // Row of the header, 0 when there is none
+}
+
+// header writes a bold header row that stays in view while scrolling
+func (s *worksheet) header(values ...any) error {
+	if err := s.write(true, values); err != nil {
+		return err
+	}
+	s.frozen = s.rows
+	return nil
+}
+
+// row writes a row of typed cells
+func (s *worksheet) row(values ...any) error {
+	return s.write(false, values)
+}
+
+// footer writes a bold totals row
+func (s *worksheet) footer(values ...any) error {
+	return s.write(true, values)
+}
+
+// label writes a row with its first cell, the label, in bold
+func (s *worksheet) label(values ...any) error {
+	if err := s.write(false, values); err != nil {
+		return err
+	}
+	cell, _ := excelize.CoordinatesToCellName(1, s.rows)
+	return s.book.file.SetCellStyle(s.name, cell, cell, s.book.styles["bold"])
+}
+
+// skip leaves an empty row
+func (s *worksheet) skip() {
+	s.rows++
+}
+
+func (s *worksheet) write(bold bool, values []any) error {
+	s.rows++
+	s.columns = max(s.columns, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		style := ""
+		switch v := value.(type) {
+		case time.Time:
+			style = "date"
+			if v.Hour() != 0 || v.Minute() != 0 {
+				style = "datetime"
+			}
+		case monthCell:
+			value, style = time.Time(v), "month"
+		case percentCell:
+			value, style = float64(v), "percent"
+		case float64:
+			style = "amount"
+		}
+		if bold && style == "" {
+			style = "bold"
+		} else if bold {
+			style += ",bold"
+		}
+
+		cell, err := excelize.CoordinatesToCellName(i+1, s.rows)
+		if err != nil {
+			return err
+		}
+		if err := s.book.file.SetCellValue(s.name, cell, value); err != nil {
+			return fmt.Errorf("failed to write cell %s!%s: %w", s.name, cell, err)
+		}
+		if style != "" {
+			if err := s.book.file.SetCellStyle(s.name, cell, cell, s.book.styles[style]); err != nil {
+				return fmt.Errorf("failed to style cell %s!%s: %w", s.name, cell, err)
+			}
+		}
+	}
+	return nil
+}
+
+// finish sets the column widths and freezes the header row
+func (s *worksheet) finish(width float64) error {
+	if s.columns > 0 {
+		last, err := excelize.ColumnNumberToName(s.columns)
+		if err != nil {
+			return err
+		}
+		if err := s.book.file.SetColWidth(s.name, "A", last, width); err != nil {
+			return fmt.Errorf("failed to size columns of %s: %w", s.name, err)
+		}
+	}
+	if s.frozen == 0 {
+		return nil
+	}
+	below, _ := excelize.CoordinatesToCellName(1, s.frozen+1)
+	if err := s.book.file.SetPanes(s.name, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      s.frozen,
+		TopLeftCell: below,
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return fmt.Errorf("failed to freeze the header of %s: %w", s.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+
+	"subscription-tracker/internal/service"
+)
+
+func TestExportService_ExportXLSX(t *testing.T) {
+	tdb := setupTestDB(t)
+	ctx := context.Background()
+
+	inputs := []service.CreateSubscriptionInput{
+		{Name: "Netflix", Amount: 15.00, Currency: "USD", BillingCycle: "monthly", NextRenewalDate: "2026-01-15", Category: "streaming"},
+		{Name: "Figma", Amount: 90.00, Currency: "EUR", BillingCycle: "yearly", NextRenewalDate: "2026-03-10", Category: "dev tools"},
+	}
+	for _, input := range inputs {
+		if _, err := tdb.SubscriptionService.Create(ctx, input); err != nil {
+			t.Fatalf("failed to create subscription: %v", err)
+		}
+	}
+	if err := tdb.CurrencyService.SetRate(ctx, "EUR", 0.90); err != nil {
+		t.Fatalf("SetRate() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	count, err := tdb.ExportService.ExportXLSX(ctx, &buf, service.XLSXOptions{Year: 2026})
+	if err != nil {
+		t.Fatalf("ExportXLSX() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("ExportXLSX() = %d, want 2", count)
+	}
+
+	f, err := excelize.OpenReader(&buf, excelize.Options{RawCellValue: true})
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	wantSheets := []string{service.SheetSubscriptions, service.SheetMonthlySpending, service.SheetAnnualTotals, service.SheetByCurrency}
+	if len(sheets) != len(wantSheets) {
+		t.Fatalf("sheets = %v, want %v", sheets, wantSheets)
+	}
+	for i, name := range wantSheets {
+		if sheets[i] != name {
+			t.Errorf("sheet %d = %q, want %q", i, sheets[i], name)
+		}
+	}
+
+	// number reads a cell that must be stored as a number
+	number := func(sheet, cell string) float64 {
+		t.Helper()
+		typ, err := f.GetCellType(sheet, cell)
+		if err != nil {
+			t.Fatalf("GetCellType(%s!%s) error = %v", sheet, cell, err)
+		}
+		value, _ := f.GetCellValue(sheet, cell)
+		if typ != excelize.CellTypeUnset && typ != excelize.CellTypeNumber {
+			t.Errorf("%s!%s = %q of type %v, want a number", sheet, cell, value, typ)
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			t.Errorf("%s!%s = %q, want a number", sheet, cell, value)
+		}
+		return n
+	}
+
+	// Netflix's amount in column C and renewal date in F, as the serial of 2026-01-15
+	for row := 2; row <= 3; row++ {
+		name, _ := f.GetCellValue(service.SheetSubscriptions, "B"+strconv.Itoa(row))
+		if name != "Netflix" {
+			continue
+		}
+		if amount := number(service.SheetSubscriptions, "C"+strconv.Itoa(row)); !almostEqual(amount, 15) {
+			t.Errorf("Netflix amount = %.2f, want 15", amount)
+		}
+		if serial := number(service.SheetSubscriptions, "F"+strconv.Itoa(row)); serial != 46037 {
+			t.Errorf("Netflix renewal = %v, want the serial 46037 of 2026-01-15", serial)
+		}
+	}
+
+	// Billing month April (row 5) has Netflix and Figma: 15 + 90 / 0.9
+	if total := number(service.SheetMonthlySpending, "H5"); !almostEqual(total, 115) {
+		t.Errorf("April total = %.2f, want 115", total)
+	}
+	// Netflix's eleven charges from its first renewal in billing month
+	// February, and Figma's one
+	if total := number(service.SheetMonthlySpending, "H14"); !almostEqual(total, 265) {
+		t.Errorf("year total = %.2f, want 265", total)
+	}
+
+	// What they cost a year
+	if total := number(service.SheetAnnualTotals, "B1"); !almostEqual(total, 280) {
+		t.Errorf("annual total = %.2f, want 280", total)
+	}
+
+	// USD first at 180, then EUR at 90 and 100 in USD
+	if currency, _ := f.GetCellValue(service.SheetByCurrency, "A2"); currency != "USD" {
+		t.Errorf("By Currency!A2 = %q, want USD", currency)
+	}
+	if total := number(service.SheetByCurrency, "C3"); !almostEqual(total, 90) {
+		t.Errorf("EUR total = %.2f, want 90", total)
+	}
+	if total := number(service.SheetByCurrency, "D3"); !almostEqual(total, 100) {
+		t.Errorf("EUR total in USD = %.2f, want 100", total)
+	}
+
+	if _, err := tdb.ExportService.ExportXLSX(ctx, &bytes.Buffer{}, service.XLSXOptions{Year: 99999}); err == nil {
+		t.Error("ExportXLSX() should reject the year 99999")
+	}
+}
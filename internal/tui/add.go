@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
@@ -9,13 +8,13 @@ import (
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
-	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
 )
 
 type AddForm struct {
 	inputs     []textinput.Model
 	focusIndex int
-	cycleIndex int // 0 = monthly, 1 = yearly
+	cycleIndex int // index into cycles
 	err        error
 }
 
@@ -24,12 +23,94 @@ const (
 	addInputAmount
 	addInputCurrency
 	addInputRenewal
+	addInputInterval
+	addInputCategory
+	addInputTags
+	addInputTrialEnd
+	addInputTrialPrice
 )
 
-var cycles = []string{"monthly", "yearly"}
+// cycleCustom is the cycle choice that reveals the "every N units" input
+const cycleCustom = "custom"
+
+// cycles lists the billing cycle choices of the add and edit forms
+var cycles = append(append([]string{}, service.BillingCycles...), cycleCustom)
+
+// defaultCycleIndex is the monthly choice
+var defaultCycleIndex, _ = cycleIndexOf(service.CycleMonthly)
+
+// cycleIndexOf returns the form choice for a stored billing cycle, and the
+// interval text ("10 days") if it is a custom interval
+func cycleIndexOf(billingCycle string) (int, string) {
+	for i, c := range cycles {
+		if c == billingCycle {
+			return i, ""
+		}
+	}
+	return len(cycles) - 1, strings.TrimPrefix(billingCycle, "every ")
+}
+
+// nextCycleIndex moves the cycle choice left or right, wrapping around
+func nextCycleIndex(index int, key string) int {
+	if key == "left" {
+		return (index + len(cycles) - 1) % len(cycles)
+	}
+	return (index + 1) % len(cycles)
+}
+
+// billingCycleValue returns the billing cycle for the chosen cycle and interval text
+func billingCycleValue(index int, interval string) string {
+	if cycles[index] == cycleCustom {
+		return "every " + strings.TrimSpace(interval)
+	}
+	return cycles[index]
+}
+
+// renderCycleSelector renders the billing cycle choices with the chosen one highlighted
+func renderCycleSelector(index int) string {
+	cycleStr := "Billing Cycle: "
+	for i, c := range cycles {
+		if i == index {
+			cycleStr += SelectedItemStyle.Render("[" + c + "]")
+		} else {
+			cycleStr += " " + c + " "
+		}
+	}
+	return cycleStr
+}
+
+// newIntervalInput creates the input for custom "every N units" intervals
+func newIntervalInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "10 days"
+	input.CharLimit = 20
+	input.Width = 15
+	input.Prompt = "Every (N days|weeks|months|years): "
+	return input
+}
+
+// newCategoryInput creates the category input of the add and edit forms
+func newCategoryInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "streaming"
+	input.CharLimit = 30
+	input.Width = 20
+	input.Prompt = "Category: "
+	return input
+}
+
+// newTagsInput creates the tags input of the add and edit forms
+func newTagsInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "family, work"
+	input.CharLimit = 100
+	input.Width = 30
+	input.Prompt = "Tags: "
+	return input
+}
 
 func NewAddForm() *AddForm {
-	inputs := make([]textinput.Model, 4)
+	inputs := make([]textinput.Model, 9)
 
 	inputs[addInputName] = textinput.New()
 	inputs[addInputName].Placeholder = "Netflix"
@@ -57,10 +138,26 @@ func NewAddForm() *AddForm {
 	inputs[addInputRenewal].Width = 12
 	inputs[addInputRenewal].Prompt = "Renewal Date (YYYY-MM-DD): "
 
+	inputs[addInputInterval] = newIntervalInput()
+	inputs[addInputCategory] = newCategoryInput()
+	inputs[addInputTags] = newTagsInput()
+
+	inputs[addInputTrialEnd] = textinput.New()
+	inputs[addInputTrialEnd].Placeholder = "optional"
+	inputs[addInputTrialEnd].CharLimit = 10
+	inputs[addInputTrialEnd].Width = 12
+	inputs[addInputTrialEnd].Prompt = "Free Trial Ends (YYYY-MM-DD): "
+
+	inputs[addInputTrialPrice] = textinput.New()
+	inputs[addInputTrialPrice].Placeholder = "same as amount"
+	inputs[addInputTrialPrice].CharLimit = 10
+	inputs[addInputTrialPrice].Width = 15
+	inputs[addInputTrialPrice].Prompt = "Price After Trial: "
+
 	return &AddForm{
 		inputs:     inputs,
 		focusIndex: 0,
-		cycleIndex: 0,
+		cycleIndex: defaultCycleIndex,
 	}
 }
 
@@ -70,7 +167,7 @@ func (f *AddForm) Init() tea.Cmd {
 
 // nextFocus returns the next focus index in the form
 func (f *AddForm) nextFocus(current int) int {
-	// Order: Name(0) -> Amount(1) -> Currency(2) -> Cycle(100) -> Renewal(3) -> Name(0)
+	// Order: Name(0) -> Amount(1) -> Currency(2) -> Cycle(100) -> [Interval(4)] -> Renewal(3) -> Category(5) -> Tags(6) -> Trial End(7) -> [Trial Price(8)] -> Name(0)
 	switch current {
 	case addInputName:
 		return addInputAmount
@@ -79,8 +176,24 @@ func (f *AddForm) nextFocus(current int) int {
 	case addInputCurrency:
 		return focusCycle
 	case focusCycle:
+		if cycles[f.cycleIndex] == cycleCustom {
+			return addInputInterval
+		}
+		return addInputRenewal
+	case addInputInterval:
 		return addInputRenewal
 	case addInputRenewal:
+		return addInputCategory
+	case addInputCategory:
+		return addInputTags
+	case addInputTags:
+		return addInputTrialEnd
+	case addInputTrialEnd:
+		if f.hasTrial() {
+			return addInputTrialPrice
+		}
+		return addInputName
+	case addInputTrialPrice:
 		return addInputName
 	default:
 		return addInputName
@@ -92,20 +205,41 @@ func (f *AddForm) prevFocus(current int) int {
 	// Reverse order
 	switch current {
 	case addInputName:
-		return addInputRenewal
+		if f.hasTrial() {
+			return addInputTrialPrice
+		}
+		return addInputTrialEnd
 	case addInputAmount:
 		return addInputName
 	case addInputCurrency:
 		return addInputAmount
 	case focusCycle:
 		return addInputCurrency
+	case addInputInterval:
+		return focusCycle
 	case addInputRenewal:
+		if cycles[f.cycleIndex] == cycleCustom {
+			return addInputInterval
+		}
 		return focusCycle
+	case addInputCategory:
+		return addInputRenewal
+	case addInputTags:
+		return addInputCategory
+	case addInputTrialEnd:
+		return addInputTags
+	case addInputTrialPrice:
+		return addInputTrialEnd
 	default:
 		return addInputName
 	}
 }
 
+// hasTrial reports whether a free trial end date was entered, which reveals the post-trial price
+func (f *AddForm) hasTrial() bool {
+	return strings.TrimSpace(f.inputs[addInputTrialEnd].Value()) != ""
+}
+
 const focusCycle = 100 // special index for cycle selector
 
 func (f *AddForm) Update(msg tea.Msg, app interface{}) (bool, tea.Cmd) {
@@ -120,7 +254,7 @@ func (f *AddForm) Update(msg tea.Msg, app interface{}) (bool, tea.Cmd) {
 			return false, f.updateFocus()
 		case "left", "right":
 			if f.focusIndex == focusCycle {
-				f.cycleIndex = 1 - f.cycleIndex
+				f.cycleIndex = nextCycleIndex(f.cycleIndex, msg.String())
 			}
 			return false, nil
 		case "enter":
@@ -172,15 +306,27 @@ func (f *AddForm) submit() tea.Cmd {
 			return errMsg{fmt.Errorf("invalid date format (use YYYY-MM-DD): %w", err)}
 		}
 
-		params := db.CreateSubscriptionParams{
+		trialPrice := 0.0
+		if f.hasTrial() && strings.TrimSpace(f.inputs[addInputTrialPrice].Value()) != "" {
+			trialPrice, err = strconv.ParseFloat(strings.TrimSpace(f.inputs[addInputTrialPrice].Value()), 64)
+			if err != nil {
+				return errMsg{fmt.Errorf("invalid price after trial: %w", err)}
+			}
+		}
+
+		input := service.CreateSubscriptionInput{
 			Name:            name,
 			Amount:          amount,
 			Currency:        strings.ToUpper(f.inputs[addInputCurrency].Value()),
-			BillingCycle:    cycles[f.cycleIndex],
-			NextRenewalDate: sql.NullString{String: dateStr, Valid: true},
+			BillingCycle:    billingCycleValue(f.cycleIndex, f.inputs[addInputInterval].Value()),
+			NextRenewalDate: dateStr,
+			Category:        f.inputs[addInputCategory].Value(),
+			Tags:            service.ParseTags(f.inputs[addInputTags].Value()),
+			TrialEndDate:    strings.TrimSpace(f.inputs[addInputTrialEnd].Value()),
+			TrialPrice:      trialPrice,
 		}
 
-		return createSubscriptionMsg{params}
+		return createSubscriptionMsg{input}
 	}
 }
 
@@ -203,20 +349,22 @@ func (f *AddForm) View() string {
 	}
 
 	// Cycle selector
-	cycleStr := "Billing Cycle: "
-	for i, c := range cycles {
-		if i == f.cycleIndex {
-			cycleStr += SelectedItemStyle.Render("[" + c + "]")
-		} else {
-			cycleStr += " " + c + " "
-		}
-	}
+	cycleStr := renderCycleSelector(f.cycleIndex)
 	if f.focusIndex == focusCycle {
 		b.WriteString(FocusedInputStyle.Render(cycleStr) + "\n")
 	} else {
 		b.WriteString(cycleStr + "\n")
 	}
 
+	// Custom interval (only for custom cycles)
+	if cycles[f.cycleIndex] == cycleCustom {
+		if f.focusIndex == addInputInterval {
+			b.WriteString(FocusedInputStyle.Render(f.inputs[addInputInterval].View()) + "\n")
+		} else {
+			b.WriteString(BlurredInputStyle.Render(f.inputs[addInputInterval].View()) + "\n")
+		}
+	}
+
 	// Renewal date (always shown)
 	if f.focusIndex == addInputRenewal {
 		b.WriteString(FocusedInputStyle.Render(f.inputs[addInputRenewal].View()) + "\n")
@@ -224,6 +372,24 @@ func (f *AddForm) View() string {
 		b.WriteString(BlurredInputStyle.Render(f.inputs[addInputRenewal].View()) + "\n")
 	}
 
+	// Category, tags and free trial
+	for _, i := range []int{addInputCategory, addInputTags, addInputTrialEnd} {
+		if i == f.focusIndex {
+			b.WriteString(FocusedInputStyle.Render(f.inputs[i].View()) + "\n")
+		} else {
+			b.WriteString(BlurredInputStyle.Render(f.inputs[i].View()) + "\n")
+		}
+	}
+
+	// Post-trial price (only with a free trial)
+	if f.hasTrial() {
+		if f.focusIndex == addInputTrialPrice {
+			b.WriteString(FocusedInputStyle.Render(f.inputs[addInputTrialPrice].View()) + "\n")
+		} else {
+			b.WriteString(BlurredInputStyle.Render(f.inputs[addInputTrialPrice].View()) + "\n")
+		}
+	}
+
 	b.WriteString("\n" + HelpStyle.Render("[tab] next  [shift+tab] prev  [←/→] cycle  [ctrl+s] save  [q/esc] cancel"))
 
 	return BoxStyle.Render(b.String())
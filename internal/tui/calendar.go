@@ -0,0 +1,227 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"subscription-tracker/internal/app"
+	"subscription-tracker/internal/service"
+)
+
+// CalendarView shows the renewals of one billing period as a month grid. The
+// grid runs from the period's first to its last day, so with a cutoff day
+// other than 1 it spans two calendar months.
+type CalendarView struct {
+	year         int // Billing period, see SpendingService.CalculateForMonth
+	month        int
+	selected     time.Time
+	periodStart  time.Time
+	periodEnd    time.Time
+	charges      map[string][]service.Charge // By YYYY-MM-DD
+	total        float64
+	baseCurrency string
+	loading      bool
+	err          error
+}
+
+func NewCalendarView() *CalendarView {
+	now := time.Now()
+	return &CalendarView{
+		selected: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		loading:  true,
+	}
+}
+
+// Init loads the billing period of today
+func (v *CalendarView) Init(a *app.App) tea.Cmd {
+	return func() tea.Msg {
+		summary, err := a.SpendingService.CalculateForCurrentMonth(context.Background())
+		if err != nil {
+			return calendarErrMsg{err}
+		}
+		return calendarLoadedMsg{summary}
+	}
+}
+
+func (v *CalendarView) loadPeriod(a *app.App) tea.Cmd {
+	year, month := v.year, v.month
+	return func() tea.Msg {
+		summary, err := a.SpendingService.CalculateForMonth(context.Background(), year, month)
+		if err != nil {
+			return calendarErrMsg{err}
+		}
+		return calendarLoadedMsg{summary}
+	}
+}
+
+type calendarLoadedMsg struct {
+	summary *service.SpendingSummary
+}
+
+type calendarErrMsg struct {
+	err error
+}
+
+func (v *CalendarView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if v.loading {
+			if msg.String() == "q" || msg.String() == "esc" {
+				return true, nil
+			}
+			return false, nil
+		}
+		switch msg.String() {
+		case "left", "h":
+			return false, v.moveTo(a, v.selected.AddDate(0, 0, -1))
+		case "right", "l":
+			return false, v.moveTo(a, v.selected.AddDate(0, 0, 1))
+		case "up", "k":
+			return false, v.moveTo(a, v.selected.AddDate(0, 0, -7))
+		case "down", "j":
+			return false, v.moveTo(a, v.selected.AddDate(0, 0, 7))
+		case "[", "H":
+			return false, v.changePeriod(a, -1)
+		case "]", "L":
+			return false, v.changePeriod(a, 1)
+		case "q", "esc":
+			return true, nil
+		}
+	case calendarLoadedMsg:
+		v.loading = false
+		v.err = nil
+		v.year, v.month = msg.summary.Year, msg.summary.Month
+		v.periodStart = msg.summary.PeriodStart
+		v.periodEnd = time.Date(msg.summary.PeriodEnd.Year(), msg.summary.PeriodEnd.Month(), msg.summary.PeriodEnd.Day(), 0, 0, 0, 0, time.UTC)
+		v.total = msg.summary.GrandTotal
+		v.baseCurrency = msg.summary.BaseCurrency
+		v.charges = make(map[string][]service.Charge)
+		for _, c := range msg.summary.Charges {
+			day := c.Date.Format("2006-01-02")
+			v.charges[day] = append(v.charges[day], c)
+		}
+		// Keep the selection within the period
+		if v.selected.Before(v.periodStart) {
+			v.selected = v.periodStart
+		} else if v.selected.After(v.periodEnd) {
+			v.selected = v.periodEnd
+		}
+		return false, nil
+	case calendarErrMsg:
+		v.loading = false
+		v.err = msg.err
+		return false, nil
+	}
+	return false, nil
+}
+
+// moveTo selects date, loading the neighbouring billing period when date is outside this one
+func (v *CalendarView) moveTo(a *app.App, date time.Time) tea.Cmd {
+	v.selected = date
+	switch {
+	case date.Before(v.periodStart):
+		return v.changePeriod(a, -1)
+	case date.After(v.periodEnd):
+		return v.changePeriod(a, 1)
+	}
+	return nil
+}
+
+// changePeriod loads the billing period months before or after this one
+func (v *CalendarView) changePeriod(a *app.App, months int) tea.Cmd {
+	v.month += months
+	for v.month < 1 {
+		v.month += 12
+		v.year--
+	}
+	for v.month > 12 {
+		v.month -= 12
+		v.year++
+	}
+	if v.selected.IsZero() || !v.selected.Before(v.periodStart) && !v.selected.After(v.periodEnd) {
+		// Jumping a whole period keeps the day of the month where possible
+		v.selected = v.selected.AddDate(0, months, 0)
+	}
+	v.loading = true
+	return v.loadPeriod(a)
+}
+
+func (v *CalendarView) View() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render(fmt.Sprintf("Renewal Calendar for %s %d", time.Month(v.month), v.year)) + "\n")
+	if !v.periodStart.IsZero() {
+		b.WriteString(SubtitleStyle.Render(fmt.Sprintf("%s - %s",
+			v.periodStart.Format("Jan 2, 2006"), v.periodEnd.Format("Jan 2, 2006"))) + "\n")
+	}
+	b.WriteString("\n")
+
+	if v.loading {
+		b.WriteString("Loading...\n")
+		return BoxStyle.Render(b.String())
+	}
+	if v.err != nil {
+		b.WriteString(ErrorStyle.Render("Error: "+v.err.Error()) + "\n\n")
+	}
+
+	// Weeks run Monday to Sunday, from the week of the first day of the period to the week of its last
+	b.WriteString(TableHeaderStyle.Render(" Mon  Tue  Wed  Thu  Fri  Sat  Sun ") + "\n")
+	day := v.periodStart.AddDate(0, 0, -((int(v.periodStart.Weekday()) + 6) % 7))
+	for !day.After(v.periodEnd) {
+		var week strings.Builder
+		for i := 0; i < 7; i++ {
+			week.WriteString(v.renderDay(day))
+			day = day.AddDate(0, 0, 1)
+		}
+		b.WriteString(week.String() + "\n")
+	}
+	b.WriteString(HelpStyle.Render(" * renewal") + "\n")
+
+	// Charges of the selected day
+	b.WriteString("\n" + SubtitleStyle.Render(v.selected.Format("Monday, Jan 2, 2006")+":") + "\n")
+	charges := v.charges[v.selected.Format("2006-01-02")]
+	if len(charges) == 0 {
+		b.WriteString("  No renewals\n")
+	}
+	var dayTotal float64
+	for _, c := range charges {
+		dayTotal += c.Converted
+		line := fmt.Sprintf("  %-20s %10.2f %s", c.Subscription.Name, c.Amount, c.Subscription.Currency)
+		if c.Subscription.Currency != v.baseCurrency {
+			line += fmt.Sprintf(" (%.2f %s)", c.Converted, v.baseCurrency)
+		}
+		b.WriteString(line + "  " + HelpStyle.Render(c.Subscription.BillingCycle) + "\n")
+	}
+	if len(charges) > 1 {
+		b.WriteString(fmt.Sprintf("  %-20s %10.2f %s\n", "Day total", dayTotal, v.baseCurrency))
+	}
+
+	b.WriteString("────────────────────────────────\n")
+	b.WriteString(AmountStyle.Render(fmt.Sprintf("PERIOD TOTAL: %.2f %s", v.total, v.baseCurrency)) + "\n")
+
+	b.WriteString("\n" + HelpStyle.Render("[h/j/k/l] move day/week  [H/L] previous/next month  [q/esc] back"))
+
+	return BoxStyle.Render(b.String())
+}
+
+// renderDay renders one five character cell of the grid
+func (v *CalendarView) renderDay(day time.Time) string {
+	marker := " "
+	if len(v.charges[day.Format("2006-01-02")]) > 0 {
+		marker = "*"
+	}
+	cell := fmt.Sprintf(" %2d%s ", day.Day(), marker)
+
+	switch {
+	case day.Equal(v.selected):
+		return SelectedItemStyle.Render(cell)
+	case day.Before(v.periodStart) || day.After(v.periodEnd):
+		return HelpStyle.Render(cell)
+	case marker != " ":
+		return AmountStyle.Render(cell)
+	}
+	return cell
+}
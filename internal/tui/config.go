@@ -9,14 +9,19 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"subscription-tracker/internal/app"
+	"subscription-tracker/internal/service"
 )
 
 type ConfigView struct {
 	cutoffInput   textinput.Model
 	salaryInput   textinput.Model
+	currencyInput textinput.Model
+	budgetInput   textinput.Model
+	trialInput    textinput.Model
 	focusIndex    int
 	currentDay    int
 	currentSalary float64
+	hasBudget     bool // An overall budget is stored
 	message       string
 	err           error
 	saved         bool
@@ -25,6 +30,10 @@ type ConfigView struct {
 const (
 	configFocusCutoff = iota
 	configFocusSalary
+	configFocusCurrency
+	configFocusBudget
+	configFocusTrial
+	configFieldCount
 )
 
 func NewConfigView() *ConfigView {
@@ -41,10 +50,31 @@ func NewConfigView() *ConfigView {
 	salaryInput.Width = 15
 	salaryInput.Prompt = "Monthly Salary: "
 
+	currencyInput := textinput.New()
+	currencyInput.Placeholder = "USD"
+	currencyInput.CharLimit = 3
+	currencyInput.Width = 5
+	currencyInput.Prompt = "Base Currency: "
+
+	budgetInput := textinput.New()
+	budgetInput.Placeholder = "none"
+	budgetInput.CharLimit = 12
+	budgetInput.Width = 15
+	budgetInput.Prompt = "Monthly Budget: "
+
+	trialInput := textinput.New()
+	trialInput.Placeholder = strconv.Itoa(service.DefaultTrialWarnDays)
+	trialInput.CharLimit = 3
+	trialInput.Width = 5
+	trialInput.Prompt = "Trial Warning (days): "
+
 	return &ConfigView{
-		cutoffInput: cutoffInput,
-		salaryInput: salaryInput,
-		focusIndex:  configFocusCutoff,
+		cutoffInput:   cutoffInput,
+		salaryInput:   salaryInput,
+		currencyInput: currencyInput,
+		budgetInput:   budgetInput,
+		trialInput:    trialInput,
+		focusIndex:    configFocusCutoff,
 	}
 }
 
@@ -63,13 +93,34 @@ func (v *ConfigView) loadConfig(a *app.App) tea.Cmd {
 		if err != nil {
 			return configErrMsg{err}
 		}
-		return configLoadedMsg{cutoffDay: day, salary: salary}
+		currency, err := a.ConfigService.GetBaseCurrency(ctx)
+		if err != nil {
+			return configErrMsg{err}
+		}
+		budgets, err := a.BudgetService.List(ctx)
+		if err != nil {
+			return configErrMsg{err}
+		}
+		trialDays, err := a.ConfigService.GetTrialWarnDays(ctx)
+		if err != nil {
+			return configErrMsg{err}
+		}
+		msg := configLoadedMsg{cutoffDay: day, salary: salary, baseCurrency: currency, trialWarnDays: trialDays}
+		for _, b := range budgets {
+			if b.Category == "" {
+				msg.budget = b.Amount
+			}
+		}
+		return msg
 	}
 }
 
 type configLoadedMsg struct {
-	cutoffDay int
-	salary    float64
+	cutoffDay     int
+	salary        float64
+	baseCurrency  string
+	budget        float64 // Overall budget; 0 if not set
+	trialWarnDays int
 }
 
 type configErrMsg struct {
@@ -85,10 +136,10 @@ func (v *ConfigView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "tab", "down":
-			v.focusIndex = (v.focusIndex + 1) % 2
+			v.focusIndex = (v.focusIndex + 1) % configFieldCount
 			return false, v.updateFocus()
 		case "shift+tab", "up":
-			v.focusIndex = (v.focusIndex + 1) % 2
+			v.focusIndex = (v.focusIndex + configFieldCount - 1) % configFieldCount
 			return false, v.updateFocus()
 		case "ctrl+s":
 			return false, v.save(a)
@@ -102,10 +153,17 @@ func (v *ConfigView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 		if msg.salary > 0 {
 			v.salaryInput.SetValue(strconv.FormatFloat(msg.salary, 'f', 2, 64))
 		}
+		v.currencyInput.SetValue(msg.baseCurrency)
+		if msg.budget > 0 {
+			v.hasBudget = true
+			v.budgetInput.SetValue(strconv.FormatFloat(msg.budget, 'f', 2, 64))
+		}
+		v.trialInput.SetValue(strconv.Itoa(msg.trialWarnDays))
 		return false, nil
 	case configSavedMsg:
 		v.message = msg.message
 		v.saved = true
+		v.hasBudget = v.budgetInput.Value() != ""
 		return false, nil
 	case configErrMsg:
 		v.err = msg.err
@@ -118,17 +176,34 @@ func (v *ConfigView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 		v.cutoffInput, cmd = v.cutoffInput.Update(msg)
 	case configFocusSalary:
 		v.salaryInput, cmd = v.salaryInput.Update(msg)
+	case configFocusCurrency:
+		v.currencyInput, cmd = v.currencyInput.Update(msg)
+	case configFocusBudget:
+		v.budgetInput, cmd = v.budgetInput.Update(msg)
+	case configFocusTrial:
+		v.trialInput, cmd = v.trialInput.Update(msg)
 	}
 	return false, cmd
 }
 
 func (v *ConfigView) updateFocus() tea.Cmd {
-	if v.focusIndex == configFocusCutoff {
-		v.salaryInput.Blur()
+	v.cutoffInput.Blur()
+	v.salaryInput.Blur()
+	v.currencyInput.Blur()
+	v.budgetInput.Blur()
+	v.trialInput.Blur()
+	switch v.focusIndex {
+	case configFocusSalary:
+		return v.salaryInput.Focus()
+	case configFocusCurrency:
+		return v.currencyInput.Focus()
+	case configFocusBudget:
+		return v.budgetInput.Focus()
+	case configFocusTrial:
+		return v.trialInput.Focus()
+	default:
 		return v.cutoffInput.Focus()
 	}
-	v.cutoffInput.Blur()
-	return v.salaryInput.Focus()
 }
 
 func (v *ConfigView) save(a *app.App) tea.Cmd {
@@ -146,6 +221,22 @@ func (v *ConfigView) save(a *app.App) tea.Cmd {
 			}
 		}
 
+		budget := 0.0
+		if v.budgetInput.Value() != "" {
+			budget, err = strconv.ParseFloat(v.budgetInput.Value(), 64)
+			if err != nil || budget < 0 {
+				return configErrMsg{fmt.Errorf("invalid budget amount")}
+			}
+		}
+
+		trialDays := service.DefaultTrialWarnDays
+		if v.trialInput.Value() != "" {
+			trialDays, err = strconv.Atoi(v.trialInput.Value())
+			if err != nil {
+				return configErrMsg{fmt.Errorf("invalid trial warning days")}
+			}
+		}
+
 		ctx := context.Background()
 		if err := a.ConfigService.SetMonthCutoffDay(ctx, day); err != nil {
 			return configErrMsg{err}
@@ -153,6 +244,21 @@ func (v *ConfigView) save(a *app.App) tea.Cmd {
 		if err := a.ConfigService.SetMonthlySalary(ctx, salary); err != nil {
 			return configErrMsg{err}
 		}
+		if err := a.CurrencyService.SetBaseCurrency(ctx, v.currencyInput.Value()); err != nil {
+			return configErrMsg{err}
+		}
+		if err := a.ConfigService.SetTrialWarnDays(ctx, trialDays); err != nil {
+			return configErrMsg{err}
+		}
+		if budget > 0 {
+			if _, err := a.BudgetService.Set(ctx, "", budget); err != nil {
+				return configErrMsg{err}
+			}
+		} else if v.hasBudget {
+			if err := a.BudgetService.Delete(ctx, ""); err != nil {
+				return configErrMsg{err}
+			}
+		}
 
 		return configSavedMsg{"Settings saved!"}
 	}
@@ -173,7 +279,10 @@ func (v *ConfigView) View() string {
 
 	b.WriteString("Configure your pay stub settings.\n")
 	b.WriteString("The payday determines when your billing period starts.\n")
-	b.WriteString("The salary is used to calculate remaining money after subscriptions.\n\n")
+	b.WriteString("The salary is used to calculate remaining money after subscriptions.\n")
+	b.WriteString("Totals are converted to the base currency using the exchange rates.\n")
+	b.WriteString("The budget caps subscription spending per billing period (empty for none).\n")
+	b.WriteString("Free trials ending within the trial warning are shown on startup.\n\n")
 
 	// Cutoff day input
 	if v.focusIndex == configFocusCutoff {
@@ -189,6 +298,27 @@ func (v *ConfigView) View() string {
 		b.WriteString(BlurredInputStyle.Render(v.salaryInput.View()) + "\n")
 	}
 
+	// Base currency input
+	if v.focusIndex == configFocusCurrency {
+		b.WriteString(FocusedInputStyle.Render(v.currencyInput.View()) + "\n")
+	} else {
+		b.WriteString(BlurredInputStyle.Render(v.currencyInput.View()) + "\n")
+	}
+
+	// Overall budget input
+	if v.focusIndex == configFocusBudget {
+		b.WriteString(FocusedInputStyle.Render(v.budgetInput.View()) + "\n")
+	} else {
+		b.WriteString(BlurredInputStyle.Render(v.budgetInput.View()) + "\n")
+	}
+
+	// Trial warning input
+	if v.focusIndex == configFocusTrial {
+		b.WriteString(FocusedInputStyle.Render(v.trialInput.View()) + "\n")
+	} else {
+		b.WriteString(BlurredInputStyle.Render(v.trialInput.View()) + "\n")
+	}
+
 	b.WriteString("\n" + HelpStyle.Render("[tab] next field  [ctrl+s] save  [q/esc] back"))
 
 	return BoxStyle.Render(b.String())
@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,6 +9,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
 )
 
 type EditForm struct {
@@ -17,6 +17,9 @@ type EditForm struct {
 	focusIndex int
 	cycleIndex int
 	subID      int64
+	amount     float64 // Price when loaded, to tell whether it changed
+	currency   string
+	history    []db.PriceChange
 	err        error
 }
 
@@ -25,10 +28,14 @@ const (
 	editInputAmount
 	editInputCurrency
 	editInputRenewal
+	editInputInterval
+	editInputCategory
+	editInputTags
+	editInputPriceEffective
 )
 
 func NewEditForm() *EditForm {
-	inputs := make([]textinput.Model, 4)
+	inputs := make([]textinput.Model, 8)
 
 	inputs[editInputName] = textinput.New()
 	inputs[editInputName].CharLimit = 50
@@ -50,29 +57,59 @@ func NewEditForm() *EditForm {
 	inputs[editInputRenewal].Width = 12
 	inputs[editInputRenewal].Prompt = "Renewal Date (YYYY-MM-DD): "
 
+	inputs[editInputInterval] = newIntervalInput()
+	inputs[editInputCategory] = newCategoryInput()
+	inputs[editInputTags] = newTagsInput()
+
+	inputs[editInputPriceEffective] = textinput.New()
+	inputs[editInputPriceEffective].Placeholder = time.Now().Format("2006-01-02")
+	inputs[editInputPriceEffective].CharLimit = 10
+	inputs[editInputPriceEffective].Width = 12
+	inputs[editInputPriceEffective].Prompt = "New Price From (YYYY-MM-DD): "
+
 	return &EditForm{
 		inputs:     inputs,
 		focusIndex: 0,
-		cycleIndex: 0,
+		cycleIndex: defaultCycleIndex,
 	}
 }
 
-func (f *EditForm) LoadSubscription(sub db.Subscription) {
+// LoadSubscription fills the form from sub; categories names its category
+func (f *EditForm) LoadSubscription(sub db.Subscription, categories map[int64]string) {
 	f.subID = sub.ID
+	f.amount = sub.Amount
+	f.currency = sub.Currency
 	f.inputs[editInputName].SetValue(sub.Name)
 	f.inputs[editInputAmount].SetValue(fmt.Sprintf("%.2f", sub.Amount))
 	f.inputs[editInputCurrency].SetValue(sub.Currency)
 	if sub.NextRenewalDate.Valid {
 		f.inputs[editInputRenewal].SetValue(sub.NextRenewalDate.String)
 	}
-	if sub.BillingCycle == "yearly" {
-		f.cycleIndex = 1
-	} else {
-		f.cycleIndex = 0
+	var interval string
+	f.cycleIndex, interval = cycleIndexOf(sub.BillingCycle)
+	f.inputs[editInputInterval].SetValue(interval)
+	if sub.CategoryID.Valid {
+		f.inputs[editInputCategory].SetValue(categories[sub.CategoryID.Int64])
 	}
+	f.inputs[editInputTags].SetValue(strings.Join(service.ParseTags(sub.Tags), ", "))
 	f.inputs[editInputName].Focus()
 }
 
+// SetPriceHistory sets the price changes shown below the form, oldest first
+func (f *EditForm) SetPriceHistory(history []db.PriceChange) {
+	f.history = history
+}
+
+// priceChanged reports whether the amount or currency was edited, which reveals
+// the date the new price takes effect
+func (f *EditForm) priceChanged() bool {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(f.inputs[editInputAmount].Value()), 64)
+	if err != nil {
+		return false
+	}
+	return amount != f.amount || !strings.EqualFold(strings.TrimSpace(f.inputs[editInputCurrency].Value()), f.currency)
+}
+
 func (f *EditForm) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -81,17 +118,31 @@ const editFocusCycle = 100 // special index for cycle selector
 
 // nextFocus returns the next focus index in the form
 func (f *EditForm) nextFocus(current int) int {
-	// Order: Name(0) -> Amount(1) -> Currency(2) -> Cycle(100) -> Renewal(3) -> Name(0)
+	// Order: Name(0) -> Amount(1) -> Currency(2) -> [PriceEffective(7)] -> Cycle(100) -> [Interval(4)] -> Renewal(3) -> Category(5) -> Tags(6) -> Name(0)
 	switch current {
 	case editInputName:
 		return editInputAmount
 	case editInputAmount:
 		return editInputCurrency
 	case editInputCurrency:
+		if f.priceChanged() {
+			return editInputPriceEffective
+		}
+		return editFocusCycle
+	case editInputPriceEffective:
 		return editFocusCycle
 	case editFocusCycle:
+		if cycles[f.cycleIndex] == cycleCustom {
+			return editInputInterval
+		}
+		return editInputRenewal
+	case editInputInterval:
 		return editInputRenewal
 	case editInputRenewal:
+		return editInputCategory
+	case editInputCategory:
+		return editInputTags
+	case editInputTags:
 		return editInputName
 	default:
 		return editInputName
@@ -103,15 +154,29 @@ func (f *EditForm) prevFocus(current int) int {
 	// Reverse order
 	switch current {
 	case editInputName:
-		return editInputRenewal
+		return editInputTags
 	case editInputAmount:
 		return editInputName
 	case editInputCurrency:
 		return editInputAmount
+	case editInputPriceEffective:
+		return editInputCurrency
 	case editFocusCycle:
+		if f.priceChanged() {
+			return editInputPriceEffective
+		}
 		return editInputCurrency
+	case editInputInterval:
+		return editFocusCycle
 	case editInputRenewal:
+		if cycles[f.cycleIndex] == cycleCustom {
+			return editInputInterval
+		}
 		return editFocusCycle
+	case editInputCategory:
+		return editInputRenewal
+	case editInputTags:
+		return editInputCategory
 	default:
 		return editInputName
 	}
@@ -129,7 +194,7 @@ func (f *EditForm) Update(msg tea.Msg, app interface{}) (bool, tea.Cmd) {
 			return false, f.updateFocus()
 		case "left", "right":
 			if f.focusIndex == editFocusCycle {
-				f.cycleIndex = 1 - f.cycleIndex
+				f.cycleIndex = nextCycleIndex(f.cycleIndex, msg.String())
 			}
 			return false, nil
 		case "enter":
@@ -176,21 +241,26 @@ func (f *EditForm) submit() tea.Cmd {
 			return errMsg{fmt.Errorf("invalid date format (use YYYY-MM-DD): %w", err)}
 		}
 
-		params := db.UpdateSubscriptionParams{
+		input := service.UpdateSubscriptionInput{
 			ID:              f.subID,
 			Name:            f.inputs[editInputName].Value(),
 			Amount:          amount,
 			Currency:        strings.ToUpper(f.inputs[editInputCurrency].Value()),
-			BillingCycle:    cycles[f.cycleIndex],
-			NextRenewalDate: sql.NullString{String: dateStr, Valid: true},
+			BillingCycle:    billingCycleValue(f.cycleIndex, f.inputs[editInputInterval].Value()),
+			NextRenewalDate: dateStr,
+			Category:        f.inputs[editInputCategory].Value(),
+			Tags:            service.ParseTags(f.inputs[editInputTags].Value()),
+		}
+		if f.priceChanged() {
+			input.PriceEffective = strings.TrimSpace(f.inputs[editInputPriceEffective].Value())
 		}
 
-		return updateSubscriptionMsg{params}
+		return updateSubscriptionMsg{input}
 	}
 }
 
 type updateSubscriptionMsg struct {
-	params db.UpdateSubscriptionParams
+	input service.UpdateSubscriptionInput
 }
 
 func (f *EditForm) View() string {
@@ -211,21 +281,32 @@ func (f *EditForm) View() string {
 		}
 	}
 
-	// Cycle selector
-	cycleStr := "Billing Cycle: "
-	for i, c := range cycles {
-		if i == f.cycleIndex {
-			cycleStr += SelectedItemStyle.Render("[" + c + "]")
+	// Effective date (only when the price changed)
+	if f.priceChanged() {
+		if f.focusIndex == editInputPriceEffective {
+			b.WriteString(FocusedInputStyle.Render(f.inputs[editInputPriceEffective].View()) + "\n")
 		} else {
-			cycleStr += " " + c + " "
+			b.WriteString(BlurredInputStyle.Render(f.inputs[editInputPriceEffective].View()) + "\n")
 		}
 	}
+
+	// Cycle selector
+	cycleStr := renderCycleSelector(f.cycleIndex)
 	if f.focusIndex == editFocusCycle {
 		b.WriteString(FocusedInputStyle.Render(cycleStr) + "\n")
 	} else {
 		b.WriteString(cycleStr + "\n")
 	}
 
+	// Custom interval (only for custom cycles)
+	if cycles[f.cycleIndex] == cycleCustom {
+		if f.focusIndex == editInputInterval {
+			b.WriteString(FocusedInputStyle.Render(f.inputs[editInputInterval].View()) + "\n")
+		} else {
+			b.WriteString(BlurredInputStyle.Render(f.inputs[editInputInterval].View()) + "\n")
+		}
+	}
+
 	// Renewal date (always shown)
 	if f.focusIndex == editInputRenewal {
 		b.WriteString(FocusedInputStyle.Render(f.inputs[editInputRenewal].View()) + "\n")
@@ -233,7 +314,37 @@ func (f *EditForm) View() string {
 		b.WriteString(BlurredInputStyle.Render(f.inputs[editInputRenewal].View()) + "\n")
 	}
 
+	// Category and tags
+	for _, i := range []int{editInputCategory, editInputTags} {
+		if i == f.focusIndex {
+			b.WriteString(FocusedInputStyle.Render(f.inputs[i].View()) + "\n")
+		} else {
+			b.WriteString(BlurredInputStyle.Render(f.inputs[i].View()) + "\n")
+		}
+	}
+
+	if len(f.history) > 0 {
+		b.WriteString("\n" + SubtitleStyle.Render("Price History") + "\n")
+		b.WriteString(renderPriceHistory(f.history))
+	}
+
 	b.WriteString("\n" + HelpStyle.Render("[tab] next  [shift+tab] prev  [←/→] cycle  [ctrl+s] save  [q/esc] cancel"))
 
 	return BoxStyle.Render(b.String())
 }
+
+// renderPriceHistory renders one line per price change, oldest first
+func renderPriceHistory(history []db.PriceChange) string {
+	var b strings.Builder
+	for _, change := range history {
+		line := fmt.Sprintf("%s  %.2f %s → %.2f %s", change.EffectiveDate,
+			change.OldAmount, change.OldCurrency, change.Amount, change.Currency)
+		c := service.PriceChange{PriceChange: change}
+		if percent, ok := c.Percent(); ok {
+			diff, _ := c.Difference()
+			line += fmt.Sprintf("  %+.2f (%+.1f%%)", diff, percent)
+		}
+		b.WriteString(NormalItemStyle.Render(line) + "\n")
+	}
+	return b.String()
+}
@@ -2,8 +2,6 @@ package tui
 
 import (
 	"context"
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -11,18 +9,28 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"subscription-tracker/internal/app"
-	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
 )
 
 type ExportView struct {
-	formatIndex int // 0 = CSV, 1 = JSON
+	formatIndex int  // Index into exportFormats
+	importing   bool // Import from the file instead of exporting to it
+	mode        service.ImportMode
 	pathInput   textinput.Model
+	preview     *service.ImportPreview // Shown until the import is confirmed
+	offset      int                    // First preview row shown
 	message     string
 	err         error
 	exported    bool
 }
 
-var exportFormats = []string{"CSV", "JSON"}
+var exportFormats = []string{"CSV", "JSON", "XLSX", "ICS", "LEDGER", "HLEDGER", "BEANCOUNT"}
+
+// importFormats is how many of exportFormats can be imported
+const importFormats = 2
+
+// previewRows is how many rows of an import preview are shown at once
+const previewRows = 12
 
 func NewExportView() *ExportView {
 	pathInput := textinput.New()
@@ -35,6 +43,7 @@ func NewExportView() *ExportView {
 
 	return &ExportView{
 		formatIndex: 0,
+		mode:        service.ImportMerge,
 		pathInput:   pathInput,
 	}
 }
@@ -46,19 +55,40 @@ func (v *ExportView) Init() tea.Cmd {
 func (v *ExportView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if v.preview != nil {
+			return v.updatePreview(msg, a)
+		}
 		switch msg.String() {
 		case "tab":
-			v.formatIndex = (v.formatIndex + 1) % len(exportFormats)
-			// Update file extension
-			path := v.pathInput.Value()
-			if v.formatIndex == 0 {
-				path = strings.TrimSuffix(path, ".json") + ".csv"
-			} else {
-				path = strings.TrimSuffix(path, ".csv") + ".json"
+			formats := len(exportFormats)
+			if v.importing {
+				formats = importFormats
+			}
+			v.setFormat((v.formatIndex + 1) % formats)
+			return false, nil
+		case "ctrl+t":
+			v.importing = !v.importing
+			v.err = nil
+			if v.importing && v.formatIndex >= importFormats {
+				v.setFormat(0)
+			}
+			return false, nil
+		case "ctrl+e":
+			if v.importing {
+				if v.mode == service.ImportMerge {
+					v.mode = service.ImportReplace
+				} else {
+					v.mode = service.ImportMerge
+				}
 			}
-			v.pathInput.SetValue(path)
 			return false, nil
 		case "enter", "ctrl+s":
+			if v.exported {
+				return true, nil
+			}
+			if v.importing {
+				return false, v.loadPreview(a)
+			}
 			return false, v.export(a)
 		case "q", "esc":
 			return true, nil
@@ -66,10 +96,16 @@ func (v *ExportView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 	case exportDoneMsg:
 		v.message = msg.message
 		v.exported = true
+		v.preview = nil
 		return false, nil
 	case exportErrMsg:
 		v.err = msg.err
 		return false, nil
+	case importPreviewMsg:
+		v.err = nil
+		v.preview = msg.preview
+		v.offset = 0
+		return false, nil
 	}
 
 	var cmd tea.Cmd
@@ -77,6 +113,35 @@ func (v *ExportView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 	return false, cmd
 }
 
+// updatePreview handles keys while an import preview is shown
+func (v *ExportView) updatePreview(msg tea.KeyMsg, a *app.App) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if v.offset > 0 {
+			v.offset--
+		}
+	case "down", "j":
+		if v.offset+previewRows < len(v.preview.Rows) {
+			v.offset++
+		}
+	case "ctrl+s":
+		return false, v.applyImport(a)
+	case "q", "esc":
+		v.preview = nil
+	}
+	return false, nil
+}
+
+// setFormat selects a format and changes the file extension to match
+func (v *ExportView) setFormat(index int) {
+	v.formatIndex = index
+	path := v.pathInput.Value()
+	for _, f := range exportFormats {
+		path = strings.TrimSuffix(path, "."+strings.ToLower(f))
+	}
+	v.pathInput.SetValue(path + "." + strings.ToLower(exportFormats[v.formatIndex]))
+}
+
 type exportDoneMsg struct {
 	message string
 }
@@ -85,6 +150,10 @@ type exportErrMsg struct {
 	err error
 }
 
+type importPreviewMsg struct {
+	preview *service.ImportPreview
+}
+
 func (v *ExportView) export(a *app.App) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
@@ -97,13 +166,10 @@ func (v *ExportView) export(a *app.App) tea.Cmd {
 			return exportErrMsg{fmt.Errorf("no subscriptions to export")}
 		}
 
+		format := service.ExportFormat(strings.ToLower(exportFormats[v.formatIndex]))
 		path := v.pathInput.Value()
 		if path == "" {
-			if v.formatIndex == 0 {
-				path = "subscriptions.csv"
-			} else {
-				path = "subscriptions.json"
-			}
+			path = "subscriptions." + string(format)
 		}
 
 		file, err := os.Create(path)
@@ -112,96 +178,71 @@ func (v *ExportView) export(a *app.App) tea.Cmd {
 		}
 		defer file.Close()
 
-		if v.formatIndex == 0 {
-			err = exportCSV(file, subs)
-		} else {
-			err = exportJSON(file, subs)
-		}
-
+		count, err := a.ExportService.Export(ctx, file, format)
 		if err != nil {
 			return exportErrMsg{err}
 		}
 
-		return exportDoneMsg{fmt.Sprintf("Exported %d subscriptions to %s", len(subs), path)}
+		if format == service.FormatICS {
+			return exportDoneMsg{fmt.Sprintf("Exported %d renewals of the next %d months to %s", count, service.DefaultICSMonths, path)}
+		}
+		if format == service.FormatXLSX {
+			return exportDoneMsg{fmt.Sprintf("Exported %d subscriptions with this year's spending to %s", count, path)}
+		}
+		if service.IsLedgerFormat(format) {
+			return exportDoneMsg{fmt.Sprintf("Exported %d transactions of this month to %s", count, path)}
+		}
+		return exportDoneMsg{fmt.Sprintf("Exported %d subscriptions to %s", count, path)}
 	}
 }
 
-func exportCSV(file *os.File, subs []db.Subscription) error {
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Header
-	header := []string{"ID", "Name", "Amount", "Currency", "Billing Cycle", "Next Renewal Date", "Created At", "Updated At"}
-	if err := writer.Write(header); err != nil {
-		return err
-	}
-
-	// Rows
-	for _, s := range subs {
-		renewalDate := ""
-		if s.NextRenewalDate.Valid {
-			renewalDate = s.NextRenewalDate.String
+// loadPreview reads the file and shows what importing it would do
+func (v *ExportView) loadPreview(a *app.App) tea.Cmd {
+	format := service.ExportFormat(strings.ToLower(exportFormats[v.formatIndex]))
+	path, mode := v.pathInput.Value(), v.mode
+	return func() tea.Msg {
+		file, err := os.Open(path)
+		if err != nil {
+			return exportErrMsg{fmt.Errorf("failed to open file: %w", err)}
 		}
+		defer file.Close()
 
-		row := []string{
-			fmt.Sprintf("%d", s.ID),
-			s.Name,
-			fmt.Sprintf("%.2f", s.Amount),
-			s.Currency,
-			s.BillingCycle,
-			renewalDate,
-			s.CreatedAt,
-			s.UpdatedAt,
-		}
-		if err := writer.Write(row); err != nil {
-			return err
+		preview, err := a.ImportService.Preview(context.Background(), file, format, mode)
+		if err != nil {
+			return exportErrMsg{err}
 		}
+		return importPreviewMsg{preview}
 	}
-
-	return nil
 }
 
-type exportSubscription struct {
-	ID              int64   `json:"id"`
-	Name            string  `json:"name"`
-	Amount          float64 `json:"amount"`
-	Currency        string  `json:"currency"`
-	BillingCycle    string  `json:"billing_cycle"`
-	NextRenewalDate string  `json:"next_renewal_date,omitempty"`
-	CreatedAt       string  `json:"created_at"`
-	UpdatedAt       string  `json:"updated_at"`
-}
-
-func exportJSON(file *os.File, subs []db.Subscription) error {
-	var exportData []exportSubscription
-
-	for _, s := range subs {
-		renewalDate := ""
-		if s.NextRenewalDate.Valid {
-			renewalDate = s.NextRenewalDate.String
+// applyImport imports the valid rows of the preview
+func (v *ExportView) applyImport(a *app.App) tea.Cmd {
+	preview, path := v.preview, v.pathInput.Value()
+	return func() tea.Msg {
+		result, err := a.ImportService.Apply(context.Background(), preview)
+		if err != nil {
+			return exportErrMsg{err}
 		}
-
-		exportData = append(exportData, exportSubscription{
-			ID:              s.ID,
-			Name:            s.Name,
-			Amount:          s.Amount,
-			Currency:        s.Currency,
-			BillingCycle:    s.BillingCycle,
-			NextRenewalDate: renewalDate,
-			CreatedAt:       s.CreatedAt,
-			UpdatedAt:       s.UpdatedAt,
-		})
+		message := fmt.Sprintf("Imported %d subscriptions from %s: %d created, %d updated",
+			result.Created+result.Updated, path, result.Created, result.Updated)
+		if result.Deleted > 0 {
+			message += fmt.Sprintf(", %d deleted", result.Deleted)
+		}
+		if result.Skipped > 0 {
+			message += fmt.Sprintf(", %d skipped", result.Skipped)
+		}
+		return exportDoneMsg{message}
 	}
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(exportData)
 }
 
 func (v *ExportView) View() string {
 	var b strings.Builder
 
-	b.WriteString(TitleStyle.Render("Export Subscriptions") + "\n\n")
+	if v.importing {
+		b.WriteString(TitleStyle.Render("Import Subscriptions") + "\n\n")
+	} else {
+		b.WriteString(TitleStyle.Render("Export Subscriptions") + "\n\n")
+	}
 
 	if v.err != nil {
 		b.WriteString(ErrorStyle.Render("Error: "+v.err.Error()) + "\n\n")
@@ -213,21 +254,87 @@ func (v *ExportView) View() string {
 		return BoxStyle.Render(b.String())
 	}
 
+	if v.preview != nil {
+		b.WriteString(v.viewPreview())
+		return BoxStyle.Render(b.String())
+	}
+
 	// Format selector
+	formats := exportFormats
+	if v.importing {
+		formats = exportFormats[:importFormats]
+	}
 	formatStr := "Format: "
-	for i, f := range exportFormats {
+	for i, f := range formats {
 		if i == v.formatIndex {
 			formatStr += SelectedItemStyle.Render("[" + f + "]")
 		} else {
 			formatStr += " " + f + " "
 		}
 	}
-	b.WriteString(formatStr + "\n\n")
+	b.WriteString(formatStr + "\n")
+
+	if v.importing {
+		modeStr := "Mode:   "
+		for _, mode := range []service.ImportMode{service.ImportMerge, service.ImportReplace} {
+			if mode == v.mode {
+				modeStr += SelectedItemStyle.Render("[" + string(mode) + "]")
+			} else {
+				modeStr += " " + string(mode) + " "
+			}
+		}
+		b.WriteString(modeStr + "\n")
+		if v.mode == service.ImportMerge {
+			b.WriteString(HelpStyle.Render("Updates subscriptions with the same name and adds the others") + "\n")
+		} else {
+			b.WriteString(WarningStyle.Render("Deletes every subscription before importing") + "\n")
+		}
+	}
+	b.WriteString("\n")
 
 	// Path input
 	b.WriteString(v.pathInput.View() + "\n\n")
 
-	b.WriteString(HelpStyle.Render("[tab] change format  [enter] export  [q/esc] cancel"))
+	if v.importing {
+		b.WriteString(HelpStyle.Render("[tab] change format  [ctrl+e] change mode  [enter] preview  [ctrl+t] export instead  [q/esc] cancel"))
+	} else {
+		b.WriteString(HelpStyle.Render("[tab] change format  [enter] export  [ctrl+t] import instead  [q/esc] cancel"))
+	}
 
 	return BoxStyle.Render(b.String())
 }
+
+// viewPreview renders what the import will do with each row
+func (v *ExportView) viewPreview() string {
+	var b strings.Builder
+
+	b.WriteString(TableHeaderStyle.Render(fmt.Sprintf("%-5s %-7s %-24s %14s %-10s %-10s", "LINE", "ACTION", "NAME", "AMOUNT", "CYCLE", "RENEWAL")) + "\n")
+	end := min(v.offset+previewRows, len(v.preview.Rows))
+	for _, row := range v.preview.Rows[v.offset:end] {
+		if row.Err != nil {
+			name := truncate(row.Subscription.Name, 24)
+			b.WriteString(ErrorStyle.Render(fmt.Sprintf("%-5d %-7s %-24s %s", row.Line, row.Action, name, row.Err.Error())) + "\n")
+			continue
+		}
+		line := fmt.Sprintf("%-5d %-7s %-24s %10.2f %-3s %-10s %-10s", row.Line, row.Action, truncate(row.Input.Name, 24),
+			row.Input.Amount, row.Input.Currency, row.Input.BillingCycle, row.Input.NextRenewalDate)
+		if row.Action == service.ImportUpdate {
+			line = YearlyStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(v.preview.Rows) > previewRows {
+		b.WriteString(HelpStyle.Render(fmt.Sprintf("rows %d-%d of %d", v.offset+1, end, len(v.preview.Rows))) + "\n")
+	}
+	if len(v.preview.Rows) == 0 {
+		b.WriteString("  No subscriptions in the file\n")
+	}
+
+	b.WriteString("\n" + v.preview.Summary() + "\n")
+	if v.preview.Errors > 0 {
+		b.WriteString(WarningStyle.Render("Rows with errors are skipped") + "\n")
+	}
+
+	b.WriteString("\n" + HelpStyle.Render("[↑/↓] scroll  [ctrl+s] import  [q/esc] back"))
+	return b.String()
+}
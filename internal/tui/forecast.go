@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"subscription-tracker/internal/app"
+	"subscription-tracker/internal/service"
+)
+
+// forecastLengths are the forecast lengths in months that ←/→ switch between
+var forecastLengths = []int{3, 6, 12, 24}
+
+// forecastBarWidth is the width of the bar of the most expensive period
+const forecastBarWidth = 24
+
+type ForecastView struct {
+	lengthIndex int
+	cursor      int
+	forecast    *service.Forecast
+	expensive   map[int]bool // Indexes of the most expensive periods
+	loading     bool
+	err         error
+}
+
+func NewForecastView() *ForecastView {
+	return &ForecastView{
+		lengthIndex: 2, // 12 months
+		loading:     true,
+	}
+}
+
+func (v *ForecastView) Init(a *app.App) tea.Cmd {
+	return v.loadForecast(a)
+}
+
+func (v *ForecastView) loadForecast(a *app.App) tea.Cmd {
+	months := forecastLengths[v.lengthIndex]
+	return func() tea.Msg {
+		forecast, err := a.SpendingService.Forecast(context.Background(), months)
+		if err != nil {
+			return forecastErrMsg{err}
+		}
+		return forecastLoadedMsg{forecast}
+	}
+}
+
+type forecastLoadedMsg struct {
+	forecast *service.Forecast
+}
+
+type forecastErrMsg struct {
+	err error
+}
+
+func (v *ForecastView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "left", "h":
+			if v.lengthIndex > 0 {
+				v.lengthIndex--
+				v.loading = true
+				return false, v.loadForecast(a)
+			}
+		case "right", "l":
+			if v.lengthIndex < len(forecastLengths)-1 {
+				v.lengthIndex++
+				v.loading = true
+				return false, v.loadForecast(a)
+			}
+		case "up", "k":
+			if v.cursor > 0 {
+				v.cursor--
+			}
+		case "down", "j":
+			if v.forecast != nil && v.cursor < len(v.forecast.Periods)-1 {
+				v.cursor++
+			}
+		case "q", "esc":
+			return true, nil
+		}
+	case forecastLoadedMsg:
+		v.loading = false
+		v.err = nil
+		v.forecast = msg.forecast
+		v.cursor = min(v.cursor, len(v.forecast.Periods)-1)
+		// The most expensive periods are highlighted, unless every period costs the same
+		v.expensive = make(map[int]bool)
+		for _, top := range v.forecast.MostExpensive(3) {
+			if top.Total <= v.forecast.Average {
+				break
+			}
+			for i, p := range v.forecast.Periods {
+				if p.Year == top.Year && p.Month == top.Month {
+					v.expensive[i] = true
+				}
+			}
+		}
+		return false, nil
+	case forecastErrMsg:
+		v.loading = false
+		v.err = msg.err
+		return false, nil
+	}
+	return false, nil
+}
+
+func (v *ForecastView) View() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render(fmt.Sprintf("Forecast for the Next %d Months", forecastLengths[v.lengthIndex])) + "\n")
+	if v.forecast != nil && len(v.forecast.Periods) > 0 {
+		first, last := v.forecast.Periods[0], v.forecast.Periods[len(v.forecast.Periods)-1]
+		b.WriteString(SubtitleStyle.Render(fmt.Sprintf("%s - %s",
+			first.PeriodStart.Format("Jan 2, 2006"), last.PeriodEnd.Format("Jan 2, 2006"))) + "\n")
+	}
+	b.WriteString("\n")
+
+	if v.loading {
+		b.WriteString("Loading...\n")
+		return BoxStyle.Render(b.String())
+	}
+	if v.err != nil {
+		b.WriteString(ErrorStyle.Render("Error: "+v.err.Error()) + "\n\n")
+	}
+
+	if v.forecast != nil {
+		var highest float64
+		for _, p := range v.forecast.Periods {
+			highest = max(highest, p.Total)
+		}
+
+		b.WriteString(TableHeaderStyle.Render(fmt.Sprintf("  %-9s %10s %11s", "Period", "Total", "Cumulative")) + "\n")
+		for i, p := range v.forecast.Periods {
+			bar := 0
+			if highest > 0 {
+				bar = int(p.Total / highest * forecastBarWidth)
+			}
+			line := fmt.Sprintf("%-9s %10.2f %11.2f  %s",
+				time.Month(p.Month).String()[:3]+" "+fmt.Sprint(p.Year), p.Total, p.Cumulative, strings.Repeat("█", bar))
+
+			switch {
+			case i == v.cursor:
+				b.WriteString(SelectedItemStyle.Render("> "+line) + "\n")
+			case v.expensive[i]:
+				b.WriteString(WarningStyle.Render("  "+line) + "\n")
+			default:
+				b.WriteString(NormalItemStyle.Render("  "+line) + "\n")
+			}
+		}
+
+		b.WriteString("────────────────────────────────\n")
+		b.WriteString(AmountStyle.Render(fmt.Sprintf("TOTAL: %.2f %s", v.forecast.Total, v.forecast.BaseCurrency)) + "\n")
+		b.WriteString(SubtitleStyle.Render(fmt.Sprintf("Average per period: %.2f %s", v.forecast.Average, v.forecast.BaseCurrency)) + "\n")
+
+		// Charges of the selected period
+		if v.cursor < len(v.forecast.Periods) {
+			p := v.forecast.Periods[v.cursor]
+			b.WriteString("\n" + SubtitleStyle.Render(fmt.Sprintf("%s - %s:",
+				p.PeriodStart.Format("Jan 2"), p.PeriodEnd.Format("Jan 2, 2006"))) + "\n")
+			if len(p.Charges) == 0 {
+				b.WriteString("  No charges\n")
+			}
+			for _, c := range p.Charges {
+				b.WriteString(fmt.Sprintf("  %s  %-20s %10.2f %s\n",
+					c.Date.Format("Jan 02"), c.Subscription.Name, c.Converted, v.forecast.BaseCurrency))
+			}
+		}
+
+		if len(v.forecast.MissingRates) > 0 {
+			b.WriteString(ErrorStyle.Render(fmt.Sprintf("No exchange rate for %s, counted unconverted", strings.Join(v.forecast.MissingRates, ", "))) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + HelpStyle.Render("[↑/↓] select period  [←/→] fewer/more months  [q/esc] back"))
+
+	return BoxStyle.Render(b.String())
+}
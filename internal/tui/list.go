@@ -4,11 +4,64 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
 )
 
+// newFilterInput creates the list filter input
+func newFilterInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "software #work"
+	input.CharLimit = 60
+	input.Width = 30
+	input.Prompt = "Filter (category #tag): "
+	return input
+}
+
+// parseListFilter reads a category name and a #tag from the filter input
+func parseListFilter(text string) service.SubscriptionFilter {
+	var filter service.SubscriptionFilter
+	for _, field := range strings.Fields(text) {
+		if tag, ok := strings.CutPrefix(field, "#"); ok {
+			filter.Tag = tag
+		} else {
+			filter.Category = field
+		}
+	}
+	return filter
+}
+
+// updateFilter handles keys while the list filter is being typed
+func (m Model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		m.filtering = false
+		m.filterInput.Blur()
+		m.filter = parseListFilter(m.filterInput.Value())
+		m.cursor = 0
+		return m, m.loadSubscriptions
+	case "esc":
+		// Clear the filter
+		m.filtering = false
+		m.filterInput.Blur()
+		m.filterInput.SetValue("")
+		m.filter = service.SubscriptionFilter{}
+		m.cursor = 0
+		return m, m.loadSubscriptions
+	}
+
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+	return m, cmd
+}
+
 func (m Model) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -51,21 +104,41 @@ func (m Model) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(m.subscriptions) > 0 {
 				m.view = ViewEdit
 				m.editForm = NewEditForm()
-				m.editForm.LoadSubscription(m.subscriptions[m.cursor])
-				return m, m.editForm.Init()
+				m.editForm.LoadSubscription(m.subscriptions[m.cursor], m.categories)
+				return m, tea.Batch(m.editForm.Init(), m.loadPriceHistory(m.subscriptions[m.cursor].ID))
 			}
 		case "d":
 			if len(m.subscriptions) > 0 {
 				return m, m.deleteSubscription(m.subscriptions[m.cursor].ID)
 			}
+		case "p":
+			if len(m.subscriptions) > 0 {
+				return m, m.togglePause(m.subscriptions[m.cursor])
+			}
+		case "C":
+			if len(m.subscriptions) > 0 {
+				return m, m.toggleCancel(m.subscriptions[m.cursor])
+			}
 		case "s":
 			m.view = ViewSpending
 			m.spendingView = NewSpendingView()
 			return m, m.spendingView.Init(m.app)
+		case "v":
+			m.view = ViewCalendar
+			m.calendarView = NewCalendarView()
+			return m, m.calendarView.Init(m.app)
+		case "f":
+			m.view = ViewForecast
+			m.forecastView = NewForecastView()
+			return m, m.forecastView.Init(m.app)
 		case "x":
 			m.view = ViewExport
 			m.exportView = NewExportView()
 			return m, nil
+		case "b":
+			m.view = ViewReview
+			m.reviewView = NewReviewView()
+			return m, m.reviewView.Init()
 		case "c":
 			m.view = ViewConfig
 			m.configView = NewConfigView()
@@ -74,6 +147,9 @@ func (m Model) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.view = ViewSync
 			m.syncView = NewSyncView()
 			return m, m.syncView.Init(m.app)
+		case "/":
+			m.filtering = true
+			return m, m.filterInput.Focus()
 		case "?":
 			m.view = ViewHelp
 			return m, nil
@@ -96,18 +172,34 @@ func (m Model) viewList() string {
 		b.WriteString(SuccessStyle.Render(m.message) + "\n\n")
 	}
 
+	// Budget warning
+	if m.warning != "" {
+		b.WriteString(WarningStyle.Render(m.warning) + "\n\n")
+	}
+
 	// Error
 	if m.err != nil {
 		b.WriteString(ErrorStyle.Render("Error: "+m.err.Error()) + "\n\n")
 	}
 
+	// Filter
+	if m.filtering {
+		b.WriteString(FocusedInputStyle.Render(m.filterInput.View()) + "\n\n")
+	} else if m.filterInput.Value() != "" {
+		b.WriteString(SubtitleStyle.Render("Filter: "+m.filterInput.Value()) + "\n")
+	}
+
 	// Subscriptions list
 	if len(m.subscriptions) == 0 {
-		b.WriteString(SubtitleStyle.Render("No subscriptions yet. Press 'a' to add one."))
+		if m.filterInput.Value() != "" {
+			b.WriteString(SubtitleStyle.Render("No subscriptions match the filter. Press '/' then esc to clear it."))
+		} else {
+			b.WriteString(SubtitleStyle.Render("No subscriptions yet. Press 'a' to add one."))
+		}
 	} else {
 		// Header
-		header := fmt.Sprintf("%-4s %-25s %-12s %-10s %-12s",
-			"ID", "Name", "Amount", "Cycle", "Renewal")
+		header := fmt.Sprintf("%-4s %-25s %-12s %-14s %-12s %-10s %-14s %-20s",
+			"ID", "Name", "Amount", "Cycle", "Renewal", "State", "Category", "Tags")
 		b.WriteString(TableHeaderStyle.Render(header) + "\n")
 
 		// Rows
@@ -124,12 +216,15 @@ func (m Model) viewList() string {
 				renewal = sub.NextRenewalDate.String
 			}
 
-			row := fmt.Sprintf("%-4d %-25s %-12s %-10s %-12s",
+			row := fmt.Sprintf("%-4d %-25s %-12s %-14s %-12s %-10s %-14s %-20s",
 				sub.ID,
 				truncate(sub.Name, 25),
 				fmt.Sprintf("%.2f %s", sub.Amount, sub.Currency),
 				sub.BillingCycle,
 				renewal,
+				stateLabel(sub),
+				truncate(service.CategoryName(sub, m.categories), 14),
+				truncate(sub.Tags, 20),
 			)
 
 			if i == m.cursor {
@@ -142,7 +237,7 @@ func (m Model) viewList() string {
 	}
 
 	// Help
-	help := "\n[↑/↓] navigate  [gg/G] top/bottom  [a]dd  [e]dit  [d]elete  [s]pending  e[x]port  [c]onfig  s[y]nc  [?]help  [q]uit"
+	help := "\n[↑/↓] navigate  [gg/G] top/bottom  [a]dd  [e]dit  [d]elete  [p]ause  [C]ancel  [/] filter  [s]pending  [f]orecast  calendar [v]iew  e[x]port  [b]ank statement  [c]onfig  s[y]nc  [?]help  [q]uit"
 	b.WriteString(HelpStyle.Render(help))
 
 	return BoxStyle.Render(b.String())
@@ -150,7 +245,7 @@ func (m Model) viewList() string {
 
 func (m Model) deleteSubscription(id int64) tea.Cmd {
 	return func() tea.Msg {
-		err := m.app.Queries.DeleteSubscription(context.Background(), id)
+		err := m.app.SubscriptionService.Delete(context.Background(), id)
 		if err != nil {
 			return errMsg{err}
 		}
@@ -158,6 +253,67 @@ func (m Model) deleteSubscription(id int64) tea.Cmd {
 	}
 }
 
+// togglePause pauses a subscription from today, or resumes it if it is paused or cancelled
+func (m Model) togglePause(sub db.Subscription) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		if service.IsRecurring(sub) {
+			if _, err := m.app.SubscriptionService.Pause(ctx, sub.ID, ""); err != nil {
+				return errMsg{err}
+			}
+			return successMsg{sub.Name + " paused"}
+		}
+		if _, err := m.app.SubscriptionService.Resume(ctx, sub.ID); err != nil {
+			return errMsg{err}
+		}
+		return successMsg{sub.Name + " resumed"}
+	}
+}
+
+// toggleCancel cancels a subscription from today, or resumes it if it is cancelled
+func (m Model) toggleCancel(sub db.Subscription) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		if sub.Status == service.StatusCancelled {
+			if _, err := m.app.SubscriptionService.Resume(ctx, sub.ID); err != nil {
+				return errMsg{err}
+			}
+			return successMsg{sub.Name + " resumed"}
+		}
+		if _, err := m.app.SubscriptionService.Cancel(ctx, sub.ID, ""); err != nil {
+			return errMsg{err}
+		}
+		return successMsg{sub.Name + " cancelled"}
+	}
+}
+
+// stateLabel shows the state of a subscription, with the days left of a free trial
+func stateLabel(sub db.Subscription) string {
+	if left, ok := service.TrialDaysLeft(sub, time.Now()); ok && left >= 0 {
+		return fmt.Sprintf("trial %dd", left)
+	}
+	return sub.Status
+}
+
+// trialWarning describes the free trials in subs and what they will cost, or
+// returns "" if there are none
+func trialWarning(subs []db.Subscription, today time.Time) string {
+	var warnings []string
+	for _, sub := range subs {
+		left, _ := service.TrialDaysLeft(sub, today)
+		when := fmt.Sprintf("in %d days", left)
+		switch left {
+		case 0:
+			when = "today"
+		case 1:
+			when = "tomorrow"
+		}
+		warnings = append(warnings, fmt.Sprintf("%s trial ends %s, then %.2f %s %s",
+			sub.Name, when, service.BilledAmount(sub), sub.Currency, sub.BillingCycle))
+	}
+	return strings.Join(warnings, "\n")
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s + strings.Repeat(" ", maxLen-len(s))
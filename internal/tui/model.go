@@ -2,9 +2,13 @@ package tui
 
 import (
 	"context"
+	"time"
+
 	"subscription-tracker/internal/app"
 	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -16,7 +20,10 @@ const (
 	ViewAdd
 	ViewEdit
 	ViewSpending
+	ViewForecast
+	ViewCalendar
 	ViewExport
+	ViewReview
 	ViewConfig
 	ViewSync
 	ViewHelp
@@ -27,18 +34,26 @@ type Model struct {
 	app           *app.App
 	view          View
 	subscriptions []db.Subscription
+	categories    map[int64]string // Category names by ID
+	filter        service.SubscriptionFilter
+	filterInput   textinput.Model
+	filtering     bool // Typing into filterInput
 	cursor        int
 	width         int
 	height        int
 	err           error
 	message       string
+	warning       string // Trials ending soon, or budgets pushed near or over their limit by the last change
 	pendingKey    string // For VIM key sequences like 'gg'
 
 	// Sub-models
 	addForm      *AddForm
 	editForm     *EditForm
 	spendingView *SpendingView
+	forecastView *ForecastView
+	calendarView *CalendarView
 	exportView   *ExportView
+	reviewView   *ReviewView
 	configView   *ConfigView
 	syncView     *SyncView
 }
@@ -48,10 +63,14 @@ func New(application *app.App) Model {
 	return Model{
 		app:          application,
 		view:         ViewList,
+		filterInput:  newFilterInput(),
 		addForm:      NewAddForm(),
 		editForm:     NewEditForm(),
 		spendingView: NewSpendingView(),
+		forecastView: NewForecastView(),
+		calendarView: NewCalendarView(),
 		exportView:   NewExportView(),
+		reviewView:   NewReviewView(),
 		configView:   NewConfigView(),
 		syncView:     NewSyncView(),
 	}
@@ -59,21 +78,60 @@ func New(application *app.App) Model {
 
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return m.loadSubscriptions
+	return tea.Batch(m.loadSubscriptions, m.loadEndingTrials)
 }
 
-// loadSubscriptions fetches subscriptions from the database
+// loadSubscriptions fetches the subscriptions matching the list filter from the database
 func (m Model) loadSubscriptions() tea.Msg {
-	subs, err := m.app.Queries.ListSubscriptions(context.Background())
+	ctx := context.Background()
+	subs, err := m.app.SubscriptionService.Filter(ctx, m.filter)
+	if err != nil {
+		return errMsg{err}
+	}
+	categories, err := m.app.CategoryService.Names(ctx)
+	if err != nil {
+		return errMsg{err}
+	}
+	return subscriptionsLoadedMsg{subs, categories}
+}
+
+// loadPriceHistory fetches the price changes of a subscription for the edit form
+func (m Model) loadPriceHistory(id int64) tea.Cmd {
+	return func() tea.Msg {
+		changes, err := m.app.SubscriptionService.PriceHistory(context.Background(), id)
+		if err != nil {
+			return errMsg{err}
+		}
+		return priceHistoryMsg{changes}
+	}
+}
+
+// loadEndingTrials looks up the free trials that end within the configured number of days
+func (m Model) loadEndingTrials() tea.Msg {
+	ctx := context.Background()
+	days, err := m.app.ConfigService.GetTrialWarnDays(ctx)
 	if err != nil {
 		return errMsg{err}
 	}
-	return subscriptionsLoadedMsg{subs}
+	subs, err := m.app.SubscriptionService.EndingTrials(ctx, days)
+	if err != nil {
+		return errMsg{err}
+	}
+	return endingTrialsMsg{subs}
 }
 
 // Messages
 type subscriptionsLoadedMsg struct {
 	subscriptions []db.Subscription
+	categories    map[int64]string
+}
+
+type endingTrialsMsg struct {
+	subscriptions []db.Subscription
+}
+
+type priceHistoryMsg struct {
+	changes []db.PriceChange
 }
 
 type errMsg struct {
@@ -88,6 +146,11 @@ type successMsg struct {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// The filter input takes every key while it is open
+		if m.view == ViewList && m.filtering {
+			return m.updateFilter(msg)
+		}
+
 		// Global key bindings
 		switch msg.String() {
 		case "ctrl+c", "q":
@@ -106,15 +169,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case subscriptionsLoadedMsg:
 		m.subscriptions = msg.subscriptions
+		m.categories = msg.categories
+		if m.cursor >= len(m.subscriptions) {
+			m.cursor = max(len(m.subscriptions)-1, 0)
+		}
 		m.err = nil
 		return m, nil
 
+	case endingTrialsMsg:
+		m.warning = trialWarning(msg.subscriptions, time.Now())
+		return m, nil
+
 	case errMsg:
 		m.err = msg.err
 		return m, nil
 
 	case successMsg:
 		m.message = msg.message
+		m.warning = ""
 		m.view = ViewList
 		return m, m.loadSubscriptions
 	}
@@ -129,8 +201,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateEdit(msg)
 	case ViewSpending:
 		return m.updateSpending(msg)
+	case ViewForecast:
+		return m.updateForecast(msg)
+	case ViewCalendar:
+		return m.updateCalendar(msg)
 	case ViewExport:
 		return m.updateExport(msg)
+	case ViewReview:
+		return m.updateReview(msg)
 	case ViewConfig:
 		return m.updateConfig(msg)
 	case ViewSync:
@@ -153,8 +231,14 @@ func (m Model) View() string {
 		return m.viewEdit()
 	case ViewSpending:
 		return m.viewSpending()
+	case ViewForecast:
+		return m.viewForecast()
+	case ViewCalendar:
+		return m.viewCalendar()
 	case ViewExport:
 		return m.viewExport()
+	case ViewReview:
+		return m.viewReview()
 	case ViewConfig:
 		return m.viewConfig()
 	case ViewSync:
@@ -0,0 +1,321 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"subscription-tracker/internal/app"
+	"subscription-tracker/internal/service"
+)
+
+// candidateDecision is what the user chose to do with a detected charge
+type candidateDecision int
+
+const (
+	decisionPending candidateDecision = iota
+	decisionAccepted
+	decisionRejected
+)
+
+// ReviewView finds recurring charges in a bank statement and lets the user
+// accept or reject each one before the accepted ones are added as subscriptions
+type ReviewView struct {
+	inputs     []textinput.Model // Statement path and optional CSV columns
+	focusIndex int
+	candidates []service.RecurringCandidate // nil until a statement is read
+	decisions  []candidateDecision
+	cursor     int
+	offset     int // First candidate shown
+	message    string
+	err        error
+	done       bool
+}
+
+const (
+	reviewPath = iota
+	reviewColumns
+)
+
+// reviewRows is how many candidates are shown at once
+const reviewRows = 12
+
+func NewReviewView() *ReviewView {
+	inputs := make([]textinput.Model, 2)
+
+	inputs[reviewPath] = textinput.New()
+	inputs[reviewPath].Placeholder = "statement.ofx"
+	inputs[reviewPath].Prompt = "Statement: "
+	inputs[reviewPath].CharLimit = 200
+	inputs[reviewPath].Width = 40
+	inputs[reviewPath].Focus()
+
+	inputs[reviewColumns] = textinput.New()
+	inputs[reviewColumns].Placeholder = "guessed from the header"
+	inputs[reviewColumns].Prompt = "CSV columns (date,payee,amount): "
+	inputs[reviewColumns].CharLimit = 100
+	inputs[reviewColumns].Width = 30
+
+	return &ReviewView{inputs: inputs}
+}
+
+func (v *ReviewView) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+type candidatesMsg struct {
+	candidates []service.RecurringCandidate
+}
+
+type reviewDoneMsg struct {
+	message string
+}
+
+type reviewErrMsg struct {
+	err error
+}
+
+func (v *ReviewView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if v.done {
+			return true, nil
+		}
+		if v.candidates != nil {
+			return v.updateCandidates(msg, a)
+		}
+		switch msg.String() {
+		case "tab", "down", "shift+tab", "up":
+			v.inputs[v.focusIndex].Blur()
+			v.focusIndex = (v.focusIndex + 1) % len(v.inputs)
+			return false, v.inputs[v.focusIndex].Focus()
+		case "enter":
+			return false, v.detect(a)
+		case "esc":
+			return true, nil
+		}
+	case candidatesMsg:
+		v.err = nil
+		v.candidates = msg.candidates
+		v.decisions = make([]candidateDecision, len(msg.candidates))
+		v.cursor, v.offset = 0, 0
+		return false, nil
+	case reviewDoneMsg:
+		v.message = msg.message
+		v.done = true
+		return false, nil
+	case reviewErrMsg:
+		v.err = msg.err
+		return false, nil
+	}
+
+	var cmd tea.Cmd
+	v.inputs[v.focusIndex], cmd = v.inputs[v.focusIndex].Update(msg)
+	return false, cmd
+}
+
+// updateCandidates handles keys while the detected charges are reviewed
+func (v *ReviewView) updateCandidates(msg tea.KeyMsg, a *app.App) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case "down", "j":
+		if v.cursor < len(v.candidates)-1 {
+			v.cursor++
+		}
+	case "a", "y":
+		v.decide(decisionAccepted)
+	case "r", "n":
+		v.decide(decisionRejected)
+	case " ":
+		if len(v.decisions) > 0 {
+			if v.decisions[v.cursor] == decisionAccepted {
+				v.decisions[v.cursor] = decisionRejected
+			} else {
+				v.decisions[v.cursor] = decisionAccepted
+			}
+		}
+	case "A":
+		for i, c := range v.candidates {
+			if c.ExistingID == 0 {
+				v.decisions[i] = decisionAccepted
+			}
+		}
+	case "ctrl+s":
+		return false, v.accept(a)
+	case "esc":
+		v.candidates = nil
+	}
+
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+reviewRows {
+		v.offset = v.cursor - reviewRows + 1
+	}
+	return false, nil
+}
+
+// decide records a decision for the selected candidate and moves on to the next one
+func (v *ReviewView) decide(decision candidateDecision) {
+	if len(v.candidates) == 0 {
+		return
+	}
+	v.decisions[v.cursor] = decision
+	if v.cursor < len(v.candidates)-1 {
+		v.cursor++
+	}
+}
+
+// detect reads the statement and finds its recurring charges
+func (v *ReviewView) detect(a *app.App) tea.Cmd {
+	path := strings.TrimSpace(v.inputs[reviewPath].Value())
+	var mapping service.StatementMapping
+	if columns := strings.TrimSpace(v.inputs[reviewColumns].Value()); columns != "" {
+		parts := strings.Split(columns, ",")
+		if len(parts) != 3 {
+			return func() tea.Msg {
+				return reviewErrMsg{fmt.Errorf("give the date, payee and amount columns separated by commas")}
+			}
+		}
+		mapping.Date, mapping.Payee, mapping.Amount = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+	}
+
+	return func() tea.Msg {
+		if path == "" {
+			return reviewErrMsg{fmt.Errorf("statement path is required")}
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return reviewErrMsg{fmt.Errorf("failed to open file: %w", err)}
+		}
+		defer file.Close()
+
+		candidates, err := a.StatementService.Detect(context.Background(), file, service.StatementFormatOf(path), mapping, 0)
+		if err != nil {
+			return reviewErrMsg{err}
+		}
+		if candidates == nil {
+			candidates = []service.RecurringCandidate{}
+		}
+		return candidatesMsg{candidates}
+	}
+}
+
+// accept adds the accepted candidates as subscriptions
+func (v *ReviewView) accept(a *app.App) tea.Cmd {
+	var accepted []service.RecurringCandidate
+	for i, c := range v.candidates {
+		if v.decisions[i] == decisionAccepted {
+			accepted = append(accepted, c)
+		}
+	}
+	return func() tea.Msg {
+		if len(accepted) == 0 {
+			return reviewErrMsg{fmt.Errorf("no candidates accepted; press a to accept one")}
+		}
+		for _, c := range accepted {
+			if _, err := a.StatementService.Accept(context.Background(), c); err != nil {
+				return reviewErrMsg{fmt.Errorf("failed to add %s: %w", c.Name, err)}
+			}
+		}
+		return reviewDoneMsg{fmt.Sprintf("Added %d subscriptions from the statement", len(accepted))}
+	}
+}
+
+func (v *ReviewView) View() string {
+	var b strings.Builder
+
+	b.WriteString(TitleStyle.Render("Find Recurring Charges") + "\n\n")
+
+	if v.err != nil {
+		b.WriteString(ErrorStyle.Render("Error: "+v.err.Error()) + "\n\n")
+	}
+
+	if v.done {
+		b.WriteString(SuccessStyle.Render(v.message) + "\n\n")
+		b.WriteString(HelpStyle.Render("[q/esc] back"))
+		return BoxStyle.Render(b.String())
+	}
+
+	if v.candidates != nil {
+		b.WriteString(v.viewCandidates())
+		return BoxStyle.Render(b.String())
+	}
+
+	b.WriteString(SubtitleStyle.Render("Reads an OFX/QFX, QIF or CSV bank statement and lists the charges that repeat") + "\n\n")
+	for _, input := range v.inputs {
+		b.WriteString(input.View() + "\n")
+	}
+	b.WriteString("\n" + HelpStyle.Render("[tab] next field  [enter] find charges  [esc] cancel"))
+
+	return BoxStyle.Render(b.String())
+}
+
+// viewCandidates renders the detected charges with their decisions
+func (v *ReviewView) viewCandidates() string {
+	var b strings.Builder
+
+	if len(v.candidates) == 0 {
+		b.WriteString("No recurring charges found in the statement\n")
+		b.WriteString("\n" + HelpStyle.Render("[esc] another file  [q] back"))
+		return b.String()
+	}
+
+	b.WriteString(TableHeaderStyle.Render(fmt.Sprintf("    %-24s %14s %-10s %-10s %7s  %-10s", "NAME", "AMOUNT", "CYCLE", "RENEWAL", "CHARGES", "SINCE")) + "\n")
+	end := min(v.offset+reviewRows, len(v.candidates))
+	accepted := 0
+	for i := v.offset; i < end; i++ {
+		c := v.candidates[i]
+		mark := "[ ]"
+		switch v.decisions[i] {
+		case decisionAccepted:
+			mark = "[✓]"
+		case decisionRejected:
+			mark = "[✗]"
+		}
+		row := fmt.Sprintf("%s %-24s %10.2f %-3s %-10s %-10s %7d  %-10s", mark, truncate(c.Name, 24),
+			c.Amount, c.Currency, c.BillingCycle, c.NextRenewalDate, c.Occurrences, c.FirstCharge.Format("2006-01-02"))
+		if c.ExistingID != 0 {
+			row += " (tracked)"
+		}
+
+		switch {
+		case i == v.cursor:
+			row = SelectedItemStyle.Render(row)
+		case v.decisions[i] == decisionRejected || c.ExistingID != 0:
+			row = HelpStyle.Render(row)
+		default:
+			row = NormalItemStyle.Render(row)
+		}
+		b.WriteString(row + "\n")
+	}
+	for _, d := range v.decisions {
+		if d == decisionAccepted {
+			accepted++
+		}
+	}
+	if len(v.candidates) > reviewRows {
+		b.WriteString(HelpStyle.Render(fmt.Sprintf("candidates %d-%d of %d", v.offset+1, end, len(v.candidates))) + "\n")
+	}
+
+	// Charges behind the selected candidate
+	c := v.candidates[v.cursor]
+	var dates []string
+	for _, txn := range c.Transactions {
+		dates = append(dates, txn.Date.Format("2006-01-02"))
+	}
+	if len(dates) > 6 {
+		dates = append([]string{"…"}, dates[len(dates)-6:]...)
+	}
+	b.WriteString("\n" + SubtitleStyle.Render(fmt.Sprintf("%s, charged on %s", c.Transactions[len(c.Transactions)-1].Payee, strings.Join(dates, ", "))) + "\n")
+
+	b.WriteString(fmt.Sprintf("\n%d of %d accepted\n", accepted, len(v.candidates)))
+	b.WriteString("\n" + HelpStyle.Render("[↑/↓] move  [a]ccept  [r]eject  [space] toggle  [A] accept all untracked  [ctrl+s] add accepted  [esc] another file"))
+	return b.String()
+}
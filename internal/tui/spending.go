@@ -3,12 +3,13 @@ package tui
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"subscription-tracker/internal/app"
-	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
 )
 
 type SpendingView struct {
@@ -19,10 +20,18 @@ type SpendingView struct {
 	periodEnd     time.Time
 	monthlyTotal  float64
 	yearlyTotal   float64
-	monthlySubs   []db.Subscription
-	yearlySubs    []db.Subscription
+	otherTotal    float64
+	averageTotal  float64
+	baseCurrency  string
+	missingRates  []string
+	monthlySubs   []service.Charge
+	yearlySubs    []service.Charge
+	otherCharges  []service.Charge
+	categories    []service.CategoryTotal
+	budgets       []service.BudgetStatus
 	monthlySalary float64
 	remaining     float64
+	message       string // Result of writing a report
 	loading       bool
 	err           error
 }
@@ -49,11 +58,32 @@ func (v *SpendingView) loadSpending(a *app.App) tea.Cmd {
 			return spendingErrMsg{err}
 		}
 
+		// Charges of other cycles are listed individually, since a weekly
+		// subscription can charge several times in one period
+		var monthlySubs, yearlySubs, otherCharges []service.Charge
+		for _, charge := range summary.Charges {
+			switch charge.Subscription.BillingCycle {
+			case service.CycleMonthly:
+				monthlySubs = append(monthlySubs, charge)
+			case service.CycleYearly:
+				yearlySubs = append(yearlySubs, charge)
+			default:
+				otherCharges = append(otherCharges, charge)
+			}
+		}
+
 		return spendingLoadedMsg{
-			monthlySubs:   summary.MonthlyItems,
-			yearlySubs:    summary.YearlyItems,
+			monthlySubs:   monthlySubs,
+			yearlySubs:    yearlySubs,
+			otherCharges:  otherCharges,
+			categories:    summary.Categories,
+			budgets:       summary.Budgets,
+			baseCurrency:  summary.BaseCurrency,
+			missingRates:  summary.MissingRates,
 			monthlyTotal:  summary.MonthlyTotal,
 			yearlyTotal:   summary.YearlyTotal,
+			otherTotal:    summary.OtherTotal,
+			averageTotal:  summary.AverageMonthly,
 			cutoffDay:     summary.CutoffDay,
 			periodStart:   summary.PeriodStart,
 			periodEnd:     summary.PeriodEnd,
@@ -64,10 +94,17 @@ func (v *SpendingView) loadSpending(a *app.App) tea.Cmd {
 }
 
 type spendingLoadedMsg struct {
-	monthlySubs   []db.Subscription
-	yearlySubs    []db.Subscription
+	monthlySubs   []service.Charge
+	yearlySubs    []service.Charge
+	otherCharges  []service.Charge
+	categories    []service.CategoryTotal
+	budgets       []service.BudgetStatus
+	baseCurrency  string
+	missingRates  []string
 	monthlyTotal  float64
 	yearlyTotal   float64
+	otherTotal    float64
+	averageTotal  float64
 	cutoffDay     int
 	periodStart   time.Time
 	periodEnd     time.Time
@@ -79,6 +116,36 @@ type spendingErrMsg struct {
 	err error
 }
 
+type reportWrittenMsg struct {
+	message string
+}
+
+// writeReport writes an HTML report of the shown billing month, or of its whole year,
+// to the working directory
+func (v *SpendingView) writeReport(a *app.App, wholeYear bool) tea.Cmd {
+	opts := service.ReportOptions{Year: v.year, Month: v.month}
+	path := fmt.Sprintf("spending-%d-%02d.html", v.year, v.month)
+	if wholeYear {
+		opts.Month = 0
+		path = fmt.Sprintf("spending-%d.html", v.year)
+	}
+	return func() tea.Msg {
+		report, err := a.ReportService.Build(context.Background(), opts)
+		if err != nil {
+			return spendingErrMsg{fmt.Errorf("failed to build report: %w", err)}
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return spendingErrMsg{fmt.Errorf("failed to create file: %w", err)}
+		}
+		defer file.Close()
+		if err := report.Write(file, service.ReportHTML); err != nil {
+			return spendingErrMsg{err}
+		}
+		return reportWrittenMsg{fmt.Sprintf("Wrote %s to %s", report.Title, path)}
+	}
+}
+
 func (v *SpendingView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -99,15 +166,31 @@ func (v *SpendingView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 			}
 			v.loading = true
 			return false, v.loadSpending(a)
+		case "w":
+			return false, v.writeReport(a, false)
+		case "W":
+			return false, v.writeReport(a, true)
 		case "q", "esc":
 			return true, nil
 		}
+	case reportWrittenMsg:
+		v.err = nil
+		v.message = msg.message
+		return false, nil
 	case spendingLoadedMsg:
 		v.loading = false
+		v.message = ""
 		v.monthlySubs = msg.monthlySubs
 		v.yearlySubs = msg.yearlySubs
+		v.otherCharges = msg.otherCharges
+		v.categories = msg.categories
+		v.budgets = msg.budgets
+		v.baseCurrency = msg.baseCurrency
+		v.missingRates = msg.missingRates
 		v.monthlyTotal = msg.monthlyTotal
 		v.yearlyTotal = msg.yearlyTotal
+		v.otherTotal = msg.otherTotal
+		v.averageTotal = msg.averageTotal
 		v.cutoffDay = msg.cutoffDay
 		v.periodStart = msg.periodStart
 		v.periodEnd = msg.periodEnd
@@ -150,37 +233,70 @@ func (v *SpendingView) View() string {
 	// Monthly subscriptions
 	if len(v.monthlySubs) > 0 {
 		b.WriteString(SubtitleStyle.Render("Monthly Subscriptions:") + "\n")
-		for _, s := range v.monthlySubs {
-			b.WriteString(fmt.Sprintf("  %s: %.2f %s\n", s.Name, s.Amount, s.Currency))
+		for _, c := range v.monthlySubs {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", c.Subscription.Name, v.formatCharge(c)))
 		}
-		b.WriteString(fmt.Sprintf("  %s\n\n", AmountStyle.Render(fmt.Sprintf("Subtotal: %.2f", v.monthlyTotal))))
+		b.WriteString(fmt.Sprintf("  %s\n\n", AmountStyle.Render(fmt.Sprintf("Subtotal: %.2f %s", v.monthlyTotal, v.baseCurrency))))
 	}
 
 	// Yearly subscriptions renewing this period
 	if len(v.yearlySubs) > 0 {
 		b.WriteString(YearlyStyle.Render("Yearly Subscriptions Renewing This Period:") + "\n")
-		for _, s := range v.yearlySubs {
-			renewal := ""
-			if s.NextRenewalDate.Valid {
-				renewal = s.NextRenewalDate.String
-			}
-			b.WriteString(fmt.Sprintf("  %s: %.2f %s (renews %s)\n", s.Name, s.Amount, s.Currency, renewal))
+		for _, c := range v.yearlySubs {
+			b.WriteString(fmt.Sprintf("  %s: %s (renews %s)\n", c.Subscription.Name, v.formatCharge(c), c.Date.Format("2006-01-02")))
 		}
-		b.WriteString(fmt.Sprintf("  %s\n\n", AmountStyle.Render(fmt.Sprintf("Subtotal: %.2f", v.yearlyTotal))))
+		b.WriteString(fmt.Sprintf("  %s\n\n", AmountStyle.Render(fmt.Sprintf("Subtotal: %.2f %s", v.yearlyTotal, v.baseCurrency))))
 	}
 
-	if len(v.monthlySubs) == 0 && len(v.yearlySubs) == 0 {
+	// Charges of subscriptions on other cycles
+	if len(v.otherCharges) > 0 {
+		b.WriteString(YearlyStyle.Render("Other Billing Cycles:") + "\n")
+		for _, c := range v.otherCharges {
+			b.WriteString(fmt.Sprintf("  %s: %s (%s, charged %s)\n",
+				c.Subscription.Name, v.formatCharge(c), c.Subscription.BillingCycle, c.Date.Format("2006-01-02")))
+		}
+		b.WriteString(fmt.Sprintf("  %s\n\n", AmountStyle.Render(fmt.Sprintf("Subtotal: %.2f %s", v.otherTotal, v.baseCurrency))))
+	}
+
+	if len(v.monthlySubs) == 0 && len(v.yearlySubs) == 0 && len(v.otherCharges) == 0 {
 		b.WriteString(SubtitleStyle.Render("No subscriptions for this period.") + "\n\n")
 	}
 
 	// Total
-	total := v.monthlyTotal + v.yearlyTotal
+	total := v.monthlyTotal + v.yearlyTotal + v.otherTotal
 	b.WriteString("────────────────────────────────\n")
-	b.WriteString(AmountStyle.Render(fmt.Sprintf("TOTAL SUBSCRIPTIONS: %.2f", total)) + "\n")
+	b.WriteString(AmountStyle.Render(fmt.Sprintf("TOTAL SUBSCRIPTIONS: %.2f %s", total, v.baseCurrency)) + "\n")
 
-	if v.yearlyTotal > 0 {
-		avgMonthly := v.monthlyTotal + (v.yearlyTotal / 12)
-		b.WriteString(SubtitleStyle.Render(fmt.Sprintf("Average Monthly (yearly prorated): %.2f", avgMonthly)) + "\n")
+	if v.yearlyTotal > 0 || v.otherTotal > 0 {
+		b.WriteString(SubtitleStyle.Render(fmt.Sprintf("Average Monthly (other cycles prorated): %.2f %s", v.averageTotal, v.baseCurrency)) + "\n")
+	}
+
+	// Breakdown by category
+	if len(v.categories) > 0 {
+		b.WriteString("\n" + SubtitleStyle.Render("By Category:") + "\n")
+		for _, c := range v.categories {
+			b.WriteString(fmt.Sprintf("  %-16s %10.2f %s\n", c.Category, c.Total, v.baseCurrency))
+		}
+	}
+
+	// Budgets, flagged when close to or over their limit
+	if len(v.budgets) > 0 {
+		b.WriteString("\n" + SubtitleStyle.Render("Budgets:") + "\n")
+		for _, budget := range v.budgets {
+			line := fmt.Sprintf("  %-16s %10.2f / %.2f %s (%.0f%%)", budget.Label(), budget.Used, budget.Limit, v.baseCurrency, budget.Percent)
+			switch budget.State {
+			case service.BudgetOver:
+				b.WriteString(ErrorStyle.Render(line+"  OVER BUDGET") + "\n")
+			case service.BudgetNear:
+				b.WriteString(WarningStyle.Render(line+"  NEAR LIMIT") + "\n")
+			default:
+				b.WriteString(line + "\n")
+			}
+		}
+	}
+
+	if len(v.missingRates) > 0 {
+		b.WriteString(ErrorStyle.Render(fmt.Sprintf("No exchange rate for %s, counted unconverted", strings.Join(v.missingRates, ", "))) + "\n")
 	}
 
 	// Show remaining money if salary is configured
@@ -194,7 +310,20 @@ func (v *SpendingView) View() string {
 		}
 	}
 
-	b.WriteString("\n" + HelpStyle.Render("[←/→] change month  [q/esc] back"))
+	if v.message != "" {
+		b.WriteString("\n" + SuccessStyle.Render(v.message) + "\n")
+	}
+
+	b.WriteString("\n" + HelpStyle.Render("[←/→] change month  [w] HTML report  [W] report of the year  [q/esc] back"))
 
 	return BoxStyle.Render(b.String())
 }
+
+// formatCharge shows a charge in its own currency, followed by the converted
+// amount when that differs from the base currency
+func (v *SpendingView) formatCharge(c service.Charge) string {
+	if c.Subscription.Currency == v.baseCurrency {
+		return fmt.Sprintf("%.2f %s", c.Amount, c.Subscription.Currency)
+	}
+	return fmt.Sprintf("%.2f %s (%.2f %s)", c.Amount, c.Subscription.Currency, c.Converted, v.baseCurrency)
+}
@@ -63,6 +63,9 @@ var (
 	SuccessStyle = lipgloss.NewStyle().
 			Foreground(successColor)
 
+	WarningStyle = lipgloss.NewStyle().
+			Foreground(warningColor)
+
 	// Table styles
 	TableHeaderStyle = lipgloss.NewStyle().
 				Bold(true).
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -12,22 +13,69 @@ import (
 )
 
 type SyncView struct {
-	passwordInput textinput.Model
-	tokenInput    textinput.Model
-	gistIDInput   textinput.Model
-	focusIndex    int
-	message       string
-	err           error
-	loading       bool
-	gistConfig    *service.GistConfig
+	passwordInput       textinput.Model
+	tokenInput          textinput.Model
+	gistIDInput         textinput.Model
+	dirInput            textinput.Model
+	webdavURLInput      textinput.Model
+	webdavUserInput     textinput.Model
+	webdavPasswordInput textinput.Model
+	s3EndpointInput     textinput.Model
+	s3RegionInput       textinput.Model
+	s3BucketInput       textinput.Model
+	s3PrefixInput       textinput.Model
+	s3AccessKeyInput    textinput.Model
+	s3SecretKeyInput    textinput.Model
+	gitRepoInput        textinput.Model
+	gitBranchInput      textinput.Model
+	s3PathStyle         bool
+	s3Versioned         bool
+	gitPlaintext        bool
+	backendIndex        int
+	focusIndex          int
+	message             string
+	err                 error
+	loading             bool
+	syncConfig          *service.SyncConfig
+	merge               *service.SyncMerge // Pulled data with conflicts to resolve; nil otherwise
+	cursor              int                // Selected conflict
+	offset              int                // First conflict shown
 }
 
+// conflictRows is how many conflicts are shown at once
+const conflictRows = 8
+
 const (
 	syncFocusPassword = iota
+	syncFocusBackend
 	syncFocusToken
 	syncFocusGistID
+	syncFocusDir
+	syncFocusWebDAVURL
+	syncFocusWebDAVUser
+	syncFocusWebDAVPassword
+	syncFocusS3Endpoint
+	syncFocusS3Region
+	syncFocusS3Bucket
+	syncFocusS3Prefix
+	syncFocusS3PathStyle
+	syncFocusS3AccessKey
+	syncFocusS3SecretKey
+	syncFocusS3Versioned
+	syncFocusGitRepo
+	syncFocusGitBranch
+	syncFocusGitPlaintext
 )
 
+// syncBackendLabels names the backends of service.SyncBackends
+var syncBackendLabels = map[string]string{
+	service.SyncBackendGist:   "GitHub Gist",
+	service.SyncBackendDir:    "Folder",
+	service.SyncBackendWebDAV: "WebDAV",
+	service.SyncBackendS3:     "S3",
+	service.SyncBackendGit:    "Git",
+}
+
 func NewSyncView() *SyncView {
 	passwordInput := textinput.New()
 	passwordInput.Placeholder = "Enter encryption password"
@@ -52,11 +100,95 @@ func NewSyncView() *SyncView {
 	gistIDInput.Width = 40
 	gistIDInput.Prompt = "Gist ID: "
 
+	dirInput := textinput.New()
+	dirInput.Placeholder = "/home/me/Sync/subscriptions"
+	dirInput.CharLimit = 500
+	dirInput.Width = 40
+	dirInput.Prompt = "Folder: "
+
+	webdavURLInput := textinput.New()
+	webdavURLInput.Placeholder = "https://cloud.example.com/remote.php/dav/files/me/subscriptions"
+	webdavURLInput.CharLimit = 500
+	webdavURLInput.Width = 40
+	webdavURLInput.Prompt = "URL: "
+
+	webdavUserInput := textinput.New()
+	webdavUserInput.CharLimit = 100
+	webdavUserInput.Width = 40
+	webdavUserInput.Prompt = "User: "
+
+	webdavPasswordInput := textinput.New()
+	webdavPasswordInput.Placeholder = "An app password"
+	webdavPasswordInput.EchoMode = textinput.EchoPassword
+	webdavPasswordInput.EchoCharacter = '•'
+	webdavPasswordInput.CharLimit = 100
+	webdavPasswordInput.Width = 40
+	webdavPasswordInput.Prompt = "Password: "
+
+	s3EndpointInput := textinput.New()
+	s3EndpointInput.Placeholder = "https://s3.eu-central-1.amazonaws.com"
+	s3EndpointInput.CharLimit = 500
+	s3EndpointInput.Width = 40
+	s3EndpointInput.Prompt = "Endpoint: "
+
+	s3RegionInput := textinput.New()
+	s3RegionInput.Placeholder = service.DefaultS3Region
+	s3RegionInput.CharLimit = 50
+	s3RegionInput.Width = 40
+	s3RegionInput.Prompt = "Region: "
+
+	s3BucketInput := textinput.New()
+	s3BucketInput.CharLimit = 100
+	s3BucketInput.Width = 40
+	s3BucketInput.Prompt = "Bucket: "
+
+	s3PrefixInput := textinput.New()
+	s3PrefixInput.Placeholder = "subscription-tracker/"
+	s3PrefixInput.CharLimit = 200
+	s3PrefixInput.Width = 40
+	s3PrefixInput.Prompt = "Prefix: "
+
+	s3AccessKeyInput := textinput.New()
+	s3AccessKeyInput.CharLimit = 200
+	s3AccessKeyInput.Width = 40
+	s3AccessKeyInput.Prompt = "Access Key: "
+
+	s3SecretKeyInput := textinput.New()
+	s3SecretKeyInput.EchoMode = textinput.EchoPassword
+	s3SecretKeyInput.EchoCharacter = '•'
+	s3SecretKeyInput.CharLimit = 200
+	s3SecretKeyInput.Width = 40
+	s3SecretKeyInput.Prompt = "Secret Key: "
+
+	gitRepoInput := textinput.New()
+	gitRepoInput.Placeholder = "git@git.example.com:me/subscriptions.git"
+	gitRepoInput.CharLimit = 500
+	gitRepoInput.Width = 40
+	gitRepoInput.Prompt = "Repository: "
+
+	gitBranchInput := textinput.New()
+	gitBranchInput.Placeholder = service.DefaultGitBranch
+	gitBranchInput.CharLimit = 100
+	gitBranchInput.Width = 40
+	gitBranchInput.Prompt = "Branch: "
+
 	return &SyncView{
-		passwordInput: passwordInput,
-		tokenInput:    tokenInput,
-		gistIDInput:   gistIDInput,
-		focusIndex:    syncFocusPassword,
+		passwordInput:       passwordInput,
+		tokenInput:          tokenInput,
+		gistIDInput:         gistIDInput,
+		dirInput:            dirInput,
+		webdavURLInput:      webdavURLInput,
+		webdavUserInput:     webdavUserInput,
+		webdavPasswordInput: webdavPasswordInput,
+		s3EndpointInput:     s3EndpointInput,
+		s3RegionInput:       s3RegionInput,
+		s3BucketInput:       s3BucketInput,
+		s3PrefixInput:       s3PrefixInput,
+		s3AccessKeyInput:    s3AccessKeyInput,
+		s3SecretKeyInput:    s3SecretKeyInput,
+		gitRepoInput:        gitRepoInput,
+		gitBranchInput:      gitBranchInput,
+		focusIndex:          syncFocusPassword,
 	}
 }
 
@@ -67,7 +199,7 @@ func (v *SyncView) Init(a *app.App) tea.Cmd {
 func (v *SyncView) loadConfig(a *app.App) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		config, err := a.SyncService.GetGistConfig(ctx)
+		config, err := a.SyncService.GetSyncConfig(ctx)
 		if err != nil {
 			return syncErrMsg{err}
 		}
@@ -76,7 +208,7 @@ func (v *SyncView) loadConfig(a *app.App) tea.Cmd {
 }
 
 type syncConfigLoadedMsg struct {
-	config *service.GistConfig
+	config *service.SyncConfig
 }
 
 type syncErrMsg struct {
@@ -87,8 +219,112 @@ type syncSuccessMsg struct {
 	message string
 }
 
+// syncConflictsMsg is a pull that needs conflicts resolved before importing
+type syncConflictsMsg struct {
+	merge *service.SyncMerge
+}
+
 type syncPushCompleteMsg struct {
-	gistID string
+	config   *service.SyncConfig
+	location string
+}
+
+// backend returns the chosen backend
+func (v *SyncView) backend() string {
+	return service.SyncBackends[v.backendIndex]
+}
+
+// fields returns the fields of the chosen backend, in focus order
+func (v *SyncView) fields() []int {
+	fields := []int{syncFocusPassword, syncFocusBackend}
+	switch v.backend() {
+	case service.SyncBackendGist:
+		fields = append(fields, syncFocusToken, syncFocusGistID)
+	case service.SyncBackendDir:
+		fields = append(fields, syncFocusDir)
+	case service.SyncBackendWebDAV:
+		fields = append(fields, syncFocusWebDAVURL, syncFocusWebDAVUser, syncFocusWebDAVPassword)
+	case service.SyncBackendS3:
+		fields = append(fields, syncFocusS3Endpoint, syncFocusS3Region, syncFocusS3Bucket, syncFocusS3Prefix,
+			syncFocusS3PathStyle, syncFocusS3AccessKey, syncFocusS3SecretKey, syncFocusS3Versioned)
+	case service.SyncBackendGit:
+		fields = append(fields, syncFocusGitRepo, syncFocusGitBranch, syncFocusGitPlaintext)
+	}
+	return fields
+}
+
+// moveFocus focuses the next or, with step -1, the previous field
+func (v *SyncView) moveFocus(step int) tea.Cmd {
+	fields := v.fields()
+	for i, f := range fields {
+		if f == v.focusIndex {
+			v.focusIndex = fields[(i+step+len(fields))%len(fields)]
+			break
+		}
+	}
+	return v.updateFocus()
+}
+
+// input returns the text input of a field, or nil for the backend choice
+// and the switches
+func (v *SyncView) input(focus int) *textinput.Model {
+	switch focus {
+	case syncFocusPassword:
+		return &v.passwordInput
+	case syncFocusToken:
+		return &v.tokenInput
+	case syncFocusGistID:
+		return &v.gistIDInput
+	case syncFocusDir:
+		return &v.dirInput
+	case syncFocusWebDAVURL:
+		return &v.webdavURLInput
+	case syncFocusWebDAVUser:
+		return &v.webdavUserInput
+	case syncFocusWebDAVPassword:
+		return &v.webdavPasswordInput
+	case syncFocusS3Endpoint:
+		return &v.s3EndpointInput
+	case syncFocusS3Region:
+		return &v.s3RegionInput
+	case syncFocusS3Bucket:
+		return &v.s3BucketInput
+	case syncFocusS3Prefix:
+		return &v.s3PrefixInput
+	case syncFocusS3AccessKey:
+		return &v.s3AccessKeyInput
+	case syncFocusS3SecretKey:
+		return &v.s3SecretKeyInput
+	case syncFocusGitRepo:
+		return &v.gitRepoInput
+	case syncFocusGitBranch:
+		return &v.gitBranchInput
+	}
+	return nil
+}
+
+// config returns the sync settings entered in the view
+func (v *SyncView) config() *service.SyncConfig {
+	return &service.SyncConfig{
+		Backend:        v.backend(),
+		GistToken:      v.tokenInput.Value(),
+		GistID:         v.gistIDInput.Value(),
+		Dir:            v.dirInput.Value(),
+		WebDAVURL:      v.webdavURLInput.Value(),
+		WebDAVUser:     v.webdavUserInput.Value(),
+		WebDAVPassword: v.webdavPasswordInput.Value(),
+		S3Endpoint:     v.s3EndpointInput.Value(),
+		S3Region:       v.s3RegionInput.Value(),
+		S3Bucket:       v.s3BucketInput.Value(),
+		S3Prefix:       v.s3PrefixInput.Value(),
+		S3PathStyle:    v.s3PathStyle,
+		S3AccessKey:    v.s3AccessKeyInput.Value(),
+		S3SecretKey:    v.s3SecretKeyInput.Value(),
+		S3Versioned:    v.s3Versioned,
+		GitRepo:        v.gitRepoInput.Value(),
+		GitBranch:      v.gitBranchInput.Value(),
+		GitPlaintext:   v.gitPlaintext,
+	}
 }
 
 func (v *SyncView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
@@ -97,64 +333,92 @@ func (v *SyncView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 		if v.loading {
 			return false, nil // Don't accept input while loading
 		}
+		if v.merge != nil {
+			return v.updateConflicts(msg, a)
+		}
 		switch msg.String() {
 		case "tab", "down":
-			v.focusIndex = (v.focusIndex + 1) % 3
-			return false, v.updateFocus()
+			return false, v.moveFocus(1)
 		case "shift+tab", "up":
-			v.focusIndex = (v.focusIndex + 2) % 3
-			return false, v.updateFocus()
-		case "ctrl+p":
-			// Push to gist
-			if v.passwordInput.Value() == "" {
-				v.err = fmt.Errorf("password is required")
+			return false, v.moveFocus(-1)
+		case "left", "right":
+			if v.focusIndex == syncFocusBackend {
+				n := len(service.SyncBackends)
+				if msg.String() == "left" {
+					v.backendIndex = (v.backendIndex + n - 1) % n
+				} else {
+					v.backendIndex = (v.backendIndex + 1) % n
+				}
+				v.err = nil
 				return false, nil
 			}
-			if v.tokenInput.Value() == "" {
-				v.err = fmt.Errorf("GitHub token is required")
+			if v.toggle() {
 				return false, nil
 			}
-			v.loading = true
-			v.err = nil
-			v.message = ""
-			return false, v.pushToGist(a)
-		case "ctrl+l":
-			// Pull from gist
-			if v.passwordInput.Value() == "" {
-				v.err = fmt.Errorf("password is required")
+		case " ":
+			if v.toggle() {
 				return false, nil
 			}
-			if v.tokenInput.Value() == "" {
-				v.err = fmt.Errorf("GitHub token is required")
+		case "ctrl+p", "ctrl+l":
+			config := v.config()
+			if v.passwordInput.Value() == "" && config.Encrypted() {
+				v.err = fmt.Errorf("password is required")
 				return false, nil
 			}
-			if v.gistIDInput.Value() == "" {
-				v.err = fmt.Errorf("Gist ID is required for pull")
+			if _, err := config.Open(); err != nil {
+				v.err = err
 				return false, nil
 			}
 			v.loading = true
 			v.err = nil
 			v.message = ""
-			return false, v.pullFromGist(a)
+			if msg.String() == "ctrl+p" {
+				return false, v.push(a, config)
+			}
+			return false, v.pull(a, config)
 		case "q", "esc":
 			return true, nil
 		}
 	case syncConfigLoadedMsg:
-		v.gistConfig = msg.config
-		if msg.config.Token != "" {
-			v.tokenInput.SetValue(msg.config.Token)
-		}
-		if msg.config.GistID != "" {
-			v.gistIDInput.SetValue(msg.config.GistID)
+		v.syncConfig = msg.config
+		for i, backend := range service.SyncBackends {
+			if backend == msg.config.Backend {
+				v.backendIndex = i
+			}
 		}
+		v.tokenInput.SetValue(msg.config.GistToken)
+		v.gistIDInput.SetValue(msg.config.GistID)
+		v.dirInput.SetValue(msg.config.Dir)
+		v.webdavURLInput.SetValue(msg.config.WebDAVURL)
+		v.webdavUserInput.SetValue(msg.config.WebDAVUser)
+		v.webdavPasswordInput.SetValue(msg.config.WebDAVPassword)
+		v.s3EndpointInput.SetValue(msg.config.S3Endpoint)
+		v.s3RegionInput.SetValue(msg.config.S3Region)
+		v.s3BucketInput.SetValue(msg.config.S3Bucket)
+		v.s3PrefixInput.SetValue(msg.config.S3Prefix)
+		v.s3AccessKeyInput.SetValue(msg.config.S3AccessKey)
+		v.s3SecretKeyInput.SetValue(msg.config.S3SecretKey)
+		v.s3PathStyle = msg.config.S3PathStyle
+		v.s3Versioned = msg.config.S3Versioned
+		v.gitRepoInput.SetValue(msg.config.GitRepo)
+		v.gitBranchInput.SetValue(msg.config.GitBranch)
+		v.gitPlaintext = msg.config.GitPlaintext
 		return false, nil
 	case syncPushCompleteMsg:
 		v.loading = false
-		v.message = fmt.Sprintf("Pushed to gist: %s", msg.gistID)
-		v.gistIDInput.SetValue(msg.gistID)
+		v.message = fmt.Sprintf("Pushed to %s", msg.location)
+		v.gistIDInput.SetValue(msg.config.GistID)
+		v.dirInput.SetValue(msg.config.Dir)
+		v.gitRepoInput.SetValue(msg.config.GitRepo)
+		return false, nil
+	case syncConflictsMsg:
+		v.loading = false
+		v.merge = msg.merge
+		v.cursor, v.offset = 0, 0
 		return false, nil
 	case syncSuccessMsg:
 		v.loading = false
+		v.merge = nil
 		v.message = msg.message
 		return false, nil
 	case syncErrMsg:
@@ -163,84 +427,147 @@ func (v *SyncView) Update(msg tea.Msg, a *app.App) (bool, tea.Cmd) {
 		return false, nil
 	}
 
-	var cmd tea.Cmd
-	switch v.focusIndex {
-	case syncFocusPassword:
-		v.passwordInput, cmd = v.passwordInput.Update(msg)
-	case syncFocusToken:
-		v.tokenInput, cmd = v.tokenInput.Update(msg)
-	case syncFocusGistID:
-		v.gistIDInput, cmd = v.gistIDInput.Update(msg)
+	if input := v.input(v.focusIndex); input != nil {
+		var cmd tea.Cmd
+		*input, cmd = input.Update(msg)
+		return false, cmd
 	}
-	return false, cmd
+	return false, nil
 }
 
-func (v *SyncView) updateFocus() tea.Cmd {
-	v.passwordInput.Blur()
-	v.tokenInput.Blur()
-	v.gistIDInput.Blur()
+// updateConflicts handles keys while the conflicts of a pull are resolved
+func (v *SyncView) updateConflicts(msg tea.KeyMsg, a *app.App) (bool, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		if v.cursor > 0 {
+			v.cursor--
+		}
+	case "down", "j":
+		if v.cursor < len(v.merge.Conflicts)-1 {
+			v.cursor++
+		}
+	case "l":
+		v.resolve(service.ResolveLocal)
+	case "r":
+		v.resolve(service.ResolveRemote)
+	case "b":
+		v.resolve(service.ResolveBoth)
+	case "ctrl+s":
+		v.loading = true
+		v.err = nil
+		return false, v.applyMerge(a)
+	case "esc":
+		v.merge = nil
+		v.err = nil
+		v.message = "Pull cancelled; nothing was imported"
+	}
 
-	switch v.focusIndex {
-	case syncFocusPassword:
-		return v.passwordInput.Focus()
-	case syncFocusToken:
-		return v.tokenInput.Focus()
-	case syncFocusGistID:
-		return v.gistIDInput.Focus()
+	if v.cursor < v.offset {
+		v.offset = v.cursor
 	}
-	return nil
+	if v.cursor >= v.offset+conflictRows {
+		v.offset = v.cursor - conflictRows + 1
+	}
+	return false, nil
 }
 
-func (v *SyncView) pushToGist(a *app.App) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+// resolve resolves the selected conflict and moves on to the next one
+func (v *SyncView) resolve(resolution string) {
+	v.merge.Conflicts[v.cursor].Resolution = resolution
+	if v.cursor < len(v.merge.Conflicts)-1 {
+		v.cursor++
+	}
+}
+
+// toggle flips the focused switch, reporting whether a switch is focused
+func (v *SyncView) toggle() bool {
+	switch v.focusIndex {
+	case syncFocusS3PathStyle:
+		v.s3PathStyle = !v.s3PathStyle
+	case syncFocusS3Versioned:
+		v.s3Versioned = !v.s3Versioned
+	case syncFocusGitPlaintext:
+		v.gitPlaintext = !v.gitPlaintext
+	default:
+		return false
+	}
+	return true
+}
 
-		config := service.GistConfig{
-			Token:  v.tokenInput.Value(),
-			GistID: v.gistIDInput.Value(),
+func (v *SyncView) updateFocus() tea.Cmd {
+	for focus := syncFocusPassword; focus <= syncFocusGitPlaintext; focus++ {
+		if input := v.input(focus); input != nil {
+			input.Blur()
 		}
+	}
+	if input := v.input(v.focusIndex); input != nil {
+		return input.Focus()
+	}
+	return nil
+}
 
-		gistID, err := a.SyncService.PushToGist(ctx, v.passwordInput.Value(), config)
+func (v *SyncView) push(a *app.App, config *service.SyncConfig) tea.Cmd {
+	password := v.passwordInput.Value()
+	return func() tea.Msg {
+		location, err := a.SyncService.Push(context.Background(), password, config)
 		if err != nil {
 			return syncErrMsg{err}
 		}
-
-		// Save the config
-		config.GistID = gistID
-		if err := a.SyncService.SaveGistConfig(ctx, &config); err != nil {
-			return syncErrMsg{fmt.Errorf("pushed but failed to save config: %w", err)}
-		}
-
-		return syncPushCompleteMsg{gistID}
+		return syncPushCompleteMsg{config: config, location: location}
 	}
 }
 
-func (v *SyncView) pullFromGist(a *app.App) tea.Cmd {
+func (v *SyncView) pull(a *app.App, config *service.SyncConfig) tea.Cmd {
+	password := v.passwordInput.Value()
 	return func() tea.Msg {
-		ctx := context.Background()
-
-		config := service.GistConfig{
-			Token:  v.tokenInput.Value(),
-			GistID: v.gistIDInput.Value(),
+		err := a.SyncService.Pull(context.Background(), password, config)
+		var conflicts *service.MergeConflictError
+		if errors.As(err, &conflicts) {
+			return syncConflictsMsg{conflicts.Merge}
 		}
+		if err != nil {
+			return syncErrMsg{err}
+		}
+		return syncSuccessMsg{"Data pulled and merged successfully!"}
+	}
+}
 
-		if err := a.SyncService.PullFromGist(ctx, v.passwordInput.Value(), config); err != nil {
+// applyMerge imports the pulled data with the conflicts resolved
+func (v *SyncView) applyMerge(a *app.App) tea.Cmd {
+	merge := v.merge
+	return func() tea.Msg {
+		if err := a.SyncService.ApplyMerge(context.Background(), merge); err != nil {
 			return syncErrMsg{err}
 		}
+		return syncSuccessMsg{"Data pulled and merged successfully!"}
+	}
+}
 
-		// Save the config
-		if err := a.SyncService.SaveGistConfig(ctx, &config); err != nil {
-			return syncErrMsg{fmt.Errorf("pulled but failed to save config: %w", err)}
+// renderBackendSelector renders the backend choices with the chosen one highlighted
+func (v *SyncView) renderBackendSelector() string {
+	s := "Backend: "
+	for i, backend := range service.SyncBackends {
+		if i == v.backendIndex {
+			s += SelectedItemStyle.Render("[" + syncBackendLabels[backend] + "]")
+		} else {
+			s += " " + syncBackendLabels[backend] + " "
 		}
+	}
+	return s
+}
 
-		return syncSuccessMsg{"Data pulled and imported successfully!"}
+// renderSwitch renders an on/off setting
+func renderSwitch(label string, on bool) string {
+	if on {
+		return label + SelectedItemStyle.Render("[on]") + " off "
 	}
+	return label + " on " + SelectedItemStyle.Render("[off]")
 }
 
 func (v *SyncView) View() string {
 	var b strings.Builder
 
-	b.WriteString(TitleStyle.Render("Sync to GitHub Gist") + "\n\n")
+	b.WriteString(TitleStyle.Render("Encrypted Sync") + "\n\n")
 
 	if v.loading {
 		b.WriteString("Syncing...\n\n")
@@ -251,6 +578,11 @@ func (v *SyncView) View() string {
 		b.WriteString(ErrorStyle.Render("Error: "+v.err.Error()) + "\n\n")
 	}
 
+	if v.merge != nil {
+		b.WriteString(v.viewConflicts())
+		return BoxStyle.Render(b.String())
+	}
+
 	if v.message != "" {
 		b.WriteString(SuccessStyle.Render(v.message) + "\n\n")
 	}
@@ -258,32 +590,129 @@ func (v *SyncView) View() string {
 	b.WriteString("Your data is encrypted locally before being uploaded.\n")
 	b.WriteString("Use the same password on both machines.\n\n")
 
-	// Password input
-	if v.focusIndex == syncFocusPassword {
-		b.WriteString(FocusedInputStyle.Render(v.passwordInput.View()) + "\n")
-	} else {
-		b.WriteString(BlurredInputStyle.Render(v.passwordInput.View()) + "\n")
+	field := func(focus int, view string) {
+		if v.focusIndex == focus {
+			b.WriteString(FocusedInputStyle.Render(view) + "\n")
+		} else {
+			b.WriteString(BlurredInputStyle.Render(view) + "\n")
+		}
 	}
 
-	b.WriteString("\n" + SubtitleStyle.Render("GitHub Settings") + "\n")
-	b.WriteString(HelpStyle.Render("Create a token at: https://github.com/settings/tokens") + "\n")
-	b.WriteString(HelpStyle.Render("Required scope: 'gist'") + "\n\n")
-
-	// Token input
-	if v.focusIndex == syncFocusToken {
-		b.WriteString(FocusedInputStyle.Render(v.tokenInput.View()) + "\n")
-	} else {
-		b.WriteString(BlurredInputStyle.Render(v.tokenInput.View()) + "\n")
+	field(syncFocusPassword, v.passwordInput.View())
+	field(syncFocusBackend, v.renderBackendSelector())
+
+	switch v.backend() {
+	case service.SyncBackendGist:
+		b.WriteString("\n" + SubtitleStyle.Render("GitHub Settings") + "\n")
+		b.WriteString(HelpStyle.Render("Create a token at: https://github.com/settings/tokens") + "\n")
+		b.WriteString(HelpStyle.Render("Required scope: 'gist'") + "\n\n")
+		field(syncFocusToken, v.tokenInput.View())
+		field(syncFocusGistID, v.gistIDInput.View())
+	case service.SyncBackendDir:
+		b.WriteString("\n" + SubtitleStyle.Render("Folder Settings") + "\n")
+		b.WriteString(HelpStyle.Render("Any folder your other machines see, such as a Syncthing or") + "\n")
+		b.WriteString(HelpStyle.Render("Dropbox folder or a mounted NAS share") + "\n\n")
+		field(syncFocusDir, v.dirInput.View())
+	case service.SyncBackendWebDAV:
+		b.WriteString("\n" + SubtitleStyle.Render("WebDAV Settings") + "\n")
+		b.WriteString(HelpStyle.Render("Nextcloud: https://HOST/remote.php/dav/files/USER/FOLDER") + "\n")
+		b.WriteString(HelpStyle.Render("The folder is created if its parent exists") + "\n")
+		b.WriteString(HelpStyle.Render("The password isn't saved; enter it again after a restart") + "\n\n")
+		field(syncFocusWebDAVURL, v.webdavURLInput.View())
+		field(syncFocusWebDAVUser, v.webdavUserInput.View())
+		field(syncFocusWebDAVPassword, v.webdavPasswordInput.View())
+	case service.SyncBackendS3:
+		b.WriteString("\n" + SubtitleStyle.Render("S3 Settings") + "\n")
+		b.WriteString(HelpStyle.Render("AWS S3, Backblaze B2, MinIO or any S3-compatible storage") + "\n")
+		b.WriteString(HelpStyle.Render("Versioned backups can be restored with: pull --backup NAME") + "\n")
+		b.WriteString(HelpStyle.Render("The secret key isn't saved; enter it again after a restart") + "\n\n")
+		field(syncFocusS3Endpoint, v.s3EndpointInput.View())
+		field(syncFocusS3Region, v.s3RegionInput.View())
+		field(syncFocusS3Bucket, v.s3BucketInput.View())
+		field(syncFocusS3Prefix, v.s3PrefixInput.View())
+		field(syncFocusS3PathStyle, renderSwitch("Path-style URLs (MinIO): ", v.s3PathStyle))
+		field(syncFocusS3AccessKey, v.s3AccessKeyInput.View())
+		field(syncFocusS3SecretKey, v.s3SecretKeyInput.View())
+		field(syncFocusS3Versioned, renderSwitch("Keep every version: ", v.s3Versioned))
+	case service.SyncBackendGit:
+		b.WriteString("\n" + SubtitleStyle.Render("Git Settings") + "\n")
+		b.WriteString(HelpStyle.Render("A local folder, or a remote such as Gitea or a bare repo over SSH") + "\n")
+		b.WriteString(HelpStyle.Render("Every push is a commit; plaintext needs no password but isn't encrypted") + "\n\n")
+		field(syncFocusGitRepo, v.gitRepoInput.View())
+		field(syncFocusGitBranch, v.gitBranchInput.View())
+		field(syncFocusGitPlaintext, renderSwitch("Plaintext JSON: ", v.gitPlaintext))
 	}
 
-	// Gist ID input
-	if v.focusIndex == syncFocusGistID {
-		b.WriteString(FocusedInputStyle.Render(v.gistIDInput.View()) + "\n")
-	} else {
-		b.WriteString(BlurredInputStyle.Render(v.gistIDInput.View()) + "\n")
+	b.WriteString("\n" + HelpStyle.Render("[tab] next field  [←/→] backend  [space] switch  [ctrl+p] push  [ctrl+l] pull  [q/esc] back"))
+
+	return BoxStyle.Render(b.String())
+}
+
+// viewConflicts renders the conflicts of a pull with their resolutions
+func (v *SyncView) viewConflicts() string {
+	var b strings.Builder
+
+	b.WriteString(SubtitleStyle.Render("Both devices changed these subscriptions since the last sync") + "\n")
+	b.WriteString(HelpStyle.Render("Everything else is merged; nothing is imported until you save") + "\n\n")
+
+	b.WriteString(TableHeaderStyle.Render(fmt.Sprintf("     %-24s %s", "NAME", "CHANGES")) + "\n")
+	end := min(v.offset+conflictRows, len(v.merge.Conflicts))
+	resolved := 0
+	for i := v.offset; i < end; i++ {
+		c := v.merge.Conflicts[i]
+		mark := "[ ] "
+		switch c.Resolution {
+		case service.ResolveLocal:
+			mark = "[L] "
+		case service.ResolveRemote:
+			mark = "[R] "
+		case service.ResolveBoth:
+			mark = "[B] "
+		}
+		row := fmt.Sprintf("%s %-24s %s", mark, truncate(c.Name(), 24), c.Describe())
+		if i == v.cursor {
+			row = SelectedItemStyle.Render(row)
+		} else {
+			row = NormalItemStyle.Render(row)
+		}
+		b.WriteString(row + "\n")
+	}
+	for _, c := range v.merge.Conflicts {
+		if c.Resolution != "" {
+			resolved++
+		}
+	}
+	if len(v.merge.Conflicts) > conflictRows {
+		b.WriteString(HelpStyle.Render(fmt.Sprintf("conflicts %d-%d of %d", v.offset+1, end, len(v.merge.Conflicts))) + "\n")
 	}
 
-	b.WriteString("\n" + HelpStyle.Render("[tab] next field  [ctrl+p] push  [ctrl+l] pull  [q/esc] back"))
+	// Both versions of the selected subscription
+	c := v.merge.Conflicts[v.cursor]
+	b.WriteString("\n" + fmt.Sprintf("Local:  %s", describeSyncSubscription(c.Local)) + "\n")
+	b.WriteString(fmt.Sprintf("Remote: %s", describeSyncSubscription(c.Remote)) + "\n")
 
-	return BoxStyle.Render(b.String())
+	b.WriteString(fmt.Sprintf("\n%d of %d resolved\n", resolved, len(v.merge.Conflicts)))
+	b.WriteString("\n" + HelpStyle.Render("[↑/↓] move  [l]ocal  [r]emote  [b]oth  [ctrl+s] import  [esc] cancel pull"))
+	return b.String()
+}
+
+// describeSyncSubscription summarizes one device's version of a subscription
+func describeSyncSubscription(sub *service.SyncSubscription) string {
+	if sub == nil {
+		return "deleted"
+	}
+	parts := []string{fmt.Sprintf("%s %.2f %s %s", sub.Name, sub.Amount, sub.Currency, sub.BillingCycle)}
+	if sub.NextRenewalDate != "" {
+		parts = append(parts, "renews "+sub.NextRenewalDate)
+	}
+	if sub.Status != "" {
+		parts = append(parts, sub.Status)
+	}
+	if sub.Category != "" {
+		parts = append(parts, sub.Category)
+	}
+	if len(sub.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(sub.Tags, " #"))
+	}
+	return strings.Join(parts, ", ")
 }
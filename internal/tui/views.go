@@ -2,10 +2,11 @@ package tui
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"subscription-tracker/internal/db"
+	"subscription-tracker/internal/service"
 )
 
 // updateAdd handles updates for the add form view
@@ -17,12 +18,13 @@ func (m Model) updateAdd(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 	case createSubscriptionMsg:
-		_, err := m.app.Queries.CreateSubscription(context.Background(), msg.params)
+		_, err := m.app.SubscriptionService.Create(context.Background(), msg.input)
 		if err != nil {
 			m.err = err
 			return m, nil
 		}
 		m.message = "Subscription added successfully"
+		m.warning = m.budgetWarning()
 		m.view = ViewList
 		return m, m.loadSubscriptions
 	}
@@ -44,14 +46,18 @@ func (m Model) updateEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 	case updateSubscriptionMsg:
-		_, err := m.app.Queries.UpdateSubscription(context.Background(), msg.params)
+		_, err := m.app.SubscriptionService.Update(context.Background(), msg.input)
 		if err != nil {
 			m.err = err
 			return m, nil
 		}
 		m.message = "Subscription updated successfully"
+		m.warning = m.budgetWarning()
 		m.view = ViewList
 		return m, m.loadSubscriptions
+	case priceHistoryMsg:
+		m.editForm.SetPriceHistory(msg.changes)
+		return m, nil
 	}
 
 	done, cmd := m.editForm.Update(msg, m.app)
@@ -61,6 +67,25 @@ func (m Model) updateEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// budgetWarning describes the budgets the current billing period has nearly
+// used up or exceeded, or returns "" if there are none
+func (m Model) budgetWarning() string {
+	summary, err := m.app.SpendingService.CalculateForCurrentMonth(context.Background())
+	if err != nil {
+		return ""
+	}
+
+	var warnings []string
+	for _, b := range service.BudgetAlerts(summary.Budgets) {
+		if b.State == service.BudgetOver {
+			warnings = append(warnings, fmt.Sprintf("%s budget exceeded (%.2f of %.2f %s)", b.Label(), b.Used, b.Limit, summary.BaseCurrency))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s budget %.0f%% used", b.Label(), b.Percent))
+		}
+	}
+	return strings.Join(warnings, "\n")
+}
+
 // updateSpending handles updates for the spending view
 func (m Model) updateSpending(msg tea.Msg) (tea.Model, tea.Cmd) {
 	done, cmd := m.spendingView.Update(msg, m.app)
@@ -71,12 +96,44 @@ func (m Model) updateSpending(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// updateForecast handles updates for the forecast view
+func (m Model) updateForecast(msg tea.Msg) (tea.Model, tea.Cmd) {
+	done, cmd := m.forecastView.Update(msg, m.app)
+	if done {
+		m.view = ViewList
+		return m, nil
+	}
+	return m, cmd
+}
+
+// updateCalendar handles updates for the calendar view
+func (m Model) updateCalendar(msg tea.Msg) (tea.Model, tea.Cmd) {
+	done, cmd := m.calendarView.Update(msg, m.app)
+	if done {
+		m.view = ViewList
+		return m, nil
+	}
+	return m, cmd
+}
+
 // updateExport handles updates for the export view
 func (m Model) updateExport(msg tea.Msg) (tea.Model, tea.Cmd) {
 	done, cmd := m.exportView.Update(msg, m.app)
 	if done {
+		// An import may have changed the subscriptions
 		m.view = ViewList
-		return m, nil
+		return m, m.loadSubscriptions
+	}
+	return m, cmd
+}
+
+// updateReview handles updates for the statement review view
+func (m Model) updateReview(msg tea.Msg) (tea.Model, tea.Cmd) {
+	done, cmd := m.reviewView.Update(msg, m.app)
+	if done {
+		// Accepted charges were added as subscriptions
+		m.view = ViewList
+		return m, m.loadSubscriptions
 	}
 	return m, cmd
 }
@@ -119,11 +176,26 @@ func (m Model) viewSpending() string {
 	return m.spendingView.View()
 }
 
+// viewForecast renders the forecast view
+func (m Model) viewForecast() string {
+	return m.forecastView.View()
+}
+
+// viewCalendar renders the calendar view
+func (m Model) viewCalendar() string {
+	return m.calendarView.View()
+}
+
 // viewExport renders the export view
 func (m Model) viewExport() string {
 	return m.exportView.View()
 }
 
+// viewReview renders the statement review view
+func (m Model) viewReview() string {
+	return m.reviewView.View()
+}
+
 // viewConfig renders the config view
 func (m Model) viewConfig() string {
 	return m.configView.View()
@@ -145,10 +217,16 @@ List View (VIM motions supported):
   a        Add new subscription
   e        Edit selected subscription
   d        Delete selected subscription
+  p        Pause selected subscription from today, or resume it
+  C        Cancel selected subscription from today, or resume it
+  /        Filter by category and #tag (Enter applies, Esc clears)
   s        View spending summary
-  x        Export subscriptions
-  c        Configuration (payday, salary)
-  y        Sync to GitHub Gist (encrypted)
+  f        Forecast spending over the coming months
+  v        Renewal calendar
+  x        Export or import subscriptions
+  b        Find recurring charges in a bank statement
+  c        Configuration (payday, salary, budget, trial warning)
+  y        Encrypted sync (GitHub Gist, folder, WebDAV, S3 or git)
   r        Refresh list
   ?        Show this help
   q        Quit
@@ -156,27 +234,68 @@ List View (VIM motions supported):
 Add/Edit Form:
   ↓/Tab    Next field
   ↑/Shift+Tab  Previous field
-  ←/→      Toggle billing cycle (monthly/yearly)
+  ←/→      Change billing cycle (weekly ... yearly, custom)
+           Category is created if new; tags are comma separated
+           A free trial end date starts the subscription on a trial,
+           charged at the price after trial (default: the amount) once it ends
+           Changing the amount or currency asks when the new price applies;
+           the edit form lists earlier price changes
   Ctrl+S   Save
   Esc      Cancel
 
 Spending View:
   ←/h      Previous month
   →/l      Next month
+  w        Write an HTML report of the month
+  W        Write an HTML report of the year
+  q/Esc    Back to list
+
+Calendar View:
+  h/l      Previous/next day
+  k/j      Previous/next week
+  H/L      Previous/next month (billing period)
+  q/Esc    Back to list
+
+Forecast View:
+  ↑/k ↓/j  Select a period to list its charges
+  ←/h →/l  Forecast 3, 6, 12 or 24 months
   q/Esc    Back to list
 
 Export View:
-  Tab      Change format (CSV/JSON)
-  Enter    Export
+  Tab      Change format (CSV/JSON/XLSX/ICS/ledger/hledger/beancount)
+  Enter    Export, or preview the import
+  Ctrl+T   Switch between export and import
+  Ctrl+E   Change import mode (merge/replace)
+  Ctrl+S   Import the previewed rows
   q/Esc    Cancel
 
+Bank Statement Review:
+  Tab      Switch between the file path and the CSV columns
+  Enter    Find recurring charges (OFX/QFX, QIF or CSV)
+  ↑/k ↓/j  Select a candidate
+  a/r      Accept/reject the candidate and go to the next one
+  Space    Toggle accept/reject
+  A        Accept every candidate that isn't tracked yet
+  Ctrl+S   Add the accepted candidates as subscriptions
+  Esc      Choose another file
+  q        Back to list
+
 Sync View:
   ↓/Tab    Next field
   ↑/Shift+Tab  Previous field
-  Ctrl+P   Push to GitHub Gist
-  Ctrl+L   Pull from GitHub Gist
+  ←/→      Change backend (GitHub Gist, folder, WebDAV, S3, git)
+  Space    Turn an S3 or git setting on or off
+  Ctrl+P   Push to the backend
+  Ctrl+L   Pull from the backend, merging with the data here
   q/Esc    Cancel
 
+Sync Conflicts (after a pull):
+  ↑/k ↓/j  Select a conflict
+  l/r/b    Keep the local, remote or both versions and go to the next one
+  Ctrl+S   Import the merged data
+  Esc      Cancel the pull
+  q        Back to list
+
 Config:
   ↓/Tab    Next field
   ↑/Shift+Tab  Previous field
@@ -191,5 +310,5 @@ Config:
 
 // Message type for creating subscriptions from add form
 type createSubscriptionMsg struct {
-	params db.CreateSubscriptionParams
+	input service.CreateSubscriptionInput
 }
@@ -1,15 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"subscription-tracker/internal/app"
+	"subscription-tracker/internal/cli"
+	"subscription-tracker/internal/service"
 	"subscription-tracker/internal/tui"
 )
 
 func main() {
+	// Reject unknown commands before touching the database
+	if len(os.Args) > 1 && !cli.IsCommand(os.Args[1]) {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s (run with 'help' for usage)\n", os.Args[1])
+		os.Exit(2)
+	}
+
 	application, err := app.New()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing app: %v\n", err)
@@ -17,6 +27,24 @@ func main() {
 	}
 	defer application.Close()
 
+	// Any arguments run a headless command instead of the TUI
+	if len(os.Args) > 1 {
+		c := cli.New(application, os.Stdout, os.Stderr)
+		if err := c.Run(context.Background(), os.Args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			application.Close()
+			os.Exit(cli.ExitCode(err))
+		}
+		return
+	}
+
+	// Webhook deliveries go out in the background while the TUI runs, and
+	// the ones queued by changes in the TUI before it quits
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go application.WebhookService.DeliverEvery(ctx, service.WebhookRetryDelay)
+	queued := application.WebhookService.Queued()
+
 	model := tui.New(application)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 
@@ -24,4 +52,13 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error running app: %v\n", err)
 		os.Exit(1)
 	}
+	cancel()
+	err = application.WebhookService.QueueErr()
+	if application.WebhookService.Queued() > queued {
+		_, deliverErr := application.WebhookService.DeliverDue(context.Background())
+		err = errors.Join(err, deliverErr)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 }